@@ -0,0 +1,58 @@
+// Command audit revisa el audit_log directo sobre la base (no necesita el servidor):
+//
+//	go run ./cmd/audit verify [-db data/uzm.db]
+//
+// Sale con 0 si la cadena de hashes está íntegra, 1 si alguna entrada se alteró o falta,
+// y 2 si no se pudo revisar.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"tarea1-uzm/internal/db"
+	"tarea1-uzm/internal/store"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Fprintln(stderr, "uso: audit verify [-db data/uzm.db]")
+		return 2
+	}
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String("db", "data/uzm.db", "base SQLite")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if _, err := os.Stat(*path); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	sqlDB, err := db.Open(*path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer sqlDB.Close()
+
+	check, err := store.New(sqlDB).Read().Audit().Verify(context.Background())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if !check.OK {
+		fmt.Fprintf(stdout, "✘ audit_log alterado en la entrada %d: %s (%d entradas íntegras antes)\n",
+			check.BrokenAt, check.Reason, check.Entries)
+		return 1
+	}
+	fmt.Fprintf(stdout, "✔ audit_log íntegro: %d entradas, último hash %s\n", check.Entries, check.Head)
+	return 0
+}
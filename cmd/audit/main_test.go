@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tarea1-uzm/internal/db"
+	"tarea1-uzm/internal/store"
+)
+
+func TestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uzm.db")
+	sqlDB, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	for i, price := range []string{`{"price":30}`, `{"price":25}`, `{"price":20}`} {
+		e := store.AuditEntry{CreatedAt: "2025-03-10T12:00:00Z", Actor: "admin", Action: "book.update", Entity: "book",
+			EntityID: 1, After: []byte(price)}
+		if i > 0 {
+			e.Actor = "anónimo"
+		}
+		if err := store.New(sqlDB).Read().Audit().Append(context.Background(), &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	verify := func() (int, string) {
+		var out, errOut bytes.Buffer
+		code := run([]string{"verify", "-db", path}, &out, &errOut)
+		return code, out.String() + errOut.String()
+	}
+	if code, out := verify(); code != 0 || !strings.Contains(out, "íntegro: 3 entradas") {
+		t.Fatalf("verify = %d %q", code, out)
+	}
+
+	// la base no deja editar el audit_log...
+	if _, err := sqlDB.Exec(`UPDATE audit_log SET actor='admin' WHERE id=2`); err == nil {
+		t.Fatal("UPDATE sobre audit_log permitido")
+	}
+	// ...pero quien tenga el archivo puede saltarse el trigger: la cadena lo delata
+	if _, err := sqlDB.Exec(`DROP TRIGGER audit_log_no_update; UPDATE audit_log SET actor='admin' WHERE id=2`); err != nil {
+		t.Fatal(err)
+	}
+	if code, out := verify(); code != 1 || !strings.Contains(out, "entrada 2") {
+		t.Errorf("verify tras editar = %d %q", code, out)
+	}
+
+	if code := run([]string{"verify", "-db", filepath.Join(t.TempDir(), "no.db")}, &bytes.Buffer{}, &bytes.Buffer{}); code != 2 {
+		t.Errorf("base inexistente: código %d, want 2", code)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"tarea1-uzm/internal/client"
+)
+
+// ======== Subcomandos (admin, sin menú) ========
+
+const usage = `uso (con UZM_ADMIN_TOKEN):
+  cli export <books|users|sales|loans> [-format csv|jsonl|marc|marcxml|dc] [-o archivo]
+  cli import books <archivo.csv|.jsonl|.mrc|.xml> [-format csv|jsonl|marc|marcxml] [-dry-run]`
+
+var exportSets = []string{"books", "users", "sales", "loans"}
+
+// command corre un subcomando y devuelve el código de salida: 0 ok, 1 falló la operación
+// (ej. filas con errores), 2 uso incorrecto o sin conexión.
+func command(args []string, stdout, stderr io.Writer) int {
+	api.AdminToken = os.Getenv("UZM_ADMIN_TOKEN")
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "", "csv | jsonl | marc | marcxml (| dc al exportar)")
+	var out *string
+	var dryRun *bool
+	switch args[0] {
+	case "export":
+		out = fs.String("o", "", "archivo de salida (por defecto, la pantalla)")
+	case "import":
+		dryRun = fs.Bool("dry-run", false, "solo validar, sin guardar")
+	default:
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+	pos, err := parseAnywhere(fs, args[1:])
+	if err != nil {
+		return 2
+	}
+
+	switch {
+	case args[0] == "export" && len(pos) == 1 && slices.Contains(exportSets, pos[0]):
+		if *format == "" && *out != "" {
+			*format = formatOf(*out)
+		}
+		return exportCmd(pos[0], cmp.Or(*format, "csv"), *out, stdout, stderr)
+	case args[0] == "import" && len(pos) == 2 && pos[0] == "books":
+		if *format == "" {
+			*format = formatOf(pos[1])
+		}
+		return importCmd(pos[1], *format, *dryRun, stdout, stderr)
+	}
+	fmt.Fprintln(stderr, usage)
+	return 2
+}
+
+// parseAnywhere acepta los flags antes o después de los argumentos.
+func parseAnywhere(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return pos, nil
+		}
+		pos = append(pos, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// formatOf deduce el formato por la extensión del archivo (un .xml se toma como MARCXML).
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	case ".mrc", ".marc":
+		return "marc"
+	case ".xml":
+		return "marcxml"
+	}
+	return "csv"
+}
+
+func exportCmd(set, format, path string, stdout, stderr io.Writer) int {
+	w := stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := api.Export(ctx, set, format, w); err != nil {
+		fmt.Fprintln(stderr, "Error exportando:", err)
+		return exitCode(err)
+	}
+	if path != "" {
+		fmt.Fprintf(stdout, "✔ %s exportado a %s\n", set, path)
+	}
+	return 0
+}
+
+func importCmd(path, format string, dryRun bool, stdout, stderr io.Writer) int {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer f.Close()
+	res, err := api.ImportBooks(ctx, format, f, dryRun)
+	if rows := client.RowErrors(err); rows != nil {
+		fmt.Fprintf(stderr, "✘ %d error(es), no se importó nada:\n", len(rows))
+		for _, e := range rows {
+			fmt.Fprintf(stderr, "  línea %d: %s %s\n", e.Line, e.Field, e.Message)
+		}
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Error importando:", err)
+		return exitCode(err)
+	}
+	verb := "Importado"
+	if dryRun {
+		verb = "Validado (sin guardar)"
+	}
+	fmt.Fprintf(stdout, "✔ %s: %d filas, %d nuevos, %d actualizados, %d sin cambios\n",
+		verb, res.Rows, res.Created, res.Updated, res.Unchanged)
+	return 0
+}
+
+// exitCode es 1 si el servidor respondió (rechazó la operación) y 2 si no se pudo hablar con él.
+func exitCode(err error) int {
+	var e *client.Error
+	if errors.As(err, &e) {
+		return 1
+	}
+	return 2
+}
@@ -2,57 +2,29 @@ package main
 
 import (
 	bufio "bufio"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
-)
-
-// ======== Config ========
-const baseURL = "http://localhost:8080"
 
-// ======== Tipos que coinciden con la API ========
+	"github.com/google/uuid"
 
-type User struct {
-	ID        int64  `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	USMPesos  int64  `json:"usm_pesos"`
-}
-
-type Book struct {
-	ID              int64  `json:"id"`
-	BookName        string `json:"book_name"`
-	BookCategory    string `json:"book_category"`
-	TransactionType string `json:"transaction_type"` // "Venta" | "Arriendo"
-	Price           int64  `json:"price"`
-	Status          string `json:"status"`
-	PopularityScore int64  `json:"popularity_score"`
-	Inventory       struct {
-		AvailableQuantity int64 `json:"available_quantity"`
-	} `json:"inventory"`
-}
+	"tarea1-uzm/internal/client"
+)
 
-type BooksResp struct {
-	Books []Book `json:"books"`
-}
+// ======== Config ========
 
-type TransactionsResp struct {
-	Transactions []Transaction `json:"transactions"`
-}
+// baseURL apunta a la API; UZM_API_URL lo cambia (ej. http://<IP_VM>:8080).
+var baseURL = "http://localhost:8080"
 
-type Transaction struct {
-	ID     int64  `json:"id"`
-	Type   string `json:"type"`
-	UserID int64  `json:"user_id"`
-	BookID int64  `json:"book_id"`
-	Date   string `json:"date"`
+func init() {
+	if u := os.Getenv("UZM_API_URL"); u != "" {
+		baseURL = strings.TrimRight(u, "/")
+	}
 }
 
 // ======== Utiles de consola ========
@@ -78,57 +50,46 @@ func readInt(prompt string) int64 {
 	}
 }
 
-// ======== HTTP helpers ========
+// ======== HTTP ========
 
-func getJSON(path string, out any) error {
-	resp, err := http.Get(baseURL + path)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("GET %s → status %s", path, resp.Status)
-	}
-	return json.NewDecoder(resp.Body).Decode(out)
-}
+// api es el cliente generado desde openapi.json; main lo crea con baseURL.
+var api *client.Client
 
-func postJSON(path string, body any, out any) error {
-	b, _ := json.Marshal(body)
-	resp, err := http.Post(baseURL+path, "application/json", strings.NewReader(string(b)))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("POST %s → status %s", path, resp.Status)
-	}
-	if out != nil {
-		return json.NewDecoder(resp.Body).Decode(out)
+var ctx = context.Background()
+
+// retryDelays son las esperas entre reintentos de once.
+var retryDelays = []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second}
+
+// once es para lo que cobra o mueve stock (compras, arriendos, devoluciones, abonos):
+// una Idempotency-Key por acción permite reintentar sin miedo a cobrar dos veces.
+func once(call func(key client.Option) error) error {
+	key := client.WithIdempotencyKey(uuid.NewString())
+	for attempt := 0; ; attempt++ {
+		err := call(key)
+		if err == nil || !retryable(err) || attempt == len(retryDelays) {
+			return err
+		}
+		fmt.Printf("→ No hubo respuesta clara del servidor (%v); reintentando...\n", err)
+		time.Sleep(retryDelays[attempt])
 	}
-	return nil
 }
 
-func patchJSON(path string, body any, out any) error {
-	b, _ := json.Marshal(body)
-	req, _ := http.NewRequest(http.MethodPatch, baseURL+path, strings.NewReader(string(b)))
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("PATCH %s → status %s", path, resp.Status)
-	}
-	if out != nil {
-		return json.NewDecoder(resp.Body).Decode(out)
+// retryable: no hubo respuesta, el server falló (5xx) o la misma key todavía se está procesando.
+func retryable(err error) bool {
+	var e *client.Error
+	if errors.As(err, &e) {
+		return e.Status >= 500 || e.Code == "idempotency_in_progress"
 	}
-	return nil
+	return true
 }
 
 // ======== Menús ========
 
 func main() {
+	api = client.New(baseURL)
+	if len(os.Args) > 1 {
+		os.Exit(command(os.Args[1:], os.Stdout, os.Stderr))
+	}
 	for {
 		switch firstMenu() {
 		case 1:
@@ -169,33 +130,44 @@ func registerFlow() {
 	ln := readLine("Apellido: ")
 	em := readLine("Email: ")
 	pw := readLine("Contraseña: ")
-	var u User
-	if err := postJSON("/users", map[string]any{
-		"first_name": fn,
-		"last_name":  ln,
-		"email":      em,
-		"password":   pw,
-	}, &u); err != nil {
+	u, err := api.CreateUser(ctx, client.CreateUserRequest{FirstName: fn, LastName: ln, Email: em, Password: pw})
+	if err != nil {
 		fmt.Println("Error registrando:", err)
 		return
 	}
 	fmt.Println("Usuario creado con éxito. ID:", u.ID)
 }
 
-func loginFlow() (User, bool) {
+func loginFlow() (client.User, bool) {
 	fmt.Println("\n== Iniciar sesión ==")
 	em := readLine("Email: ")
 	pw := readLine("Contraseña: ")
-	var u User
-	if err := postJSON("/login", map[string]any{"email": em, "password": pw}, &u); err != nil {
+	u, err := api.Login(ctx, client.LoginRequest{Email: em, Password: pw})
+	if err != nil {
 		fmt.Println("Error de login:", err)
-		return User{}, false
+		return client.User{}, false
 	}
 	fmt.Printf("Bienvenido, %s %s!\n", u.FirstName, u.LastName)
-	return u, true
+	showNotifications(*u)
+	return *u, true
+}
+
+// showNotifications muestra la bandeja no leída y la marca como leída.
+func showNotifications(user client.User) {
+	resp, err := api.ListNotifications(ctx, user.ID, client.ListNotificationsParams{Unread: "1"})
+	if err != nil || len(resp.Notifications) == 0 {
+		return
+	}
+	fmt.Printf("Tienes %d notificación(es) nuevas:\n", len(resp.Notifications))
+	ids := make([]int64, 0, len(resp.Notifications))
+	for _, n := range resp.Notifications {
+		fmt.Printf("  🔔 %s\n", n.Message)
+		ids = append(ids, n.ID)
+	}
+	_, _ = api.MarkNotificationsRead(ctx, user.ID, client.MarkReadRequest{IDs: ids})
 }
 
-func secondMenu(user User) {
+func secondMenu(user client.User) {
 	for {
 		fmt.Println("\nMenu")
 		fmt.Println("1. Ver catálogo")
@@ -204,11 +176,16 @@ func secondMenu(user User) {
 		fmt.Println("4. Populares")
 		fmt.Println("5. Solicitar arriendo") // ← NUEVO
 		fmt.Println("6. Devolver préstamo")  // ← NUEVO
-		fmt.Println("7. Salir al menú principal")
+		fmt.Println("7. Reseñas")
+		fmt.Println("8. Lista de deseos")
+		fmt.Println("9. Salir al menú principal")
 		op := readLine("Seleccione una opción: ")
 		switch op {
 		case "1":
-			showCatalog()
+			books := showCatalog()
+			if readLine("Enter para volver, «v» para seguirlo en vivo: ") == "v" {
+				liveCatalog(books)
+			}
 		case "2":
 			user = cartFlow(user)
 		case "3":
@@ -220,6 +197,10 @@ func secondMenu(user User) {
 		case "6":
 			loanReturnFlow(user) // ← NUEVO
 		case "7":
+			reviewsFlow(user)
+		case "8":
+			wishlistFlow(user)
+		case "9":
 			return
 		default:
 			fmt.Println("→ Opción inválida.")
@@ -229,22 +210,102 @@ func secondMenu(user User) {
 
 // ======== Catálogo ========
 
-func showCatalog() []Book {
-	var br BooksResp
-	if err := getJSON("/books", &br); err != nil {
+func showCatalog() []client.Book {
+	return showCatalogSorted("id")
+}
+
+// showCatalogSorted lista el catálogo con el orden de GET /books?sort=...
+func showCatalogSorted(order string) []client.Book {
+	br, err := api.ListBooks(ctx, client.ListBooksParams{Sort: order})
+	if err != nil {
 		fmt.Println("Error catálogo:", err)
 		return nil
 	}
-	fmt.Println("-----------------------------------------------------------------")
-	fmt.Printf("| %-7s | %-20s | %-10s | %-8s | %-5s |\n", "ID", "Nombre", "Categoría", "Modo", "Valor")
-	fmt.Println("-----------------------------------------------------------------")
+	fmt.Println("------------------------------------------------------------------------------------")
+	fmt.Printf("| %-7s | %-20s | %-10s | %-8s | %-5s | %-5s | %-8s |\n", "ID", "Nombre", "Categoría", "Modo", "Valor", "Stock", "Rating")
+	fmt.Println("------------------------------------------------------------------------------------")
 	for _, b := range br.Books {
-		fmt.Printf("| %-7d | %-20s | %-10s | %-8s | %-5d |\n", b.ID, trim(b.BookName, 20), trim(b.BookCategory, 10), b.TransactionType, b.Price)
+		fmt.Printf("| %-7d | %-20s | %-10s | %-8s | %-5d | %-5d | %-8s |\n", b.ID, trim(b.BookName, 20), trim(b.BookCategory, 10), b.TransactionType, b.Price, b.Inventory.AvailableQuantity, ratingLabel(b))
 	}
-	fmt.Println("-----------------------------------------------------------------")
+	fmt.Println("------------------------------------------------------------------------------------")
 	return br.Books
 }
 
+// bookChange es el data de los eventos book.stock y book.price de GET /events.
+type bookChange struct {
+	BookID          int64  `json:"book_id"`
+	BookName        string `json:"book_name"`
+	TransactionType string `json:"transaction_type"`
+	Price           int64  `json:"price"`
+	OldPrice        int64  `json:"old_price"`
+	Available       int64  `json:"available"`
+	OldAvailable    int64  `json:"old_available"`
+}
+
+// liveCatalog sigue el catálogo ya mostrado (books) con GET /events: cada cambio de stock o
+// precio aparece como una línea nueva hasta que el usuario presiona Enter. Si se corta la
+// conexión, reconecta desde el último evento recibido.
+func liveCatalog(books []client.Book) {
+	known := map[int64]bool{}
+	for _, b := range books {
+		known[b.ID] = true
+	}
+	live, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		params := client.EventsParams{
+			Types:  []string{"book.stock", "book.price"},
+			OnOpen: func() { fmt.Println("● En vivo: los cambios aparecen abajo (Enter para volver).") },
+		}
+		for attempt := 0; ; attempt++ {
+			last, err := api.Events(live, params, func(e client.StreamEvent) error {
+				var b bookChange
+				if err := e.Decode(&b); err != nil {
+					return err
+				}
+				printChange(e.Event, b, known)
+				return nil
+			})
+			if live.Err() != nil {
+				return
+			}
+			params.Since = last
+			fmt.Println("→ Se cortó la conexión en vivo, reconectando…", err)
+			select {
+			case <-live.Done():
+				return
+			case <-time.After(retryDelays[min(attempt, len(retryDelays)-1)]):
+			}
+		}
+	}()
+	readLine("")
+	cancel()
+	<-done
+}
+
+// printChange muestra un evento del catálogo en vivo; known son los libros ya listados.
+func printChange(event string, b bookChange, known map[int64]bool) {
+	switch {
+	case event == "book.price":
+		fmt.Printf("  ↻ %d «%s»: precio %d → %d\n", b.BookID, b.BookName, b.OldPrice, b.Price)
+	case !known[b.BookID] && b.Available > 0:
+		known[b.BookID] = true
+		fmt.Printf("  ＋ %d «%s» (%s, %d usm pesos): %d disponibles\n", b.BookID, b.BookName, b.TransactionType, b.Price, b.Available)
+	case b.Available == 0:
+		fmt.Printf("  ✘ %d «%s»: agotado\n", b.BookID, b.BookName)
+	default:
+		fmt.Printf("  ↻ %d «%s»: stock %d → %d\n", b.BookID, b.BookName, b.OldAvailable, b.Available)
+	}
+}
+
+func ratingLabel(b client.Book) string {
+	if b.ReviewCount == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f (%d)", b.AverageRating, b.ReviewCount)
+}
+
 func trim(s string, n int) string {
 	r := []rune(s)
 	if len(r) > n {
@@ -255,7 +316,7 @@ func trim(s string, n int) string {
 
 // ======== Carrito (Venta) ========
 
-func cartFlow(user User) User {
+func cartFlow(user client.User) client.User {
 	fmt.Println("\n== Carrito (solo Venta por ahora) ==")
 	books := showCatalog()
 	if len(books) == 0 {
@@ -263,7 +324,7 @@ func cartFlow(user User) User {
 		return user
 	}
 	// Mapa por ID
-	idx := map[int64]Book{}
+	idx := map[int64]client.Book{}
 	for _, b := range books {
 		idx[b.ID] = b
 	}
@@ -274,7 +335,7 @@ func cartFlow(user User) User {
 		return user
 	}
 	parts := strings.Fields(strings.ReplaceAll(line, ",", " "))
-	var cart []Book
+	var cart []client.Book
 	seen := map[int64]bool{}
 	for _, p := range parts {
 		id, err := strconv.ParseInt(p, 10, 64)
@@ -303,38 +364,38 @@ func cartFlow(user User) User {
 		return user
 	}
 
-	// Resumen
-	total := int64(0)
-	fmt.Println("------------------------------------------------------------")
-	fmt.Printf("| %-20s | %-8s | %-5s |\n", "Nombre", "Modalidad", "Valor")
-	fmt.Println("------------------------------------------------------------")
-	for _, b := range cart {
-		fmt.Printf("| %-20s | %-8s | %-5d |\n", trim(b.BookName, 20), b.TransactionType, b.Price)
-		total += b.Price
+	// Resumen con promociones (el server calcula descuentos automáticos y el código)
+	code := readLine("Código de descuento (Enter si no tienes): ")
+	q, ok := quoteCart(user, cart, code)
+	if !ok {
+		return user
 	}
-	fmt.Println("------------------------------------------------------------")
-	fmt.Printf("Total: %d usm pesos\n", total)
+	if q.CodeError != "" {
+		fmt.Println("→", q.CodeError, "(se cotiza sin código)")
+		code = ""
+	}
+	printQuote(q)
 	fmt.Printf("Tu saldo: %d usm pesos\n", user.USMPesos)
 
-	if user.USMPesos >= total {
+	if user.USMPesos >= q.Total {
 		fmt.Print("Confirmar pedido (Enter para confirmar, cualquier texto para cancelar): ")
 		if readLine("") == "" {
-			user = executeSales(cart, user)
+			user = executeCheckout(cart, code, user)
 		}
 		return user
 	}
 
-	fmt.Printf("No alcanza el saldo. Tienes %d y el pedido cuesta %d.\n", user.USMPesos, total)
+	fmt.Printf("No alcanza el saldo. Tienes %d y el pedido cuesta %d.\n", user.USMPesos, q.Total)
 	fmt.Println("1) Optimizar carrito (agrega del más barato al más caro según fondos)")
 	fmt.Println("2) Cancelar")
 	opt := readLine("Seleccione opción: ")
 	if opt != "1" {
 		return user
 	}
-	// Optimizar
-	sorted := append([]Book(nil), cart...)
+	// Optimizar (por precio de lista; los descuentos solo pueden bajar el total)
+	sorted := append([]client.Book(nil), cart...)
 	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Price < sorted[j].Price })
-	var optCart []Book
+	var optCart []client.Book
 	sum := int64(0)
 	for _, b := range sorted {
 		if sum+b.Price <= user.USMPesos {
@@ -346,44 +407,102 @@ func cartFlow(user User) User {
 		fmt.Println("Ni el libro más barato cabe en tu saldo. Cancela o abona fondos en Mi cuenta.")
 		return user
 	}
+	q, ok = quoteCart(user, optCart, code)
+	if !ok {
+		return user
+	}
 	fmt.Println("Carro optimizado:")
+	printQuote(q)
+	fmt.Printf("Total optimizado: %d usm pesos (saldo %d)\n", q.Total, user.USMPesos)
+	fmt.Print("Confirmar pedido optimizado (Enter confirma): ")
+	if readLine("") == "" {
+		user = executeCheckout(optCart, code, user)
+	}
+	return user
+}
+
+func cartBody(user client.User, items []client.Book, code string) client.CartRequest {
+	ids := make([]int64, 0, len(items))
+	for _, b := range items {
+		ids = append(ids, b.ID)
+	}
+	return client.CartRequest{UserID: user.ID, BookIDs: ids, Code: &code}
+}
+
+func quoteCart(user client.User, items []client.Book, code string) (client.Quote, bool) {
+	q, err := api.QuoteCart(ctx, cartBody(user, items, code))
+	if err != nil {
+		fmt.Println("Error cotizando:", err)
+		return client.Quote{}, false
+	}
+	return *q, true
+}
+
+func printQuote(q client.Quote) {
 	fmt.Println("------------------------------------------------------------")
-	fmt.Printf("| %-20s | %-8s | %-5s |\n", "Nombre", "Modalidad", "Valor")
+	fmt.Printf("| %-20s | %-6s | %-9s | %-6s |\n", "Nombre", "Valor", "Descuento", "Pagas")
 	fmt.Println("------------------------------------------------------------")
-	for _, b := range optCart {
-		fmt.Printf("| %-20s | %-8s | %-5d |\n", trim(b.BookName, 20), b.TransactionType, b.Price)
+	for _, l := range q.Lines {
+		fmt.Printf("| %-20s | %-6d | %-9d | %-6d |\n", trim(l.BookName, 20), l.Price, l.Discount, l.Final)
 	}
 	fmt.Println("------------------------------------------------------------")
-	fmt.Printf("Total optimizado: %d usm pesos (saldo %d)\n", sum, user.USMPesos)
-	fmt.Print("Confirmar pedido optimizado (Enter confirma): ")
-	if readLine("") == "" {
-		user = executeSales(optCart, user)
+	for _, a := range q.Applied {
+		fmt.Printf("  %s: -%d\n", a.Name, a.Discount)
 	}
-	return user
+	fmt.Printf("Subtotal: %d | Descuento: %d | Total: %d usm pesos\n", q.Subtotal, q.Discount, q.Total)
 }
 
-func executeSales(items []Book, user User) User {
+// executeCheckout compra todo el carro en una sola transacción (POST /sales/checkout).
+func executeCheckout(items []client.Book, code string, user client.User) client.User {
+	var resp *client.CheckoutResult
+	err := once(func(key client.Option) (err error) {
+		resp, err = api.Checkout(ctx, cartBody(user, items, code), key)
+		return err
+	})
+	if err != nil {
+		fmt.Println("× Falló la compra:", err)
+		return user
+	}
+	names := map[int64]string{}
 	for _, b := range items {
-		var saleResp struct {
-			ID       int64  `json:"id"`
-			UserID   int64  `json:"user_id"`
-			BookID   int64  `json:"book_id"`
-			SaleDate string `json:"sale_date"`
-		}
-		err := postJSON("/sales", map[string]any{"user_id": user.ID, "book_id": b.ID}, &saleResp)
-		if err != nil {
-			fmt.Printf("× Falló compra de %s: %v\n", b.BookName, err)
-			continue
-		}
-		user.USMPesos -= b.Price
-		fmt.Printf("✔ Comprado: %s (fecha %s)\n", b.BookName, saleResp.SaleDate)
+		names[b.ID] = b.BookName
+	}
+	for _, s := range resp.Sales {
+		fmt.Printf("✔ Comprado: %s (fecha %s)\n", names[s.BookID], s.SaleDate)
 	}
+	if resp.Quote.Discount > 0 {
+		fmt.Printf("Ahorraste %d usm pesos.\n", resp.Quote.Discount)
+	}
+	user.USMPesos -= resp.Quote.Total
+	showRecommendations(user)
 	return user
 }
 
+// showRecommendations muestra sugerencias basadas en el historial del usuario.
+func showRecommendations(user client.User) {
+	resp, err := api.ListRecommendations(ctx, user.ID, client.ListRecommendationsParams{Limit: 3})
+	if err != nil {
+		return
+	}
+	if len(resp.Recommendations) == 0 {
+		return
+	}
+	fmt.Println("También te podría interesar:")
+	for _, r := range resp.Recommendations {
+		motivo := "popular"
+		switch r.Reason {
+		case "similar":
+			motivo = "otros lectores también lo llevaron"
+		case "categoria":
+			motivo = "de tus categorías"
+		}
+		fmt.Printf("  · [%d] %s (%s, %d usm) — %s\n", r.Book.ID, r.Book.BookName, r.Book.TransactionType, r.Book.Price, motivo)
+	}
+}
+
 // ======== Mi cuenta ========
 
-func myAccount(user User) User {
+func myAccount(user client.User) client.User {
 	for {
 		fmt.Println("\nMi cuenta")
 		fmt.Println("1. Consultar saldo")
@@ -393,12 +512,12 @@ func myAccount(user User) User {
 		s := readLine("Seleccione: ")
 		switch s {
 		case "1":
-			var u User
-			if err := getJSON("/users/"+strconv.FormatInt(user.ID, 10), &u); err != nil {
+			u, err := api.GetUser(ctx, user.ID)
+			if err != nil {
 				fmt.Println("Error:", err)
 				break
 			}
-			user = u
+			user = *u
 			fmt.Println("Saldo:", user.USMPesos, "usm pesos")
 		case "2":
 			amt := readInt("Monto a abonar: ")
@@ -406,14 +525,21 @@ func myAccount(user User) User {
 				fmt.Println("Nada que abonar")
 				break
 			}
-			if err := patchJSON("/users/"+strconv.FormatInt(user.ID, 10), map[string]any{"abonar": amt}, &user); err != nil {
+			err := once(func(key client.Option) error {
+				u, err := api.UpdateUser(ctx, user.ID, client.UpdateUserRequest{Abonar: &amt}, key)
+				if err == nil {
+					user = *u
+				}
+				return err
+			})
+			if err != nil {
 				fmt.Println("Error abonando:", err)
 				break
 			}
 			fmt.Println("Nuevo saldo:", user.USMPesos)
 		case "3":
-			var tr TransactionsResp
-			if err := getJSON("/users/"+strconv.FormatInt(user.ID, 10)+"/transactions", &tr); err != nil {
+			tr, err := api.ListUserTransactions(ctx, user.ID)
+			if err != nil {
 				fmt.Println("Error:", err)
 				break
 			}
@@ -435,8 +561,8 @@ func myAccount(user User) User {
 // ======== Populares ========
 
 func showPopular() {
-	var br BooksResp
-	if err := getJSON("/books/popular?limit=5", &br); err != nil {
+	br, err := api.ListPopularBooks(ctx, client.ListPopularBooksParams{Limit: 5})
+	if err != nil {
 		fmt.Println("Error populares:", err)
 		return
 	}
@@ -449,18 +575,169 @@ func showPopular() {
 	fmt.Println("---------------------------------------------------------------")
 }
 
+// ======== Reseñas ========
+
+func reviewsFlow(user client.User) {
+	for {
+		fmt.Println("\n== Reseñas ==")
+		fmt.Println("1. Catálogo ordenado por rating")
+		fmt.Println("2. Ver reseñas de un libro")
+		fmt.Println("3. Escribir o editar mi reseña")
+		fmt.Println("4. Borrar mi reseña")
+		fmt.Println("5. Volver")
+		switch readLine("Seleccione: ") {
+		case "1":
+			showCatalogSorted("rating")
+		case "2":
+			id := readInt("ID del libro: ")
+			if id != 0 {
+				showReviews(id)
+			}
+		case "3":
+			writeReviewFlow(user)
+		case "4":
+			id := readInt("ID del libro: ")
+			if id == 0 {
+				break
+			}
+			mine := myReview(id, user.ID)
+			if mine == nil {
+				fmt.Println("No tienes reseña para ese libro.")
+				break
+			}
+			if err := api.DeleteReview(ctx, id, mine.ID, client.DeleteReviewParams{UserID: user.ID}); err != nil {
+				fmt.Println("Error borrando:", err)
+				break
+			}
+			fmt.Println("✔ Reseña borrada.")
+		case "5":
+			return
+		default:
+			fmt.Println("→ Opción inválida.")
+		}
+	}
+}
+
+func fetchReviews(bookID int64) ([]client.Review, error) {
+	resp, err := api.ListReviews(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Reviews, nil
+}
+
+func myReview(bookID, userID int64) *client.Review {
+	list, err := fetchReviews(bookID)
+	if err != nil {
+		return nil
+	}
+	for i := range list {
+		if list[i].UserID == userID {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+func showReviews(bookID int64) {
+	list, err := fetchReviews(bookID)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if len(list) == 0 {
+		fmt.Println("Aún no hay reseñas para este libro.")
+		return
+	}
+	for _, r := range list {
+		fmt.Printf("%s %s — %s\n", strings.Repeat("★", int(r.Rating))+strings.Repeat("☆", 5-int(r.Rating)), r.UserName, r.Comment)
+	}
+}
+
+func writeReviewFlow(user client.User) {
+	id := readInt("ID del libro (debes haberlo comprado o arrendado): ")
+	if id == 0 {
+		return
+	}
+	rating := readInt("Estrellas (1-5): ")
+	if rating < 1 || rating > 5 {
+		fmt.Println("Las estrellas van de 1 a 5.")
+		return
+	}
+	comment := readLine("Comentario: ")
+	if mine := myReview(id, user.ID); mine != nil {
+		body := client.UpdateReviewRequest{UserID: user.ID, Rating: &rating, Comment: &comment}
+		if _, err := api.UpdateReview(ctx, id, mine.ID, body); err != nil {
+			fmt.Println("Error editando:", err)
+			return
+		}
+		fmt.Println("✔ Reseña actualizada.")
+		return
+	}
+	body := client.CreateReviewRequest{UserID: user.ID, Rating: rating, Comment: &comment}
+	if _, err := api.CreateReview(ctx, id, body); err != nil {
+		fmt.Println("Error publicando:", err)
+		return
+	}
+	fmt.Println("✔ Reseña publicada.")
+}
+
+// ======== Lista de deseos ========
+
+func wishlistFlow(user client.User) {
+	for {
+		resp, err := api.GetWishlist(ctx, user.ID)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Println("\n== Lista de deseos ==")
+		if len(resp.Wishlist) == 0 {
+			fmt.Println("(vacía) Te avisaremos si un libro que sigas baja de precio o vuelve a tener stock.")
+		}
+		for _, w := range resp.Wishlist {
+			fmt.Printf("- [%d] %s — %d usm, %s\n", w.Book.ID, w.Book.BookName, w.Book.Price, w.Book.Status)
+		}
+		fmt.Println("1. Seguir un libro")
+		fmt.Println("2. Dejar de seguir")
+		fmt.Println("3. Volver")
+		switch readLine("Seleccione: ") {
+		case "1":
+			id := readInt("ID del libro: ")
+			if id == 0 {
+				break
+			}
+			if _, err := api.AddToWishlist(ctx, user.ID, client.WishlistRequest{BookID: id}); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case "2":
+			id := readInt("ID del libro: ")
+			if id == 0 {
+				break
+			}
+			if err := api.RemoveFromWishlist(ctx, user.ID, id); err != nil {
+				fmt.Println("Error:", err)
+			}
+		case "3":
+			return
+		default:
+			fmt.Println("→ Opción inválida.")
+		}
+	}
+}
+
 // ======== Nota ========
 // Próximos pasos que se integrarán aquí:
 // - Flujo de arriendo (POST /loans) y devolución (PATCH /loans/:id/return)
 // - Validación de cantidades por inventario en el carrito
 // - Mostrar fecha de devolución estimada para arriendos
 //
-// Este CLI asume que el servidor está escuchando en http://localhost:8080.
-// Puedes cambiar baseURL arriba o leerlo desde una variable de entorno.
+// Este CLI asume que el servidor está escuchando en http://localhost:8080
+// salvo que se indique otra URL en UZM_API_URL.
 
 // Fin.
 
-func loanRequestFlow(user User) {
+func loanRequestFlow(user client.User) {
 	fmt.Println("\n== Solicitar arriendo ==")
 	books := showCatalog()
 	if len(books) == 0 {
@@ -471,7 +748,7 @@ func loanRequestFlow(user User) {
 	if id == 0 {
 		return
 	}
-	var picked *Book
+	var picked *client.Book
 	for i := range books {
 		if books[i].ID == id {
 			picked = &books[i]
@@ -490,32 +767,23 @@ func loanRequestFlow(user User) {
 		fmt.Println("Sin stock.")
 		return
 	}
-	var out struct {
-		ID      int64  `json:"id"`
-		DueDate string `json:"due_date"`
-		Status  string `json:"status"`
-	}
-	if err := postJSON("/loans", map[string]any{"user_id": user.ID, "book_id": id}, &out); err != nil {
+	var out *client.Loan
+	err := once(func(key client.Option) (err error) {
+		out, err = api.CreateLoan(ctx, client.LoanRequest{UserID: user.ID, BookID: id}, key)
+		return err
+	})
+	if err != nil {
 		fmt.Println("Error:", err)
 		return
 	}
 	fmt.Printf("✔ Arriendo creado (id %d). Fecha límite: %s\n", out.ID, out.DueDate)
 }
 
-func loanReturnFlow(user User) {
+func loanReturnFlow(user client.User) {
 	fmt.Println("\n== Devolver préstamo ==")
-	// Obtener todos los préstamos y filtrar por usuario/pendiente
-	var resp struct {
-		Loans []struct {
-			ID        int64  `json:"id"`
-			UserID    int64  `json:"user_id"`
-			BookID    int64  `json:"book_id"`
-			Status    string `json:"status"`
-			StartDate string `json:"start_date"`
-			DueDate   string `json:"due_date"`
-		} `json:"loans"`
-	}
-	if err := getJSON("/loans", &resp); err != nil {
+	// Obtener todos los préstamos y filtrar por usuario/sin devolver (pendiente o vencido)
+	resp, err := api.ListLoans(ctx)
+	if err != nil {
 		fmt.Println("Error:", err)
 		return
 	}
@@ -524,7 +792,7 @@ func loanReturnFlow(user User) {
 		Due        string
 	}
 	for _, l := range resp.Loans {
-		if l.UserID == user.ID && l.Status == "pendiente" {
+		if l.UserID == user.ID && (l.Status == "pendiente" || l.Status == "vencido") {
 			pending = append(pending, struct {
 				ID, BookID int64
 				Due        string
@@ -543,16 +811,17 @@ func loanReturnFlow(user User) {
 	if loanID == 0 {
 		return
 	}
-	date := readLine("Fecha de devolución (DD/MM/YYYY, vacío = hoy): ")
-	if strings.TrimSpace(date) == "" {
-		date = time.Now().Format("02/01/2006")
-	}
-	var out struct {
-		Status   string `json:"status"`
-		DaysLate int64  `json:"days_late"`
-		Penalty  int64  `json:"penalty"`
+	// vacío = hoy según el reloj del server (su zona horaria, o la fecha simulada en demos)
+	var body client.ReturnLoanRequest
+	if date := strings.TrimSpace(readLine("Fecha de devolución (DD/MM/YYYY, vacío = hoy): ")); date != "" {
+		body.ReturnDate = &date
 	}
-	if err := patchJSON("/loans/"+strconv.FormatInt(loanID, 10)+"/return", map[string]any{"return_date": date}, &out); err != nil {
+	var out *client.Loan
+	err = once(func(key client.Option) (err error) {
+		out, err = api.ReturnLoan(ctx, loanID, body, key)
+		return err
+	})
+	if err != nil {
 		fmt.Println("Error devolviendo:", err)
 		return
 	}
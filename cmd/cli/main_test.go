@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	server "tarea1-uzm/internal/api" // api es el cliente global de main.go
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/client"
+)
+
+// runCLI ejecuta main() contra srv leyendo las líneas de script como si fueran el teclado
+// y devuelve todo lo que se imprimió. El guion debe terminar saliendo del programa.
+func runCLI(t *testing.T, srv *apitest.Server, script ...string) string {
+	t.Helper()
+	oldURL, oldIn, oldOut, oldArgs := baseURL, in, os.Stdout, os.Args
+	t.Cleanup(func() { baseURL, in, os.Stdout, os.Args = oldURL, oldIn, oldOut, oldArgs })
+	os.Args = os.Args[:1] // sin subcomando: el menú
+
+	baseURL = srv.URL
+	in = bufio.NewReader(strings.NewReader(strings.Join(script, "\n") + "\n"))
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	var out bytes.Buffer
+	copied := make(chan struct{})
+	go func() { io.Copy(&out, r); close(copied) }()
+
+	done := make(chan struct{})
+	go func() { main(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		w.Close()
+		<-copied
+		t.Fatalf("el CLI no terminó; salida:\n%s", out.String())
+	}
+	w.Close()
+	<-copied
+	return out.String()
+}
+
+// session es un main() en curso al que se le escribe de a poco, para los flujos que esperan
+// algo del servidor antes de seguir (el catálogo en vivo).
+type session struct {
+	t      *testing.T
+	keys   *io.PipeWriter
+	mu     sync.Mutex
+	out    bytes.Buffer
+	done   chan struct{}
+	copied chan struct{}
+}
+
+// startCLI ejecuta main() contra srv; el teclado se escribe con send y la salida se espera
+// con waitFor.
+func startCLI(t *testing.T, srv *apitest.Server) *session {
+	t.Helper()
+	oldURL, oldIn, oldOut, oldArgs := baseURL, in, os.Stdout, os.Args
+	pr, pw := io.Pipe()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &session{t: t, keys: pw, done: make(chan struct{}), copied: make(chan struct{})}
+	baseURL, in, os.Stdout, os.Args = srv.URL, bufio.NewReader(pr), w, os.Args[:1]
+	t.Cleanup(func() {
+		pw.Close()
+		w.Close()
+		<-s.copied
+		baseURL, in, os.Stdout, os.Args = oldURL, oldIn, oldOut, oldArgs
+	})
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := r.Read(buf)
+			s.mu.Lock()
+			s.out.Write(buf[:n])
+			s.mu.Unlock()
+			if err != nil {
+				close(s.copied)
+				return
+			}
+		}
+	}()
+	go func() { main(); close(s.done) }()
+	return s
+}
+
+// send escribe lines en el teclado; vuelve cuando main() las leyó.
+func (s *session) send(lines ...string) {
+	s.keys.Write([]byte(strings.Join(lines, "\n") + "\n"))
+}
+
+func (s *session) output() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.out.String()
+}
+
+// waitFor espera a que la salida contenga part.
+func (s *session) waitFor(part string) {
+	s.t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(s.output(), part) {
+		if time.Now().After(deadline) {
+			s.t.Fatalf("la salida no llegó a %q:\n%s", part, s.output())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// wait espera a que main() termine.
+func (s *session) wait() {
+	s.t.Helper()
+	select {
+	case <-s.done:
+	case <-time.After(10 * time.Second):
+		s.t.Fatalf("el CLI no terminó; salida:\n%s", s.output())
+	}
+}
+
+func wantContains(t *testing.T, out string, parts ...string) {
+	t.Helper()
+	for _, p := range parts {
+		if !strings.Contains(out, p) {
+			t.Errorf("la salida no contiene %q:\n%s", p, out)
+		}
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	srv := apitest.NewServer(t)
+	out := runCLI(t, srv,
+		"1", "Ana", "Rojas", "ana@usm.cl", "clave123", // registrarse
+		"1", "Ana", "Rojas", "ana@usm.cl", "clave123", // de nuevo: email repetido
+		"2", "ana@usm.cl", "mala", // login fallido
+		"2", "ana@usm.cl", "clave123", "9", // login y volver
+		"3",
+	)
+	wantContains(t, out,
+		"Usuario creado con éxito. ID: 1",
+		"Error registrando: ya existe un registro con ese email",
+		"Error de login: email o contraseña incorrectos",
+		"Bienvenido, Ana Rojas!",
+		"¡Gracias por usar UZM!",
+	)
+}
+
+func TestBuyWithTopUp(t *testing.T) {
+	srv := apitest.NewServer(t)
+	apitest.NewUser().Email("ana@usm.cl").Password("clave123").Balance(5).Insert(t, srv.DB)
+	book := apitest.NewBook().Name("Rayuela").Price(30).Stock(3).Insert(t, srv.DB)
+
+	out := runCLI(t, srv,
+		"2", "ana@usm.cl", "clave123",
+		"2", fmt.Sprint(book), "", "2", // carro: no alcanza el saldo -> cancelar
+		"3", "2", "50", "4", // mi cuenta: abonar 50
+		"2", fmt.Sprint(book), "", "", // carro: ahora sí, confirmar
+		"3", "1", "4", // saldo
+		"9", "3",
+	)
+	wantContains(t, out,
+		"No alcanza el saldo. Tienes 5 y el pedido cuesta 30.",
+		"Nuevo saldo: 55",
+		"✔ Comprado: Rayuela",
+		"Saldo: 25 usm pesos",
+	)
+}
+
+func TestLoanAndLateReturn(t *testing.T) {
+	srv := apitest.NewServer(t)
+	user := apitest.NewUser().Email("ana@usm.cl").Password("clave123").Balance(10).Insert(t, srv.DB)
+	book := apitest.NewBook().Name("Ficciones").ForLoan().Stock(1).Insert(t, srv.DB)
+	loan := apitest.NewLoan(user, book).Started("01/01/2025").Insert(t, srv.DB)
+	forSale := apitest.NewBook().ForSale().Insert(t, srv.DB)
+
+	out := runCLI(t, srv,
+		"2", "ana@usm.cl", "clave123",
+		"5", fmt.Sprint(forSale), // pedir en arriendo un libro de Venta
+		"5", fmt.Sprint(book), // arriendo ok
+		"6", fmt.Sprint(loan), "11/02/2025", // devolver el antiguo con 10 días de atraso
+		"3", "1", "4",
+		"9", "3",
+	)
+	wantContains(t, out,
+		"Ese libro no está en Arriendo.",
+		"✔ Arriendo creado (id 2).",
+		"✔ Devuelto. Atraso: 10 días, multa: 20",
+		"Saldo: -10 usm pesos",
+	)
+}
+
+// dropResponses es un proxy a srv que ejecuta las primeras n requests a path pero corta la
+// conexión sin responder, como cuando se pierde la respuesta en la red de la VM.
+// (net/http ya reintenta una vez por su cuenta las requests con Idempotency-Key.)
+func dropResponses(t *testing.T, srv *apitest.Server, path string, n int) *apitest.Server {
+	t.Helper()
+	target, _ := url.Parse(srv.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	var dropped atomic.Int32
+	p := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path || dropped.Add(1) > int32(n) {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+		proxy.ServeHTTP(httptest.NewRecorder(), r)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		conn.Close()
+	}))
+	t.Cleanup(p.Close)
+	return &apitest.Server{Server: p, DB: srv.DB}
+}
+
+func TestPurchaseRetriedAfterLostResponse(t *testing.T) {
+	oldDelays := retryDelays
+	retryDelays = []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond}
+	t.Cleanup(func() { retryDelays = oldDelays })
+
+	srv := apitest.NewServer(t)
+	user := apitest.NewUser().Email("ana@usm.cl").Password("clave123").Balance(50).Insert(t, srv.DB)
+	book := apitest.NewBook().Name("Rayuela").Price(30).Stock(3).Insert(t, srv.DB)
+
+	out := runCLI(t, dropResponses(t, srv, srv.Base+"/sales/checkout", 2),
+		"2", "ana@usm.cl", "clave123",
+		"2", fmt.Sprint(book), "", "", // carro: las dos primeras respuestas se pierden
+		"9", "3",
+	)
+	wantContains(t, out, "reintentando", "✔ Comprado: Rayuela")
+	if got := srv.QueryInt(`SELECT COUNT(*) FROM sales WHERE user_id=?`, user); got != 1 {
+		t.Errorf("ventas = %d, want 1", got)
+	}
+	if got := srv.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); got != 20 {
+		t.Errorf("saldo = %d, want 20 (un solo cobro)", got)
+	}
+}
+
+func TestLiveCatalog(t *testing.T) {
+	srv := apitest.NewServer(t, server.WithEventPoll(10*time.Millisecond))
+	apitest.NewUser().Email("ana@usm.cl").Password("clave123").Insert(t, srv.DB)
+	buyer := apitest.NewUser().Email("beto@usm.cl").Balance(100).Insert(t, srv.DB)
+	book := apitest.NewBook().Name("Rayuela").Price(30).Stock(2).Insert(t, srv.DB)
+
+	cli := startCLI(t, srv)
+	cli.send("2", "ana@usm.cl", "clave123", "1", "v")
+	cli.waitFor("En vivo")
+
+	// mientras tanto otro usuario compra y cambia el precio
+	if resp := srv.Do(http.MethodPost, "/sales", map[string]any{"user_id": buyer, "book_id": book}); resp.Status != http.StatusCreated {
+		t.Fatalf("compra: %d %s", resp.Status, resp.Body)
+	}
+	cli.waitFor("«Rayuela»: stock 2 → 1")
+	srv.Do(http.MethodPatch, fmt.Sprintf("/books/%d", book), map[string]any{"price": 25})
+	cli.waitFor("«Rayuela»: precio 30 → 25")
+	srv.Do(http.MethodPost, "/sales", map[string]any{"user_id": buyer, "book_id": book})
+	cli.waitFor("«Rayuela»: agotado")
+
+	cli.send("", "9", "3")
+	cli.wait()
+	cli.waitFor("¡Gracias por usar UZM!")
+	wantContains(t, cli.output(), "| Stock |")
+}
+
+func TestImportExportCommands(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	srv := apitest.NewServer(t)
+	oldAPI := api
+	api = client.New(srv.URL)
+	t.Cleanup(func() { api = oldAPI })
+	apitest.NewBook().Name("Rayuela").Price(30).Insert(t, srv.DB)
+
+	dir := t.TempDir()
+	run := func(want int, args ...string) (string, string) {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if got := command(args, &stdout, &stderr); got != want {
+			t.Fatalf("cli %s = %d, want %d\n%s%s", strings.Join(args, " "), got, want, stdout.String(), stderr.String())
+		}
+		return stdout.String(), stderr.String()
+	}
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	file := write("libros.jsonl", `{"book_name":"Rayuela","price":25}`+"\n"+`{"isbn":"9788437604572","book_name":"Ficciones","book_category":"Cuentos","transaction_type":"Arriendo"}`+"\n")
+	out, _ := run(0, "import", "books", file, "-dry-run")
+	wantContains(t, out, "Validado (sin guardar): 2 filas, 1 nuevos, 1 actualizados")
+	out, _ = run(0, "import", "-dry-run=false", "books", file)
+	wantContains(t, out, "Importado: 2 filas, 1 nuevos, 1 actualizados")
+
+	_, errOut := run(1, "import", "books", write("malo.csv", "book_name,price\nNuevo,diez\n"))
+	wantContains(t, errOut, "1 error(es), no se importó nada", "línea 2: price debe ser un entero")
+
+	export := filepath.Join(dir, "libros.csv")
+	out, _ = run(0, "export", "books", "-o", export)
+	wantContains(t, out, "books exportado a "+export)
+	raw, _ := os.ReadFile(export)
+	wantContains(t, string(raw), "1,,Rayuela,,General,Venta,25,1,0", "2,9788437604572,Ficciones,,Cuentos,Arriendo,0,0,0")
+	// la extensión elige el formato: .xml es MARCXML, de ida y de vuelta
+	marcxml := filepath.Join(dir, "catalogo.xml")
+	run(0, "export", "books", "-o", marcxml)
+	raw, _ = os.ReadFile(marcxml)
+	wantContains(t, string(raw), `<collection xmlns="http://www.loc.gov/MARC21/slim">`, `<subfield code="a">Ficciones</subfield>`)
+	out, _ = run(0, "import", "books", marcxml)
+	wantContains(t, out, "Importado: 2 filas, 0 nuevos, 0 actualizados, 2 sin cambios")
+	out, _ = run(0, "export", "users", "-format", "jsonl")
+	if out != "" {
+		t.Errorf("users sin usuarios = %q", out)
+	}
+
+	run(2, "export", "passwords")
+	run(2, "import", "users", file)
+	t.Setenv("UZM_ADMIN_TOKEN", "")
+	_, errOut = run(1, "export", "books")
+	wantContains(t, errOut, "requiere token de administrador")
+}
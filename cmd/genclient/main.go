@@ -0,0 +1,34 @@
+// Command genclient genera internal/client/client.gen.go desde internal/api/openapi.json.
+// Se corre con `go generate ./internal/client`.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"tarea1-uzm/internal/openapi"
+)
+
+func main() {
+	spec := flag.String("spec", "../api/openapi.json", "especificación OpenAPI")
+	out := flag.String("out", "client.gen.go", "archivo a escribir")
+	pkg := flag.String("pkg", "client", "paquete del código generado")
+	flag.Parse()
+
+	data, err := os.ReadFile(*spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc, err := openapi.Parse(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := openapi.Generate(doc, *pkg, "internal/api/openapi.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
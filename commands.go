@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"tarea1-uzm/internal/backup"
+	"tarea1-uzm/internal/clock"
+	"tarea1-uzm/internal/db"
+	"tarea1-uzm/internal/seed"
+)
+
+// dbPath es la base del servidor.
+const dbPath = "data/uzm.db"
+
+const usage = `uso (sin argumentos levanta el servidor; directorio y retención en UZM_BACKUP_*):
+  go run . backup  [-db data/uzm.db]            copia en línea, sirve con el servidor arriba
+  go run . backups                              lista las copias
+  go run . verify  <copia>                      descomprime y corre integrity_check
+  go run . restore <copia> [-db data/uzm.db]    reemplaza la base (con el servidor abajo)
+  go run . seed    [-db data/uzm.db] [tamaño]   llena una base vacía con datos de demo
+  go run . reset   [-db data/uzm.db] [tamaño] [-empty]
+                                                copia, borra y vuelve a sembrar (con el servidor abajo)
+tamaño: -seed 1 -users 20 -books 60 -sales 150 -loans 80 -days 120`
+
+// command corre un subcomando del servidor y devuelve el código de salida: 0 ok, 1 la
+// copia no sirve, la base ya tiene datos o el restore/reset se negó, 2 uso incorrecto o error.
+func command(args []string, stdout, stderr io.Writer) int {
+	cfg, err := backup.FromEnv()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String("db", dbPath, "base SQLite")
+	opts := seed.Defaults
+	var empty bool
+	if args[0] == "seed" || args[0] == "reset" {
+		fs.Uint64Var(&opts.Seed, "seed", opts.Seed, "semilla: la misma da los mismos datos (el mismo día)")
+		fs.IntVar(&opts.Users, "users", opts.Users, "usuarios")
+		fs.IntVar(&opts.Books, "books", opts.Books, "libros")
+		fs.IntVar(&opts.Sales, "sales", opts.Sales, "ventas")
+		fs.IntVar(&opts.Loans, "loans", opts.Loans, "préstamos")
+		fs.IntVar(&opts.Days, "days", opts.Days, "días de historia")
+	}
+	if args[0] == "reset" {
+		fs.BoolVar(&empty, "empty", false, "deja la base vacía (sin sembrar)")
+	}
+	pos, err := parseAnywhere(fs, args[1:])
+	if err != nil {
+		return 2
+	}
+	ctx := context.Background()
+
+	switch {
+	case args[0] == "backup" && len(pos) == 0:
+		if _, err := os.Stat(*path); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		sqlDB, err := db.Open(*path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		defer sqlDB.Close()
+		res, err := backup.Create(ctx, sqlDB, cfg, time.Now())
+		if err != nil {
+			fmt.Fprintln(stderr, "✘", err)
+			return 2
+		}
+		fmt.Fprintf(stdout, "✔ %s (%d bytes, integridad ok)\n", res.Name, res.Size)
+		for _, name := range res.Deleted {
+			fmt.Fprintf(stdout, "  borrada por retención: %s\n", name)
+		}
+		return 0
+
+	case args[0] == "backups" && len(pos) == 0:
+		files, err := backup.List(cfg.Dir)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		for _, f := range files {
+			fmt.Fprintf(stdout, "%s  %10d bytes  %s\n", f.Name, f.Size, f.CreatedAt.Local().Format(time.DateTime))
+		}
+		fmt.Fprintf(stdout, "%d copias en %s (se guardan las %d más nuevas y la última de cada uno de los últimos %d días)\n",
+			len(files), cfg.Dir, cfg.Keep, cfg.Daily)
+		return 0
+
+	case args[0] == "verify" && len(pos) == 1:
+		file, ok := resolve(cfg, pos[0], stderr)
+		if !ok {
+			return 2
+		}
+		if err := backup.Verify(file); err != nil {
+			fmt.Fprintln(stdout, "✘", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "✔ %s íntegra\n", file)
+		return 0
+
+	case args[0] == "restore" && len(pos) == 1:
+		file, ok := resolve(cfg, pos[0], stderr)
+		if !ok {
+			return 2
+		}
+		safety, err := backup.Restore(ctx, file, *path, cfg)
+		if errors.Is(err, db.ErrInUse) {
+			fmt.Fprintf(stderr, "✘ el servidor está corriendo sobre %s: detenlo antes de restaurar\n", *path)
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, "✘ no se restauró:", err)
+			return 1
+		}
+		if safety != nil {
+			fmt.Fprintf(stdout, "  la base anterior quedó en %s\n", safety.Name)
+		}
+		fmt.Fprintf(stdout, "✔ %s restaurada desde %s\n", *path, file)
+		return 0
+
+	case args[0] == "seed" && len(pos) == 0:
+		sqlDB, err := db.Open(*path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		defer sqlDB.Close()
+		if err := db.Migrate(sqlDB); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		return seedDB(ctx, sqlDB, opts, stdout, stderr)
+
+	case args[0] == "reset" && len(pos) == 0:
+		// exclusivo hasta terminar de sembrar: el servidor no puede levantar a medias
+		lock, err := db.Lock(*path, true)
+		if errors.Is(err, db.ErrInUse) {
+			fmt.Fprintf(stderr, "✘ el servidor está corriendo sobre %s: detenlo antes del reset\n", *path)
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		defer lock.Close()
+		if _, err := os.Stat(*path); err == nil {
+			current, err := db.Open(*path)
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return 2
+			}
+			res, err := backup.Create(ctx, current, cfg, time.Now())
+			current.Close()
+			if err != nil {
+				fmt.Fprintln(stderr, "✘ no se pudo respaldar la base actual:", err)
+				return 2
+			}
+			fmt.Fprintf(stdout, "  la base anterior quedó en %s\n", res.Name)
+		}
+		// solo la base y su WAL: el .lock lo tenemos tomado
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := os.Remove(*path + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintln(stderr, err)
+				return 2
+			}
+		}
+		sqlDB, err := db.Open(*path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		defer sqlDB.Close()
+		if err := db.Migrate(sqlDB); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		if empty {
+			fmt.Fprintf(stdout, "✔ %s vacía\n", *path)
+			return 0
+		}
+		return seedDB(ctx, sqlDB, opts, stdout, stderr)
+	}
+	fmt.Fprintln(stderr, usage)
+	return 2
+}
+
+// seedDB siembra sqlDB con la hora del negocio (UZM_TZ) y muestra el resumen.
+func seedDB(ctx context.Context, sqlDB *sql.DB, opts seed.Options, stdout, stderr io.Writer) int {
+	loc, err := clock.LoadZone(os.Getenv("UZM_TZ"))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	start := time.Now()
+	sum, err := seed.Run(ctx, sqlDB, opts, clock.System{Loc: loc}.Now())
+	if errors.Is(err, seed.ErrNotEmpty) {
+		fmt.Fprintln(stderr, "✘ la base ya tiene usuarios o libros: usa reset para empezar de cero")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "✘", err)
+		return 2
+	}
+	fmt.Fprintf(stdout, "✔ semilla %d: %d usuarios (contraseña %s), %d libros (%d venta, %d arriendo)\n",
+		opts.Seed, sum.Users, seed.Password, sum.ForSale+sum.ForLoan, sum.ForSale, sum.ForLoan)
+	fmt.Fprintf(stdout, "  %d ventas y %d préstamos en los últimos %d días: %d devueltos (%d con atraso), %d vencidos, %d al día\n",
+		sum.Sales, sum.Loans, opts.Days, sum.Returned, sum.Late, sum.Overdue, sum.Loans-sum.Returned-sum.Overdue)
+	fmt.Fprintf(stdout, "  %d reposiciones de stock, %d abonos (%s)\n", sum.Restocks, sum.Deposits, time.Since(start).Round(time.Millisecond))
+	return 0
+}
+
+// parseAnywhere acepta los flags antes o después de los argumentos.
+func parseAnywhere(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return pos, nil
+		}
+		pos = append(pos, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// resolve acepta la ruta de una copia o su nombre dentro del directorio de backups.
+func resolve(cfg backup.Config, arg string, stderr io.Writer) (string, bool) {
+	if _, err := os.Stat(arg); err == nil {
+		return arg, true
+	}
+	path, err := backup.Path(cfg.Dir, arg)
+	if err != nil {
+		fmt.Fprintf(stderr, "no existe la copia %s\n", arg)
+		return "", false
+	}
+	return path, true
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tarea1-uzm/internal/backup"
+	"tarea1-uzm/internal/db"
+)
+
+func TestBackupCommands(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("UZM_BACKUP_DIR", filepath.Join(dir, "backups"))
+	path := filepath.Join(dir, "uzm.db")
+	sqlDB, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqlDB.Exec(`INSERT INTO users(first_name,last_name,email,password) VALUES('Ana','Pérez','ana@usm.cl','x')`); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(want int, args ...string) string {
+		t.Helper()
+		var out, errOut bytes.Buffer
+		if code := command(args, &out, &errOut); code != want {
+			t.Fatalf("%s = %d, want %d\n%s%s", strings.Join(args, " "), code, want, out.String(), errOut.String())
+		}
+		return out.String() + errOut.String()
+	}
+
+	// con la base abierta (el servidor arriba) se puede sacar copia, no restaurar
+	server, err := db.Lock(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := run(0, "backup", "-db", path)
+	if !strings.Contains(out, "integridad ok") {
+		t.Errorf("backup: %s", out)
+	}
+	files, _ := backup.List(filepath.Join(dir, "backups"))
+	if len(files) != 1 {
+		t.Fatalf("copias = %+v", files)
+	}
+	name := files[0].Name
+	if out := run(0, "backups"); !strings.Contains(out, name) || !strings.Contains(out, "1 copias") {
+		t.Errorf("backups: %s", out)
+	}
+	if out := run(0, "verify", name); !strings.Contains(out, "íntegra") {
+		t.Errorf("verify: %s", out)
+	}
+	if out := run(1, "restore", name, "-db", path); !strings.Contains(out, "el servidor está corriendo") {
+		t.Errorf("restore con el servidor arriba: %s", out)
+	}
+
+	if _, err := sqlDB.Exec(`DELETE FROM users`); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+	server.Close()
+	out = run(0, "restore", filepath.Join(dir, "backups", name), "-db", path)
+	if !strings.Contains(out, "restaurada desde") || !strings.Contains(out, "la base anterior quedó en") {
+		t.Errorf("restore: %s", out)
+	}
+	sqlDB, err = db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	var n int
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("usuarios tras restaurar = %d, %v", n, err)
+	}
+
+	run(2, "verify", "no-existe.db.gz")
+	run(2, "restore")
+	run(2, "otra-cosa")
+}
+
+func TestSeedCommands(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("UZM_BACKUP_DIR", filepath.Join(dir, "backups"))
+	path := filepath.Join(dir, "uzm.db")
+	run := func(want int, args ...string) string {
+		t.Helper()
+		var out, errOut bytes.Buffer
+		if code := command(args, &out, &errOut); code != want {
+			t.Fatalf("%s = %d, want %d\n%s%s", strings.Join(args, " "), code, want, out.String(), errOut.String())
+		}
+		return out.String() + errOut.String()
+	}
+	users := func() int {
+		t.Helper()
+		sqlDB, err := db.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sqlDB.Close()
+		var n int
+		if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	size := []string{"-users", "5", "-books", "10", "-sales", "20", "-loans", "10", "-days", "60"}
+	out := run(0, append([]string{"seed", "-db", path}, size...)...)
+	if !strings.Contains(out, "5 usuarios (contraseña demo1234), 10 libros") || !strings.Contains(out, "20 ventas y 10 préstamos") {
+		t.Errorf("seed: %s", out)
+	}
+	if out := run(1, "seed", "-db", path); !strings.Contains(out, "usa reset") {
+		t.Errorf("seed sobre una base con datos: %s", out)
+	}
+
+	// reset se niega con el servidor arriba y deja la base como estaba
+	server, err := db.Lock(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := run(1, "reset", "-db", path); !strings.Contains(out, "el servidor está corriendo") {
+		t.Errorf("reset con el servidor arriba: %s", out)
+	}
+	server.Close()
+	if n := users(); n != 5 {
+		t.Errorf("usuarios tras el reset negado = %d", n)
+	}
+
+	out = run(0, append([]string{"reset", "-db", path, "-seed", "2", "-users", "3"}, size[2:]...)...)
+	if !strings.Contains(out, "la base anterior quedó en") || !strings.Contains(out, "semilla 2: 3 usuarios") {
+		t.Errorf("reset: %s", out)
+	}
+	if n := users(); n != 3 {
+		t.Errorf("usuarios tras el reset = %d", n)
+	}
+	run(0, "reset", "-db", path, "-empty")
+	if n := users(); n != 0 {
+		t.Errorf("usuarios tras reset -empty = %d", n)
+	}
+	if files, _ := backup.List(filepath.Join(dir, "backups")); len(files) != 2 {
+		t.Errorf("copias = %+v", files)
+	}
+
+	run(2, "seed", "-users", "0", "-db", path)
+	run(2, "seed", "-empty")
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireAdmin deja pasar solo requests con X-Admin-Token igual a UZM_ADMIN_TOKEN.
+// Si la variable no está definida, las rutas /admin quedan deshabilitadas.
+func requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c) {
+			abort(c, http.StatusForbidden, CodeForbidden, "requiere token de administrador")
+			return
+		}
+		c.Next()
+	}
+}
+
+// isAdmin indica si la request trae el X-Admin-Token correcto (también fuera de /admin,
+// para atribuir la acción en el audit_log).
+func isAdmin(c *gin.Context) bool {
+	token := os.Getenv("UZM_ADMIN_TOKEN")
+	got := c.GetHeader("X-Admin-Token")
+	return token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/store"
+)
+
+// change es lo que una acción deja en el audit_log. Before y After son instantáneas de los
+// campos que tocó (nil = la entidad no existía o dejó de existir); nunca llevan contraseñas.
+type change struct {
+	Action, Entity string
+	EntityID       int64
+	UserID         int64 // usuario que actúa según la request (0 = no se sabe)
+	Before, After  any
+}
+
+// actor identifica a quien hizo la acción: admin si trae un X-Admin-Token válido,
+// si no el usuario de la request, si no anónimo.
+func actor(c *gin.Context, userID int64) string {
+	switch {
+	case isAdmin(c):
+		return "admin"
+	case userID > 0:
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+	return "anónimo"
+}
+
+// audit agrega ch al audit_log dentro de tx, para que quede registrado si y solo si el
+// cambio se confirma. created_at es la hora real, no la del reloj de la API (que en demos
+// se puede adelantar con /admin/clock): la bitácora dice cuándo pasó de verdad; la fecha de
+// negocio, si importa, va en Before/After (ej. sale_date).
+func (cfg *config) audit(c *gin.Context, tx store.Tx, ch change) error {
+	e := store.AuditEntry{
+		CreatedAt: time.Now().UTC().Format(eventFmt),
+		Actor:     actor(c, ch.UserID),
+		Action:    ch.Action,
+		Entity:    ch.Entity,
+		EntityID:  ch.EntityID,
+		RequestID: c.GetString(requestIDKey),
+	}
+	var err error
+	if e.Before, err = snapshot(ch.Before); err != nil {
+		return err
+	}
+	if e.After, err = snapshot(ch.After); err != nil {
+		return err
+	}
+	return tx.Audit().Append(c.Request.Context(), &e)
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	return raw, nil
+}
+
+// writeAudited corre write en una transacción y registra el cambio que devuelve en la misma;
+// si write devuelve un change sin Action (ej. no tocó filas) no se registra nada.
+func (cfg *config) writeAudited(c *gin.Context, db *sql.DB, write func(tx *sql.Tx) (change, error)) error {
+	tx, err := db.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	ch, err := write(tx)
+	if err != nil {
+		return err
+	}
+	if ch.Action != "" {
+		if err := cfg.audit(c, store.Bind(tx), ch); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// auditAlone registra ch en su propia transacción, para acciones que no pasan por la base
+// (ej. mover el reloj) o que ya confirmaron la suya.
+func (cfg *config) auditAlone(c *gin.Context, db *sql.DB, ch change) error {
+	return store.New(db).InTx(c.Request.Context(), func(tx store.Tx) error {
+		return cfg.audit(c, tx, ch)
+	})
+}
+
+func registerAuditRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	st := store.New(db)
+	admin := r.Group("/admin", requireAdmin())
+
+	// GET /admin/audit?actor=&action=&entity=&entity_id=&from=DD/MM/YYYY&to=DD/MM/YYYY&before_id=&limit=
+	// -> de la más nueva a la más antigua; para la página siguiente, before_id = último id recibido
+	admin.GET("/audit", func(c *gin.Context) {
+		f := store.AuditFilter{
+			Actor:  c.Query("actor"),
+			Action: c.Query("action"),
+			Entity: c.Query("entity"),
+			Limit:  100,
+		}
+		ints := []struct {
+			name string
+			dst  *int64
+		}{{"entity_id", &f.EntityID}, {"before_id", &f.BeforeID}}
+		for _, p := range ints {
+			if s := c.Query(p.name); s != "" {
+				n, err := strconv.ParseInt(s, 10, 64)
+				if err != nil || n <= 0 {
+					abort(c, http.StatusBadRequest, CodeInvalidParam, p.name+" inválido")
+					return
+				}
+				*p.dst = n
+			}
+		}
+		if s := c.Query("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > 1000 {
+				abort(c, http.StatusBadRequest, CodeInvalidParam, "limit debe estar entre 1 y 1000")
+				return
+			}
+			f.Limit = n
+		}
+		// from y to son días completos en la zona del negocio: [from 00:00, to+1 00:00)
+		loc := cfg.clock.Now().Location()
+		for _, p := range []struct {
+			name string
+			dst  *time.Time
+			days int
+		}{{"from", &f.From, 0}, {"to", &f.To, 1}} {
+			if s := c.Query(p.name); s != "" {
+				d, err := time.ParseInLocation(loanFmt, s, loc)
+				if err != nil {
+					abort(c, http.StatusBadRequest, CodeInvalidParam, p.name+" debe ser DD/MM/YYYY")
+					return
+				}
+				*p.dst = d.AddDate(0, 0, p.days)
+			}
+		}
+
+		out, err := st.Read().Audit().List(c.Request.Context(), f)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"entries": out})
+	})
+
+	// GET /admin/audit/verify  -> recorre la cadena de hashes (ok=false y broken_at si se alteró)
+	admin.GET("/audit/verify", func(c *gin.Context) {
+		check, err := st.Read().Audit().Verify(c.Request.Context())
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, check)
+	})
+}
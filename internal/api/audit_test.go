@@ -0,0 +1,120 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/clock"
+)
+
+type auditEntry struct {
+	ID       int64           `json:"id"`
+	Actor    string          `json:"actor"`
+	Action   string          `json:"action"`
+	Entity   string          `json:"entity"`
+	EntityID int64           `json:"entity_id"`
+	Before   json.RawMessage `json:"before"`
+	After    json.RawMessage `json:"after"`
+}
+
+func listAudit(t *testing.T, s *apitest.Server, query string) []auditEntry {
+	t.Helper()
+	resp := s.Do(http.MethodGet, "/admin/audit"+query, nil, "X-Admin-Token", "secreto")
+	if resp.Status != http.StatusOK {
+		t.Fatalf("GET /admin/audit%s: %d %s", query, resp.Status, resp.Body)
+	}
+	var out struct {
+		Entries []auditEntry `json:"entries"`
+	}
+	resp.Decode(t, &out)
+	return out.Entries
+}
+
+func TestAuditLog(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	s := apitest.NewServer(t, api.WithClock(clock.Fixed(time.Date(2025, 3, 10, 12, 0, 0, 0, santiago(t)))))
+	user := apitest.NewUser().Balance(10).Insert(t, s.DB)
+	book := apitest.NewBook().Price(30).Stock(2).Insert(t, s.DB)
+
+	// el precio lo cambia un administrador; el abono, el propio usuario
+	if resp := s.Do(http.MethodPatch, fmt.Sprintf("/books/%d", book), map[string]any{"price": 25}, "X-Admin-Token", "secreto"); resp.Status != http.StatusNoContent {
+		t.Fatalf("PATCH /books: %d %s", resp.Status, resp.Body)
+	}
+	s.Do(http.MethodPatch, fmt.Sprintf("/users/%d", user), map[string]any{"abonar": 40, "password": "nuevaclave"})
+	s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": book})
+	// una compra rechazada no deja rastro
+	s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": 404})
+
+	got := listAudit(t, s, "")
+	var summary []string
+	for _, e := range got {
+		summary = append(summary, fmt.Sprintf("%s %s %s:%d %s -> %s", e.Actor, e.Action, e.Entity, e.EntityID, e.Before, e.After))
+	}
+	want := []string{
+		`user:1 sale.create sale:1 {"usm_pesos":50} -> {"sale":{"id":1,"user_id":1,"book_id":1,"sale_date":"10/03/2025","price":25,"discount":0},"usm_pesos":25}`,
+		`user:1 user.update user:1 {"usm_pesos":10} -> {"password_changed":true,"usm_pesos":50}`,
+		`admin book.update book:1 {"price":30} -> {"price":25}`,
+	}
+	if strings.Join(summary, "\n") != strings.Join(want, "\n") {
+		t.Errorf("audit_log =\n%s\nwant\n%s", strings.Join(summary, "\n"), strings.Join(want, "\n"))
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM audit_log WHERE before_json LIKE '%nuevaclave%' OR after_json LIKE '%nuevaclave%'`); got != 0 {
+		t.Error("el audit_log guardó la contraseña")
+	}
+
+	// filtros
+	if got := listAudit(t, s, "?entity=book&entity_id=1"); len(got) != 1 || got[0].Action != "book.update" {
+		t.Errorf("entity=book: %+v", got)
+	}
+	if got := listAudit(t, s, "?actor=user:1&limit=1"); len(got) != 1 || got[0].Action != "sale.create" {
+		t.Errorf("actor=user:1&limit=1: %+v", got)
+	}
+	if got := listAudit(t, s, fmt.Sprintf("?before_id=%d", got[0].ID)); len(got) != 2 {
+		t.Errorf("before_id: %+v", got)
+	}
+	// created_at es la hora real, no la del reloj fijo de la API (10/03/2025)
+	today := time.Now().In(santiago(t))
+	if got := listAudit(t, s, "?to=10/03/2025"); len(got) != 0 {
+		t.Errorf("to=10/03/2025: %+v", got)
+	}
+	if got := listAudit(t, s, "?from="+today.AddDate(0, 0, 1).Format("02/01/2006")); len(got) != 0 {
+		t.Errorf("from=mañana: %+v", got)
+	}
+	day := today.Format("02/01/2006")
+	if got := listAudit(t, s, "?from="+day+"&to="+day); len(got) != 3 {
+		t.Errorf("from=to=hoy: %+v", got)
+	}
+	if resp := s.Do(http.MethodGet, "/admin/audit?from=2025-03-10", nil, "X-Admin-Token", "secreto"); resp.Status != http.StatusBadRequest {
+		t.Errorf("from inválido: %d", resp.Status)
+	}
+	if resp := s.Do(http.MethodGet, "/admin/audit", nil); resp.Status != http.StatusForbidden {
+		t.Errorf("sin token: %d", resp.Status)
+	}
+
+	// la cadena está íntegra; si alguien edita una entrada (saltándose el trigger), ya no
+	var check struct {
+		OK       bool  `json:"ok"`
+		Entries  int64 `json:"entries"`
+		BrokenAt int64 `json:"broken_at"`
+	}
+	s.Do(http.MethodGet, "/admin/audit/verify", nil, "X-Admin-Token", "secreto").Decode(t, &check)
+	if !check.OK || check.Entries != 3 {
+		t.Errorf("verify = %+v", check)
+	}
+	if _, err := s.DB.Exec(`UPDATE audit_log SET after_json='{"price":1}' WHERE id=1`); err == nil {
+		t.Fatal("UPDATE sobre audit_log permitido")
+	}
+	if _, err := s.DB.Exec(`DROP TRIGGER audit_log_no_update; UPDATE audit_log SET after_json='{"price":1}' WHERE id=1`); err != nil {
+		t.Fatal(err)
+	}
+	s.Do(http.MethodGet, "/admin/audit/verify", nil, "X-Admin-Token", "secreto").Decode(t, &check)
+	if check.OK || check.BrokenAt != 1 {
+		t.Errorf("verify tras editar = %+v", check)
+	}
+}
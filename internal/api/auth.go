@@ -2,34 +2,33 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/store"
 )
 
-func registerAuthRoutes(r *gin.Engine, db *sql.DB) {
+func registerAuthRoutes(r gin.IRouter, db *sql.DB) {
 	type loginReq struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" binding:"required"`
+		Password string `json:"password" binding:"required"`
 	}
 	r.POST("/login", func(c *gin.Context) {
 		var in loginReq
-		if err := c.BindJSON(&in); err != nil || in.Email == "" || in.Password == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "credenciales inválidas"})
+		if !bindJSON(c, &in) {
 			return
 		}
-		var u User
-		err := db.QueryRow(`SELECT id,first_name,last_name,email,password,usm_pesos FROM users WHERE email=? AND password=?`,
-			in.Email, in.Password).
-			Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.Password, &u.USMPesos)
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "email o contraseña incorrectos"})
+		a, err := store.New(db).Read().Users().Login(c.Request.Context(), in.Email, in.Password)
+		if errors.Is(err, store.ErrNotFound) {
+			abort(c, http.StatusUnauthorized, CodeUnauthorized, "email o contraseña incorrectos")
 			return
 		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			fail(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, u)
+		c.JSON(http.StatusOK, userOut(a))
 	})
 }
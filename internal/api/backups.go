@@ -0,0 +1,58 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/backup"
+)
+
+func registerBackupRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	admin := r.Group("/admin", requireAdmin())
+
+	// GET /admin/backups  -> copias en el directorio, de la más nueva a la más vieja
+	admin.GET("/backups", func(c *gin.Context) {
+		files, err := backup.List(cfg.backups.Dir)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"backups": files, "keep": cfg.backups.Keep, "daily": cfg.backups.Daily})
+	})
+
+	// POST /admin/backups  -> copia en línea, verificada y comprimida; aplica la retención
+	admin.POST("/backups", func(c *gin.Context) {
+		// la hora real, no la del negocio: es el nombre del archivo
+		res, err := backup.Create(c.Request.Context(), db, cfg.backups, time.Now())
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		if err := cfg.auditAlone(c, db, change{Action: "backup.create", Entity: "backup",
+			After: gin.H{"name": res.Name, "size_bytes": res.Size, "deleted": res.Deleted}}); err != nil {
+			fail(c, err)
+			return
+		}
+		logger(c).Info("backup", "name", res.Name, "bytes", res.Size, "deleted", len(res.Deleted))
+		c.JSON(http.StatusCreated, res)
+	})
+
+	// POST /admin/backups/:name/verify  -> descomprime y corre integrity_check; ok=false con
+	// el motivo si la copia no sirve
+	admin.POST("/backups/:name/verify", func(c *gin.Context) {
+		path, err := backup.Path(cfg.backups.Dir, c.Param("name"))
+		if errors.Is(err, backup.ErrNotFound) {
+			abort(c, http.StatusNotFound, CodeNotFound, "la copia no existe")
+			return
+		}
+		out := gin.H{"name": c.Param("name"), "ok": true}
+		if err := backup.Verify(path); err != nil {
+			out["ok"], out["error"] = false, err.Error()
+		}
+		c.JSON(http.StatusOK, out)
+	})
+}
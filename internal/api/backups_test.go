@@ -0,0 +1,75 @@
+package api_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/backup"
+)
+
+func TestBackups(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	dir := t.TempDir()
+	s := apitest.NewFileServer(t, api.WithBackups(backup.Config{Dir: dir, Keep: 1}))
+	admin := []string{"X-Admin-Token", "secreto"}
+	apitest.NewBook().Name("Rayuela").Insert(t, s.DB)
+
+	var created backup.Result
+	resp := s.Do(http.MethodPost, "/admin/backups", nil, admin...)
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("POST /admin/backups: %d %s", resp.Status, resp.Body)
+	}
+	resp.Decode(t, &created)
+	if created.Size == 0 || len(created.Deleted) != 0 {
+		t.Errorf("backup = %+v", created)
+	}
+	if n := s.QueryInt(`SELECT COUNT(*) FROM audit_log WHERE action='backup.create' AND actor='admin'`); n != 1 {
+		t.Errorf("auditoría backup.create = %d", n)
+	}
+
+	var list struct {
+		Backups []backup.File `json:"backups"`
+		Keep    int           `json:"keep"`
+	}
+	s.Do(http.MethodGet, "/admin/backups", nil, admin...).Decode(t, &list)
+	if len(list.Backups) != 1 || list.Backups[0].Name != created.Name || list.Keep != 1 {
+		t.Errorf("lista = %+v", list)
+	}
+
+	var verify struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	s.Do(http.MethodPost, "/admin/backups/"+created.Name+"/verify", nil, admin...).Decode(t, &verify)
+	if !verify.OK {
+		t.Errorf("verify = %+v", verify)
+	}
+	// una copia dañada se informa, no es un error de la request
+	if err := os.WriteFile(filepath.Join(dir, created.Name), []byte("basura"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resp = s.Do(http.MethodPost, "/admin/backups/"+created.Name+"/verify", nil, admin...)
+	resp.Decode(t, &verify)
+	if resp.Status != http.StatusOK || verify.OK || verify.Error == "" {
+		t.Errorf("verify dañada = %d %s", resp.Status, resp.Body)
+	}
+
+	for _, tt := range []struct {
+		method, path string
+		headers      []string
+		status       int
+	}{
+		{http.MethodPost, "/admin/backups/uzm-20990101-000000.000.db.gz/verify", admin, http.StatusNotFound},
+		{http.MethodPost, "/admin/backups/..%2Fuzm.db/verify", admin, http.StatusNotFound},
+		{http.MethodPost, "/admin/backups", nil, http.StatusForbidden},
+		{http.MethodGet, "/admin/backups", nil, http.StatusForbidden},
+	} {
+		if resp := s.Do(tt.method, tt.path, nil, tt.headers...); resp.Status != tt.status {
+			t.Errorf("%s %s = %d %s", tt.method, tt.path, resp.Status, resp.Body)
+		}
+	}
+}
@@ -2,142 +2,162 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
 )
 
 type Book struct {
-	ID              int64  `json:"id"`
-	BookName        string `json:"book_name"`
-	BookCategory    string `json:"book_category"`
-	TransactionType string `json:"transaction_type"` // Venta | Arriendo
-	Price           int64  `json:"price"`
-	Status          string `json:"status"` // Disponible | Agotado (calculado)
-	PopularityScore int64  `json:"popularity_score"`
+	ID              int64   `json:"id"`
+	ISBN            string  `json:"isbn,omitempty"`
+	BookName        string  `json:"book_name"`
+	Author          string  `json:"author,omitempty"`
+	BookCategory    string  `json:"book_category"`
+	TransactionType string  `json:"transaction_type"` // Venta | Arriendo
+	Price           int64   `json:"price"`
+	Status          string  `json:"status"` // Disponible | Agotado (calculado)
+	PopularityScore int64   `json:"popularity_score"`
+	TrendingScore   float64 `json:"trending_score,omitempty"` // solo en /books/trending
+	AverageRating   float64 `json:"average_rating"`           // promedio de reseñas visibles
+	ReviewCount     int64   `json:"review_count"`
 	Inventory       struct {
 		AvailableQuantity int64 `json:"available_quantity"`
 	} `json:"inventory"`
 }
 
-func registerBookRoutes(r *gin.Engine, db *sql.DB) {
+// bookOut arma la respuesta de un libro del catálogo; el estado se calcula del stock.
+func bookOut(l store.Listing) Book {
+	b := Book{ID: l.ID, ISBN: l.ISBN, BookName: l.Name, Author: l.Author, BookCategory: l.Category,
+		TransactionType: l.TransactionType, Price: l.Price, PopularityScore: l.Popularity,
+		AverageRating: l.AverageRating, ReviewCount: l.ReviewCount}
+	b.Inventory.AvailableQuantity = l.Available
+	if l.Available > 0 {
+		b.Status = "Disponible"
+	} else {
+		b.Status = "Agotado"
+	}
+	return b
+}
+
+// booksOut convierte un listado del catálogo.
+func booksOut(list []store.Listing) []Book {
+	out := make([]Book, len(list))
+	for i, l := range list {
+		out[i] = bookOut(l)
+	}
+	return out
+}
+
+func registerBookRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	st := store.New(db)
+
 	// POST /books  (crea libro + inventario)
 	r.POST("/books", func(c *gin.Context) {
 		var in struct {
-			BookName          string `json:"book_name"`
-			BookCategory      string `json:"book_category"`
-			TransactionType   string `json:"transaction_type"` // Venta | Arriendo
-			Price             int64  `json:"price"`
-			AvailableQuantity int64  `json:"available_quantity"`
-		}
-		if err := c.BindJSON(&in); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "json inválido"})
+			ISBN              string `json:"isbn" binding:"omitempty,max=20"`
+			BookName          string `json:"book_name" binding:"required,max=200"`
+			Author            string `json:"author" binding:"max=200"`
+			BookCategory      string `json:"book_category" binding:"required,max=100"`
+			TransactionType   string `json:"transaction_type" binding:"required,oneof=Venta Arriendo"`
+			Price             int64  `json:"price" binding:"gte=0"`
+			AvailableQuantity int64  `json:"available_quantity" binding:"gte=0"`
+		}
+		if !bindJSON(c, &in) {
 			return
 		}
-		tx, err := db.Begin()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		res, err := tx.Exec(`INSERT INTO books(book_name,book_category,transaction_type,price) VALUES(?,?,?,?)`,
-			in.BookName, in.BookCategory, in.TransactionType, in.Price)
+		if in.ISBN != "" {
+			isbn, err := service.NormalizeISBN(in.ISBN)
+			if err != nil {
+				msg := "isbn " + err.Error()
+				abortDetails(c, http.StatusUnprocessableEntity, CodeValidation, msg, map[string]any{"fields": []string{"isbn"},
+					"errors": []FieldError{{Field: "isbn", Rule: "isbn", Message: msg}}})
+				return
+			}
+			in.ISBN = isbn
+		}
+		ctx := c.Request.Context()
+		book := store.BookStock{Name: in.BookName, Category: in.BookCategory, TransactionType: in.TransactionType,
+			Price: in.Price, Available: in.AvailableQuantity, ISBN: in.ISBN, Author: in.Author}
+		err := st.InTx(ctx, func(tx store.Tx) error {
+			if err := tx.Books().Create(ctx, &book); err != nil {
+				return err
+			}
+			if err := cfg.audit(c, tx, change{Action: "book.create", Entity: "book", EntityID: book.ID, After: in}); err != nil {
+				return err
+			}
+			// para el catálogo en vivo (GET /events), un libro nuevo es stock que aparece
+			stock := service.BookChange{BookID: book.ID, BookName: in.BookName, BookCategory: in.BookCategory,
+				TransactionType: in.TransactionType, Price: in.Price, OldPrice: in.Price, Available: in.AvailableQuantity}
+			return tx.Outbox().Publish(ctx, service.EventBookStock, stock, cfg.clock.Now())
+		})
 		if err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		id, _ := res.LastInsertId()
-		if _, err := tx.Exec(`INSERT INTO inventory(book_id,available_quantity) VALUES(?,?)`, id, in.AvailableQuantity); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			fail(c, err)
 			return
 		}
-		if err := tx.Commit(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		out := Book{
-			ID:              id,
-			BookName:        in.BookName,
-			BookCategory:    in.BookCategory,
-			TransactionType: in.TransactionType,
-			Price:           in.Price,
-		}
-		out.Inventory.AvailableQuantity = in.AvailableQuantity
-		if in.AvailableQuantity > 0 {
-			out.Status = "Disponible"
-		} else {
-			out.Status = "Agotado"
-		}
-		c.JSON(http.StatusCreated, out)
+		c.JSON(http.StatusCreated, bookOut(store.Listing{BookStock: book}))
 	})
 
-	// GET /books  (solo stock > 0, como pide el enunciado)
+	// GET /books?sort=id|rating|price|popularity  (solo stock > 0, como pide el enunciado)
 	r.GET("/books", func(c *gin.Context) {
-		rows, err := db.Query(`
-SELECT b.id, b.book_name, b.book_category, b.transaction_type, b.price, b.popularity_score, i.available_quantity
-FROM books b
-JOIN inventory i ON i.book_id = b.id
-WHERE i.available_quantity > 0
-ORDER BY b.id`)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		order := c.DefaultQuery("sort", "id")
+		if _, ok := store.ListingOrders[order]; !ok {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "sort inválido, use id, rating, price o popularity")
 			return
 		}
-		defer rows.Close()
-
-		var list []Book
-		for rows.Next() {
-			var b Book
-			if err := rows.Scan(&b.ID, &b.BookName, &b.BookCategory, &b.TransactionType, &b.Price, &b.PopularityScore, &b.Inventory.AvailableQuantity); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			if b.Inventory.AvailableQuantity > 0 {
-				b.Status = "Disponible"
-			} else {
-				b.Status = "Agotado"
-			}
-			list = append(list, b)
+		list, err := st.Read().Books().Catalog(c.Request.Context(), store.ListingFilter{InStock: true, Order: order})
+		if err != nil {
+			fail(c, err)
+			return
 		}
-		c.JSON(http.StatusOK, gin.H{"books": list})
+		c.JSON(http.StatusOK, gin.H{"books": booksOut(list)})
 	})
 
 	// PATCH /books/:id  (actualiza precio o cantidad disponible)
 	r.PATCH("/books/:id", func(c *gin.Context) {
 		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
 			return
 		}
 
 		var in struct {
-			Price             *int64 `json:"price"`
-			AvailableQuantity *int64 `json:"available_quantity"`
+			Price             *int64 `json:"price" binding:"omitnil,gte=0"`
+			AvailableQuantity *int64 `json:"available_quantity" binding:"omitnil,gte=0"`
 		}
-		if err := c.BindJSON(&in); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "json inválido"})
+		if !bindJSON(c, &in) {
 			return
 		}
 
-		if in.Price != nil {
-			if _, err := db.Exec(`UPDATE books SET price=? WHERE id=?`, *in.Price, id); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
+		err = st.InTx(c.Request.Context(), func(tx store.Tx) error {
+			old, err := tx.Books().Stock(c.Request.Context(), id)
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				return err
 			}
-		}
-		if in.AvailableQuantity != nil {
-			if _, err := db.Exec(`UPDATE inventory SET available_quantity=? WHERE book_id=?`, *in.AvailableQuantity, id); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
+			if err := service.UpdateBook(c.Request.Context(), tx, id, in.Price, in.AvailableQuantity, cfg.clock.Now()); err != nil {
+				return err
 			}
+			before, after := gin.H{}, gin.H{}
+			if in.Price != nil {
+				before["price"], after["price"] = old.Price, *in.Price
+			}
+			if in.AvailableQuantity != nil {
+				before["available_quantity"], after["available_quantity"] = old.Available, *in.AvailableQuantity
+			}
+			return cfg.audit(c, tx, change{Action: "book.update", Entity: "book", EntityID: id, Before: before, After: after})
+		})
+		if err != nil {
+			fail(c, err)
+			return
 		}
 		c.Status(http.StatusNoContent)
 	})
 
-	// GET /books/popular?limit=10
+	// GET /books/popular?limit=10&category=X  (ranking histórico, sin decaimiento)
 	r.GET("/books/popular", func(c *gin.Context) {
 		limit := 10
 		if s := c.DefaultQuery("limit", "10"); s != "" {
@@ -145,34 +165,13 @@ ORDER BY b.id`)
 				limit = n
 			}
 		}
-
-		rows, err := db.Query(`
-SELECT b.id, b.book_name, b.book_category, b.transaction_type, b.price, b.popularity_score, i.available_quantity
-FROM books b
-JOIN inventory i ON i.book_id = b.id
-ORDER BY b.popularity_score DESC, b.id ASC
-LIMIT ?`, limit)
+		list, err := st.Read().Books().Catalog(c.Request.Context(),
+			store.ListingFilter{Category: c.Query("category"), Order: "popularity", Limit: limit})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			fail(c, err)
 			return
 		}
-		defer rows.Close()
-
-		var list []Book
-		for rows.Next() {
-			var b Book
-			if err := rows.Scan(&b.ID, &b.BookName, &b.BookCategory, &b.TransactionType, &b.Price, &b.PopularityScore, &b.Inventory.AvailableQuantity); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			if b.Inventory.AvailableQuantity > 0 {
-				b.Status = "Disponible"
-			} else {
-				b.Status = "Agotado"
-			}
-			list = append(list, b)
-		}
-		c.JSON(http.StatusOK, gin.H{"books": list})
+		c.JSON(http.StatusOK, gin.H{"books": booksOut(list)})
 	})
 
 }
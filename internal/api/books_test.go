@@ -0,0 +1,62 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"tarea1-uzm/internal/apitest"
+)
+
+func TestCreateBookValidation(t *testing.T) {
+	s := apitest.NewServer(t)
+	resp := s.Do(http.MethodPost, "/books", map[string]any{
+		"book_name": "", "book_category": "Ficción", "transaction_type": "Trueque", "price": -1, "available_quantity": -2,
+	})
+	if resp.Status != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, body %s", resp.Status, resp.Body)
+	}
+	// todos los campos inválidos vienen en la misma respuesta
+	if got := len(resp.APIError().Details["errors"].([]any)); got != 4 {
+		t.Errorf("errores = %d, want 4 (%s)", got, resp.Body)
+	}
+	if n := s.QueryInt(`SELECT COUNT(*) FROM books`); n != 0 {
+		t.Errorf("books = %d, want 0", n)
+	}
+
+	resp = s.Do(http.MethodPost, "/books", map[string]any{
+		"book_name": "Rayuela", "book_category": "Ficción", "transaction_type": "Venta", "price": 12, "available_quantity": 3,
+	})
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("alta válida: %d %s", resp.Status, resp.Body)
+	}
+}
+
+func TestPatchBookNotifiesWishlist(t *testing.T) {
+	s := apitest.NewServer(t)
+	fan := apitest.NewUser().Insert(t, s.DB)
+	other := apitest.NewUser().Insert(t, s.DB)
+	book := apitest.NewBook().Price(20).Stock(0).Insert(t, s.DB)
+	if resp := s.Do(http.MethodPost, fmt.Sprintf("/users/%d/wishlist", fan), map[string]any{"book_id": book}); resp.Status != http.StatusCreated {
+		t.Fatalf("wishlist: %d %s", resp.Status, resp.Body)
+	}
+
+	path := fmt.Sprintf("/books/%d", book)
+	if resp := s.Do(http.MethodPatch, path, map[string]any{"price": 15, "available_quantity": 2}); resp.Status != http.StatusNoContent {
+		t.Fatalf("patch: %d %s", resp.Status, resp.Body)
+	}
+	// subir el precio no avisa
+	s.Do(http.MethodPatch, path, map[string]any{"price": 18})
+
+	if got := s.QueryInt(`SELECT COUNT(*) FROM notifications WHERE user_id=?`, fan); got != 2 {
+		t.Errorf("avisos fan = %d, want 2 (precio + stock)", got)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM notifications WHERE user_id=?`, other); got != 0 {
+		t.Errorf("avisos other = %d, want 0", got)
+	}
+
+	resp := s.Do(http.MethodPatch, "/books/999", map[string]any{"price": 1})
+	if resp.Status != http.StatusNotFound {
+		t.Errorf("libro inexistente: %d", resp.Status)
+	}
+}
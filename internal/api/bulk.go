@@ -0,0 +1,205 @@
+package api
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/bulk"
+	"tarea1-uzm/internal/marc"
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+// maxImportBytes es el tamaño máximo de un archivo de POST /admin/books/import.
+const maxImportBytes = 10 << 20
+
+// exportSets son los conjuntos con GET /admin/<nombre>/export, en el orden de la especificación.
+var exportSets = []string{"books", "users", "sales", "loans"}
+
+// importFormats son los formatos de POST /admin/books/import; marcFormats, los que además de
+// csv y jsonl tiene GET /admin/books/export.
+var (
+	importFormats = []string{bulk.CSV, bulk.JSONL, marc.ISO2709, marc.XML}
+	marcFormats   = []string{marc.ISO2709, marc.XML, marc.DC}
+)
+
+// errRollback deshace la transacción de una importación que no se confirma (dry run o con
+// errores) sin que sea un error de la request.
+var errRollback = errors.New("rollback")
+
+func registerBulkRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	admin := r.Group("/admin", requireAdmin())
+	st := store.New(db)
+
+	// POST /admin/books/import?format=csv|jsonl|marc|marcxml&dry_run=1  (cuerpo: el archivo;
+	// sin format se deduce del Content-Type). Todo o nada: si alguna fila tiene errores
+	// responde 422 con todos ellos y no cambia nada. dry_run=1 valida y cuenta sin guardar.
+	// Los registros MARC no traen modalidad ni categoría obligatoria: los libros nuevos quedan
+	// con ?transaction_type= (Arriendo por defecto), categoría General si no hay 650, y sin stock.
+	admin.POST("/books/import", func(c *gin.Context) {
+		format := c.Query("format")
+		if format == "" {
+			format = cmp.Or(bulk.FormatOf(c.ContentType()), marc.FormatOf(c.ContentType()))
+		}
+		if !slices.Contains(importFormats, format) {
+			abort(c, http.StatusBadRequest, CodeInvalidParam,
+				"format debe ser csv, jsonl, marc o marcxml (o enviar el Content-Type del formato)")
+			return
+		}
+		dryRun := c.Query("dry_run") == "1" || c.Query("dry_run") == "true"
+
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+		var (
+			rows     []bulk.Row
+			rowErrs  []bulk.RowError
+			defaults map[string]string
+			err      error
+		)
+		if format == bulk.CSV || format == bulk.JSONL {
+			rows, rowErrs, err = bulk.Read(body, format)
+		} else {
+			kind := c.DefaultQuery("transaction_type", "Arriendo")
+			if kind != "Venta" && kind != "Arriendo" {
+				abort(c, http.StatusBadRequest, CodeInvalidParam, "transaction_type debe ser Venta o Arriendo")
+				return
+			}
+			defaults = map[string]string{"book_category": "General", "transaction_type": kind}
+			rows, rowErrs, err = marc.Rows(body, format)
+		}
+		var tooBig *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooBig):
+			abort(c, http.StatusRequestEntityTooLarge, CodeInvalidFile, fmt.Sprintf("el archivo supera los %d MB", maxImportBytes>>20))
+			return
+		case err != nil:
+			abort(c, http.StatusBadRequest, CodeInvalidFile, err.Error())
+			return
+		case len(rows) == 0 && len(rowErrs) == 0:
+			abort(c, http.StatusBadRequest, CodeInvalidFile, "el archivo no trae filas")
+			return
+		}
+
+		var res service.BookImport
+		err = cfg.writeAudited(c, db, func(tx *sql.Tx) (change, error) {
+			var err error
+			if res, err = service.ImportBooks(c.Request.Context(), store.Bind(tx), rows, defaults, cfg.clock.Now()); err != nil {
+				return change{}, err
+			}
+			if res.Errors = append(rowErrs, res.Errors...); len(res.Errors) > 0 || dryRun {
+				return change{}, errRollback
+			}
+			return change{Action: "book.import", Entity: "book",
+				After: gin.H{"format": format, "rows": res.Rows, "created": res.Created, "updated": res.Updated}}, nil
+		})
+		if err != nil && !errors.Is(err, errRollback) {
+			fail(c, err)
+			return
+		}
+		if len(res.Errors) > 0 {
+			slices.SortStableFunc(res.Errors, func(a, b bulk.RowError) int { return cmp.Compare(a.Line, b.Line) })
+			msgs := make([]string, 0, 3)
+			for _, e := range res.Errors[:min(3, len(res.Errors))] {
+				msgs = append(msgs, e.Error())
+			}
+			abortDetails(c, http.StatusUnprocessableEntity, CodeValidation,
+				fmt.Sprintf("%d error(es), no se importó nada: %s", len(res.Errors), strings.Join(msgs, "; ")),
+				map[string]any{"errors": res.Errors})
+			return
+		}
+		if !dryRun {
+			logger(c).Info("importación de libros", "format", format, "rows", res.Rows, "created", res.Created, "updated", res.Updated)
+		}
+		c.JSON(http.StatusOK, gin.H{"dry_run": dryRun, "rows": res.Rows, "created": res.Created,
+			"updated": res.Updated, "unchanged": res.Unchanged})
+	})
+
+	// GET /admin/{books,users,sales,loans}/export?format=csv|jsonl  (csv por defecto); books
+	// además en marc, marcxml y dc (Dublin Core).
+	for _, name := range exportSets {
+		admin.GET("/"+name+"/export", func(c *gin.Context) {
+			format := c.DefaultQuery("format", bulk.CSV)
+			if name == "books" && slices.Contains(marcFormats, format) {
+				exportCatalog(c, st, cfg, format)
+				return
+			}
+			if !slices.Contains(bulk.Formats, format) {
+				msg := "format debe ser csv o jsonl"
+				if name == "books" {
+					msg = "format debe ser csv, jsonl, marc, marcxml o dc"
+				}
+				abort(c, http.StatusBadRequest, CodeInvalidParam, msg)
+				return
+			}
+			// se lee todo antes de responder: un error de la base todavía es un 500 y la
+			// conexión no queda tomada mientras el cliente descarga
+			rows, err := st.Export(c.Request.Context(), name)
+			if err != nil {
+				fail(c, err)
+				return
+			}
+			attachment(c, cfg, name, format, bulk.ContentType(format))
+			w, err := bulk.NewWriter(c.Writer, format, store.ExportSets[name].Columns)
+			for i := 0; err == nil && i < len(rows); i++ {
+				err = w.Write(rows[i])
+			}
+			if err == nil {
+				err = w.Flush()
+			}
+			if err != nil {
+				// el 200 ya salió: el archivo queda cortado y el error solo va al log
+				logger(c).Error("exportación", "set", name, "rows", len(rows), "err", err)
+				return
+			}
+			logger(c).Info("exportación", "set", name, "format", format, "rows", len(rows))
+		})
+	}
+}
+
+// attachment abre la respuesta 200 de una exportación como archivo <set>-AAAAMMDD.<ext>.
+func attachment(c *gin.Context, cfg *config, set, ext, contentType string) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`,
+		set, cfg.clock.Now().Format("20060102"), ext))
+	c.Status(http.StatusOK)
+}
+
+// exportCatalog escribe el catálogo en MARC21 (ISO 2709 o MARCXML) o Dublin Core. Lee todo
+// antes de responder, así que un error de la base todavía es un 500.
+func exportCatalog(c *gin.Context, st *store.Store, cfg *config, format string) {
+	list, err := st.Read().Books().All(c.Request.Context())
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	books := make([]marc.Book, len(list))
+	recs := make([]marc.Record, len(list))
+	for i, b := range list {
+		books[i] = marc.Book{ID: b.ID, ISBN: b.ISBN, Title: b.Name, Author: b.Author, Subject: b.Category}
+		recs[i] = marc.FromBook(books[i])
+	}
+	attachment(c, cfg, "books", marc.Ext(format), marc.ContentType(format))
+	switch format {
+	case marc.ISO2709:
+		for _, rec := range recs {
+			if err = marc.WriteISO2709(c.Writer, rec); err != nil {
+				break
+			}
+		}
+	case marc.XML:
+		err = marc.WriteXML(c.Writer, recs)
+	case marc.DC:
+		err = marc.WriteDC(c.Writer, books)
+	}
+	if err != nil {
+		logger(c).Error("exportación", "set", "books", "format", format, "err", err)
+		return
+	}
+	logger(c).Info("exportación", "set", "books", "format", format, "rows", len(list))
+}
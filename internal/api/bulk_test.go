@@ -0,0 +1,260 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+)
+
+type importResult struct {
+	DryRun    bool `json:"dry_run"`
+	Rows      int  `json:"rows"`
+	Created   int  `json:"created"`
+	Updated   int  `json:"updated"`
+	Unchanged int  `json:"unchanged"`
+}
+
+func TestImportBooks(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	s := apitest.NewServer(t)
+	admin := []string{"X-Admin-Token", "secreto"}
+	rayuela := apitest.NewBook().Name("Rayuela").Category("Novela").Price(30).Stock(2).Insert(t, s.DB)
+	apitest.NewBook().Name("Ficciones").Insert(t, s.DB)
+	apitest.NewBook().Name("Ficciones").ForLoan().Insert(t, s.DB)
+
+	file := `isbn,book_name,book_category,transaction_type,price,available_quantity
+978-84-376-0457-2,rayuela,Novela,Venta,25,5
+9780307474728,Cien años de soledad,Novela,Venta,40,3
+,El Aleph,Cuentos,Arriendo,0,1
+`
+	imp := func(query, body string) (apitest.Response, importResult) {
+		t.Helper()
+		resp := s.Do(http.MethodPost, "/admin/books/import"+query, body, append(admin, "Content-Type", "text/csv")...)
+		var res importResult
+		if resp.Status == http.StatusOK {
+			resp.Decode(t, &res)
+		}
+		return resp, res
+	}
+
+	// dry run: cuenta lo que haría sin tocar nada
+	resp, res := imp("?dry_run=1", file)
+	if resp.Status != http.StatusOK || res != (importResult{DryRun: true, Rows: 3, Created: 2, Updated: 1}) {
+		t.Fatalf("dry run: %d %s", resp.Status, resp.Body)
+	}
+	if n := s.QueryInt(`SELECT COUNT(*) FROM books`); n != 3 {
+		t.Fatalf("el dry run dejó %d libros", n)
+	}
+
+	// Rayuela no tenía ISBN: calza por nombre y se le asigna
+	if resp, res = imp("", file); resp.Status != http.StatusOK || res != (importResult{Rows: 3, Created: 2, Updated: 1}) {
+		t.Fatalf("import: %d %s", resp.Status, resp.Body)
+	}
+	var list struct{ Books []api.Book }
+	s.Do(http.MethodGet, "/books", nil).Decode(t, &list)
+	if b := list.Books[0]; b.ID != rayuela || b.ISBN != "9788437604572" || b.BookName != "rayuela" || b.Price != 25 || b.Inventory.AvailableQuantity != 5 {
+		t.Errorf("Rayuela = %+v", b)
+	}
+	if n := s.QueryInt(`SELECT COUNT(*) FROM outbox WHERE event='book.price' AND json_extract(payload,'$.old_price')=30`); n != 1 {
+		t.Errorf("eventos book.price = %d", n)
+	}
+	if n := s.QueryInt(`SELECT COUNT(*) FROM audit_log WHERE action='book.import' AND actor='admin'`); n != 1 {
+		t.Errorf("auditoría book.import = %d", n)
+	}
+	// la segunda vez no cambia nada
+	if resp, res = imp("", file); res != (importResult{Rows: 3, Unchanged: 3}) {
+		t.Errorf("reimport: %d %s", resp.Status, resp.Body)
+	}
+
+	// JSON Lines con errores: no se importa ninguna fila y se informan todas
+	bad := `{"isbn":"9780307474729","book_name":"Otro"}
+{"book_name":"Ficciones","price":5}
+{"book_name":"Pedro Páramo","transaction_type":"Venta"}
+{"book_name":"Ensayo sobre la ceguera","book_category":"Novela","transaction_type":"Regalo","price":-1,"autor":"Saramago"}
+{"book_name":"Nuevo","book_category":"Novela","transaction_type":"Venta"}
+`
+	resp = s.Do(http.MethodPost, "/admin/books/import?format=jsonl", bad, admin...)
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				Errors []struct {
+					Line  int    `json:"line"`
+					Field string `json:"field"`
+				} `json:"errors"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	resp.Decode(t, &body)
+	var got []string
+	for _, e := range body.Error.Details.Errors {
+		got = append(got, fmt.Sprintf("%d:%s", e.Line, e.Field))
+	}
+	want := "1:isbn 2:book_name 3:book_category 4:autor 4:transaction_type 4:price"
+	if resp.Status != http.StatusUnprocessableEntity || body.Error.Code != api.CodeValidation || strings.Join(got, " ") != want {
+		t.Errorf("errores = %d %s (%v), want %s", resp.Status, resp.Body, got, want)
+	}
+	if n := s.QueryInt(`SELECT COUNT(*) FROM books`); n != 5 {
+		t.Errorf("libros = %d tras una importación fallida", n)
+	}
+
+	for _, tt := range []struct {
+		query, body string
+		headers     []string
+		status      int
+		code        string
+	}{
+		{"", file, admin, http.StatusBadRequest, api.CodeInvalidParam}, // application/json, sin format
+		{"?format=xml", file, admin, http.StatusBadRequest, api.CodeInvalidParam},
+		{"?format=csv", "", admin, http.StatusBadRequest, api.CodeInvalidFile},
+		{"?format=csv", "isbn\n", admin, http.StatusBadRequest, api.CodeInvalidFile},
+		{"?format=csv", file, nil, http.StatusForbidden, api.CodeForbidden},
+	} {
+		resp := s.Do(http.MethodPost, "/admin/books/import"+tt.query, tt.body, tt.headers...)
+		if resp.Status != tt.status || resp.APIError().Code != tt.code {
+			t.Errorf("import%s %q: %d %s", tt.query, tt.body, resp.Status, resp.Body)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	s := apitest.NewServer(t)
+	admin := []string{"X-Admin-Token", "secreto"}
+	user := apitest.NewUser().Email("ana@usm.cl").Password("clave123").Balance(100).Insert(t, s.DB)
+	book := apitest.NewBook().Name("Rayuela, 2ª ed.").ISBN("9788437604572").Price(30).Stock(2).Insert(t, s.DB)
+	apitest.NewBook().Name("Ficciones").ForLoan().Stock(1).Insert(t, s.DB)
+	apitest.NewLoan(user, book).Started("01/02/2025").Insert(t, s.DB)
+	s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": book})
+
+	resp := s.Do(http.MethodGet, "/admin/books/export", nil, admin...)
+	want := `id,isbn,book_name,author,book_category,transaction_type,price,available_quantity,popularity_score
+1,9788437604572,"Rayuela, 2ª ed.",,General,Venta,30,1,1
+2,,Ficciones,,General,Arriendo,10,1,0
+`
+	if resp.Status != http.StatusOK || string(resp.Body) != want || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") {
+		t.Errorf("books csv: %d %s\n%s", resp.Status, resp.Header.Get("Content-Type"), resp.Body)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="books-`) || !strings.HasSuffix(cd, `.csv"`) {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	resp = s.Do(http.MethodGet, "/admin/users/export?format=jsonl", nil, admin...)
+	var u map[string]any
+	if err := json.Unmarshal(resp.Body, &u); err != nil || u["email"] != "ana@usm.cl" || u["usm_pesos"] != 70.0 || u["password"] != nil {
+		t.Errorf("users jsonl: %s (%v)", resp.Body, err)
+	}
+	resp = s.Do(http.MethodGet, "/admin/loans/export?format=jsonl", nil, admin...)
+	if want := `{"id":1,"user_id":1,"book_id":1,"start_date":"01/02/2025","return_date":null,"status":"pendiente","fine":0}` + "\n"; string(resp.Body) != want {
+		t.Errorf("loans jsonl = %s", resp.Body)
+	}
+	resp = s.Do(http.MethodGet, "/admin/sales/export", nil, admin...)
+	if lines := strings.Split(strings.TrimSpace(string(resp.Body)), "\n"); len(lines) != 2 || lines[0] != "id,user_id,book_id,sale_date,price,discount" {
+		t.Errorf("sales csv = %s", resp.Body)
+	}
+
+	if resp := s.Do(http.MethodGet, "/admin/books/export?format=xml", nil, admin...); resp.Status != http.StatusBadRequest {
+		t.Errorf("format=xml: %d", resp.Status)
+	}
+	if resp := s.Do(http.MethodGet, "/admin/users/export", nil); resp.Status != http.StatusForbidden {
+		t.Errorf("sin token: %d", resp.Status)
+	}
+
+	// lo exportado se puede volver a importar tal cual
+	for _, format := range []string{"csv", "jsonl"} {
+		file := s.Do(http.MethodGet, "/admin/books/export?format="+format, nil, admin...).Body
+		resp := s.Do(http.MethodPost, "/admin/books/import?format="+format, string(file), admin...)
+		var res importResult
+		resp.Decode(t, &res)
+		if res != (importResult{Rows: 2, Unchanged: 2}) {
+			t.Errorf("%s: reimportar lo exportado = %s", format, resp.Body)
+		}
+	}
+}
+
+func TestImportExportMARC(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	s := apitest.NewServer(t)
+	admin := []string{"X-Admin-Token", "secreto"}
+	rayuela := apitest.NewBook().Name("Rayuela").Category("Novela").Price(30).Stock(2).Insert(t, s.DB)
+	xmlFile, err := os.ReadFile("../marc/testdata/muestra.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mrcFile, err := os.ReadFile("../marc/testdata/muestra.mrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := func(query string, body []byte, headers ...string) (apitest.Response, importResult) {
+		t.Helper()
+		resp := s.Do(http.MethodPost, "/admin/books/import"+query, string(body), append(admin, headers...)...)
+		var res importResult
+		if resp.Status == http.StatusOK {
+			resp.Decode(t, &res)
+		}
+		return resp, res
+	}
+
+	// Rayuela calza por nombre y toma ISBN, autor y materia; los otros dos se crean sin stock
+	if resp, res := imp("?format=marcxml", xmlFile); res != (importResult{Rows: 3, Created: 2, Updated: 1}) {
+		t.Fatalf("marcxml: %d %s", resp.Status, resp.Body)
+	}
+	var list struct{ Books []api.Book }
+	s.Do(http.MethodGet, "/books", nil).Decode(t, &list)
+	if b := list.Books[0]; b.ID != rayuela || b.ISBN != "9788437604572" || b.Author != "Cortázar, Julio" ||
+		b.BookCategory != "Novela argentina" || b.Price != 30 || b.Inventory.AvailableQuantity != 2 {
+		t.Errorf("Rayuela = %+v", b)
+	}
+	if n := s.QueryInt(`SELECT COUNT(*) FROM books b JOIN inventory i ON i.book_id = b.id
+WHERE b.book_name = 'Cien años de soledad : novela' AND b.isbn = '9780307474728' AND b.author = 'García Márquez, Gabriel'
+AND b.transaction_type = 'Arriendo' AND b.book_category = 'Novela colombiana' AND i.available_quantity = 0`); n != 1 {
+		t.Errorf("Cien años de soledad no quedó como se esperaba")
+	}
+	// el mismo catálogo en binario, reconocido por el Content-Type
+	if resp, res := imp("", mrcFile, "Content-Type", "application/marc"); res != (importResult{Rows: 3, Unchanged: 3}) {
+		t.Errorf("marc: %d %s", resp.Status, resp.Body)
+	}
+
+	// lo exportado en MARC se vuelve a importar sin cambios
+	for _, format := range []string{"marc", "marcxml"} {
+		resp := s.Do(http.MethodGet, "/admin/books/export?format="+format, nil, admin...)
+		if resp.Status != http.StatusOK || resp.Header.Get("Content-Type") != map[string]string{"marc": "application/marc", "marcxml": "application/marcxml+xml"}[format] {
+			t.Fatalf("export %s: %d %s", format, resp.Status, resp.Header.Get("Content-Type"))
+		}
+		if resp, res := imp("?format="+format, resp.Body); res != (importResult{Rows: 3, Unchanged: 3}) {
+			t.Errorf("%s: reimportar lo exportado = %d %s", format, resp.Status, resp.Body)
+		}
+	}
+	resp := s.Do(http.MethodGet, "/admin/books/export?format=dc", nil, admin...)
+	if cd := resp.Header.Get("Content-Disposition"); resp.Status != http.StatusOK || !strings.HasSuffix(cd, `.xml"`) ||
+		!strings.Contains(string(resp.Body), "<dc:creator>García Márquez, Gabriel</dc:creator>") {
+		t.Errorf("dc: %d %s\n%s", resp.Status, cd, resp.Body)
+	}
+
+	for _, tt := range []struct {
+		path, query string
+		body        []byte
+		status      int
+		code        string
+	}{
+		{"/admin/books/import", "?format=marcxml&transaction_type=Regalo", xmlFile, http.StatusBadRequest, api.CodeInvalidParam},
+		{"/admin/books/import", "?format=marc", mrcFile[:100], http.StatusBadRequest, api.CodeInvalidFile},
+		{"/admin/books/import", "?format=marcxml", []byte("<collection/>"), http.StatusBadRequest, api.CodeInvalidFile},
+		{"/admin/users/export", "?format=marcxml", nil, http.StatusBadRequest, api.CodeInvalidParam},
+	} {
+		method := http.MethodPost
+		if tt.body == nil {
+			method = http.MethodGet
+		}
+		resp := s.Do(method, tt.path+tt.query, string(tt.body), admin...)
+		if resp.Status != tt.status || resp.APIError().Code != tt.code {
+			t.Errorf("%s%s: %d %s", tt.path, tt.query, resp.Status, resp.Body)
+		}
+	}
+}
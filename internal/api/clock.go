@@ -0,0 +1,122 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/clock"
+)
+
+// clockView es lo que muestran las rutas /admin/clock.
+func clockView(clk clock.Clock) gin.H {
+	now := clk.Now()
+	out := gin.H{"now": now.Format(time.RFC3339), "today": now.Format(loanFmt), "zone": now.Location().String(), "travel": false}
+	if t, ok := clk.(*clock.Travel); ok {
+		s := t.State()
+		out["travel"] = true
+		out["offset"] = s.Offset.Round(time.Second).String()
+		out["frozen"] = s.Frozen
+	}
+	return out
+}
+
+// parseAdvance acepta duraciones de Go ("36h", "-90m") o días ("3d", "-1d").
+func parseAdvance(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		return time.Duration(n) * 24 * time.Hour, err
+	}
+	return time.ParseDuration(s)
+}
+
+func registerClockRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	admin := r.Group("/admin", requireAdmin())
+
+	// GET /admin/clock
+	admin.GET("/clock", func(c *gin.Context) {
+		c.JSON(http.StatusOK, clockView(cfg.clock))
+	})
+
+	// travel devuelve el reloj si el server partió con viaje en el tiempo (UZM_TIME_TRAVEL=1).
+	travel := func(c *gin.Context) (*clock.Travel, bool) {
+		t, ok := cfg.clock.(*clock.Travel)
+		if !ok {
+			abort(c, http.StatusConflict, CodeConflict, "el viaje en el tiempo no está habilitado (UZM_TIME_TRAVEL=1)")
+		}
+		return t, ok
+	}
+
+	// PUT /admin/clock {at?: "DD/MM/YYYY" | RFC3339, advance?: "72h" | "3d", freeze?}
+	// at fija la hora (una fecha sola es la medianoche de ese día en la zona del negocio),
+	// advance la mueve y freeze la detiene.
+	admin.PUT("/clock", func(c *gin.Context) {
+		var in struct {
+			At      string `json:"at" binding:"required_without=Advance"`
+			Advance string `json:"advance"`
+			Freeze  bool   `json:"freeze"`
+		}
+		if !bindJSON(c, &in) {
+			return
+		}
+		t, ok := travel(c)
+		if !ok {
+			return
+		}
+		loc := t.Now().Location()
+		var at time.Time
+		if in.At != "" {
+			var err error
+			if at, err = time.ParseInLocation(loanFmt, in.At, loc); err != nil {
+				if at, err = time.Parse(time.RFC3339, in.At); err != nil {
+					abort(c, http.StatusUnprocessableEntity, CodeValidation, "at debe ser DD/MM/YYYY o RFC3339")
+					return
+				}
+			}
+		}
+		var d time.Duration
+		if in.Advance != "" {
+			var err error
+			if d, err = parseAdvance(in.Advance); err != nil {
+				abort(c, http.StatusUnprocessableEntity, CodeValidation, `advance debe ser una duración como "72h" o "3d"`)
+				return
+			}
+		}
+		before := clockView(t)
+		switch {
+		case in.At != "" && in.Freeze:
+			t.Freeze(at)
+		case in.At != "":
+			t.Set(at)
+		case in.Freeze:
+			t.Freeze(t.Now())
+		}
+		t.Advance(d)
+		after := clockView(t)
+		if err := cfg.auditAlone(c, db, change{Action: "clock.set", Entity: "clock", Before: before, After: after}); err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, after)
+	})
+
+	// DELETE /admin/clock vuelve a la hora real.
+	admin.DELETE("/clock", func(c *gin.Context) {
+		t, ok := travel(c)
+		if !ok {
+			return
+		}
+		before := clockView(t)
+		t.Reset()
+		after := clockView(t)
+		if err := cfg.auditAlone(c, db, change{Action: "clock.reset", Entity: "clock", Before: before, After: after}); err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, after)
+	})
+}
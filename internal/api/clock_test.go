@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/clock"
+)
+
+func santiago(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := clock.LoadZone("America/Santiago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestLoanDatesFollowClock(t *testing.T) {
+	// 23:30 en Santiago ya es el 16/01 en UTC: manda la fecha del negocio
+	now := time.Date(2025, 1, 15, 23, 30, 0, 0, santiago(t))
+	s := apitest.NewServer(t, api.WithClock(clock.Fixed(now)))
+	user := apitest.NewUser().Insert(t, s.DB)
+	book := apitest.NewBook().ForLoan().Insert(t, s.DB)
+
+	resp := s.Do(http.MethodPost, "/loans", map[string]any{"user_id": user, "book_id": book})
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("status = %d, body %s", resp.Status, resp.Body)
+	}
+	var loan struct {
+		StartDate string `json:"start_date"`
+		DueDate   string `json:"due_date"`
+		DaysLeft  int64  `json:"days_left"`
+	}
+	resp.Decode(t, &loan)
+	if loan.StartDate != "15/01/2025" || loan.DueDate != "15/02/2025" || loan.DaysLeft != 31 {
+		t.Errorf("loan = %+v", loan)
+	}
+}
+
+func TestAdminClockTravel(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, santiago(t))
+	s := apitest.NewServer(t, api.WithClock(clock.NewTravel(clock.Fixed(start))))
+	user := apitest.NewUser().Balance(50).Insert(t, s.DB)
+	book := apitest.NewBook().ForLoan().Insert(t, s.DB)
+
+	var loan struct {
+		ID int64 `json:"id"`
+	}
+	s.Do(http.MethodPost, "/loans", map[string]any{"user_id": user, "book_id": book}).Decode(t, &loan)
+
+	// 41 días después: 10 días de atraso sobre el vencimiento del 15/02
+	resp := s.Do(http.MethodPut, "/admin/clock", map[string]any{"advance": "41d"}, "X-Admin-Token", "secreto")
+	var view struct {
+		Today  string `json:"today"`
+		Zone   string `json:"zone"`
+		Travel bool   `json:"travel"`
+	}
+	resp.Decode(t, &view)
+	if resp.Status != http.StatusOK || view.Today != "25/02/2025" || view.Zone != "America/Santiago" || !view.Travel {
+		t.Fatalf("PUT /admin/clock: %d %s", resp.Status, resp.Body)
+	}
+
+	// sin return_date se devuelve "hoy" según el reloj
+	resp = s.Do(http.MethodPatch, fmt.Sprintf("/loans/%d/return", loan.ID), map[string]any{})
+	var out struct {
+		ReturnDate string `json:"return_date"`
+		DaysLate   int64  `json:"days_late"`
+		Penalty    int64  `json:"penalty"`
+	}
+	resp.Decode(t, &out)
+	if resp.Status != http.StatusOK || out.ReturnDate != "25/02/2025" || out.DaysLate != 10 || out.Penalty != 20 {
+		t.Errorf("devolución: %d %s", resp.Status, resp.Body)
+	}
+
+	resp = s.Do(http.MethodDelete, "/admin/clock", nil, "X-Admin-Token", "secreto")
+	resp.Decode(t, &view)
+	if view.Today != "15/01/2025" {
+		t.Errorf("DELETE /admin/clock: %s", resp.Body)
+	}
+}
+
+func TestAdminClockErrors(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	fixed := apitest.NewServer(t, api.WithClock(clock.Fixed(time.Date(2025, 1, 15, 10, 0, 0, 0, santiago(t)))))
+	travel := apitest.NewServer(t, api.WithClock(clock.NewTravel(clock.Fixed(time.Now()))))
+
+	tests := []struct {
+		name   string
+		srv    *apitest.Server
+		method string
+		body   any
+		token  string
+		status int
+		code   string
+	}{
+		{"sin token", travel, http.MethodGet, nil, "", http.StatusForbidden, "forbidden"},
+		{"viaje deshabilitado", fixed, http.MethodPut, map[string]any{"advance": "1d"}, "secreto", http.StatusConflict, "conflict"},
+		{"sin at ni advance", travel, http.MethodPut, map[string]any{"freeze": true}, "secreto", http.StatusUnprocessableEntity, "validation_failed"},
+		{"at inválido", travel, http.MethodPut, map[string]any{"at": "2025-02-30"}, "secreto", http.StatusUnprocessableEntity, "validation_failed"},
+		{"advance inválido", travel, http.MethodPut, map[string]any{"advance": "mañana"}, "secreto", http.StatusUnprocessableEntity, "validation_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := tt.srv.Do(tt.method, "/admin/clock", tt.body, "X-Admin-Token", tt.token)
+			if resp.Status != tt.status || resp.APIError().Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q (%s)", resp.Status, resp.APIError().Code, tt.status, tt.code, resp.Body)
+			}
+		})
+	}
+}
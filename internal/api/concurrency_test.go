@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"tarea1-uzm/internal/apitest"
+)
+
+// buyConcurrently lanza n POST /sales a la vez y cuenta las respuestas por status/código.
+func buyConcurrently(t *testing.T, s *apitest.Server, n int, body map[string]any) map[string]int {
+	t.Helper()
+	raw, _ := json.Marshal(body)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	got := map[string]int{}
+	start := make(chan struct{})
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			resp, err := http.Post(s.URL+s.Base+"/sales", "application/json", bytes.NewReader(raw))
+			key := "error de red"
+			if err == nil {
+				var e struct {
+					Error struct{ Code string } `json:"error"`
+				}
+				json.NewDecoder(resp.Body).Decode(&e)
+				resp.Body.Close()
+				key = http.StatusText(resp.StatusCode)
+				if e.Error.Code != "" {
+					key = e.Error.Code
+				}
+			}
+			mu.Lock()
+			got[key]++
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return got
+}
+
+func TestConcurrentSalesNeverOverdraw(t *testing.T) {
+	s := apitest.NewFileServer(t)
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	book := apitest.NewBook().Price(3).Stock(1000).Insert(t, s.DB)
+
+	got := buyConcurrently(t, s, 300, map[string]any{"user_id": user, "book_id": book})
+	// 100 alcanza para 33 compras de 3
+	if got["Created"] != 33 || got["insufficient_funds"] != 300-33 {
+		t.Errorf("respuestas = %v, want 33 Created y 267 insufficient_funds", got)
+	}
+	if saldo := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); saldo != 1 {
+		t.Errorf("saldo = %d, want 1", saldo)
+	}
+	if n := s.QueryInt(`SELECT COUNT(*) FROM sales WHERE user_id=?`, user); n != 33 {
+		t.Errorf("ventas = %d, want 33", n)
+	}
+}
+
+func TestConcurrentSalesNeverOversell(t *testing.T) {
+	s := apitest.NewFileServer(t)
+	user := apitest.NewUser().Balance(1_000_000).Insert(t, s.DB)
+	book := apitest.NewBook().Price(10).Stock(20).Insert(t, s.DB)
+
+	got := buyConcurrently(t, s, 300, map[string]any{"user_id": user, "book_id": book})
+	if got["Created"] != 20 || got["out_of_stock"] != 280 {
+		t.Errorf("respuestas = %v, want 20 Created y 280 out_of_stock", got)
+	}
+	if stock := s.QueryInt(`SELECT available_quantity FROM inventory WHERE book_id=?`, book); stock != 0 {
+		t.Errorf("stock = %d, want 0", stock)
+	}
+	if saldo := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); saldo != 1_000_000-200 {
+		t.Errorf("saldo = %d, want %d", saldo, 1_000_000-200)
+	}
+}
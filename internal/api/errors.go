@@ -0,0 +1,194 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+// Códigos de error estables: los clientes deciden por code; message es para humanos.
+const (
+	CodeInvalidJSON       = "invalid_json"
+	CodeInvalidParam      = "invalid_param"
+	CodeValidation        = "validation_failed"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeUnauthorized      = "unauthorized"
+	CodeForbidden         = "forbidden"
+	CodeOutOfStock        = "out_of_stock"
+	CodeWrongMode         = "wrong_mode"
+	CodeInsufficientFunds = "insufficient_funds"
+	CodeAlreadyReturned   = "already_returned"
+	CodeInvalidPromotion  = "invalid_promotion"
+	CodeInvalidReference  = "invalid_reference"
+	CodeInvalidFile       = "invalid_file"
+	CodeKeyReused         = "idempotency_key_reused"
+	CodeKeyInProgress     = "idempotency_in_progress"
+	CodeInternal          = "internal"
+)
+
+// APIError es el cuerpo de todos los errores: {"error": {code, message, details, request_id}}.
+type APIError struct {
+	Code      string         `json:"code"`
+	Message   string         `json:"message"`
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+type errorBody struct {
+	Error APIError `json:"error"`
+}
+
+// apiError es un error de negocio con su status HTTP; fail lo responde tal cual.
+type apiError struct {
+	status  int
+	code    string
+	msg     string
+	details map[string]any
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+func newError(status int, code, msg string) *apiError {
+	return &apiError{status: status, code: code, msg: msg}
+}
+
+// abort responde un error con código y corta la cadena de handlers.
+func abort(c *gin.Context, status int, code, msg string) {
+	abortDetails(c, status, code, msg, nil)
+}
+
+func abortDetails(c *gin.Context, status int, code, msg string, details map[string]any) {
+	c.AbortWithStatusJSON(status, errorBody{APIError{
+		Code:      code,
+		Message:   msg,
+		Details:   details,
+		RequestID: c.GetString(requestIDKey),
+	}})
+}
+
+// fail traduce err a una respuesta: *apiError tal cual, restricciones de SQLite a 409/422
+// con los campos involucrados, y cualquier otra cosa a 500 sin exponer el detalle
+// (que sí queda en el log junto al request id).
+func fail(c *gin.Context, err error) {
+	var ae *apiError
+	if errors.As(err, &ae) {
+		abortDetails(c, ae.status, ae.code, ae.msg, ae.details)
+		return
+	}
+	if ae := serviceError(err); ae != nil {
+		abort(c, ae.status, ae.code, ae.msg)
+		return
+	}
+	if ae := constraintError(err); ae != nil {
+		abortDetails(c, ae.status, ae.code, ae.msg, ae.details)
+		return
+	}
+	logger(c).Error("error interno", "method", c.Request.Method, "route", c.FullPath(), "err", err)
+	abort(c, http.StatusInternalServerError, CodeInternal, "error interno, intenta nuevamente")
+}
+
+// serviceErrors dice con qué status y código responde cada error de negocio.
+var serviceErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{service.ErrUserNotFound, http.StatusNotFound, CodeNotFound},
+	{service.ErrBookNotFound, http.StatusNotFound, CodeNotFound},
+	{service.ErrLoanNotFound, http.StatusNotFound, CodeNotFound},
+	{store.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{service.ErrEmptyCart, http.StatusBadRequest, CodeValidation},
+	{service.ErrNotForSale, http.StatusBadRequest, CodeWrongMode},
+	{service.ErrNotForLoan, http.StatusBadRequest, CodeWrongMode},
+	{service.ErrOutOfStock, http.StatusConflict, CodeOutOfStock},
+	{service.ErrInsufficientFunds, http.StatusBadRequest, CodeInsufficientFunds},
+	{service.ErrAlreadyReturned, http.StatusBadRequest, CodeAlreadyReturned},
+	{service.ErrReviewNotFound, http.StatusNotFound, CodeNotFound},
+	{service.ErrNotReviewer, http.StatusForbidden, CodeForbidden},
+	{service.ErrNotAuthor, http.StatusForbidden, CodeForbidden},
+}
+
+func serviceError(err error) *apiError {
+	var pe *service.PromotionError
+	if errors.As(err, &pe) {
+		status := http.StatusBadRequest
+		if pe.Exhausted {
+			status = http.StatusConflict
+		}
+		return newError(status, CodeInvalidPromotion, pe.Msg)
+	}
+	for _, e := range serviceErrors {
+		if errors.Is(err, e.err) {
+			msg := e.err.Error()
+			if e.err == store.ErrNotFound {
+				msg = "no encontrado"
+			}
+			return newError(e.status, e.code, msg)
+		}
+	}
+	return nil
+}
+
+// constraintDetail es lo que SQLite pone tras el último "constraint failed:",
+// ej. "users.email" (UNIQUE / NOT NULL) o "price >= 0" (CHECK).
+var constraintDetail = regexp.MustCompile(`.*constraint failed: (.+?)(?: \(\d+\))?$`)
+var identifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+func constraintError(err error) *apiError {
+	var se *sqlite.Error
+	if !errors.As(err, &se) {
+		return nil
+	}
+	var fields []string
+	if m := constraintDetail.FindStringSubmatch(se.Error()); m != nil {
+		if se.Code() == sqlite3.SQLITE_CONSTRAINT_CHECK {
+			// el mensaje trae la expresión; el campo es su primer identificador
+			if f := identifier.FindString(m[1]); f != "" {
+				fields = append(fields, f)
+			}
+		} else {
+			for _, col := range strings.Split(m[1], ",") {
+				col = strings.TrimSpace(col)
+				fields = append(fields, col[strings.LastIndex(col, ".")+1:])
+			}
+		}
+	}
+	details := map[string]any{}
+	if len(fields) > 0 {
+		details["fields"] = fields
+	}
+
+	list := strings.Join(fields, ", ")
+	switch se.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		msg := "ya existe un registro con esos datos"
+		if list != "" {
+			msg = "ya existe un registro con ese " + list
+		}
+		return &apiError{http.StatusConflict, CodeConflict, msg, details}
+	case sqlite3.SQLITE_CONSTRAINT_CHECK, sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		msg := "valor no permitido"
+		if list != "" {
+			msg = "valor no permitido en " + list
+		}
+		return &apiError{http.StatusUnprocessableEntity, CodeValidation, msg, details}
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return &apiError{http.StatusUnprocessableEntity, CodeInvalidReference, "referencia a un registro que no existe", details}
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var se *sqlite.Error
+	return errors.As(err, &se) &&
+		(se.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || se.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+// streamEvents son los eventos del outbox que publica GET /events (user.created no: trae
+// datos personales).
+var streamEvents = []string{service.EventBookStock, service.EventBookPrice, store.EventOutOfStock,
+	store.EventSaleCreated, store.EventLoanCreated, store.EventLoanReturned}
+
+const (
+	// DefaultEventPoll es cada cuánto GET /events busca eventos nuevos en el outbox.
+	DefaultEventPoll = time.Second
+	// eventHeartbeat es cada cuánto va un comentario al stream, para que los proxies no
+	// corten una conexión sin tráfico.
+	eventHeartbeat = 15 * time.Second
+	// EventRetention es hasta dónde se puede volver con Last-Event-ID: el job outbox.purge
+	// borra los eventos más antiguos (salvo los que aún tienen entregas de webhook pendientes).
+	EventRetention = 7 * 24 * time.Hour
+)
+
+func registerEventRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	st := store.New(db)
+
+	// GET /events?book_id=&user_id=&types=book.stock,book.price&since=  -> Server-Sent Events.
+	// Cada evento lleva su id del outbox: al reconectar con Last-Event-ID (o ?since=) se
+	// recibe lo que pasó entremedio; sin ellos, solo lo que ocurra desde ahora.
+	r.GET("/events", func(c *gin.Context) {
+		f := store.OutboxFilter{Events: streamEvents, Limit: 100}
+		ints := []struct {
+			name string
+			val  string
+			dst  *int64
+		}{
+			{"book_id", c.Query("book_id"), &f.BookID},
+			{"user_id", c.Query("user_id"), &f.UserID},
+			{"since", c.Query("since"), &f.AfterID},
+			{"Last-Event-ID", c.GetHeader("Last-Event-ID"), &f.AfterID},
+		}
+		for _, p := range ints {
+			if p.val == "" {
+				continue
+			}
+			n, err := strconv.ParseInt(p.val, 10, 64)
+			if err != nil || n < 0 {
+				abort(c, http.StatusBadRequest, CodeInvalidParam, p.name+" inválido")
+				return
+			}
+			*p.dst = n
+		}
+		if s := c.Query("types"); s != "" {
+			f.Events = strings.Split(s, ",")
+			for _, e := range f.Events {
+				if !slices.Contains(streamEvents, e) {
+					abort(c, http.StatusBadRequest, CodeInvalidParam, "types debe ser una lista de: "+strings.Join(streamEvents, ", "))
+					return
+				}
+			}
+		}
+		if c.Query("since") == "" && c.GetHeader("Last-Event-ID") == "" {
+			last, err := st.Read().Outbox().LastID(c.Request.Context())
+			if err != nil {
+				fail(c, err)
+				return
+			}
+			f.AfterID = last
+		}
+
+		c.Header("Content-Type", sse.ContentType)
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no") // nginx: no juntar el stream en un buffer
+		c.Status(http.StatusOK)
+		c.Writer.WriteString(": conectado\n\n")
+		c.Writer.Flush()
+
+		poll := time.NewTicker(cfg.eventPoll)
+		defer poll.Stop()
+		heartbeat := time.NewTicker(eventHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-heartbeat.C:
+				c.Writer.WriteString(": ping\n\n")
+			case <-poll.C:
+				events, err := st.Read().Outbox().After(c.Request.Context(), f)
+				if err != nil {
+					// el cliente reconecta con Last-Event-ID y no pierde nada
+					if c.Request.Context().Err() == nil {
+						logger(c).Error("stream de eventos", "err", err)
+					}
+					return
+				}
+				for _, e := range events {
+					c.Render(-1, sse.Event{Id: strconv.FormatInt(e.ID, 10), Event: e.Event, Data: e})
+					f.AfterID = e.ID
+				}
+			}
+			c.Writer.Flush()
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+)
+
+// sseEvent es un mensaje del stream tal como viaja: id y event de sus campos, data sin parsear.
+type sseEvent struct {
+	ID, Event string
+	Data      struct {
+		ID    int64           `json:"id"`
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}
+}
+
+// openStream abre GET /events?query y devuelve los mensajes que van llegando; vuelve cuando
+// el servidor ya aceptó la conexión.
+func openStream(t *testing.T, s *apitest.Server, query string, headers ...string) <-chan sseEvent {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, s.URL+s.Base+"/events?"+query, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("GET /events?%s: %d %s", query, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	ch := make(chan sseEvent, 100)
+	go func() {
+		defer close(ch)
+		sc := bufio.NewScanner(resp.Body)
+		var e sseEvent
+		for sc.Scan() {
+			k, v, _ := strings.Cut(sc.Text(), ":")
+			v = strings.TrimPrefix(v, " ")
+			switch k {
+			case "id":
+				e.ID = v
+			case "event":
+				e.Event = v
+			case "data":
+				if err := json.Unmarshal([]byte(v), &e.Data); err != nil {
+					t.Errorf("data %q: %v", v, err)
+				}
+			case "":
+				if e.Event != "" {
+					ch <- e
+				}
+				e = sseEvent{}
+			}
+		}
+	}()
+	return ch
+}
+
+// next espera los n próximos eventos de ch.
+func next(t *testing.T, ch <-chan sseEvent, n int) []sseEvent {
+	t.Helper()
+	var out []sseEvent
+	for len(out) < n {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				t.Fatalf("el stream se cerró tras %d eventos", len(out))
+			}
+			if e.ID != fmt.Sprint(e.Data.ID) || e.Event != e.Data.Event {
+				t.Errorf("campos id/event %q/%q no calzan con data %+v", e.ID, e.Event, e.Data)
+			}
+			out = append(out, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("llegaron %d de %d eventos", len(out), n)
+		}
+	}
+	return out
+}
+
+func names(events []sseEvent) string {
+	var s []string
+	for _, e := range events {
+		s = append(s, e.Event)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestEventStream(t *testing.T) {
+	s := apitest.NewServer(t, api.WithEventPoll(10*time.Millisecond))
+	ana := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	beto := apitest.NewUser().Insert(t, s.DB)
+	rayuela := apitest.NewBook().Name("Rayuela").Price(30).Stock(3).Insert(t, s.DB)
+	ficciones := apitest.NewBook().Name("Ficciones").ForLoan().Stock(1).Insert(t, s.DB)
+	// lo de antes de conectarse no llega
+	s.Do(http.MethodPost, "/sales", map[string]any{"user_id": ana, "book_id": rayuela})
+
+	all := openStream(t, s, "")
+	byBook := openStream(t, s, fmt.Sprintf("book_id=%d", rayuela))
+	byUser := openStream(t, s, fmt.Sprintf("user_id=%d", beto))
+	prices := openStream(t, s, "types=book.price")
+
+	s.Do(http.MethodPost, "/users", map[string]any{"first_name": "Ema", "last_name": "Paz", "email": "ema@usm.cl", "password": "clave123"})
+	s.Do(http.MethodPost, "/sales", map[string]any{"user_id": ana, "book_id": rayuela})
+	s.Do(http.MethodPatch, fmt.Sprintf("/books/%d", rayuela), map[string]any{"price": 25})
+	if resp := s.Do(http.MethodPost, "/loans", map[string]any{"user_id": beto, "book_id": ficciones}); resp.Status != http.StatusCreated {
+		t.Fatalf("arriendo: %d %s", resp.Status, resp.Body)
+	}
+
+	got := next(t, all, 6)
+	if want := "book.stock sale.created book.price book.stock book.out_of_stock loan.created"; names(got) != want {
+		t.Errorf("todos = %s, want %s (sin user.created)", names(got), want)
+	}
+	var stock struct {
+		BookID       int64 `json:"book_id"`
+		Available    int64 `json:"available"`
+		OldAvailable int64 `json:"old_available"`
+	}
+	if err := json.Unmarshal(got[0].Data.Data, &stock); err != nil || stock.BookID != rayuela || stock.Available != 1 || stock.OldAvailable != 2 {
+		t.Errorf("book.stock = %s, %v", got[0].Data.Data, err)
+	}
+	if got := next(t, byBook, 3); names(got) != "book.stock sale.created book.price" {
+		t.Errorf("book_id = %s", names(got))
+	}
+	if got := next(t, byUser, 1); names(got) != "loan.created" {
+		t.Errorf("user_id = %s", names(got))
+	}
+	if got := next(t, prices, 1); names(got) != "book.price" || !strings.Contains(string(got[0].Data.Data), `"old_price":30`) {
+		t.Errorf("types = %s %s", names(got), got[0].Data.Data)
+	}
+
+	// al reconectar con Last-Event-ID (o ?since=) llega lo que faltaba
+	resumed := next(t, openStream(t, s, "", "Last-Event-ID", got[2].ID), 3)
+	if names(resumed) != "book.stock book.out_of_stock loan.created" || resumed[0].ID != got[3].ID {
+		t.Errorf("Last-Event-ID %s = %s", got[2].ID, names(resumed))
+	}
+	if resumed := next(t, openStream(t, s, "since="+got[4].ID), 1); resumed[0].ID != got[5].ID {
+		t.Errorf("since %s = %+v", got[4].ID, resumed)
+	}
+
+	for _, q := range []string{"types=user.created", "book_id=x", "since=-1"} {
+		resp := s.Do(http.MethodGet, "/events?"+q, nil)
+		if resp.Status != http.StatusBadRequest || resp.APIError().Code != api.CodeInvalidParam {
+			t.Errorf("GET /events?%s = %d %s", q, resp.Status, resp.Body)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/store"
+)
+
+// IdempotencyTTL es cuánto se recuerda una Idempotency-Key; después se puede reutilizar.
+const IdempotencyTTL = 24 * time.Hour
+
+var validIdempotencyKey = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,255}$`)
+
+// bodyRecorder copia lo que el handler escribe para poder guardarlo.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// claves en el gin.Context entre idempotent y keepResponse
+const (
+	ctxIdempotencyKey  = "idempotency_key"
+	ctxIdempotencyKept = "idempotency_kept"
+)
+
+// keepResponse guarda la respuesta exitosa de una request con Idempotency-Key dentro de tx, la
+// misma transacción que cobra o mueve stock: si el proceso cae justo después del commit, el
+// reintento repite la respuesta en vez de quedar "en curso" o volver a cobrar. Va al final de
+// la transacción y el handler luego responde c.JSON(status, body). Sin key no hace nada.
+func keepResponse(c *gin.Context, tx store.Tx, status int, body any) error {
+	key := c.GetString(ctxIdempotencyKey)
+	if key == "" {
+		return nil
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	if err := tx.Idempotency().Save(c.Request.Context(), key, status, raw); err != nil {
+		return err
+	}
+	c.Set(ctxIdempotencyKept, true)
+	return nil
+}
+
+// idempotent hace que reintentar una request con el mismo header Idempotency-Key no la
+// ejecute dos veces: la primera respuesta se guarda y las siguientes la reciben tal cual
+// (con Idempotent-Replayed: true). La misma key con otro método, ruta o cuerpo es 409, igual
+// que si la primera aún no termina. Los éxitos los guarda el handler con keepResponse, en su
+// transacción; los errores de negocio (que no cambiaron nada) se guardan al final y los 5xx
+// no se guardan, para que el reintento vuelva a intentarlo. Sin el header, la request pasa
+// normal.
+func idempotent(st *store.Store, cfg *config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey.MatchString(key) {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "Idempotency-Key inválida (1 a 255 caracteres: letras, números, . _ : -)")
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidJSON, "no se pudo leer el cuerpo")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.New()
+		io.WriteString(sum, c.Request.Method+" "+c.Request.URL.Path+"\n")
+		sum.Write(body)
+		hash := hex.EncodeToString(sum.Sum(nil))
+
+		ctx := c.Request.Context()
+		now := cfg.clock.Now()
+		var saved store.SavedResponse
+		var claimed bool
+		err = st.InTx(ctx, func(tx store.Tx) (err error) {
+			saved, claimed, err = tx.Idempotency().Claim(ctx, key, hash, now, now.Add(-IdempotencyTTL))
+			return err
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		if !claimed {
+			switch {
+			case saved.RequestHash != hash:
+				abort(c, http.StatusConflict, CodeKeyReused, "esa Idempotency-Key ya se usó con otra request")
+			case saved.Status == 0:
+				abort(c, http.StatusConflict, CodeKeyInProgress, "la request con esa Idempotency-Key todavía se está procesando")
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(saved.Status, "application/json; charset=utf-8", saved.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		// sin el contexto de la request: si el cliente se desconectó, igual hay que guardar
+		bg := context.WithoutCancel(ctx)
+		defer func() {
+			if p := recover(); p != nil {
+				st.Read().Idempotency().Release(bg, key)
+				panic(p)
+			}
+		}()
+		rec := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Set(ctxIdempotencyKey, key)
+		c.Next()
+
+		// Release no toca una respuesta ya guardada: si keepResponse corrió y la transacción
+		// falló igual, suelta la reserva; si hizo commit, la respuesta queda
+		if status := rec.Status(); status >= 500 {
+			err = st.Read().Idempotency().Release(bg, key)
+		} else if !c.GetBool(ctxIdempotencyKept) {
+			err = st.Read().Idempotency().Save(bg, key, status, rec.body.Bytes())
+		}
+		if err != nil {
+			logger(c).Error("idempotency key", "key", key, "err", err)
+		}
+	}
+}
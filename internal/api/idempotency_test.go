@@ -0,0 +1,138 @@
+package api_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/clock"
+)
+
+func TestIdempotentSaleIsChargedOnce(t *testing.T) {
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	book := apitest.NewBook().Price(30).Stock(5).Insert(t, s.DB)
+	body := map[string]any{"user_id": user, "book_id": book}
+
+	first := s.Do(http.MethodPost, "/sales", body, "Idempotency-Key", "compra-1")
+	again := s.Do(http.MethodPost, "/sales", body, "Idempotency-Key", "compra-1")
+	if first.Status != http.StatusCreated || again.Status != http.StatusCreated {
+		t.Fatalf("status = %d / %d, body %s", first.Status, again.Status, again.Body)
+	}
+	if string(again.Body) != string(first.Body) || again.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("el reintento no repitió la respuesta: %s vs %s", again.Body, first.Body)
+	}
+	if got := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); got != 70 {
+		t.Errorf("saldo = %d, want 70 (un solo cobro)", got)
+	}
+
+	// sin key, o con otra, es otra compra
+	s.Do(http.MethodPost, "/sales", body, "Idempotency-Key", "compra-2")
+	s.Do(http.MethodPost, "/sales", body)
+	if got := s.QueryInt(`SELECT COUNT(*) FROM sales WHERE user_id=?`, user); got != 3 {
+		t.Errorf("ventas = %d, want 3", got)
+	}
+}
+
+func TestIdempotencyKeyErrors(t *testing.T) {
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Balance(10).Insert(t, s.DB)
+	book := apitest.NewBook().Price(30).Insert(t, s.DB)
+	path := "/users/" + strconv.FormatInt(user, 10)
+
+	// los errores de negocio también se repiten: el reintento no cobra aunque ahora alcance
+	resp := s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": book}, "Idempotency-Key", "k1")
+	if resp.APIError().Code != "insufficient_funds" {
+		t.Fatalf("primera: %d %s", resp.Status, resp.Body)
+	}
+	s.Do(http.MethodPatch, path, map[string]any{"abonar": 50}, "Idempotency-Key", "abono-1")
+	s.Do(http.MethodPatch, path, map[string]any{"abonar": 50}, "Idempotency-Key", "abono-1")
+	resp = s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": book}, "Idempotency-Key", "k1")
+	if resp.APIError().Code != "insufficient_funds" || resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("repetida: %d %s", resp.Status, resp.Body)
+	}
+	if got := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); got != 60 {
+		t.Errorf("saldo = %d, want 60 (un solo abono)", got)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		key    string
+		status int
+		code   string
+	}{
+		{"otro cuerpo", http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": book + 1}, "k1", http.StatusConflict, "idempotency_key_reused"},
+		{"otra ruta", http.MethodPatch, path, map[string]any{"abonar": 1}, "k1", http.StatusConflict, "idempotency_key_reused"},
+		{"key inválida", http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": book}, "con espacios", http.StatusBadRequest, "invalid_param"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Do(tt.method, tt.path, tt.body, "Idempotency-Key", tt.key)
+			if resp.Status != tt.status || resp.APIError().Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q (%s)", resp.Status, resp.APIError().Code, tt.status, tt.code, resp.Body)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyExpires(t *testing.T) {
+	clk := clock.NewTravel(clock.Fixed(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)))
+	s := apitest.NewServer(t, api.WithClock(clk))
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	book := apitest.NewBook().Price(10).Stock(5).Insert(t, s.DB)
+	body := map[string]any{"user_id": user, "book_id": book}
+
+	s.Do(http.MethodPost, "/sales", body, "Idempotency-Key", "vieja")
+	clk.Advance(api.IdempotencyTTL + time.Minute)
+	resp := s.Do(http.MethodPost, "/sales", body, "Idempotency-Key", "vieja")
+	if resp.Status != http.StatusCreated || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("tras vencer: %d %s", resp.Status, resp.Body)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM sales`); got != 2 {
+		t.Errorf("ventas = %d, want 2", got)
+	}
+}
+
+// La respuesta se guarda en la misma transacción que el cobro: si no se puede guardar, la
+// venta tampoco queda y el reintento la ejecuta (nunca un cobro sin respuesta que repetir).
+func TestIdempotentResponseCommitsWithSale(t *testing.T) {
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	book := apitest.NewBook().Price(30).Stock(5).Insert(t, s.DB)
+	body := map[string]any{"user_id": user, "book_id": book}
+
+	if _, err := s.DB.Exec(`CREATE TRIGGER idem_full BEFORE UPDATE ON idempotency_keys
+BEGIN SELECT RAISE(ABORT, 'disco lleno'); END`); err != nil {
+		t.Fatal(err)
+	}
+	resp := s.Do(http.MethodPost, "/sales", body, "Idempotency-Key", "compra-1")
+	if resp.Status != http.StatusInternalServerError {
+		t.Fatalf("sin poder guardar: %d %s", resp.Status, resp.Body)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM sales`); got != 0 {
+		t.Errorf("ventas = %d, want 0", got)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM idempotency_keys`); got != 0 {
+		t.Errorf("la key quedó reservada")
+	}
+
+	if _, err := s.DB.Exec(`DROP TRIGGER idem_full`); err != nil {
+		t.Fatal(err)
+	}
+	if resp := s.Do(http.MethodPost, "/sales", body, "Idempotency-Key", "compra-1"); resp.Status != http.StatusCreated ||
+		resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("reintento: %d %s", resp.Status, resp.Body)
+	}
+	if got := s.QueryInt(`SELECT status FROM idempotency_keys WHERE key='compra-1'`); got != http.StatusCreated {
+		t.Errorf("status guardado = %d", got)
+	}
+	if got := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); got != 70 {
+		t.Errorf("saldo = %d, want 70", got)
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/notify"
+	"tarea1-uzm/internal/scheduler"
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+// Jobs son las tareas periódicas del servidor (main las registra en el scheduler):
+//   - loans.overdue marca vencidos los préstamos pendientes cuyo plazo ya pasó.
+//   - loans.fines deja al día la multa acumulada de los vencidos (se cobra al devolver).
+//   - loans.reminders avisa los préstamos por vencer, que vencen hoy o vencidos, en la
+//     bandeja y por channels (correo, webhook).
+//   - idempotency.purge borra las Idempotency-Key más viejas que IdempotencyTTL.
+//   - popularity.recompute recalcula los puntajes de /books/trending.
+func Jobs(db *sql.DB, channels []notify.Notifier) []scheduler.Job {
+	st := store.New(db)
+	channels = append([]notify.Notifier{notify.Inbox{DB: db}}, channels...)
+	// inTx corre una función de service en una transacción y resume cuántas filas tocó.
+	inTx := func(what string, fn func(context.Context, store.Tx, time.Time) (int64, error)) func(context.Context, time.Time) (string, error) {
+		return func(ctx context.Context, now time.Time) (string, error) {
+			var n int64
+			err := st.InTx(ctx, func(tx store.Tx) (err error) {
+				n, err = fn(ctx, tx, now)
+				return err
+			})
+			return fmt.Sprintf("%d %s", n, what), err
+		}
+	}
+	return []scheduler.Job{
+		{Name: "loans.overdue", Every: time.Hour, Run: inTx("préstamos marcados vencido", service.MarkOverdue)},
+		{Name: "loans.fines", Every: time.Hour, Run: inTx("multas actualizadas", service.AccrueFines)},
+		{Name: "loans.reminders", Every: time.Hour, Run: func(ctx context.Context, now time.Time) (string, error) {
+			// fuera de una transacción: el correo o el webhook pueden tardar
+			n, err := service.SendReminders(ctx, st.Read(), channels, now)
+			return fmt.Sprintf("%d recordatorios enviados", n), err
+		}},
+		{Name: "idempotency.purge", Every: time.Hour, Run: func(ctx context.Context, now time.Time) (string, error) {
+			n, err := st.Read().Idempotency().Purge(ctx, now.Add(-IdempotencyTTL))
+			return fmt.Sprintf("%d keys borradas", n), err
+		}},
+		{Name: "outbox.purge", Every: time.Hour, Run: func(ctx context.Context, now time.Time) (string, error) {
+			var events, deliveries int64
+			err := st.InTx(ctx, func(tx store.Tx) (err error) {
+				events, deliveries, err = tx.Outbox().Purge(ctx, now.Add(-EventRetention))
+				return err
+			})
+			return fmt.Sprintf("%d eventos y %d entregas borrados", events, deliveries), err
+		}},
+		{Name: "popularity.recompute", Every: 15 * time.Minute, Run: func(ctx context.Context, now time.Time) (string, error) {
+			return "ventanas " + strings.Join(TrendingWindows, ", "), RecomputePopularity(ctx, db, now)
+		}},
+	}
+}
+
+func registerJobRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	admin := r.Group("/admin", requireAdmin())
+	st := store.New(db)
+
+	// job busca name entre los jobs del scheduler; si no está responde 404.
+	job := func(c *gin.Context) (scheduler.Job, bool) {
+		for _, j := range cfg.scheduler.Jobs() {
+			if j.Name == c.Param("name") {
+				return j, true
+			}
+		}
+		abort(c, http.StatusNotFound, CodeNotFound, "job no existe")
+		return scheduler.Job{}, false
+	}
+
+	// GET /admin/jobs  -> jobs registrados con su última ejecución
+	admin.GET("/jobs", func(c *gin.Context) {
+		out := []gin.H{}
+		for _, j := range cfg.scheduler.Jobs() {
+			item := gin.H{"name": j.Name, "every": j.Every.String()}
+			last, err := st.Read().Jobs().LastRun(c.Request.Context(), j.Name)
+			switch {
+			case err == nil:
+				item["last_run"] = last
+			case !errors.Is(err, store.ErrNotFound):
+				fail(c, err)
+				return
+			}
+			out = append(out, item)
+		}
+		c.JSON(http.StatusOK, gin.H{"jobs": out})
+	})
+
+	// GET /admin/jobs/:name/runs?limit=20
+	admin.GET("/jobs/:name/runs", func(c *gin.Context) {
+		j, ok := job(c)
+		if !ok {
+			return
+		}
+		limit := 20
+		if s := c.Query("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > 1000 {
+				abort(c, http.StatusBadRequest, CodeInvalidParam, "limit debe estar entre 1 y 1000")
+				return
+			}
+			limit = n
+		}
+		runs, err := st.Read().Jobs().Runs(c.Request.Context(), j.Name, limit)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"job": j.Name, "runs": runs})
+	})
+
+	// POST /admin/jobs/:name/run  -> lo corre ahora, aunque no le toque
+	admin.POST("/jobs/:name/run", func(c *gin.Context) {
+		j, ok := job(c)
+		if !ok {
+			return
+		}
+		run, err := cfg.scheduler.Run(c.Request.Context(), j.Name, true)
+		if errors.Is(err, scheduler.ErrLocked) {
+			abort(c, http.StatusConflict, CodeConflict, "el job ya está corriendo; reintenta en un rato")
+			return
+		}
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		if err := cfg.auditAlone(c, db, change{Action: "job.run", Entity: "job_run", EntityID: run.ID,
+			After: gin.H{"job": run.Job, "status": run.Status, "detail": run.Detail}}); err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, run)
+	})
+}
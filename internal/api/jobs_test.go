@@ -0,0 +1,161 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/clock"
+)
+
+func runJob(t *testing.T, s *apitest.Server, name string) string {
+	t.Helper()
+	resp := s.Do(http.MethodPost, "/admin/jobs/"+name+"/run", nil, "X-Admin-Token", "secreto")
+	if resp.Status != http.StatusOK {
+		t.Fatalf("POST /admin/jobs/%s/run: %d %s", name, resp.Status, resp.Body)
+	}
+	var run struct {
+		Status string `json:"status"`
+		Detail string `json:"detail"`
+		Error  string `json:"error"`
+	}
+	resp.Decode(t, &run)
+	if run.Status != "ok" {
+		t.Fatalf("%s: %+v", name, run)
+	}
+	return run.Detail
+}
+
+func TestOverdueJobs(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	clk := clock.NewTravel(clock.Fixed(time.Date(2025, 3, 10, 12, 0, 0, 0, santiago(t))))
+	s := apitest.NewServer(t, api.WithClock(clk))
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	book := apitest.NewBook().ForLoan().Stock(0).Insert(t, s.DB)
+	late := apitest.NewLoan(user, book).Started("01/02/2025").Insert(t, s.DB) // vence el 01/03
+	apitest.NewLoan(user, book).Started("01/03/2025").Insert(t, s.DB)
+	apitest.NewLoan(user, book).Started("01/01/2025").Returned("20/01/2025").Insert(t, s.DB)
+
+	if got := runJob(t, s, "loans.overdue"); got != "1 préstamos marcados vencido" {
+		t.Errorf("loans.overdue = %q", got)
+	}
+	if got := runJob(t, s, "loans.fines"); got != "1 multas actualizadas" {
+		t.Errorf("loans.fines = %q", got)
+	}
+	// correrlos de nuevo el mismo día no cambia nada
+	if got := runJob(t, s, "loans.overdue") + ", " + runJob(t, s, "loans.fines"); got != "0 préstamos marcados vencido, 0 multas actualizadas" {
+		t.Errorf("segunda pasada = %q", got)
+	}
+	type loanView struct {
+		Status   string `json:"status"`
+		Fine     int64  `json:"fine"`
+		DaysLeft int64  `json:"days_left"`
+		Penalty  int64  `json:"penalty"`
+	}
+	var list struct {
+		Loans []loanView `json:"loans"`
+	}
+	s.Do(http.MethodGet, "/loans", nil).Decode(t, &list)
+	loan := list.Loans[late-1]
+	if loan.Status != "vencido" || loan.Fine != 9*2 || loan.DaysLeft != -9 {
+		t.Errorf("préstamo vencido = %+v", loan)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM loans WHERE status='pendiente'`); got != 1 {
+		t.Errorf("pendientes = %d, want 1", got)
+	}
+
+	// la multa sigue corriendo; al devolver se cobra la del día de devolución
+	clk.Advance(24 * time.Hour)
+	runJob(t, s, "loans.fines")
+	resp := s.Do(http.MethodPatch, fmt.Sprintf("/loans/%d/return", late), map[string]any{})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("devolver vencido: %d %s", resp.Status, resp.Body)
+	}
+	resp.Decode(t, &loan)
+	if loan.Status != "finalizado" || loan.Penalty != 20 || loan.Fine != 20 {
+		t.Errorf("devuelto = %+v", loan)
+	}
+	if got := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); got != 80 {
+		t.Errorf("saldo = %d, want 80", got)
+	}
+
+	// idempotency.purge borra solo las keys vencidas
+	for key, at := range map[string]string{"vieja": "2025-03-01T00:00:00Z", "nueva": "2025-03-11T14:00:00Z"} {
+		if _, err := s.DB.Exec(`INSERT INTO idempotency_keys(key,request_hash,created_at) VALUES(?,'x',?)`, key, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := runJob(t, s, "idempotency.purge"); got != "1 keys borradas" {
+		t.Errorf("idempotency.purge = %q", got)
+	}
+
+	var jobs struct {
+		Jobs []struct {
+			Name    string `json:"name"`
+			LastRun *struct {
+				Status string `json:"status"`
+			} `json:"last_run"`
+		} `json:"jobs"`
+	}
+	s.Do(http.MethodGet, "/admin/jobs", nil, "X-Admin-Token", "secreto").Decode(t, &jobs)
+	if len(jobs.Jobs) != 6 || jobs.Jobs[0].Name != "loans.overdue" || jobs.Jobs[0].LastRun == nil || jobs.Jobs[5].LastRun != nil {
+		t.Errorf("GET /admin/jobs = %+v", jobs)
+	}
+	var runs struct {
+		Runs []struct {
+			ID int64 `json:"id"`
+		} `json:"runs"`
+	}
+	s.Do(http.MethodGet, "/admin/jobs/loans.fines/runs?limit=2", nil, "X-Admin-Token", "secreto").Decode(t, &runs)
+	if len(runs.Runs) != 2 || runs.Runs[0].ID < runs.Runs[1].ID {
+		t.Errorf("runs = %+v", runs)
+	}
+	if resp := s.Do(http.MethodPost, "/admin/jobs/nada/run", nil, "X-Admin-Token", "secreto"); resp.Status != http.StatusNotFound {
+		t.Errorf("job desconocido: %d", resp.Status)
+	}
+	if resp := s.Do(http.MethodPost, "/admin/jobs/loans.fines/run", nil); resp.Status != http.StatusForbidden {
+		t.Errorf("sin token: %d", resp.Status)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM audit_log WHERE action='job.run'`); got != 6 {
+		t.Errorf("job.run en audit_log = %d, want 6", got)
+	}
+}
+
+func TestOutboxPurgeJob(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	now := time.Date(2025, 3, 20, 12, 0, 0, 0, time.UTC)
+	s := apitest.NewServer(t, api.WithClock(clock.Fixed(now)))
+	old := now.Add(-api.EventRetention - time.Hour).Format(time.RFC3339)
+	recent := now.Add(-time.Hour).Format(time.RFC3339)
+	for _, q := range []string{
+		`INSERT INTO webhooks(id,url,secret,events,created_at) VALUES(1,'http://x','s','[]',?1)`,
+		`INSERT INTO outbox(id,event,payload,created_at) VALUES
+		   (1,'sale.created','{}',?1), (2,'sale.created','{}',?1), (3,'sale.created','{}',?1), (4,'sale.created','{}',?1),
+		   (5,'sale.created','{}',?2)`,
+		`INSERT INTO webhook_deliveries(webhook_id,outbox_id,status,created_at) VALUES
+		   (1,2,'entregado',?1), (1,3,'pendiente',?1), (1,4,'muerto',?1), (1,5,'entregado',?2)`,
+	} {
+		if _, err := s.DB.Exec(q, old, recent); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 1 no tenía entregas y 2 ya se entregó; 3 y 4 aún se pueden enviar o reenviar
+	if got := runJob(t, s, "outbox.purge"); got != "2 eventos y 1 entregas borrados" {
+		t.Errorf("outbox.purge = %q", got)
+	}
+	var ids string
+	s.DB.QueryRow(`SELECT group_concat(id) FROM (SELECT id FROM outbox ORDER BY id)`).Scan(&ids)
+	if ids != "3,4,5" {
+		t.Errorf("outbox = %s, want 3,4,5", ids)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM webhook_deliveries`); got != 3 {
+		t.Errorf("entregas = %d, want 3", got)
+	}
+	if got := runJob(t, s, "outbox.purge"); got != "0 eventos y 0 entregas borrados" {
+		t.Errorf("segunda pasada = %q", got)
+	}
+}
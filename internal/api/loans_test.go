@@ -0,0 +1,138 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"tarea1-uzm/internal/apitest"
+)
+
+func TestLendBook(t *testing.T) {
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Insert(t, s.DB)
+	book := apitest.NewBook().ForLoan().Stock(1).Insert(t, s.DB)
+
+	resp := s.Do(http.MethodPost, "/loans", map[string]any{"user_id": user, "book_id": book})
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("status = %d, body %s", resp.Status, resp.Body)
+	}
+	var loan struct {
+		Status   string `json:"status"`
+		DueDate  string `json:"due_date"`
+		DaysLeft int64  `json:"days_left"`
+	}
+	resp.Decode(t, &loan)
+	if loan.Status != "pendiente" || loan.DueDate == "" || loan.DaysLeft < 28 {
+		t.Errorf("loan = %+v", loan)
+	}
+	if got := s.QueryInt(`SELECT available_quantity FROM inventory WHERE book_id=?`, book); got != 0 {
+		t.Errorf("stock = %d, want 0", got)
+	}
+
+	// el único ejemplar ya está prestado
+	resp = s.Do(http.MethodPost, "/loans", map[string]any{"user_id": user, "book_id": book})
+	if resp.Status != http.StatusConflict || resp.APIError().Code != "out_of_stock" {
+		t.Errorf("segundo préstamo: %d %s", resp.Status, resp.Body)
+	}
+}
+
+func TestLendBookErrors(t *testing.T) {
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Insert(t, s.DB)
+	forSale := apitest.NewBook().ForSale().Insert(t, s.DB)
+	soldOut := apitest.NewBook().ForLoan().Stock(0).Insert(t, s.DB)
+
+	tests := []struct {
+		name   string
+		body   any
+		status int
+		code   string
+	}{
+		{"modalidad Venta", map[string]any{"user_id": user, "book_id": forSale}, http.StatusBadRequest, "wrong_mode"},
+		{"sin stock", map[string]any{"user_id": user, "book_id": soldOut}, http.StatusConflict, "out_of_stock"},
+		{"libro no existe", map[string]any{"user_id": user, "book_id": 404}, http.StatusNotFound, "not_found"},
+		{"sin user_id", map[string]any{"book_id": forSale}, http.StatusUnprocessableEntity, "validation_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Do(http.MethodPost, "/loans", tt.body)
+			if resp.Status != tt.status || resp.APIError().Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q (%s)", resp.Status, resp.APIError().Code, tt.status, tt.code, resp.Body)
+			}
+		})
+	}
+}
+
+func TestReturnLoanPenalty(t *testing.T) {
+	// préstamo del 01/01/2025: vence el 01/02/2025, 2 usm pesos por día de atraso
+	tests := []struct {
+		returned string
+		daysLate int64
+		penalty  int64
+	}{
+		{"15/01/2025", 0, 0},
+		{"01/02/2025", 0, 0},
+		{"02/02/2025", 1, 2},
+		{"11/02/2025", 10, 20},
+		{"01/03/2025", 28, 56},
+	}
+	for _, tt := range tests {
+		t.Run(tt.returned, func(t *testing.T) {
+			s := apitest.NewServer(t)
+			user := apitest.NewUser().Balance(10).Insert(t, s.DB)
+			book := apitest.NewBook().ForLoan().Stock(0).Insert(t, s.DB)
+			loan := apitest.NewLoan(user, book).Started("01/01/2025").Insert(t, s.DB)
+
+			resp := s.Do(http.MethodPatch, fmt.Sprintf("/loans/%d/return", loan), map[string]any{"return_date": tt.returned})
+			if resp.Status != http.StatusOK {
+				t.Fatalf("status = %d, body %s", resp.Status, resp.Body)
+			}
+			var out struct {
+				Status   string `json:"status"`
+				DaysLate int64  `json:"days_late"`
+				Penalty  int64  `json:"penalty"`
+			}
+			resp.Decode(t, &out)
+			if out.Status != "finalizado" || out.DaysLate != tt.daysLate || out.Penalty != tt.penalty {
+				t.Errorf("got %+v, want days_late=%d penalty=%d", out, tt.daysLate, tt.penalty)
+			}
+			// la multa puede dejar el saldo negativo
+			if got := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); got != 10-tt.penalty {
+				t.Errorf("saldo = %d, want %d", got, 10-tt.penalty)
+			}
+			if got := s.QueryInt(`SELECT available_quantity FROM inventory WHERE book_id=?`, book); got != 1 {
+				t.Errorf("stock = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestReturnLoanErrors(t *testing.T) {
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Insert(t, s.DB)
+	book := apitest.NewBook().ForLoan().Insert(t, s.DB)
+	done := apitest.NewLoan(user, book).Returned("10/01/2025").Insert(t, s.DB)
+	open := apitest.NewLoan(user, book).Insert(t, s.DB)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   any
+		status int
+		code   string
+	}{
+		{"ya devuelto", fmt.Sprintf("/loans/%d/return", done), map[string]any{"return_date": "11/01/2025"}, http.StatusBadRequest, "already_returned"},
+		{"no existe", "/loans/999/return", map[string]any{"return_date": "11/01/2025"}, http.StatusNotFound, "not_found"},
+		{"id inválido", "/loans/abc/return", map[string]any{"return_date": "11/01/2025"}, http.StatusBadRequest, "invalid_param"},
+		{"fecha inválida", fmt.Sprintf("/loans/%d/return", open), map[string]any{"return_date": "2025-01-11"}, http.StatusUnprocessableEntity, "validation_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Do(http.MethodPatch, tt.path, tt.body)
+			if resp.Status != tt.status || resp.APIError().Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q (%s)", resp.Status, resp.APIError().Code, tt.status, tt.code, resp.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/logging"
+)
+
+// logBuffer junta las líneas JSON del log (el servidor escribe desde otras goroutines).
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// find devuelve las líneas con ese msg.
+func (b *logBuffer) find(t *testing.T, msg string) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("línea no es JSON: %v\n%s", err, raw)
+		}
+		if line["msg"] == msg {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func TestLogging(t *testing.T) {
+	var logs logBuffer
+	l, err := logging.New(&logs, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := apitest.NewServer(t, api.WithLogger(l))
+	book := apitest.NewBook().Price(30).Stock(2).Insert(t, s.DB)
+	loanBook := apitest.NewBook().ForLoan().Stock(1).Insert(t, s.DB)
+
+	resp := s.Do(http.MethodPost, "/users", map[string]any{
+		"first_name": "Ana", "last_name": "Rojas", "email": "ana@usm.cl", "password": "supersecreta",
+	})
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("crear usuario: %d %s", resp.Status, resp.Body)
+	}
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	resp.Decode(t, &user)
+	s.Do(http.MethodPatch, fmt.Sprintf("/users/%d", user.ID), map[string]any{"password": "otraclave", "abonar": 50})
+
+	resp = s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user.ID, "book_id": book}, "X-Request-ID", "venta-1")
+	if resp.Status != http.StatusCreated || resp.Header.Get("X-Request-ID") != "venta-1" {
+		t.Fatalf("venta: %d %v %s", resp.Status, resp.Header, resp.Body)
+	}
+	loan := apitest.NewLoan(user.ID, loanBook).Started("01/01/2025").Insert(t, s.DB)
+	s.Do(http.MethodPatch, fmt.Sprintf("/loans/%d/return", loan), map[string]any{"return_date": "11/02/2025"})
+	s.Do(http.MethodPost, "/loans", map[string]any{"user_id": user.ID, "book_id": loanBook})
+	resp = s.Do(http.MethodGet, "/users/404", nil)
+	if id := resp.APIError().RequestID; id == "" || id != resp.Header.Get("X-Request-ID") {
+		t.Errorf("request_id del cuerpo = %q, header = %q", id, resp.Header.Get("X-Request-ID"))
+	}
+
+	if strings.Contains(logs.String(), "supersecreta") || strings.Contains(logs.String(), "otraclave") {
+		t.Fatalf("el log contiene una contraseña:\n%s", logs.String())
+	}
+
+	sales := logs.find(t, "venta")
+	if len(sales) != 1 || sales[0]["request_id"] != "venta-1" ||
+		sales[0]["user_id"] != float64(user.ID) || sales[0]["book_id"] != float64(book) {
+		t.Errorf("venta = %v", sales)
+	}
+	var balance []string
+	for _, line := range logs.find(t, "saldo") {
+		balance = append(balance, fmt.Sprintf("%s %v", line["reason"], line["delta"]))
+	}
+	if got, want := strings.Join(balance, ", "), "abono 50, compra -30, multa -20"; got != want {
+		t.Errorf("movimientos de saldo = %q, want %q", got, want)
+	}
+	if ret := logs.find(t, "devolución"); len(ret) != 1 || ret[0]["days_late"] != float64(10) || ret[0]["book_id"] != float64(loanBook) {
+		t.Errorf("devolución = %v", ret)
+	}
+	if loans := logs.find(t, "préstamo"); len(loans) != 1 || loans[0]["user_id"] != float64(user.ID) {
+		t.Errorf("préstamo = %v", loans)
+	}
+	if upd := logs.find(t, "usuario actualizado"); len(upd) != 1 || fmt.Sprint(upd[0]["fields"]) != "[password]" {
+		t.Errorf("usuario actualizado = %v", upd)
+	}
+
+	var access []string
+	for _, line := range logs.find(t, "request") {
+		access = append(access, fmt.Sprintf("%s %s %v %s", line["method"], line["route"], line["status"], line["level"]))
+	}
+	if want := "POST /api/v1/sales 201 INFO"; !slices.Contains(access, want) {
+		t.Errorf("access log sin %q: %v", want, access)
+	}
+}
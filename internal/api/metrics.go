@@ -0,0 +1,104 @@
+package api
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/metrics"
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+// newMetrics arma las métricas de esta instancia de la API (HTTP, pool de conexiones y
+// negocio): observe mide cada request y scrape sirve /metrics, que también incluye
+// metrics.Default (las duraciones de las consultas que mide internal/db).
+func newMetrics(db *sql.DB, cfg *config) (observe, scrape gin.HandlerFunc) {
+	reg := metrics.NewRegistry()
+	requests := reg.NewCounter("uzm_http_requests_total",
+		"Requests HTTP atendidas por método, ruta y status.", "method", "route", "status")
+	latency := reg.NewHistogram("uzm_http_request_duration_seconds",
+		"Latencia de las requests HTTP por método, ruta y status.", metrics.DefBuckets, "method", "route", "status")
+
+	// la ruta es la plantilla de gin (/api/v1/books/:id) para no abrir una serie por id
+	observe = func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		requests.Inc(c.Request.Method, route, status)
+		latency.Observe(time.Since(start).Seconds(), c.Request.Method, route, status)
+	}
+
+	pool := func(f func(sql.DBStats) float64) func() (float64, error) {
+		return func() (float64, error) { return f(db.Stats()), nil }
+	}
+	reg.NewGaugeFunc("uzm_db_connections_open", "Conexiones a SQLite abiertas (en uso + ociosas).",
+		pool(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	reg.NewGaugeFunc("uzm_db_connections_in_use", "Conexiones a SQLite en uso.",
+		pool(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	reg.NewGaugeFunc("uzm_db_connections_idle", "Conexiones a SQLite ociosas.",
+		pool(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	reg.NewGaugeFunc("uzm_db_connections_max_open", "Máximo de conexiones abiertas (0 = sin límite).",
+		pool(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	reg.NewCounterFunc("uzm_db_connections_wait_total", "Veces que se esperó por una conexión libre.",
+		pool(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	reg.NewCounterFunc("uzm_db_connections_wait_seconds_total", "Tiempo total esperando conexiones libres.",
+		pool(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+
+	// el negocio se calcula una vez por scrape (handler) y cada métrica lee de esa foto
+	var (
+		mu    sync.Mutex
+		stats service.Stats
+		err   error
+	)
+	business := func(f func(service.Stats) int64) func() (float64, error) {
+		return func() (float64, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return float64(f(stats)), err
+		}
+	}
+	logErr := func(name string, err error) { cfg.logger.Error("métrica", "name", name, "err", err) }
+	reg.NewCounterFunc("uzm_sales_total", "Ventas registradas.",
+		business(func(s service.Stats) int64 { return s.Sales })).OnError(logErr)
+	reg.NewCounterFunc("uzm_loans_opened_total", "Préstamos creados.",
+		business(func(s service.Stats) int64 { return s.LoansOpened })).OnError(logErr)
+	reg.NewCounterFunc("uzm_loans_returned_total", "Préstamos devueltos.",
+		business(func(s service.Stats) int64 { return s.LoansReturned })).OnError(logErr)
+	reg.NewGaugeFunc("uzm_loans_overdue", "Préstamos sin devolver con la fecha de devolución vencida.",
+		business(func(s service.Stats) int64 { return s.LoansOverdue })).OnError(logErr)
+	reg.NewCounterFunc("uzm_penalties_charged_usm_pesos_total", "Multas por atraso cobradas, en usm pesos.",
+		business(func(s service.Stats) int64 { return s.PenaltiesCharged })).OnError(logErr)
+	reg.NewGaugeFunc("uzm_usm_pesos_in_circulation", "Suma de los saldos de todos los usuarios, en usm pesos.",
+		business(func(s service.Stats) int64 { return s.USMPesos })).OnError(logErr)
+
+	st := store.New(db)
+	token := os.Getenv("UZM_METRICS_TOKEN")
+	scrape = func(c *gin.Context) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			abort(c, http.StatusUnauthorized, CodeUnauthorized, "requiere Authorization: Bearer <UZM_METRICS_TOKEN>")
+			return
+		}
+		mu.Lock()
+		stats, err = service.CollectStats(c.Request.Context(), st.Read(), cfg.clock.Now())
+		mu.Unlock()
+
+		c.Header("Content-Type", metrics.ContentType)
+		c.Status(http.StatusOK)
+		if err := reg.Write(c.Writer); err != nil {
+			return
+		}
+		metrics.Default.Write(c.Writer)
+	}
+	return observe, scrape
+}
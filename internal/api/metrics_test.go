@@ -0,0 +1,76 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/clock"
+)
+
+// scrape lee /metrics, que vive en la raíz y no bajo /api/v1.
+func scrape(t *testing.T, s *apitest.Server, headers ...string) apitest.Response {
+	t.Helper()
+	base := s.Base
+	s.Base = ""
+	defer func() { s.Base = base }()
+	return s.Do(http.MethodGet, "/metrics", nil, headers...)
+}
+
+func TestMetrics(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, santiago(t))
+	s := apitest.NewServer(t, api.WithClock(clock.Fixed(now)))
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	book := apitest.NewBook().Price(30).Insert(t, s.DB)
+	rental := apitest.NewBook().ForLoan().Insert(t, s.DB)
+	// uno devuelto con 10 días de atraso (20 de multa) y otro pendiente y vencido
+	late := apitest.NewLoan(user, rental).Started("01/01/2025").Insert(t, s.DB)
+	apitest.NewLoan(user, rental).Started("01/02/2025").Insert(t, s.DB)
+
+	if resp := s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": book}); resp.Status != http.StatusCreated {
+		t.Fatalf("venta: %d %s", resp.Status, resp.Body)
+	}
+	if resp := s.Do(http.MethodPatch, fmt.Sprintf("/loans/%d/return", late), map[string]any{"return_date": "11/02/2025"}); resp.Status != http.StatusOK {
+		t.Fatalf("devolución: %d %s", resp.Status, resp.Body)
+	}
+	s.Do(http.MethodGet, "/books/999999/reviews", nil)
+	s.Do(http.MethodGet, "/no-existe", nil)
+
+	resp := scrape(t, s)
+	if resp.Status != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("metrics: %d %v", resp.Status, resp.Header)
+	}
+	body := string(resp.Body)
+	for _, line := range []string{
+		`uzm_http_requests_total{method="POST",route="/api/v1/sales",status="201"} 1`,
+		`uzm_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`uzm_http_request_duration_seconds_count{method="PATCH",route="/api/v1/loans/:id/return",status="200"} 1`,
+		"uzm_sales_total 1",
+		"uzm_loans_opened_total 2",
+		"uzm_loans_returned_total 1",
+		"uzm_loans_overdue 1",
+		"uzm_penalties_charged_usm_pesos_total 20",
+		"uzm_usm_pesos_in_circulation 50", // 100 - 30 de la compra - 20 de multa
+		"uzm_db_connections_max_open 1",
+		`uzm_db_query_duration_seconds_count{op="insert"}`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("falta %q en:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsToken(t *testing.T) {
+	t.Setenv("UZM_METRICS_TOKEN", "prom")
+	s := apitest.NewServer(t)
+	if resp := scrape(t, s); resp.Status != http.StatusUnauthorized {
+		t.Errorf("sin token: %d", resp.Status)
+	}
+	if resp := scrape(t, s, "Authorization", "Bearer prom"); resp.Status != http.StatusOK {
+		t.Errorf("con token: %d", resp.Status)
+	}
+}
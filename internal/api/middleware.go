@@ -0,0 +1,99 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDKey = "request_id"
+	loggerKey    = "logger"
+)
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestID reutiliza el X-Request-ID entrante (si es razonable) o genera uno,
+// lo devuelve en la respuesta y lo deja en el contexto para los errores y los logs.
+func requestID(log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Set(requestIDKey, id)
+		c.Set(loggerKey, log.With("request_id", id))
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
+
+// logger es el logger de la request (con su request_id); slog.Default fuera de una.
+func logger(c *gin.Context) *slog.Logger {
+	if l, ok := c.Value(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// accessLog escribe una línea por request: warn para 4xx, error para 5xx. Solo la ruta,
+// sin query ni headers, para no dejar datos del cliente en el log.
+func accessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		logger(c).LogAttrs(c.Request.Context(), level, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("ip", c.ClientIP()),
+		)
+	}
+}
+
+// recovery convierte un panic en un 500 con el cuerpo de error estándar y lo deja en el log
+// con el stack (reemplaza al gin.Recovery de gin.Default, que escribe texto plano).
+func recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				logger(c).Error("panic", "route", c.FullPath(), "panic", p, "stack", string(debug.Stack()))
+				if c.Writer.Written() {
+					c.Abort()
+					return
+				}
+				abort(c, http.StatusInternalServerError, CodeInternal, "error interno, intenta nuevamente")
+			}
+		}()
+		c.Next()
+	}
+}
+
+// logBalance deja constancia de un movimiento de saldo (delta con signo) y su motivo:
+// compra, abono o multa.
+func logBalance(c *gin.Context, userID, delta int64, reason string) {
+	logger(c).Info("saldo", "user_id", userID, "delta", delta, "reason", reason)
+}
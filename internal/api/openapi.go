@@ -0,0 +1,39 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPISpec es la especificación OpenAPI 3 de todas las rutas de RegisterRoutes.
+// De ella salen el cliente de internal/client (go generate) y los tests de contrato.
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
+
+// docsPage carga Swagger UI desde un CDN apuntando a /api/v1/openapi.json.
+const docsPage = `<!doctype html>
+<html lang="es">
+<head>
+  <meta charset="utf-8">
+  <title>UZM API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({url: "/api/v1/openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+func registerOpenAPIRoutes(r gin.IRouter) {
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", OpenAPISpec)
+	})
+	r.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+	})
+}
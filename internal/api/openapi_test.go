@@ -0,0 +1,143 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+)
+
+// TestSpecCoversRoutes exige que openapi.json y RegisterRoutes declaren las mismas rutas.
+func TestSpecCoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api.RegisterRoutes(r, apitest.OpenDB(t))
+
+	// cada ruta de la especificación existe en /api/v1 y como alias en la raíz
+	v1, legacy := map[string]bool{}, map[string]bool{}
+	for _, rt := range r.Routes() {
+		parts := strings.Split(rt.Path, "/")
+		for i, p := range parts {
+			if strings.HasPrefix(p, ":") {
+				parts[i] = "{" + p[1:] + "}"
+			}
+		}
+		path := strings.Join(parts, "/")
+		if path == "/metrics" {
+			continue // para Prometheus, fuera de la API versionada
+		}
+		if strings.HasPrefix(path, api.V1Prefix+"/") {
+			v1[rt.Method+" "+strings.TrimPrefix(path, api.V1Prefix)] = true
+		} else {
+			legacy[rt.Method+" "+path] = true
+		}
+	}
+	documented := map[string]bool{}
+	for path, ops := range apitest.Spec(t).Paths {
+		for method := range ops {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	for name, registered := range map[string]map[string]bool{api.V1Prefix: v1, "raíz": legacy} {
+		var missing, extra []string
+		for route := range registered {
+			if !documented[route] {
+				missing = append(missing, route)
+			}
+		}
+		for route := range documented {
+			if !registered[route] {
+				extra = append(extra, route)
+			}
+		}
+		sort.Strings(missing)
+		sort.Strings(extra)
+		for _, route := range missing {
+			t.Errorf("%s (%s) no está en openapi.json", route, name)
+		}
+		for _, route := range extra {
+			t.Errorf("%s está en openapi.json pero no en %s", route, name)
+		}
+	}
+}
+
+func TestOpenAPIDocs(t *testing.T) {
+	s := apitest.NewServer(t)
+	resp := s.Do(http.MethodGet, "/openapi.json", nil)
+	if resp.Status != http.StatusOK || string(resp.Body) != string(api.OpenAPISpec) {
+		t.Fatalf("openapi.json: %d", resp.Status)
+	}
+	resp = s.Do(http.MethodGet, "/docs", nil)
+	if resp.Status != http.StatusOK || !strings.Contains(string(resp.Body), `url: "/api/v1/openapi.json"`) {
+		t.Fatalf("docs: %d %s", resp.Status, resp.Body)
+	}
+}
+
+// TestContractReads recorre las lecturas con datos de verdad (ventas, préstamos, reseñas,
+// deseos, promociones): apitest valida cada respuesta contra la especificación.
+func TestContractReads(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	sale := apitest.NewBook().Category("Ficción").Stock(5).Insert(t, s.DB)
+	loan := apitest.NewBook().ForLoan().Stock(2).Insert(t, s.DB)
+
+	writes := []struct {
+		method, path string
+		body         any
+	}{
+		{http.MethodPost, "/admin/promotions", map[string]any{
+			"name": "Otoño", "kind": "porcentaje", "value": 10, "starts_at": "01/01/2000", "ends_at": "31/12/2099",
+		}},
+		{http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": sale}},
+		{http.MethodPost, "/loans", map[string]any{"user_id": user, "book_id": loan}},
+		{http.MethodPost, fmt.Sprintf("/books/%d/reviews", sale), map[string]any{"user_id": user, "rating": 4, "comment": "bueno"}},
+		{http.MethodPost, fmt.Sprintf("/users/%d/wishlist", user), map[string]any{"book_id": loan}},
+		{http.MethodPatch, fmt.Sprintf("/books/%d", loan), map[string]any{"price": 5}},
+		{http.MethodPost, "/books/trending/recompute", nil},
+	}
+	for _, w := range writes {
+		if resp := s.Do(w.method, w.path, w.body, "X-Admin-Token", "secreto"); resp.Status >= 300 {
+			t.Fatalf("%s %s: %d %s", w.method, w.path, resp.Status, resp.Body)
+		}
+	}
+
+	reads := []string{
+		"/health",
+		"/users",
+		fmt.Sprintf("/users/%d", user),
+		fmt.Sprintf("/users/%d/transactions", user),
+		fmt.Sprintf("/users/%d/recommendations", user),
+		fmt.Sprintf("/users/%d/wishlist", user),
+		fmt.Sprintf("/users/%d/notifications", user),
+		"/books?sort=rating",
+		"/books/popular",
+		"/books/trending",
+		"/books/trending?fresh=1",
+		"/books/trending/categories",
+		fmt.Sprintf("/books/%d/related", sale),
+		fmt.Sprintf("/books/%d/reviews", sale),
+		"/sales",
+		"/loans",
+		"/transactions",
+		"/admin/reviews",
+		"/admin/promotions",
+		"/admin/clock",
+	}
+	for _, path := range reads {
+		if resp := s.Do(http.MethodGet, path, nil, "X-Admin-Token", "secreto"); resp.Status != http.StatusOK {
+			t.Errorf("GET %s: %d %s", path, resp.Status, resp.Body)
+		}
+	}
+	resp := s.Do(http.MethodPost, "/sales/quote", map[string]any{"user_id": user, "book_ids": []int64{sale}})
+	if resp.Status != http.StatusOK {
+		t.Errorf("quote: %d %s", resp.Status, resp.Body)
+	}
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/store"
+)
+
+// TrendingWindows son las ventanas que RecomputePopularity deja precalculadas.
+var TrendingWindows = []string{"7d", "30d"}
+
+const eventFmt = store.EventFmt
+
+// parseWindow acepta "24h", "7d" o "2w".
+func parseWindow(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("ventana inválida: %q", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("ventana inválida: %q", s)
+	}
+	switch s[len(s)-1] {
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("ventana inválida: %q", s)
+}
+
+// decayWeight: un evento pesa 1 al ocurrir y la mitad cada halfLife.
+func decayWeight(age, halfLife time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return math.Exp(-math.Ln2 * float64(age) / float64(halfLife))
+}
+
+// trendingScores suma los eventos de la ventana con decaimiento exponencial
+// (vida media = mitad de la ventana).
+func trendingScores(ctx context.Context, tx store.Tx, now time.Time, window time.Duration) (map[int64]float64, error) {
+	events, err := tx.Events().Since(ctx, now.Add(-window))
+	if err != nil {
+		return nil, err
+	}
+	halfLife := window / 2
+	scores := map[int64]float64{}
+	for _, ev := range events {
+		scores[ev.BookID] += decayWeight(now.Sub(ev.At), halfLife)
+	}
+	return scores, nil
+}
+
+// RecomputePopularity recalcula book_popularity para cada ventana de TrendingWindows.
+func RecomputePopularity(ctx context.Context, db *sql.DB, now time.Time) error {
+	st := store.New(db)
+	for _, span := range TrendingWindows {
+		window, err := parseWindow(span)
+		if err != nil {
+			return err
+		}
+		scores, err := trendingScores(ctx, st.Read(), now, window)
+		if err != nil {
+			return err
+		}
+		if err := st.InTx(ctx, func(tx store.Tx) error {
+			return tx.Popularity().Replace(ctx, span, scores, now)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trendingBooks arma el ranking de una ventana: usa lo precalculado si existe
+// (y fresh=false), si no calcula en vivo. Devuelve además cuándo se calculó.
+func trendingBooks(ctx context.Context, db *sql.DB, now time.Time, span, category string, fresh bool) ([]Book, string, error) {
+	window, err := parseWindow(span)
+	if err != nil {
+		return nil, "", err
+	}
+
+	st := store.New(db)
+	var scores map[int64]float64
+	var computed string
+	if !fresh {
+		if scores, computed, err = st.Read().Popularity().Scores(ctx, span); err != nil {
+			return nil, "", err
+		}
+	}
+	if computed == "" {
+		if scores, err = trendingScores(ctx, st.Read(), now, window); err != nil {
+			return nil, "", err
+		}
+		computed = now.UTC().Format(eventFmt)
+	}
+
+	all, err := st.Read().Books().Catalog(ctx, store.ListingFilter{Category: category})
+	if err != nil {
+		return nil, "", err
+	}
+	list := []Book{}
+	for _, l := range all {
+		s, ok := scores[l.ID]
+		if !ok || s <= 0 {
+			continue
+		}
+		b := bookOut(l)
+		b.TrendingScore = math.Round(s*1000) / 1000
+		list = append(list, b)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].TrendingScore != list[j].TrendingScore {
+			return list[i].TrendingScore > list[j].TrendingScore
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, computed, nil
+}
+
+func registerPopularityRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	// GET /books/trending?window=7d&limit=10&category=X&fresh=1
+	r.GET("/books/trending", func(c *gin.Context) {
+		span := strings.ToLower(c.DefaultQuery("window", "7d"))
+		limit := 10
+		if n, err := strconv.Atoi(c.DefaultQuery("limit", "10")); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+
+		if _, err := parseWindow(span); err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "ventana inválida, use por ejemplo 24h, 7d o 2w")
+			return
+		}
+
+		list, computed, err := trendingBooks(c.Request.Context(), db, cfg.clock.Now(), span, c.Query("category"), c.Query("fresh") == "1")
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		if len(list) > limit {
+			list = list[:limit]
+		}
+		c.JSON(http.StatusOK, gin.H{"window": span, "computed_at": computed, "books": list})
+	})
+
+	// GET /books/trending/categories?window=7d&limit=3  -> top N por categoría
+	r.GET("/books/trending/categories", func(c *gin.Context) {
+		span := strings.ToLower(c.DefaultQuery("window", "7d"))
+		limit := 3
+		if n, err := strconv.Atoi(c.DefaultQuery("limit", "3")); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+
+		if _, err := parseWindow(span); err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "ventana inválida, use por ejemplo 24h, 7d o 2w")
+			return
+		}
+
+		list, computed, err := trendingBooks(c.Request.Context(), db, cfg.clock.Now(), span, "", c.Query("fresh") == "1")
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		byCat := map[string][]Book{}
+		for _, b := range list {
+			if len(byCat[b.BookCategory]) < limit {
+				byCat[b.BookCategory] = append(byCat[b.BookCategory], b)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"window": span, "computed_at": computed, "categories": byCat})
+	})
+
+	// POST /books/trending/recompute  -> fuerza el recálculo del job (solo admin: recalcula
+	// todo el catálogo)
+	r.POST("/books/trending/recompute", requireAdmin(), func(c *gin.Context) {
+		if err := RecomputePopularity(c.Request.Context(), db, cfg.clock.Now()); err != nil {
+			fail(c, err)
+			return
+		}
+		// solo recalcula datos derivados, pero también es una escritura: queda registrada
+		if err := cfg.auditAlone(c, db, change{Action: "popularity.recompute", Entity: "popularity",
+			After: gin.H{"windows": TrendingWindows}}); err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "windows": TrendingWindows})
+	})
+}
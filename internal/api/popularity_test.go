@@ -0,0 +1,158 @@
+package api_test
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/clock"
+)
+
+type trending struct {
+	Window     string `json:"window"`
+	ComputedAt string `json:"computed_at"`
+	Books      []struct {
+		ID            int64   `json:"id"`
+		TrendingScore float64 `json:"trending_score"`
+	} `json:"books"`
+}
+
+// scores deja el ranking como libro → puntaje, en el orden en que vino.
+func (tr trending) scores() ([]int64, map[int64]float64) {
+	var order []int64
+	m := map[int64]float64{}
+	for _, b := range tr.Books {
+		order = append(order, b.ID)
+		m[b.ID] = b.TrendingScore
+	}
+	return order, m
+}
+
+func addEvent(t *testing.T, db *sql.DB, book int64, at time.Time) {
+	t.Helper()
+	if _, err := db.Exec(`INSERT INTO popularity_events(book_id,kind,created_at) VALUES(?,'Venta',?)`,
+		book, at.UTC().Format(time.RFC3339)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTrendingWindow(t *testing.T) {
+	s := apitest.NewServer(t)
+	for _, w := range []string{"0d", "7x", "d", "-1d", "abc"} {
+		for _, path := range []string{"/books/trending?window=", "/books/trending/categories?window="} {
+			resp := s.Do(http.MethodGet, path+w, nil)
+			if resp.Status != http.StatusBadRequest || resp.APIError().Code != "invalid_param" {
+				t.Errorf("%s%s: %d %s", path, w, resp.Status, resp.Body)
+			}
+		}
+	}
+	for _, w := range []string{"24h", "7d", "2w", "30D"} {
+		if resp := s.Do(http.MethodGet, "/books/trending?window="+w, nil); resp.Status != http.StatusOK {
+			t.Errorf("window=%s: %d %s", w, resp.Status, resp.Body)
+		}
+	}
+}
+
+func TestTrendingHalfLife(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	s := apitest.NewServer(t, api.WithClock(clock.Fixed(now)))
+	fresh := apitest.NewBook().Insert(t, s.DB)
+	halfway := apitest.NewBook().Insert(t, s.DB)
+	old := apitest.NewBook().Insert(t, s.DB)
+	twice := apitest.NewBook().Insert(t, s.DB)
+
+	// 2d de ventana = 1d de vida media: un evento de hace un día pesa la mitad
+	addEvent(t, s.DB, fresh, now)
+	addEvent(t, s.DB, halfway, now.Add(-24*time.Hour))
+	addEvent(t, s.DB, old, now.Add(-72*time.Hour)) // fuera de la ventana
+	addEvent(t, s.DB, twice, now.Add(-12*time.Hour))
+	addEvent(t, s.DB, twice, now.Add(-12*time.Hour))
+
+	for _, tt := range []struct {
+		window string
+		want   map[int64]float64
+		order  []int64
+	}{
+		{"2d", map[int64]float64{twice: 2 * math.Sqrt(0.5), fresh: 1, halfway: 0.5}, []int64{twice, fresh, halfway}},
+		{"48h", map[int64]float64{twice: 2 * math.Sqrt(0.5), fresh: 1, halfway: 0.5}, []int64{twice, fresh, halfway}},
+		{"6d", map[int64]float64{twice: 2 * math.Pow(0.5, 1.0/6), fresh: 1, halfway: math.Pow(0.5, 1.0/3), old: 0.5},
+			[]int64{twice, fresh, halfway, old}},
+	} {
+		var tr trending
+		s.Do(http.MethodGet, "/books/trending?fresh=1&window="+tt.window, nil).Decode(t, &tr)
+		order, got := tr.scores()
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: ranking = %v", tt.window, got)
+			continue
+		}
+		for id, w := range tt.want {
+			if math.Abs(got[id]-w) > 0.001 {
+				t.Errorf("%s: libro %d = %v, want %.3f", tt.window, id, got[id], w)
+			}
+		}
+		for i := range order {
+			if order[i] != tt.order[i] {
+				t.Errorf("%s: orden = %v, want %v", tt.window, order, tt.order)
+				break
+			}
+		}
+	}
+}
+
+func TestTrendingPrecomputed(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	start := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewTravel(clock.Fixed(start))
+	s := apitest.NewServer(t, api.WithClock(clk))
+	a := apitest.NewBook().Insert(t, s.DB)
+	b := apitest.NewBook().Insert(t, s.DB)
+	addEvent(t, s.DB, a, start.Add(-time.Hour))
+
+	// sin precalcular, calcula en vivo
+	var tr trending
+	s.Do(http.MethodGet, "/books/trending", nil).Decode(t, &tr)
+	if ids, _ := tr.scores(); len(ids) != 1 || ids[0] != a || tr.ComputedAt != "2025-03-10T12:00:00Z" {
+		t.Errorf("en vivo = %+v", tr)
+	}
+
+	// recalcular es solo de admin
+	if resp := s.Do(http.MethodPost, "/books/trending/recompute", nil); resp.Status != http.StatusForbidden {
+		t.Fatalf("recompute sin token: %d %s", resp.Status, resp.Body)
+	}
+	if resp := s.Do(http.MethodPost, "/books/trending/recompute", nil, "X-Admin-Token", "secreto"); resp.Status != http.StatusOK {
+		t.Fatalf("recompute: %d %s", resp.Status, resp.Body)
+	}
+	if n := s.QueryInt(`SELECT COUNT(*) FROM book_popularity`); n != 2 {
+		t.Errorf("book_popularity = %d filas, want 2 (7d y 30d)", n)
+	}
+
+	// un evento nuevo no aparece en lo precalculado hasta el próximo recálculo, sí con fresh=1
+	clk.Advance(2 * time.Hour)
+	addEvent(t, s.DB, b, start.Add(time.Hour))
+	addEvent(t, s.DB, b, start.Add(time.Hour))
+	tr = trending{}
+	s.Do(http.MethodGet, "/books/trending", nil).Decode(t, &tr)
+	if ids, _ := tr.scores(); len(ids) != 1 || ids[0] != a || tr.ComputedAt != "2025-03-10T12:00:00Z" {
+		t.Errorf("precalculado = %+v", tr)
+	}
+	tr = trending{}
+	s.Do(http.MethodGet, "/books/trending?fresh=1", nil).Decode(t, &tr)
+	if ids, _ := tr.scores(); len(ids) != 2 || ids[0] != b || tr.ComputedAt != "2025-03-10T14:00:00Z" {
+		t.Errorf("fresh = %+v", tr)
+	}
+
+	// el job hace lo mismo que el endpoint
+	if err := api.RecomputePopularity(context.Background(), s.DB, clk.Now()); err != nil {
+		t.Fatal(err)
+	}
+	tr = trending{}
+	s.Do(http.MethodGet, "/books/trending?window=30d", nil).Decode(t, &tr)
+	if ids, _ := tr.scores(); len(ids) != 2 || ids[0] != b || tr.ComputedAt != "2025-03-10T14:00:00Z" {
+		t.Errorf("tras el job = %+v", tr)
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+// errEndsBeforeStart corta la transacción del PATCH cuando el nuevo ends_at no cumple.
+var errEndsBeforeStart = errors.New("ends_at anterior a starts_at")
+
+func registerPromotionRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	st := store.New(db)
+
+	type cartReq struct {
+		UserID  int64   `json:"user_id" binding:"required,gt=0"`
+		BookIDs []int64 `json:"book_ids" binding:"required,min=1,dive,gt=0"`
+		Code    string  `json:"code" binding:"max=40"`
+	}
+
+	// POST /sales/quote {user_id, book_ids, code?}  -> precio con descuentos, sin comprar
+	r.POST("/sales/quote", func(c *gin.Context) {
+		var in cartReq
+		if !bindJSON(c, &in) {
+			return
+		}
+		var q service.Quote
+		err := st.InTx(c.Request.Context(), func(tx store.Tx) (err error) {
+			q, _, err = service.Checkout(c.Request.Context(), tx, in.UserID, in.BookIDs, in.Code, true, cfg.clock.Now())
+			return err
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, q)
+	})
+
+	// POST /sales/checkout {user_id, book_ids, code?}  -> compra todo el carro en una transacción
+	r.POST("/sales/checkout", cfg.idempotent, func(c *gin.Context) {
+		var in cartReq
+		if !bindJSON(c, &in) {
+			return
+		}
+		var q service.Quote
+		var sales []store.Sale
+		err := st.InTx(c.Request.Context(), func(tx store.Tx) (err error) {
+			balance, err := tx.Users().Balance(c.Request.Context(), in.UserID)
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				return err
+			}
+			q, sales, err = service.Checkout(c.Request.Context(), tx, in.UserID, in.BookIDs, in.Code, false, cfg.clock.Now())
+			if err != nil {
+				return err
+			}
+			if err := cfg.audit(c, tx, change{Action: "sale.checkout", Entity: "user", EntityID: in.UserID, UserID: in.UserID,
+				Before: gin.H{"usm_pesos": balance},
+				After:  gin.H{"usm_pesos": balance - q.Total, "sales": sales, "code": in.Code}}); err != nil {
+				return err
+			}
+			return keepResponse(c, tx, http.StatusCreated, gin.H{"sales": sales, "quote": q})
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		for _, s := range sales {
+			logger(c).Info("venta", "sale_id", s.ID, "user_id", s.UserID, "book_id", s.BookID,
+				"price", s.Price, "discount", s.Discount)
+		}
+		logBalance(c, in.UserID, -q.Total, "compra")
+		c.JSON(http.StatusCreated, gin.H{"sales": sales, "quote": q})
+	})
+
+	// ---- administración de promociones (X-Admin-Token) ----
+	admin := r.Group("/admin", requireAdmin())
+
+	// POST /admin/promotions
+	admin.POST("/promotions", func(c *gin.Context) {
+		var p store.Promotion
+		p.Active = true
+		if !bindJSON(c, &p) {
+			return
+		}
+		p.Code = strings.ToUpper(strings.TrimSpace(p.Code))
+		err := st.InTx(c.Request.Context(), func(tx store.Tx) error {
+			if err := tx.Promotions().Create(c.Request.Context(), &p); err != nil {
+				return err
+			}
+			return cfg.audit(c, tx, change{Action: "promotion.create", Entity: "promotion", EntityID: p.ID, After: p})
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				abort(c, http.StatusConflict, CodeConflict, "ya existe una promoción con ese código")
+				return
+			}
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, p)
+	})
+
+	// GET /admin/promotions
+	admin.GET("/promotions", func(c *gin.Context) {
+		out, err := st.Read().Promotions().List(c.Request.Context())
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"promotions": out})
+	})
+
+	// PATCH /admin/promotions/:id {active?, ends_at?, max_uses?}
+	admin.PATCH("/promotions/:id", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		var in struct {
+			Active  *bool   `json:"active"`
+			EndsAt  *string `json:"ends_at" binding:"omitnil,fecha"`
+			MaxUses *int64  `json:"max_uses" binding:"omitnil,gte=0"`
+		}
+		if !bindJSON(c, &in) {
+			return
+		}
+		var p store.Promotion
+		err = st.InTx(c.Request.Context(), func(tx store.Tx) error {
+			promos := tx.Promotions()
+			old, err := promos.Get(c.Request.Context(), id)
+			if err != nil {
+				return err
+			}
+			// la regla de promotionRules, contra el starts_at guardado
+			if in.EndsAt != nil && endsBeforeStart(old.StartsAt, *in.EndsAt) {
+				return errEndsBeforeStart
+			}
+			if _, err := promos.Update(c.Request.Context(), id, in.Active, in.EndsAt, in.MaxUses); err != nil {
+				return err
+			}
+			if p, err = promos.Get(c.Request.Context(), id); err != nil {
+				return err
+			}
+			return cfg.audit(c, tx, change{Action: "promotion.update", Entity: "promotion", EntityID: id, Before: old, After: p})
+		})
+		if errors.Is(err, store.ErrNotFound) {
+			abort(c, http.StatusNotFound, CodeNotFound, "promoción no existe")
+			return
+		}
+		if errors.Is(err, errEndsBeforeStart) {
+			abortField(c, "ends_at", "despues_de_inicio", msgBeforeStart)
+			return
+		}
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+}
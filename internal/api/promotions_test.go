@@ -0,0 +1,101 @@
+package api_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/apitest"
+)
+
+func TestPromotionsAtCheckout(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	novel := apitest.NewBook().Category("Novela").Price(40).Insert(t, s.DB)
+	essay := apitest.NewBook().Category("Ensayo").Price(20).Stock(2).Insert(t, s.DB)
+
+	today := time.Now()
+	promo := map[string]any{
+		"name": "Novelas 25%", "kind": "porcentaje", "value": 25, "category": "Novela",
+		"starts_at": today.AddDate(0, 0, -1).Format("02/01/2006"), "ends_at": today.AddDate(0, 0, 1).Format("02/01/2006"),
+	}
+	if resp := s.Do(http.MethodPost, "/admin/promotions", promo); resp.Status != http.StatusForbidden {
+		t.Fatalf("sin token: %d", resp.Status)
+	}
+	if resp := s.Do(http.MethodPost, "/admin/promotions", promo, "X-Admin-Token", "secreto"); resp.Status != http.StatusCreated {
+		t.Fatalf("crear: %d %s", resp.Status, resp.Body)
+	}
+	code := map[string]any{
+		"name": "Cinco", "code": "cinco", "kind": "monto", "value": 5, "max_uses": 1,
+		"starts_at": promo["starts_at"], "ends_at": promo["ends_at"],
+	}
+	if resp := s.Do(http.MethodPost, "/admin/promotions", code, "X-Admin-Token", "secreto"); resp.Status != http.StatusCreated {
+		t.Fatalf("crear código: %d %s", resp.Status, resp.Body)
+	}
+
+	cart := map[string]any{"user_id": user, "book_ids": []int64{novel, essay}, "code": "CINCO"}
+	var q struct {
+		Subtotal int64 `json:"subtotal"`
+		Discount int64 `json:"discount"`
+		Total    int64 `json:"total"`
+	}
+	s.Do(http.MethodPost, "/sales/quote", cart).Decode(t, &q)
+	// 25% de 40 = 10, y el código resta 5 más sobre lo que queda
+	if q.Subtotal != 60 || q.Discount != 15 || q.Total != 45 {
+		t.Fatalf("quote = %+v", q)
+	}
+
+	if resp := s.Do(http.MethodPost, "/sales/checkout", cart); resp.Status != http.StatusCreated {
+		t.Fatalf("checkout: %d %s", resp.Status, resp.Body)
+	}
+	if got := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); got != 55 {
+		t.Errorf("saldo = %d, want 55", got)
+	}
+	if got := s.QueryInt(`SELECT SUM(discount) FROM sales`); got != 15 {
+		t.Errorf("descuento guardado = %d, want 15", got)
+	}
+
+	// el código tenía un solo uso
+	resp := s.Do(http.MethodPost, "/sales/checkout", map[string]any{"user_id": user, "book_ids": []int64{essay}, "code": "CINCO"})
+	if resp.Status != http.StatusBadRequest || resp.APIError().Code != "invalid_promotion" {
+		t.Errorf("código agotado: %d %s", resp.Status, resp.Body)
+	}
+}
+
+func TestPromotionEndsBeforeStart(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	s := apitest.NewServer(t)
+	promo := map[string]any{"name": "Marzo", "kind": "monto", "value": 5, "starts_at": "10/03/2025", "ends_at": "09/03/2025"}
+
+	// la misma regla al crear y al editar: 422 sobre ends_at
+	check := func(name string, resp apitest.Response) {
+		t.Helper()
+		e := resp.APIError()
+		if resp.Status != http.StatusUnprocessableEntity || e.Code != "validation_failed" {
+			t.Fatalf("%s: %d %s", name, resp.Status, resp.Body)
+		}
+		errs := e.Details["errors"].([]any)
+		if f := errs[0].(map[string]any); len(errs) != 1 || f["field"] != "ends_at" || f["rule"] != "despues_de_inicio" {
+			t.Errorf("%s: errores = %v", name, errs)
+		}
+	}
+	check("crear", s.Do(http.MethodPost, "/admin/promotions", promo, "X-Admin-Token", "secreto"))
+
+	promo["ends_at"] = "31/03/2025"
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	s.Do(http.MethodPost, "/admin/promotions", promo, "X-Admin-Token", "secreto").Decode(t, &created)
+	path := "/admin/promotions/" + strconv.FormatInt(created.ID, 10)
+	check("editar", s.Do(http.MethodPatch, path, map[string]any{"ends_at": "01/03/2025"}, "X-Admin-Token", "secreto"))
+	var endsAt string
+	if err := s.DB.QueryRow(`SELECT ends_at FROM promotions WHERE id=?`, created.ID).Scan(&endsAt); err != nil || endsAt != "31/03/2025" {
+		t.Errorf("ends_at = %q (%v)", endsAt, err)
+	}
+	// el mismo día de inicio sí vale
+	if resp := s.Do(http.MethodPatch, path, map[string]any{"ends_at": "10/03/2025"}, "X-Admin-Token", "secreto"); resp.Status != http.StatusOK {
+		t.Errorf("editar al inicio: %d %s", resp.Status, resp.Body)
+	}
+}
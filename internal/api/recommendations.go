@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+// Recommendation es un libro sugerido con su puntaje y el motivo:
+// "similar" (co-ocurrencia en historiales), "categoria" o "popular" (cold-start).
+type Recommendation struct {
+	Book   Book    `json:"book"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// history guarda qué libros tocó (compra o arriendo) cada usuario, y su inverso.
+type history struct {
+	byUser map[int64]map[int64]bool
+	byBook map[int64]map[int64]bool
+}
+
+func loadHistory(ctx context.Context, db *sql.DB) (*history, error) {
+	touches, err := store.New(db).Read().History().Touches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h := &history{byUser: map[int64]map[int64]bool{}, byBook: map[int64]map[int64]bool{}}
+	for _, t := range touches {
+		if h.byUser[t.UserID] == nil {
+			h.byUser[t.UserID] = map[int64]bool{}
+		}
+		if h.byBook[t.BookID] == nil {
+			h.byBook[t.BookID] = map[int64]bool{}
+		}
+		h.byUser[t.UserID][t.BookID] = true
+		h.byBook[t.BookID][t.UserID] = true
+	}
+	return h, nil
+}
+
+// similar devuelve la similitud coseno entre bookID y cada libro que co-ocurre con él.
+func (h *history) similar(bookID int64) map[int64]float64 {
+	co := map[int64]int{}
+	for u := range h.byBook[bookID] {
+		for other := range h.byUser[u] {
+			if other != bookID {
+				co[other]++
+			}
+		}
+	}
+	out := make(map[int64]float64, len(co))
+	ni := float64(len(h.byBook[bookID]))
+	for j, n := range co {
+		out[j] = float64(n) / math.Sqrt(ni*float64(len(h.byBook[j])))
+	}
+	return out
+}
+
+// catalog carga todos los libros (con stock o no) indexados por id.
+func catalog(ctx context.Context, db *sql.DB) (map[int64]Book, error) {
+	list, err := store.New(db).Read().Books().Catalog(ctx, store.ListingFilter{})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int64]Book, len(list))
+	for _, l := range list {
+		out[l.ID] = bookOut(l)
+	}
+	return out, nil
+}
+
+// rank ordena los candidatos por puntaje y completa hasta limit con los más
+// populares de las categorías preferidas y luego del catálogo completo.
+// Solo se sugieren libros con stock que no estén en exclude.
+func rank(books map[int64]Book, scores map[int64]float64, categories map[string]bool, exclude map[int64]bool, limit int) []Recommendation {
+	var out []Recommendation
+	picked := map[int64]bool{}
+	add := func(list []Recommendation) {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Score != list[j].Score {
+				return list[i].Score > list[j].Score
+			}
+			return list[i].Book.ID < list[j].Book.ID
+		})
+		for _, r := range list {
+			if len(out) >= limit {
+				return
+			}
+			out = append(out, r)
+			picked[r.Book.ID] = true
+		}
+	}
+	eligible := func(b Book) bool {
+		return b.Inventory.AvailableQuantity > 0 && !exclude[b.ID] && !picked[b.ID]
+	}
+
+	var similar []Recommendation
+	for id, s := range scores {
+		if b, ok := books[id]; ok && eligible(b) {
+			similar = append(similar, Recommendation{Book: b, Score: math.Round(s*1000) / 1000, Reason: "similar"})
+		}
+	}
+	add(similar)
+
+	var byCategory, popular []Recommendation
+	for _, b := range books {
+		if !eligible(b) {
+			continue
+		}
+		r := Recommendation{Book: b, Score: float64(b.PopularityScore)}
+		if categories[b.BookCategory] {
+			r.Reason = "categoria"
+			byCategory = append(byCategory, r)
+		} else {
+			r.Reason = "popular"
+			popular = append(popular, r)
+		}
+	}
+	add(byCategory)
+	add(popular)
+	if out == nil {
+		out = []Recommendation{}
+	}
+	return out
+}
+
+func registerRecommendationRoutes(r gin.IRouter, db *sql.DB) {
+	st := store.New(db)
+
+	// GET /users/:id/recommendations?limit=5
+	r.GET("/users/:id/recommendations", func(c *gin.Context) {
+		userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		limit := 5
+		if n, err := strconv.Atoi(c.DefaultQuery("limit", "5")); err == nil && n > 0 && n <= 50 {
+			limit = n
+		}
+
+		// se excluye lo comprado y lo que tiene en préstamo; lo ya devuelto sí puede sugerirse
+		exclude, err := service.Holding(c.Request.Context(), st.Read(), userID)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+
+		h, err := loadHistory(c.Request.Context(), db)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		books, err := catalog(c.Request.Context(), db)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+
+		scores := map[int64]float64{}
+		categories := map[string]bool{}
+		for bookID := range h.byUser[userID] {
+			categories[books[bookID].BookCategory] = true
+			for j, s := range h.similar(bookID) {
+				scores[j] += s
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"recommendations": rank(books, scores, categories, exclude, limit)})
+	})
+
+	// GET /books/:id/related?limit=5
+	r.GET("/books/:id/related", func(c *gin.Context) {
+		bookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		limit := 5
+		if n, err := strconv.Atoi(c.DefaultQuery("limit", "5")); err == nil && n > 0 && n <= 50 {
+			limit = n
+		}
+
+		books, err := catalog(c.Request.Context(), db)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		book, ok := books[bookID]
+		if !ok {
+			abort(c, http.StatusNotFound, CodeNotFound, "libro no existe")
+			return
+		}
+		h, err := loadHistory(c.Request.Context(), db)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+
+		related := rank(books, h.similar(bookID), map[string]bool{book.BookCategory: true}, map[int64]bool{bookID: true}, limit)
+		c.JSON(http.StatusOK, gin.H{"recommendations": related})
+	})
+}
@@ -0,0 +1,147 @@
+package api_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+
+	"tarea1-uzm/internal/apitest"
+)
+
+type recommendation struct {
+	Book struct {
+		ID int64 `json:"id"`
+	} `json:"book"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+func recommendations(t *testing.T, s *apitest.Server, path string) []recommendation {
+	t.Helper()
+	resp := s.Do(http.MethodGet, path, nil)
+	if resp.Status != http.StatusOK {
+		t.Fatalf("%s: %d %s", path, resp.Status, resp.Body)
+	}
+	var out struct {
+		Recommendations []recommendation `json:"recommendations"`
+	}
+	resp.Decode(t, &out)
+	return out.Recommendations
+}
+
+func sold(t *testing.T, db *sql.DB, user int64, books ...int64) {
+	t.Helper()
+	for _, b := range books {
+		if _, err := db.Exec(`INSERT INTO sales(user_id,book_id,sale_date,price) VALUES(?,?,'01/03/2025',10)`, user, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func popularity(t *testing.T, db *sql.DB, book, score int64) {
+	t.Helper()
+	if _, err := db.Exec(`UPDATE books SET popularity_score=? WHERE id=?`, score, book); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wantRec es una sugerencia esperada: libro, motivo y puntaje.
+type wantRec struct {
+	id     int64
+	reason string
+	score  float64
+}
+
+func checkRecs(t *testing.T, name string, got []recommendation, want []wantRec) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: %d sugerencias, want %d: %+v", name, len(got), len(want), got)
+		return
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Book.ID != w.id || g.Reason != w.reason || math.Abs(g.Score-w.score) > 0.001 {
+			t.Errorf("%s[%d] = libro %d %s %.3f, want libro %d %s %.3f", name, i, g.Book.ID, g.Reason, g.Score, w.id, w.reason, w.score)
+		}
+	}
+}
+
+func TestRecommendations(t *testing.T) {
+	s := apitest.NewServer(t)
+	novel := apitest.NewBook().Category("Novela").Stock(5)
+	a := novel.Insert(t, s.DB)
+	b := novel.Insert(t, s.DB)
+	soldOut := apitest.NewBook().Category("Novela").Stock(0).Insert(t, s.DB)
+	g := novel.Insert(t, s.DB)
+	c := apitest.NewBook().Category("Ciencia").Stock(5).Insert(t, s.DB)
+	d := apitest.NewBook().Category("Ciencia").Stock(5).Insert(t, s.DB)
+	f := apitest.NewBook().Category("Poesía").Stock(5).Insert(t, s.DB)
+	borrowed := apitest.NewBook().Category("Infantil").ForLoan().Insert(t, s.DB)
+	kids := apitest.NewBook().Category("Infantil").Stock(5).Insert(t, s.DB)
+	returned := apitest.NewBook().Category("Poesía").ForLoan().Insert(t, s.DB)
+	popularity(t, s.DB, d, 9)
+	popularity(t, s.DB, f, 5)
+	popularity(t, s.DB, g, 2)
+	popularity(t, s.DB, kids, 1)
+
+	u1 := apitest.NewUser().Insert(t, s.DB)
+	u2 := apitest.NewUser().Insert(t, s.DB)
+	u3 := apitest.NewUser().Insert(t, s.DB)
+	me := apitest.NewUser().Insert(t, s.DB)
+	sold(t, s.DB, u1, a, b)
+	sold(t, s.DB, u2, a, c)
+	sold(t, s.DB, u3, a, b, soldOut)
+	sold(t, s.DB, me, a)
+	apitest.NewLoan(me, borrowed).Insert(t, s.DB)
+	apitest.NewLoan(me, returned).Returned("15/01/2025").Insert(t, s.DB)
+
+	// a lo tienen 4 usuarios; b 2 de ellos y c 1: coseno 2/√(4·2) y 1/√(4·1). returned ya
+	// se devolvió, así que se puede sugerir: co-ocurre con a (0.5) y con borrowed (1). soldOut
+	// co-ocurre pero no tiene stock. Luego las categorías de su historial (Novela, Infantil,
+	// Poesía) por popularidad, y el resto del catálogo
+	path := fmt.Sprintf("/users/%d/recommendations?limit=10", me)
+	checkRecs(t, "usuario", recommendations(t, s, path), []wantRec{
+		{returned, "similar", 1.5},
+		{b, "similar", 2 / math.Sqrt(8)},
+		{c, "similar", 0.5},
+		{f, "categoria", 5},
+		{g, "categoria", 2},
+		{kids, "categoria", 1},
+		{d, "popular", 9},
+	})
+	// lo comprado (a) y lo que tiene en préstamo (borrowed) nunca aparece
+	for _, r := range recommendations(t, s, path) {
+		if r.Book.ID == a || r.Book.ID == borrowed || r.Book.ID == soldOut {
+			t.Errorf("sugiere el libro %d", r.Book.ID)
+		}
+	}
+	if got := recommendations(t, s, fmt.Sprintf("/users/%d/recommendations?limit=2", me)); len(got) != 2 {
+		t.Errorf("limit=2: %+v", got)
+	}
+
+	// cold start: sin historial, los más populares del catálogo con stock
+	newcomer := apitest.NewUser().Insert(t, s.DB)
+	checkRecs(t, "cold start", recommendations(t, s, fmt.Sprintf("/users/%d/recommendations?limit=3", newcomer)), []wantRec{
+		{d, "popular", 9},
+		{f, "popular", 5},
+		{g, "popular", 2},
+	})
+
+	// relacionados: co-ocurrencia con el libro (empates por id) y luego su categoría
+	checkRecs(t, "relacionados", recommendations(t, s, fmt.Sprintf("/books/%d/related?limit=6", a)), []wantRec{
+		{b, "similar", 2 / math.Sqrt(8)},
+		{c, "similar", 0.5},
+		{borrowed, "similar", 0.5},
+		{returned, "similar", 0.5},
+		{g, "categoria", 2},
+		{d, "popular", 9},
+	})
+
+	for _, path := range []string{"/users/999/recommendations", "/books/999/related"} {
+		if resp := s.Do(http.MethodGet, path, nil); resp.Status != http.StatusNotFound {
+			t.Errorf("%s: %d", path, resp.Status)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/notify"
+	"tarea1-uzm/internal/store"
+)
+
+// reminderChannels dice, por canal de notify.Channels, si el usuario recibe recordatorios.
+func reminderChannels(optOut map[string]bool) gin.H {
+	out := gin.H{}
+	for _, ch := range notify.Channels {
+		out[ch] = !optOut[ch]
+	}
+	return out
+}
+
+func registerReminderRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	st := store.New(db)
+
+	// user lee el :id de la ruta y responde 404 si el usuario no existe.
+	user := func(c *gin.Context) (int64, bool) {
+		userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return 0, false
+		}
+		_, err = st.Read().Users().Get(c.Request.Context(), userID)
+		if errors.Is(err, store.ErrNotFound) {
+			abort(c, http.StatusNotFound, CodeNotFound, "usuario no existe")
+			return 0, false
+		}
+		if err != nil {
+			fail(c, err)
+			return 0, false
+		}
+		return userID, true
+	}
+
+	// GET /users/:id/reminders  -> {email, webhook, inbox}: true = recibe recordatorios
+	r.GET("/users/:id/reminders", func(c *gin.Context) {
+		userID, ok := user(c)
+		if !ok {
+			return
+		}
+		optOut, err := st.Read().Reminders().OptedOut(c.Request.Context(), userID)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "channels": reminderChannels(optOut)})
+	})
+
+	// PUT /users/:id/reminders {email?, webhook?, inbox?}  -> false apaga el canal
+	r.PUT("/users/:id/reminders", func(c *gin.Context) {
+		userID, ok := user(c)
+		if !ok {
+			return
+		}
+		var in struct {
+			Email   *bool `json:"email"`
+			Webhook *bool `json:"webhook"`
+			Inbox   *bool `json:"inbox"`
+		}
+		if !bindJSON(c, &in) {
+			return
+		}
+		want := map[string]*bool{"email": in.Email, "webhook": in.Webhook, "inbox": in.Inbox}
+
+		var optOut map[string]bool
+		err := cfg.writeAudited(c, db, func(tx *sql.Tx) (change, error) {
+			reminders := store.Bind(tx).Reminders()
+			var err error
+			if optOut, err = reminders.OptedOut(c.Request.Context(), userID); err != nil {
+				return change{}, err
+			}
+			before, after := gin.H{}, gin.H{}
+			for _, ch := range notify.Channels {
+				on := want[ch]
+				if on == nil || *on == !optOut[ch] {
+					continue
+				}
+				if err := reminders.SetOptOut(c.Request.Context(), userID, ch, !*on); err != nil {
+					return change{}, err
+				}
+				before[ch], after[ch] = !*on, *on
+				optOut[ch] = !*on
+			}
+			if len(after) == 0 {
+				return change{}, nil
+			}
+			return change{Action: "reminders.update", Entity: "user", EntityID: userID, UserID: userID,
+				Before: before, After: after}, nil
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "channels": reminderChannels(optOut)})
+	})
+}
@@ -0,0 +1,114 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/clock"
+	"tarea1-uzm/internal/notify"
+)
+
+// mailbox hace de canal "email": guarda lo enviado o falla si down.
+type mailbox struct {
+	sent []notify.Message
+	down bool
+}
+
+func (*mailbox) Name() string { return "email" }
+
+func (m *mailbox) Send(_ context.Context, msg notify.Message) error {
+	if m.down {
+		return errors.New("smtp caído")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestLoanReminders(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	clk := clock.NewTravel(clock.Fixed(time.Date(2025, 3, 10, 12, 0, 0, 0, santiago(t))))
+	mail := &mailbox{}
+	s := apitest.NewServer(t, api.WithClock(clk), api.WithNotifiers(mail))
+	ana := apitest.NewUser().Email("ana@usm.cl").Insert(t, s.DB)
+	beto := apitest.NewUser().Email("beto@usm.cl").Insert(t, s.DB)
+	book := apitest.NewBook().Name("El Principito").ForLoan().Stock(0).Insert(t, s.DB)
+	soon := apitest.NewLoan(ana, book).Started("13/02/2025").Insert(t, s.DB)  // vence el 13/03: en 3 días
+	today := apitest.NewLoan(ana, book).Started("10/02/2025").Insert(t, s.DB) // vence hoy
+	apitest.NewLoan(beto, book).Started("01/02/2025").Insert(t, s.DB)         // vencido hace 9 días
+	apitest.NewLoan(ana, book).Started("01/03/2025").Insert(t, s.DB)          // vence en abril
+	apitest.NewLoan(beto, book).Started("01/01/2025").Returned("05/01/2025").Insert(t, s.DB)
+
+	// beto no quiere correos
+	var prefs struct {
+		Channels map[string]bool `json:"channels"`
+	}
+	resp := s.Do(http.MethodPut, fmt.Sprintf("/users/%d/reminders", beto), map[string]any{"email": false})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("PUT reminders: %d %s", resp.Status, resp.Body)
+	}
+	s.Do(http.MethodGet, fmt.Sprintf("/users/%d/reminders", beto), nil).Decode(t, &prefs)
+	if prefs.Channels["email"] || !prefs.Channels["inbox"] || !prefs.Channels["webhook"] {
+		t.Errorf("canales de beto = %v", prefs.Channels)
+	}
+	if resp := s.Do(http.MethodGet, "/users/99/reminders", nil); resp.Status != http.StatusNotFound {
+		t.Errorf("usuario inexistente: %d", resp.Status)
+	}
+
+	// ana: 2 préstamos × (bandeja + correo); beto: el vencido, solo a la bandeja
+	if got := runJob(t, s, "loans.reminders"); got != "5 recordatorios enviados" {
+		t.Errorf("loans.reminders = %q", got)
+	}
+	var subjects []string
+	for _, m := range mail.sent {
+		subjects = append(subjects, fmt.Sprintf("%s %d %s", m.Email, m.LoanID, m.Subject))
+	}
+	want := fmt.Sprintf("ana@usm.cl %d Tu préstamo de «El Principito» vence en 3 días\nana@usm.cl %d Hoy vence tu préstamo de «El Principito»", soon, today)
+	if got := strings.Join(subjects, "\n"); got != want {
+		t.Errorf("correos =\n%s\nwant\n%s", got, want)
+	}
+	var inbox struct {
+		Notifications []struct {
+			Kind    string `json:"kind"`
+			Message string `json:"message"`
+		} `json:"notifications"`
+	}
+	s.Do(http.MethodGet, fmt.Sprintf("/users/%d/notifications", beto), nil).Decode(t, &inbox)
+	if len(inbox.Notifications) != 1 || inbox.Notifications[0].Kind != "vencido" ||
+		inbox.Notifications[0].Message != "Tu préstamo de «El Principito» está vencido" {
+		t.Errorf("bandeja de beto = %+v", inbox.Notifications)
+	}
+	// cada recordatorio sale una vez
+	if got := runJob(t, s, "loans.reminders"); got != "0 recordatorios enviados" {
+		t.Errorf("segunda pasada = %q", got)
+	}
+
+	// tres días después: el primero vence hoy y el segundo ya venció. Si el correo falla, la
+	// bandeja igual recibe y el correo se reintenta en la pasada siguiente.
+	clk.Advance(3 * 24 * time.Hour)
+	mail.down = true
+	var run struct {
+		Status string `json:"status"`
+		Detail string `json:"detail"`
+		Error  string `json:"error"`
+	}
+	s.Do(http.MethodPost, "/admin/jobs/loans.reminders/run", nil, "X-Admin-Token", "secreto").Decode(t, &run)
+	if run.Status != "error" || run.Detail != "2 recordatorios enviados" || !strings.Contains(run.Error, "smtp caído") {
+		t.Errorf("con el correo caído: %+v", run)
+	}
+	mail.down = false
+	mail.sent = nil
+	if got := runJob(t, s, "loans.reminders"); got != "2 recordatorios enviados" || len(mail.sent) != 2 ||
+		mail.sent[0].Kind != "vence_hoy" || mail.sent[1].Kind != "vencido" {
+		t.Errorf("reintento = %q %+v", got, mail.sent)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM audit_log WHERE action='reminders.update'`); got != 1 {
+		t.Errorf("reminders.update en audit_log = %d, want 1", got)
+	}
+}
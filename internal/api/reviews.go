@@ -0,0 +1,205 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+func registerReviewRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	st := store.New(db)
+
+	// POST /books/:id/reviews {user_id, rating, comment}  -> solo quien compró o arrendó el libro
+	r.POST("/books/:id/reviews", func(c *gin.Context) {
+		bookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		var in struct {
+			UserID  int64  `json:"user_id" binding:"required,gt=0"`
+			Rating  int64  `json:"rating" binding:"required,min=1,max=5"`
+			Comment string `json:"comment" binding:"max=2000"`
+		}
+		if !bindJSON(c, &in) {
+			return
+		}
+
+		var rv store.Review
+		err = st.InTx(c.Request.Context(), func(tx store.Tx) (err error) {
+			rv, err = service.CreateReview(c.Request.Context(), tx,
+				store.Review{BookID: bookID, UserID: in.UserID, Rating: in.Rating, Comment: in.Comment}, cfg.clock.Now())
+			if err != nil {
+				return err
+			}
+			return cfg.audit(c, tx, change{Action: "review.create", Entity: "review", EntityID: rv.ID, UserID: in.UserID,
+				After: gin.H{"book_id": bookID, "rating": rv.Rating, "comment": rv.Comment}})
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				abort(c, http.StatusConflict, CodeConflict, "ya reseñaste este libro; edítala con PATCH")
+				return
+			}
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, rv)
+	})
+
+	// GET /books/:id/reviews  -> reseñas visibles (el promedio viene en el Book)
+	r.GET("/books/:id/reviews", func(c *gin.Context) {
+		bookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		list, err := st.Read().Reviews().List(c.Request.Context(), store.ReviewFilter{BookID: bookID, Status: "visible"})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"reviews": list})
+	})
+
+	// PATCH /books/:id/reviews/:review_id {user_id, rating?, comment?}  -> solo el autor
+	r.PATCH("/books/:id/reviews/:review_id", func(c *gin.Context) {
+		bookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		reviewID, err := strconv.ParseInt(c.Param("review_id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		var in struct {
+			UserID  int64   `json:"user_id" binding:"required,gt=0"`
+			Rating  *int64  `json:"rating" binding:"omitnil,min=1,max=5"`
+			Comment *string `json:"comment" binding:"omitnil,max=2000"`
+		}
+		if !bindJSON(c, &in) {
+			return
+		}
+
+		var rv store.Review
+		err = st.InTx(c.Request.Context(), func(tx store.Tx) error {
+			after, before, err := service.UpdateReview(c.Request.Context(), tx, bookID, reviewID, in.UserID,
+				service.ReviewChanges{Rating: in.Rating, Comment: in.Comment}, cfg.clock.Now())
+			if err != nil {
+				return err
+			}
+			rv = after
+			return cfg.audit(c, tx, change{Action: "review.update", Entity: "review", EntityID: rv.ID, UserID: in.UserID,
+				Before: gin.H{"rating": before.Rating, "comment": before.Comment},
+				After:  gin.H{"rating": after.Rating, "comment": after.Comment}})
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, rv)
+	})
+
+	// DELETE /books/:id/reviews/:review_id?user_id=N  -> solo el autor
+	r.DELETE("/books/:id/reviews/:review_id", func(c *gin.Context) {
+		userID, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "user_id requerido")
+			return
+		}
+		bookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		reviewID, err := strconv.ParseInt(c.Param("review_id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		if err := deleteReview(c, st, cfg, bookID, reviewID, userID); err != nil {
+			fail(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// ---- moderación (X-Admin-Token) ----
+	admin := r.Group("/admin", requireAdmin())
+
+	// GET /admin/reviews?status=oculta
+	admin.GET("/reviews", func(c *gin.Context) {
+		list, err := st.Read().Reviews().List(c.Request.Context(), store.ReviewFilter{Status: c.Query("status")})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"reviews": list})
+	})
+
+	// PATCH /admin/reviews/:id {status: visible|oculta}
+	admin.PATCH("/reviews/:id", func(c *gin.Context) {
+		var in struct {
+			Status string `json:"status" binding:"required,oneof=visible oculta"`
+		}
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		if !bindJSON(c, &in) {
+			return
+		}
+		var rv store.Review
+		err = st.InTx(c.Request.Context(), func(tx store.Tx) error {
+			old, err := service.ModerateReview(c.Request.Context(), tx, id, in.Status)
+			if err != nil {
+				return err
+			}
+			if err := cfg.audit(c, tx, change{Action: "review.moderate", Entity: "review", EntityID: id,
+				Before: gin.H{"status": old}, After: gin.H{"status": in.Status}}); err != nil {
+				return err
+			}
+			rv, err = tx.Reviews().Get(c.Request.Context(), id)
+			return err
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, rv)
+	})
+
+	// DELETE /admin/reviews/:id
+	admin.DELETE("/reviews/:id", func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		if err := deleteReview(c, st, cfg, 0, id, 0); err != nil {
+			fail(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+// deleteReview borra la reseña y deja en el audit_log cómo era. userID es el autor cuando
+// la borra él mismo, 0 cuando la borra un administrador (y entonces bookID también es 0).
+func deleteReview(c *gin.Context, st *store.Store, cfg *config, bookID, reviewID, userID int64) error {
+	return st.InTx(c.Request.Context(), func(tx store.Tx) error {
+		rv, err := service.DeleteReview(c.Request.Context(), tx, bookID, reviewID, userID)
+		if err != nil {
+			return err
+		}
+		return cfg.audit(c, tx, change{Action: "review.delete", Entity: "review", EntityID: rv.ID, UserID: userID,
+			Before: gin.H{"book_id": rv.BookID, "user_id": rv.UserID, "rating": rv.Rating, "comment": rv.Comment, "status": rv.Status}})
+	})
+}
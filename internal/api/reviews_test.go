@@ -0,0 +1,181 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"tarea1-uzm/internal/apitest"
+)
+
+type reviewOut struct {
+	ID       int64  `json:"id"`
+	UserID   int64  `json:"user_id"`
+	UserName string `json:"user_name"`
+	Rating   int64  `json:"rating"`
+	Comment  string `json:"comment"`
+	Status   string `json:"status"`
+}
+
+// bookRating busca el libro en GET /books y devuelve su promedio y cantidad de reseñas.
+func bookRating(t *testing.T, s *apitest.Server, id int64) (float64, int64) {
+	t.Helper()
+	var list struct {
+		Books []struct {
+			ID            int64   `json:"id"`
+			AverageRating float64 `json:"average_rating"`
+			ReviewCount   int64   `json:"review_count"`
+		} `json:"books"`
+	}
+	s.Do(http.MethodGet, "/books", nil).Decode(t, &list)
+	for _, b := range list.Books {
+		if b.ID == id {
+			return b.AverageRating, b.ReviewCount
+		}
+	}
+	t.Fatalf("libro %d no está en /books", id)
+	return 0, 0
+}
+
+func TestReviewRules(t *testing.T) {
+	s := apitest.NewServer(t)
+	book := apitest.NewBook().Stock(5).Insert(t, s.DB)
+	author := apitest.NewUser().Name("Ana", "Rojas").Insert(t, s.DB)
+	other := apitest.NewUser().Insert(t, s.DB)
+	stranger := apitest.NewUser().Insert(t, s.DB)
+	sold(t, s.DB, author, book)
+	apitest.NewLoan(other, book).Returned("20/01/2025").Insert(t, s.DB)
+	path := fmt.Sprintf("/books/%d/reviews", book)
+
+	// solo quien compró o arrendó
+	resp := s.Do(http.MethodPost, path, map[string]any{"user_id": stranger, "rating": 5})
+	if resp.Status != http.StatusForbidden || resp.APIError().Code != "forbidden" {
+		t.Errorf("sin comprar: %d %s", resp.Status, resp.Body)
+	}
+
+	// rating entre 1 y 5
+	for _, rating := range []int{0, 6, -1} {
+		resp := s.Do(http.MethodPost, path, map[string]any{"user_id": author, "rating": rating})
+		if resp.Status != http.StatusUnprocessableEntity {
+			t.Errorf("rating %d: %d %s", rating, resp.Status, resp.Body)
+		}
+	}
+
+	resp = s.Do(http.MethodPost, path, map[string]any{"user_id": author, "rating": 4, "comment": "  bueno  "})
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("crear: %d %s", resp.Status, resp.Body)
+	}
+	var rv reviewOut
+	resp.Decode(t, &rv)
+	if rv.UserName != "Ana R." || rv.Comment != "bueno" || rv.Status != "visible" {
+		t.Errorf("reseña = %+v", rv)
+	}
+
+	// una por usuario y libro
+	resp = s.Do(http.MethodPost, path, map[string]any{"user_id": author, "rating": 2})
+	if resp.Status != http.StatusConflict || resp.APIError().Code != "conflict" {
+		t.Errorf("segunda reseña: %d %s", resp.Status, resp.Body)
+	}
+
+	// solo el autor edita o borra
+	one := fmt.Sprintf("%s/%d", path, rv.ID)
+	if resp := s.Do(http.MethodPatch, one, map[string]any{"user_id": other, "rating": 1}); resp.Status != http.StatusForbidden {
+		t.Errorf("editar ajena: %d %s", resp.Status, resp.Body)
+	}
+	if resp := s.Do(http.MethodDelete, fmt.Sprintf("%s?user_id=%d", one, other), nil); resp.Status != http.StatusForbidden {
+		t.Errorf("borrar ajena: %d %s", resp.Status, resp.Body)
+	}
+	if resp := s.Do(http.MethodPatch, one, map[string]any{"user_id": author, "rating": 9}); resp.Status != http.StatusUnprocessableEntity {
+		t.Errorf("editar con rating 9: %d %s", resp.Status, resp.Body)
+	}
+	resp = s.Do(http.MethodPatch, one, map[string]any{"user_id": author, "rating": 5})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("editar: %d %s", resp.Status, resp.Body)
+	}
+	resp.Decode(t, &rv)
+	if rv.Rating != 5 || rv.Comment != "bueno" {
+		t.Errorf("editada = %+v", rv)
+	}
+	if got := s.QueryInt(`SELECT rating FROM reviews WHERE id=?`, rv.ID); got != 5 {
+		t.Errorf("rating guardado = %d", got)
+	}
+
+	if resp := s.Do(http.MethodDelete, fmt.Sprintf("%s?user_id=%d", one, author), nil); resp.Status != http.StatusNoContent {
+		t.Fatalf("borrar: %d %s", resp.Status, resp.Body)
+	}
+	if resp := s.Do(http.MethodDelete, fmt.Sprintf("%s?user_id=%d", one, author), nil); resp.Status != http.StatusNotFound {
+		t.Errorf("borrar de nuevo: %d", resp.Status)
+	}
+	// borrada, se puede volver a reseñar
+	if resp := s.Do(http.MethodPost, path, map[string]any{"user_id": author, "rating": 3}); resp.Status != http.StatusCreated {
+		t.Errorf("reseñar de nuevo: %d %s", resp.Status, resp.Body)
+	}
+}
+
+func TestReviewModeration(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	s := apitest.NewServer(t)
+	book := apitest.NewBook().Stock(5).Insert(t, s.DB)
+	path := fmt.Sprintf("/books/%d/reviews", book)
+	var ids []int64
+	for _, rating := range []int{5, 3, 1} {
+		user := apitest.NewUser().Insert(t, s.DB)
+		sold(t, s.DB, user, book)
+		var rv reviewOut
+		s.Do(http.MethodPost, path, map[string]any{"user_id": user, "rating": rating}).Decode(t, &rv)
+		ids = append(ids, rv.ID)
+	}
+	if avg, n := bookRating(t, s, book); avg != 3 || n != 3 {
+		t.Errorf("promedio = %v (%d)", avg, n)
+	}
+
+	// moderar es solo de admin
+	hide := fmt.Sprintf("/admin/reviews/%d", ids[2])
+	if resp := s.Do(http.MethodPatch, hide, map[string]any{"status": "oculta"}); resp.Status != http.StatusForbidden {
+		t.Errorf("sin token: %d", resp.Status)
+	}
+	if resp := s.Do(http.MethodPatch, hide, map[string]any{"status": "borrada"}, "X-Admin-Token", "secreto"); resp.Status != http.StatusUnprocessableEntity {
+		t.Errorf("status inválido: %d %s", resp.Status, resp.Body)
+	}
+	resp := s.Do(http.MethodPatch, hide, map[string]any{"status": "oculta"}, "X-Admin-Token", "secreto")
+	if resp.Status != http.StatusOK {
+		t.Fatalf("ocultar: %d %s", resp.Status, resp.Body)
+	}
+
+	// la oculta no cuenta en el promedio ni aparece en el listado público
+	if avg, n := bookRating(t, s, book); avg != 4 || n != 2 {
+		t.Errorf("promedio con una oculta = %v (%d)", avg, n)
+	}
+	var list struct {
+		Reviews []reviewOut `json:"reviews"`
+	}
+	s.Do(http.MethodGet, path, nil).Decode(t, &list)
+	if len(list.Reviews) != 2 {
+		t.Errorf("públicas = %+v", list.Reviews)
+	}
+	list.Reviews = nil
+	s.Do(http.MethodGet, "/admin/reviews?status=oculta", nil, "X-Admin-Token", "secreto").Decode(t, &list)
+	if len(list.Reviews) != 1 || list.Reviews[0].ID != ids[2] {
+		t.Errorf("ocultas = %+v", list.Reviews)
+	}
+
+	// volver a mostrarla
+	s.Do(http.MethodPatch, hide, map[string]any{"status": "visible"}, "X-Admin-Token", "secreto")
+	if avg, n := bookRating(t, s, book); avg != 3 || n != 3 {
+		t.Errorf("promedio tras mostrarla = %v (%d)", avg, n)
+	}
+
+	if resp := s.Do(http.MethodPatch, "/admin/reviews/999", map[string]any{"status": "oculta"}, "X-Admin-Token", "secreto"); resp.Status != http.StatusNotFound {
+		t.Errorf("moderar inexistente: %d", resp.Status)
+	}
+	resp = s.Do(http.MethodPatch, "/admin/reviews/abc", map[string]any{"status": "oculta"}, "X-Admin-Token", "secreto")
+	if resp.Status != http.StatusBadRequest || resp.APIError().Code != "invalid_param" {
+		t.Errorf("moderar id inválido: %d %s", resp.Status, resp.Body)
+	}
+	if resp := s.Do(http.MethodDelete, fmt.Sprintf("/admin/reviews/%d", ids[0]), nil, "X-Admin-Token", "secreto"); resp.Status != http.StatusNoContent {
+		t.Errorf("borrar como admin: %d %s", resp.Status, resp.Body)
+	}
+	if avg, n := bookRating(t, s, book); avg != 2 || n != 2 {
+		t.Errorf("promedio tras borrar = %v (%d)", avg, n)
+	}
+}
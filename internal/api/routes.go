@@ -2,20 +2,145 @@ package api
 
 import (
 	"database/sql"
+	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/backup"
+	"tarea1-uzm/internal/clock"
+	"tarea1-uzm/internal/notify"
+	"tarea1-uzm/internal/scheduler"
+	"tarea1-uzm/internal/store"
+	"tarea1-uzm/internal/webhooks"
 )
 
-func RegisterRoutes(r *gin.Engine, db *sql.DB) {
+// config reúne las dependencias opcionales de la API.
+type config struct {
+	clock      clock.Clock
+	idempotent gin.HandlerFunc // para rutas que cobran o mueven stock (ver idempotency.go)
+	logger     *slog.Logger
+	scheduler  *scheduler.Scheduler // jobs que muestra y corre /admin/jobs
+	notifiers  []notify.Notifier    // canales de recordatorio, además de la bandeja, del scheduler por defecto
+	dispatcher *webhooks.Dispatcher // entrega los eventos del outbox (POST /admin/webhooks/deliver)
+	eventPoll  time.Duration        // cada cuánto GET /events revisa el outbox
+	backups    backup.Config        // directorio y retención de /admin/backups
+}
+
+// Option ajusta la configuración de RegisterRoutes.
+type Option func(*config)
+
+// WithClock fija el reloj de la API (por defecto, la hora real en la zona UZM_TZ).
+func WithClock(c clock.Clock) Option {
+	return func(cfg *config) { cfg.clock = c }
+}
+
+// WithLogger fija el logger de la API (por defecto, slog.Default()).
+func WithLogger(l *slog.Logger) Option {
+	return func(cfg *config) { cfg.logger = l }
+}
+
+// WithScheduler fija el scheduler que exponen las rutas /admin/jobs (por defecto, uno con
+// Jobs que no corre solo: los jobs se disparan a mano).
+func WithScheduler(s *scheduler.Scheduler) Option {
+	return func(cfg *config) { cfg.scheduler = s }
+}
+
+// WithNotifiers agrega canales de recordatorio (correo, webhook) a la bandeja en el
+// scheduler por defecto. No afecta a un scheduler pasado con WithScheduler.
+func WithNotifiers(n ...notify.Notifier) Option {
+	return func(cfg *config) { cfg.notifiers = n }
+}
+
+// WithDispatcher fija el despachador de webhooks (por defecto, uno que no corre solo: las
+// entregas salen con POST /admin/webhooks/deliver).
+func WithDispatcher(d *webhooks.Dispatcher) Option {
+	return func(cfg *config) { cfg.dispatcher = d }
+}
+
+// WithEventPoll fija cada cuánto GET /events busca eventos nuevos (por defecto DefaultEventPoll).
+func WithEventPoll(d time.Duration) Option {
+	return func(cfg *config) { cfg.eventPoll = d }
+}
+
+// WithBackups fija dónde guarda y cuántas conserva /admin/backups (por defecto, lo de
+// backup.FromEnv).
+func WithBackups(b backup.Config) Option {
+	return func(cfg *config) { cfg.backups = b }
+}
+
+func RegisterRoutes(r *gin.Engine, db *sql.DB, opts ...Option) {
+	cfg := &config{}
+	for _, o := range opts {
+		o(cfg)
+	}
+	if cfg.clock == nil {
+		loc, err := clock.LoadZone(os.Getenv("UZM_TZ"))
+		if err != nil {
+			log.Fatalf("zona horaria: %v", err)
+		}
+		cfg.clock = clock.System{Loc: loc}
+	}
+	if cfg.logger == nil {
+		cfg.logger = slog.Default()
+	}
+	if cfg.scheduler == nil {
+		cfg.scheduler = scheduler.New(db, cfg.clock, cfg.logger)
+		cfg.scheduler.Add(Jobs(db, cfg.notifiers)...)
+	}
+	if cfg.dispatcher == nil {
+		cfg.dispatcher = webhooks.New(db, cfg.logger)
+	}
+	if cfg.backups.Dir == "" {
+		b, err := backup.FromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.backups = b
+	}
+	if cfg.eventPoll <= 0 {
+		cfg.eventPoll = DefaultEventPoll
+	}
+	cfg.idempotent = idempotent(store.New(db), cfg)
+
+	observe, scrape := newMetrics(db, cfg)
+	r.Use(observe, requestID(cfg.logger), accessLog(), recovery())
+	// fuera de /api/v1: es para Prometheus, no para los clientes de la API
+	r.GET("/metrics", scrape)
+	r.NoRoute(func(c *gin.Context) {
+		abort(c, http.StatusNotFound, CodeNotFound, "ruta no existe")
+	})
+	registerV1(r.Group(V1Prefix, apiVersion(1)), db, cfg)
+	// alias de la raíz para clientes antiguos: mismos handlers, con aviso de obsolescencia
+	registerV1(r.Group("/", apiVersion(1), deprecated(LegacyDeprecated, LegacySunset, V1Prefix)), db, cfg)
+}
+
+// registerV1 registra todas las rutas de la versión 1 (las que describe openapi.json).
+func registerV1(r gin.IRouter, db *sql.DB, cfg *config) {
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 	registerAuthRoutes(r, db)
-	registerUserRoutes(r, db)
-	registerBookRoutes(r, db)
-	registerSalesRoutes(r, db)
+	registerUserRoutes(r, db, cfg)
+	registerBookRoutes(r, db, cfg)
+	registerSalesRoutes(r, db, cfg)
 	registerTransactionRoutes(r, db)
-	registerLoanRoutes(r, db)
-
+	registerLoanRoutes(r, db, cfg)
+	registerPopularityRoutes(r, db, cfg)
+	registerRecommendationRoutes(r, db)
+	registerReviewRoutes(r, db, cfg)
+	registerWishlistRoutes(r, db, cfg)
+	registerPromotionRoutes(r, db, cfg)
+	registerClockRoutes(r, db, cfg)
+	registerAuditRoutes(r, db, cfg)
+	registerJobRoutes(r, db, cfg)
+	registerReminderRoutes(r, db, cfg)
+	registerWebhookRoutes(r, db, cfg)
+	registerEventRoutes(r, db, cfg)
+	registerBulkRoutes(r, db, cfg)
+	registerBackupRoutes(r, db, cfg)
+	registerOpenAPIRoutes(r)
 }
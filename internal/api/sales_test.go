@@ -0,0 +1,100 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"tarea1-uzm/internal/apitest"
+)
+
+func TestSellBook(t *testing.T) {
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	book := apitest.NewBook().Price(30).Stock(2).Insert(t, s.DB)
+
+	resp := s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user, "book_id": book})
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("status = %d, body %s", resp.Status, resp.Body)
+	}
+	var sale struct {
+		ID       int64 `json:"id"`
+		Price    int64 `json:"price"`
+		Discount int64 `json:"discount"`
+	}
+	resp.Decode(t, &sale)
+	if sale.ID == 0 || sale.Price != 30 || sale.Discount != 0 {
+		t.Errorf("sale = %+v", sale)
+	}
+	if got := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); got != 70 {
+		t.Errorf("saldo = %d, want 70", got)
+	}
+	if got := s.QueryInt(`SELECT available_quantity FROM inventory WHERE book_id=?`, book); got != 1 {
+		t.Errorf("stock = %d, want 1", got)
+	}
+	if got := s.QueryInt(`SELECT popularity_score FROM books WHERE id=?`, book); got != 1 {
+		t.Errorf("popularity = %d, want 1", got)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM popularity_events WHERE book_id=? AND kind='Venta'`, book); got != 1 {
+		t.Errorf("events = %d, want 1", got)
+	}
+}
+
+func TestSellBookErrors(t *testing.T) {
+	s := apitest.NewServer(t)
+	rich := apitest.NewUser().Balance(1000).Insert(t, s.DB)
+	poor := apitest.NewUser().Balance(5).Insert(t, s.DB)
+	forSale := apitest.NewBook().Price(10).Insert(t, s.DB)
+	soldOut := apitest.NewBook().Stock(0).Insert(t, s.DB)
+	forLoan := apitest.NewBook().ForLoan().Insert(t, s.DB)
+
+	tests := []struct {
+		name   string
+		body   any
+		status int
+		code   string
+	}{
+		{"sin stock", map[string]any{"user_id": rich, "book_id": soldOut}, http.StatusConflict, "out_of_stock"},
+		{"modalidad Arriendo", map[string]any{"user_id": rich, "book_id": forLoan}, http.StatusBadRequest, "wrong_mode"},
+		{"fondos insuficientes", map[string]any{"user_id": poor, "book_id": forSale}, http.StatusBadRequest, "insufficient_funds"},
+		{"usuario no existe", map[string]any{"user_id": 999, "book_id": forSale}, http.StatusNotFound, "not_found"},
+		{"libro no existe", map[string]any{"user_id": rich, "book_id": 999}, http.StatusNotFound, "not_found"},
+		{"código inexistente", map[string]any{"user_id": rich, "book_id": forSale, "code": "NOPE"}, http.StatusBadRequest, "invalid_promotion"},
+		{"faltan ids", map[string]any{}, http.StatusUnprocessableEntity, "validation_failed"},
+		{"json roto", `{"user_id":`, http.StatusBadRequest, "invalid_json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Do(http.MethodPost, "/sales", tt.body)
+			if resp.Status != tt.status || resp.APIError().Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q (%s)", resp.Status, resp.APIError().Code, tt.status, tt.code, resp.Body)
+			}
+		})
+	}
+
+	// ningún intento fallido debe haber cobrado ni movido stock
+	if got := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, poor); got != 5 {
+		t.Errorf("saldo poor = %d, want 5", got)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM sales`); got != 0 {
+		t.Errorf("sales = %d, want 0", got)
+	}
+}
+
+func TestCheckoutIsAllOrNothing(t *testing.T) {
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Balance(100).Insert(t, s.DB)
+	a := apitest.NewBook().Price(10).Insert(t, s.DB)
+	b := apitest.NewBook().Price(10).Stock(1).Insert(t, s.DB)
+
+	// b va dos veces y solo hay uno: no se compra nada
+	resp := s.Do(http.MethodPost, "/sales/checkout", map[string]any{"user_id": user, "book_ids": []int64{a, b, b}})
+	if resp.Status != http.StatusConflict {
+		t.Fatalf("status = %d, body %s", resp.Status, resp.Body)
+	}
+	if got := s.QueryInt(`SELECT available_quantity FROM inventory WHERE book_id=?`, a); got != 1 {
+		t.Errorf("stock a = %d, want 1", got)
+	}
+	if got := s.QueryInt(`SELECT usm_pesos FROM users WHERE id=?`, user); got != 100 {
+		t.Errorf("saldo = %d, want 100", got)
+	}
+}
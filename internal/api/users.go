@@ -2,62 +2,72 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/store"
 )
 
 type User struct {
 	ID        int64  `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
+	FirstName string `json:"first_name" binding:"required,max=100"`
+	LastName  string `json:"last_name" binding:"required,max=100"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required,min=4"`
 	USMPesos  int64  `json:"usm_pesos"`
 }
 
-func registerUserRoutes(r *gin.Engine, db *sql.DB) {
+func userOut(a store.Account) User {
+	return User{ID: a.ID, FirstName: a.FirstName, LastName: a.LastName, Email: a.Email, Password: a.Password, USMPesos: a.USMPesos}
+}
+
+func registerUserRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	st := store.New(db)
+
 	r.POST("/users", func(c *gin.Context) {
 		var in User
-		if err := c.BindJSON(&in); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "json inválido"})
-			return
-		}
-		if in.FirstName == "" || in.LastName == "" || in.Email == "" || in.Password == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "faltan campos"})
+		if !bindJSON(c, &in) {
 			return
 		}
-		res, err := db.Exec(
-			`INSERT INTO users(first_name,last_name,email,password,usm_pesos) VALUES(?,?,?,?,0)`,
-			in.FirstName, in.LastName, in.Email, in.Password,
-		)
+		ctx := c.Request.Context()
+		p := store.Profile{FirstName: in.FirstName, LastName: in.LastName, Email: in.Email}
+		err := st.InTx(ctx, func(tx store.Tx) error {
+			if err := tx.Users().Create(ctx, &p, in.Password); err != nil {
+				return err
+			}
+			// sin la contraseña, ni en el audit_log ni en el evento
+			public := gin.H{"first_name": p.FirstName, "last_name": p.LastName, "email": p.Email, "usm_pesos": 0}
+			if err := cfg.audit(c, tx, change{Action: "user.create", Entity: "user", EntityID: p.ID, UserID: p.ID, After: public}); err != nil {
+				return err
+			}
+			event := gin.H{"id": p.ID}
+			for k, v := range public {
+				event[k] = v
+			}
+			return tx.Outbox().Publish(ctx, store.EventUserCreated, event, cfg.clock.Now())
+		})
 		if err != nil {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			fail(c, err)
 			return
 		}
-		id, _ := res.LastInsertId()
-		in.ID = id
+		in.ID = p.ID
 		in.USMPesos = 0
+		logger(c).Info("usuario creado", "user_id", p.ID)
 		c.JSON(http.StatusCreated, in)
 	})
 
 	r.GET("/users", func(c *gin.Context) {
-		rows, err := db.Query(`SELECT id,first_name,last_name,email,password,usm_pesos FROM users ORDER BY id`)
+		accounts, err := st.Read().Users().List(c.Request.Context())
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			fail(c, err)
 			return
 		}
-		defer rows.Close()
-
-		out := []User{}
-		for rows.Next() {
-			var u User
-			if err := rows.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.Password, &u.USMPesos); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			out = append(out, u)
+		out := make([]User, len(accounts))
+		for i, a := range accounts {
+			out[i] = userOut(a)
 		}
 		c.JSON(http.StatusOK, gin.H{"users": out})
 	})
@@ -65,80 +75,106 @@ func registerUserRoutes(r *gin.Engine, db *sql.DB) {
 	r.GET("/users/:id", func(c *gin.Context) {
 		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
 			return
 		}
-		var u User
-		err = db.QueryRow(`SELECT id,first_name,last_name,email,password,usm_pesos FROM users WHERE id=?`, id).
-			Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.Password, &u.USMPesos)
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "no encontrado"})
+		a, err := st.Read().Users().Account(c.Request.Context(), id)
+		if errors.Is(err, store.ErrNotFound) {
+			abort(c, http.StatusNotFound, CodeNotFound, "no encontrado")
 			return
 		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			fail(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, u)
+		c.JSON(http.StatusOK, userOut(a))
 	})
-	r.PATCH("/users/:id", func(c *gin.Context) {
+	r.PATCH("/users/:id", cfg.idempotent, func(c *gin.Context) {
 		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
 			return
 		}
 
 		// Campos opcionales
 		var in struct {
-			FirstName *string `json:"first_name"`
-			LastName  *string `json:"last_name"`
-			Password  *string `json:"password"`
-			Abonar    *int64  `json:"abonar"` // suma a usm_pesos
+			FirstName *string `json:"first_name" binding:"omitnil,min=1,max=100"`
+			LastName  *string `json:"last_name" binding:"omitnil,min=1,max=100"`
+			Password  *string `json:"password" binding:"omitnil,min=4"`
+			Abonar    *int64  `json:"abonar" binding:"omitnil,gt=0"` // suma a usm_pesos
 		}
-		if err := c.BindJSON(&in); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "json inválido"})
+		if !bindJSON(c, &in) {
 			return
 		}
 
-		// Actualizaciones simples
-		if in.FirstName != nil {
-			if _, err := db.Exec(`UPDATE users SET first_name=? WHERE id=?`, *in.FirstName, id); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
+		ch := store.UserChanges{FirstName: in.FirstName, LastName: in.LastName, Password: in.Password}
+		if in.Abonar != nil {
+			ch.Deposit = *in.Abonar
 		}
-		if in.LastName != nil {
-			if _, err := db.Exec(`UPDATE users SET last_name=? WHERE id=?`, *in.LastName, id); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
+
+		// un solo UPDATE: se aplican todos los cambios o ninguno (y queda en el audit_log
+		// con los valores anteriores, salvo la contraseña)
+		var out User
+		err = st.InTx(c.Request.Context(), func(tx store.Tx) error {
+			old, err := tx.Users().Get(c.Request.Context(), id)
+			if err != nil {
+				return err
 			}
-		}
-		if in.Password != nil {
-			if _, err := db.Exec(`UPDATE users SET password=? WHERE id=?`, *in.Password, id); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
+			if _, err := tx.Users().Update(c.Request.Context(), id, ch); err != nil {
+				return err
 			}
-		}
-		if in.Abonar != nil {
-			if _, err := db.Exec(`UPDATE users SET usm_pesos = usm_pesos + ? WHERE id=?`, *in.Abonar, id); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
+			before, after := gin.H{}, gin.H{}
+			if in.FirstName != nil {
+				before["first_name"], after["first_name"] = old.FirstName, *in.FirstName
 			}
-		}
-
-		// Devuelve el usuario actualizado
-		var u User
-		err = db.QueryRow(`SELECT id,first_name,last_name,email,password,usm_pesos FROM users WHERE id=?`, id).
-			Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.Password, &u.USMPesos)
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "no encontrado"})
+			if in.LastName != nil {
+				before["last_name"], after["last_name"] = old.LastName, *in.LastName
+			}
+			if in.Password != nil {
+				after["password_changed"] = true
+			}
+			if in.Abonar != nil {
+				before["usm_pesos"], after["usm_pesos"] = old.USMPesos, old.USMPesos+*in.Abonar
+			}
+			if err := cfg.audit(c, tx, change{Action: "user.update", Entity: "user", EntityID: id, UserID: id,
+				Before: before, After: after}); err != nil {
+				return err
+			}
+			// devuelve el usuario actualizado
+			a, err := tx.Users().Account(c.Request.Context(), id)
+			if err != nil {
+				return err
+			}
+			out = userOut(a)
+			return keepResponse(c, tx, http.StatusOK, out)
+		})
+		if errors.Is(err, store.ErrNotFound) {
+			abort(c, http.StatusNotFound, CodeNotFound, "no encontrado")
 			return
 		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			fail(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, u)
+
+		// qué campos cambiaron, nunca sus valores (la contraseña no se escribe en el log)
+		var fields []string
+		if in.FirstName != nil {
+			fields = append(fields, "first_name")
+		}
+		if in.LastName != nil {
+			fields = append(fields, "last_name")
+		}
+		if in.Password != nil {
+			fields = append(fields, "password")
+		}
+		if len(fields) > 0 {
+			logger(c).Info("usuario actualizado", "user_id", id, "fields", fields)
+		}
+		if in.Abonar != nil {
+			logBalance(c, id, *in.Abonar, "abono")
+		}
+		c.JSON(http.StatusOK, out)
 	})
 
 }
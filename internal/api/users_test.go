@@ -0,0 +1,86 @@
+package api_test
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+
+	"tarea1-uzm/internal/apitest"
+)
+
+func TestCreateUserErrors(t *testing.T) {
+	s := apitest.NewServer(t)
+	apitest.NewUser().Email("ana@usm.cl").Insert(t, s.DB)
+
+	tests := []struct {
+		name   string
+		body   any
+		status int
+		code   string
+		fields []string
+	}{
+		{"email repetido", map[string]any{"first_name": "A", "last_name": "B", "email": "ana@usm.cl", "password": "clave123"},
+			http.StatusConflict, "conflict", []string{"email"}},
+		{"email inválido", map[string]any{"first_name": "A", "last_name": "B", "email": "ana", "password": "clave123"},
+			http.StatusUnprocessableEntity, "validation_failed", []string{"email"}},
+		{"todo vacío", map[string]any{},
+			http.StatusUnprocessableEntity, "validation_failed", []string{"first_name", "last_name", "email", "password"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Do(http.MethodPost, "/users", tt.body)
+			e := resp.APIError()
+			if resp.Status != tt.status || e.Code != tt.code {
+				t.Fatalf("got %d %q, want %d %q (%s)", resp.Status, e.Code, tt.status, tt.code, resp.Body)
+			}
+			var fields []string
+			for _, f := range e.Details["fields"].([]any) {
+				fields = append(fields, f.(string))
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("fields = %v, want %v", fields, tt.fields)
+			}
+			if e.RequestID == "" || resp.Header.Get("X-Request-ID") != e.RequestID {
+				t.Errorf("request_id = %q, header %q", e.RequestID, resp.Header.Get("X-Request-ID"))
+			}
+		})
+	}
+}
+
+func TestLoginAndTopUp(t *testing.T) {
+	s := apitest.NewServer(t)
+	user := apitest.NewUser().Email("ana@usm.cl").Password("secreta").Insert(t, s.DB)
+
+	if resp := s.Do(http.MethodPost, "/login", map[string]any{"email": "ana@usm.cl", "password": "otra"}); resp.Status != http.StatusUnauthorized {
+		t.Errorf("login con clave mala: %d", resp.Status)
+	}
+	if resp := s.Do(http.MethodPost, "/login", map[string]any{"email": "ana@usm.cl", "password": "secreta"}); resp.Status != http.StatusOK {
+		t.Errorf("login: %d %s", resp.Status, resp.Body)
+	}
+
+	path := "/users/" + strconv.FormatInt(user, 10)
+	if resp := s.Do(http.MethodPatch, path, map[string]any{"abonar": -5}); resp.Status != http.StatusUnprocessableEntity {
+		t.Errorf("abono negativo: %d %s", resp.Status, resp.Body)
+	}
+	resp := s.Do(http.MethodPatch, path, map[string]any{"abonar": 50, "first_name": "Anita"})
+	var u struct {
+		FirstName string `json:"first_name"`
+		USMPesos  int64  `json:"usm_pesos"`
+	}
+	resp.Decode(t, &u)
+	if u.USMPesos != 50 || u.FirstName != "Anita" {
+		t.Errorf("usuario = %+v, want Anita con 50", u)
+	}
+	if resp := s.Do(http.MethodPatch, "/users/999", map[string]any{"abonar": 50}); resp.Status != http.StatusNotFound {
+		t.Errorf("usuario inexistente: %d %s", resp.Status, resp.Body)
+	}
+}
+
+func TestUnknownRoute(t *testing.T) {
+	s := apitest.NewServer(t)
+	resp := s.Do(http.MethodGet, "/nada", nil, "X-Request-ID", "prueba-1")
+	if resp.Status != http.StatusNotFound || resp.APIError().Code != "not_found" || resp.APIError().RequestID != "prueba-1" {
+		t.Errorf("got %d %s", resp.Status, resp.Body)
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"tarea1-uzm/internal/store"
+)
+
+// FieldError es un problema de validación en un campo del cuerpo (nombre JSON).
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// en los errores usamos el nombre JSON del campo, no el de Go
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	v.RegisterValidation("fecha", func(fl validator.FieldLevel) bool {
+		_, err := time.Parse(loanFmt, fl.Field().String())
+		return err == nil
+	})
+	v.RegisterStructValidation(promotionRules, store.Promotion{})
+}
+
+// msgBeforeStart es el error de ends_at anterior a starts_at (al crear y al editar).
+const msgBeforeStart = "no puede ser anterior a starts_at"
+
+// promotionRules son las reglas que dependen de más de un campo de la promoción.
+func promotionRules(sl validator.StructLevel) {
+	p := sl.Current().Interface().(store.Promotion)
+	switch p.Kind {
+	case "porcentaje":
+		if p.Value < 1 || p.Value > 100 {
+			sl.ReportError(p.Value, "value", "Value", "porcentaje", "")
+		}
+	case "monto":
+		if p.Value < 1 {
+			sl.ReportError(p.Value, "value", "Value", "monto", "")
+		}
+	case "bundle":
+		if p.PayQty < 1 || p.BuyQty <= p.PayQty {
+			sl.ReportError(p.BuyQty, "buy_qty", "BuyQty", "bundle", "")
+		}
+	}
+	if endsBeforeStart(p.StartsAt, p.EndsAt) {
+		sl.ReportError(p.EndsAt, "ends_at", "EndsAt", "despues_de_inicio", "")
+	}
+}
+
+// endsBeforeStart dice si ends_at es anterior a starts_at; con fechas inválidas ya reclama
+// la regla fecha.
+func endsBeforeStart(startsAt, endsAt string) bool {
+	start, err1 := time.Parse(loanFmt, startsAt)
+	end, err2 := time.Parse(loanFmt, endsAt)
+	return err1 == nil && err2 == nil && end.Before(start)
+}
+
+// bindJSON decodifica y valida el cuerpo. Si falla ya respondió: 400 si el JSON no se
+// puede leer, 422 con todos los campos inválidos si no cumple las reglas.
+func bindJSON(c *gin.Context, out any) bool {
+	err := c.ShouldBindJSON(out)
+	if err == nil {
+		return true
+	}
+
+	var verrs validator.ValidationErrors
+	var terr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &verrs):
+		fields := make([]string, 0, len(verrs))
+		msgs := make([]string, 0, len(verrs))
+		list := make([]FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			name := fieldPath(fe)
+			msg := name + " " + ruleMessage(fe)
+			fields = append(fields, name)
+			msgs = append(msgs, msg)
+			list = append(list, FieldError{Field: name, Rule: fe.Tag(), Message: msg})
+		}
+		abortDetails(c, http.StatusUnprocessableEntity, CodeValidation, strings.Join(msgs, "; "),
+			map[string]any{"fields": fields, "errors": list})
+	case errors.As(err, &terr) && terr.Field != "":
+		abortField(c, terr.Field, "type", "debe ser de tipo "+jsonType(terr.Type))
+	default:
+		abort(c, http.StatusBadRequest, CodeInvalidJSON, "json inválido")
+	}
+	return false
+}
+
+// abortField responde 422 con un solo campo inválido, con la misma forma que bindJSON; para
+// reglas que se revisan después de leer la base.
+func abortField(c *gin.Context, field, rule, msg string) {
+	msg = field + " " + msg
+	abortDetails(c, http.StatusUnprocessableEntity, CodeValidation, msg,
+		map[string]any{"fields": []string{field}, "errors": []FieldError{{Field: field, Rule: rule, Message: msg}}})
+}
+
+// fieldPath quita el nombre del struct raíz: "Promotion.value" -> "value", "book_ids[0]" queda igual.
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	numeric := false
+	switch fe.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		numeric = true
+	}
+	switch fe.Tag() {
+	case "required":
+		return "es obligatorio"
+	case "required_without":
+		return "es obligatorio si no se indica " + strings.ToLower(fe.Param())
+	case "email":
+		return "debe ser un email válido"
+	case "http_url":
+		return "debe ser una URL http(s)"
+	case "oneof":
+		return "debe ser uno de: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "fecha":
+		return "debe tener formato DD/MM/YYYY"
+	case "gt":
+		return "debe ser mayor que " + fe.Param()
+	case "gte":
+		return "no puede ser menor que " + fe.Param()
+	case "min":
+		if numeric {
+			return "no puede ser menor que " + fe.Param()
+		}
+		if fe.Kind() == reflect.Slice {
+			return "debe tener al menos " + fe.Param() + " elemento(s)"
+		}
+		return "debe tener al menos " + fe.Param() + " caracter(es)"
+	case "max":
+		if numeric {
+			return "no puede ser mayor que " + fe.Param()
+		}
+		return "no puede tener más de " + fe.Param() + " caracteres"
+	case "porcentaje":
+		return "debe estar entre 1 y 100 para kind porcentaje"
+	case "monto":
+		return "debe ser positivo para kind monto"
+	case "bundle":
+		return "debe ser mayor que pay_qty, y pay_qty al menos 1 (ej. 3 por 2)"
+	case "despues_de_inicio":
+		return msgBeforeStart
+	}
+	return "no es válido (" + fe.Tag() + ")"
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "arreglo"
+	case reflect.Struct, reflect.Map:
+		return "objeto"
+	}
+	return "número"
+}
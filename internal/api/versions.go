@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// V1Prefix es donde vive la versión actual de la API. Una v2 se registra como otro grupo
+// (/api/v2) con sus propios handlers; la v1 pasa entonces a llevar deprecated(...) igual
+// que hoy las rutas de la raíz.
+const V1Prefix = "/api/v1"
+
+// Las rutas antiguas en la raíz (/books, /loans, ...) siguen respondiendo lo mismo que /api/v1
+// para no romper el CLI ya desplegado, pero se anuncian como obsoletas desde LegacyDeprecated
+// y se eliminan después de LegacySunset.
+var (
+	LegacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	LegacySunset     = time.Date(2027, time.March, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// apiVersion deja en cada respuesta la versión que la atendió (API-Version: 1).
+func apiVersion(v int) gin.HandlerFunc {
+	version := strconv.Itoa(v)
+	return func(c *gin.Context) {
+		c.Header("API-Version", version)
+		c.Next()
+	}
+}
+
+// deprecated marca una ruta obsoleta con Deprecation (RFC 9745) y Sunset (RFC 8594) y
+// apunta a su reemplazo con Link rel="successor-version".
+func deprecated(since, sunset time.Time, successor string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	sunsetAt := sunset.UTC().Format(http.TimeFormat)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunsetAt)
+		c.Header("Link", "<"+successor+c.Request.URL.Path+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
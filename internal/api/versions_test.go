@@ -0,0 +1,49 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+)
+
+func TestLegacyRoutesAreDeprecated(t *testing.T) {
+	s := apitest.NewServer(t)
+	apitest.NewBook().Insert(t, s.DB)
+
+	resp := s.Do(http.MethodGet, "/books", nil)
+	if resp.Status != http.StatusOK || resp.Header.Get("API-Version") != "1" {
+		t.Fatalf("v1: %d %v", resp.Status, resp.Header)
+	}
+	if got := resp.Header.Get("Deprecation"); got != "" {
+		t.Errorf("v1 no es obsoleta, Deprecation = %q", got)
+	}
+
+	// el CLI ya desplegado sigue llamando a la raíz: responde igual, pero avisa
+	s.Base = ""
+	legacy := s.Do(http.MethodGet, "/books", nil)
+	if legacy.Status != http.StatusOK || string(legacy.Body) != string(resp.Body) {
+		t.Fatalf("alias: %d %s", legacy.Status, legacy.Body)
+	}
+	want := map[string]string{
+		"API-Version": "1",
+		"Deprecation": "@1792368000",
+		"Sunset":      api.LegacySunset.Format(http.TimeFormat),
+		"Link":        `</api/v1/books>; rel="successor-version"`,
+	}
+	for h, v := range want {
+		if got := legacy.Header.Get(h); got != v {
+			t.Errorf("%s = %q, want %q", h, got, v)
+		}
+	}
+	if resp := s.Do(http.MethodPost, "/users", map[string]any{
+		"first_name": "Ana", "last_name": "Rojas", "email": "ana@usm.cl", "password": "clave123",
+	}); resp.Status != http.StatusCreated || resp.Header.Get("Sunset") == "" {
+		t.Errorf("POST /users antiguo: %d %v", resp.Status, resp.Header)
+	}
+
+	if resp := s.Do(http.MethodGet, "/api/v2/books", nil); resp.Status != http.StatusNotFound {
+		t.Errorf("v2 todavía no existe: %d", resp.Status)
+	}
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/store"
+	"tarea1-uzm/internal/webhooks"
+)
+
+// deliveryStatuses son los valores de ?status= en las entregas.
+var deliveryStatuses = []string{"pendiente", "entregado", "muerto"}
+
+func registerWebhookRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	admin := r.Group("/admin", requireAdmin())
+	st := store.New(db)
+
+	// id lee un :id numérico de la ruta; si no lo es responde 400.
+	id := func(c *gin.Context) (int64, bool) {
+		n, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return 0, false
+		}
+		return n, true
+	}
+	// deliveries responde las entregas que calzan con f, más ?limit= (por defecto 50) y, si f
+	// no fija el estado, ?status=.
+	deliveries := func(c *gin.Context, f store.DeliveryFilter) {
+		f.Limit = 50
+		if s := c.Query("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > 1000 {
+				abort(c, http.StatusBadRequest, CodeInvalidParam, "limit debe estar entre 1 y 1000")
+				return
+			}
+			f.Limit = n
+		}
+		if s := c.Query("status"); s != "" && f.Status == "" {
+			if !slices.Contains(deliveryStatuses, s) {
+				abort(c, http.StatusBadRequest, CodeInvalidParam, "status debe ser uno de: "+strings.Join(deliveryStatuses, ", "))
+				return
+			}
+			f.Status = s
+		}
+		list, err := st.Read().Webhooks().Deliveries(c.Request.Context(), f)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"deliveries": list})
+	}
+
+	// POST /admin/webhooks {url, events, secret?}  -> el secreto (generado si no viene) solo
+	// se muestra en esta respuesta
+	admin.POST("/webhooks", func(c *gin.Context) {
+		var in struct {
+			URL    string   `json:"url" binding:"required,http_url,max=2000"`
+			Events []string `json:"events" binding:"required,min=1,dive,oneof=sale.created loan.created loan.returned book.out_of_stock user.created"`
+			Secret string   `json:"secret" binding:"omitempty,min=16,max=200"`
+		}
+		if !bindJSON(c, &in) {
+			return
+		}
+		w := store.Webhook{URL: in.URL, Secret: in.Secret, Events: dedupe(in.Events), Active: true,
+			CreatedAt: cfg.clock.Now().UTC().Format(eventFmt)}
+		if w.Secret == "" {
+			w.Secret = webhooks.NewSecret()
+		}
+		err := cfg.writeAudited(c, db, func(tx *sql.Tx) (change, error) {
+			if err := store.Bind(tx).Webhooks().Create(c.Request.Context(), &w); err != nil {
+				return change{}, err
+			}
+			return change{Action: "webhook.create", Entity: "webhook", EntityID: w.ID,
+				After: gin.H{"url": w.URL, "events": w.Events, "active": w.Active}}, nil
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		logger(c).Info("webhook creado", "webhook_id", w.ID, "events", w.Events)
+		c.JSON(http.StatusCreated, w)
+	})
+
+	// GET /admin/webhooks  -> sin secretos
+	admin.GET("/webhooks", func(c *gin.Context) {
+		list, err := st.Read().Webhooks().List(c.Request.Context())
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"webhooks": list})
+	})
+
+	// PATCH /admin/webhooks/:id {url?, events?, active?}
+	admin.PATCH("/webhooks/:id", func(c *gin.Context) {
+		hookID, ok := id(c)
+		if !ok {
+			return
+		}
+		var in struct {
+			URL    *string  `json:"url" binding:"omitnil,http_url,max=2000"`
+			Events []string `json:"events" binding:"omitnil,min=1,dive,oneof=sale.created loan.created loan.returned book.out_of_stock user.created"`
+			Active *bool    `json:"active"`
+		}
+		if !bindJSON(c, &in) {
+			return
+		}
+		var w store.Webhook
+		err := cfg.writeAudited(c, db, func(tx *sql.Tx) (change, error) {
+			repo := store.Bind(tx).Webhooks()
+			old, err := repo.Get(c.Request.Context(), hookID)
+			if err != nil {
+				return change{}, err
+			}
+			w = old
+			if in.URL != nil {
+				w.URL = *in.URL
+			}
+			if in.Events != nil {
+				w.Events = dedupe(in.Events)
+			}
+			if in.Active != nil {
+				w.Active = *in.Active
+			}
+			if _, err := repo.Update(c.Request.Context(), w); err != nil {
+				return change{}, err
+			}
+			return change{Action: "webhook.update", Entity: "webhook", EntityID: w.ID,
+				Before: gin.H{"url": old.URL, "events": old.Events, "active": old.Active},
+				After:  gin.H{"url": w.URL, "events": w.Events, "active": w.Active}}, nil
+		})
+		if errors.Is(err, store.ErrNotFound) {
+			abort(c, http.StatusNotFound, CodeNotFound, "webhook no existe")
+			return
+		}
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, w)
+	})
+
+	// DELETE /admin/webhooks/:id  -> también borra sus entregas
+	admin.DELETE("/webhooks/:id", func(c *gin.Context) {
+		hookID, ok := id(c)
+		if !ok {
+			return
+		}
+		err := cfg.writeAudited(c, db, func(tx *sql.Tx) (change, error) {
+			repo := store.Bind(tx).Webhooks()
+			old, err := repo.Get(c.Request.Context(), hookID)
+			if err != nil {
+				return change{}, err
+			}
+			if _, err := repo.Delete(c.Request.Context(), hookID); err != nil {
+				return change{}, err
+			}
+			return change{Action: "webhook.delete", Entity: "webhook", EntityID: hookID,
+				Before: gin.H{"url": old.URL, "events": old.Events, "active": old.Active}}, nil
+		})
+		if errors.Is(err, store.ErrNotFound) {
+			abort(c, http.StatusNotFound, CodeNotFound, "webhook no existe")
+			return
+		}
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// GET /admin/webhooks/:id/deliveries?status=&limit=50  -> de la más nueva a la más antigua
+	admin.GET("/webhooks/:id/deliveries", func(c *gin.Context) {
+		hookID, ok := id(c)
+		if !ok {
+			return
+		}
+		if _, err := st.Read().Webhooks().Get(c.Request.Context(), hookID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				abort(c, http.StatusNotFound, CodeNotFound, "webhook no existe")
+				return
+			}
+			fail(c, err)
+			return
+		}
+		deliveries(c, store.DeliveryFilter{WebhookID: hookID})
+	})
+
+	// GET /admin/webhooks/dead-letters?limit=50  -> entregas muertas de todos los webhooks
+	admin.GET("/webhooks/dead-letters", func(c *gin.Context) {
+		deliveries(c, store.DeliveryFilter{Status: "muerto"})
+	})
+
+	// POST /admin/webhooks/deliveries/:id/replay  -> la vuelve a dejar pendiente (aunque ya se
+	// haya entregado) con los intentos en cero
+	admin.POST("/webhooks/deliveries/:id/replay", func(c *gin.Context) {
+		deliveryID, ok := id(c)
+		if !ok {
+			return
+		}
+		var d store.Delivery
+		err := cfg.writeAudited(c, db, func(tx *sql.Tx) (change, error) {
+			repo := store.Bind(tx).Webhooks()
+			old, err := repo.Delivery(c.Request.Context(), deliveryID)
+			if err != nil {
+				return change{}, err
+			}
+			if _, err := repo.Replay(c.Request.Context(), deliveryID); err != nil {
+				return change{}, err
+			}
+			if d, err = repo.Delivery(c.Request.Context(), deliveryID); err != nil {
+				return change{}, err
+			}
+			return change{Action: "webhook.replay", Entity: "webhook_delivery", EntityID: deliveryID,
+				Before: gin.H{"status": old.Status, "attempts": old.Attempts}, After: gin.H{"status": d.Status}}, nil
+		})
+		if errors.Is(err, store.ErrNotFound) {
+			abort(c, http.StatusNotFound, CodeNotFound, "entrega no existe")
+			return
+		}
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, d)
+	})
+
+	// POST /admin/webhooks/:id/replay  -> reenvía todas las entregas muertas del webhook
+	admin.POST("/webhooks/:id/replay", func(c *gin.Context) {
+		hookID, ok := id(c)
+		if !ok {
+			return
+		}
+		var n int64
+		err := cfg.writeAudited(c, db, func(tx *sql.Tx) (change, error) {
+			repo := store.Bind(tx).Webhooks()
+			if _, err := repo.Get(c.Request.Context(), hookID); err != nil {
+				return change{}, err
+			}
+			var err error
+			if n, err = repo.ReplayDead(c.Request.Context(), hookID); err != nil || n == 0 {
+				return change{}, err
+			}
+			return change{Action: "webhook.replay", Entity: "webhook", EntityID: hookID, After: gin.H{"replayed": n}}, nil
+		})
+		if errors.Is(err, store.ErrNotFound) {
+			abort(c, http.StatusNotFound, CodeNotFound, "webhook no existe")
+			return
+		}
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"webhook_id": hookID, "replayed": n})
+	})
+
+	// POST /admin/webhooks/deliver  -> envía ya las entregas pendientes que tocan, sin esperar
+	// al despachador
+	admin.POST("/webhooks/deliver", func(c *gin.Context) {
+		res, err := cfg.dispatcher.Deliver(context.WithoutCancel(c.Request.Context()))
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, res)
+	})
+}
+
+// dedupe quita eventos repetidos conservando el orden.
+func dedupe(events []string) []string {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,260 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/webhooks"
+)
+
+// receiver es un endpoint de webhooks que verifica la firma con secret y guarda los eventos.
+type receiver struct {
+	*httptest.Server
+	secret string
+
+	mu     sync.Mutex
+	events []webhooks.Envelope
+	status int
+}
+
+func newReceiver(t *testing.T, secret string) *receiver {
+	rc := &receiver{secret: secret, status: http.StatusOK}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhooks.HeaderTimestamp), 10, 64)
+		if got := r.Header.Get(webhooks.HeaderSignature); got != webhooks.Sign(rc.secret, ts, body) {
+			t.Errorf("firma inválida: %s", got)
+		}
+		var env webhooks.Envelope
+		if err := json.Unmarshal(body, &env); err != nil {
+			t.Error(err)
+		}
+		rc.events = append(rc.events, env)
+		w.WriteHeader(rc.status)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+// set cambia el secreto y la respuesta del endpoint.
+func (rc *receiver) set(secret string, status int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.secret, rc.status = secret, status
+}
+
+// take devuelve los nombres de los eventos recibidos desde la última llamada.
+func (rc *receiver) take() string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	var names []string
+	for _, e := range rc.events {
+		names = append(names, e.Event)
+	}
+	rc.events = nil
+	return strings.Join(names, ",")
+}
+
+type deliverResult struct {
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+	Dead      int `json:"dead"`
+}
+
+func deliver(t *testing.T, s *apitest.Server) deliverResult {
+	t.Helper()
+	var res deliverResult
+	resp := s.Do(http.MethodPost, "/admin/webhooks/deliver", nil, "X-Admin-Token", "secreto")
+	if resp.Status != http.StatusOK {
+		t.Fatalf("deliver: %d %s", resp.Status, resp.Body)
+	}
+	resp.Decode(t, &res)
+	return res
+}
+
+func TestWebhooks(t *testing.T) {
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	s := apitest.NewServer(t)
+	admin := []string{"X-Admin-Token", "secreto"}
+	rc := newReceiver(t, "")
+
+	var hook struct {
+		ID     int64    `json:"id"`
+		Secret string   `json:"secret"`
+		Events []string `json:"events"`
+		Active bool     `json:"active"`
+	}
+	all := []string{"sale.created", "loan.created", "loan.returned", "book.out_of_stock", "user.created"}
+	resp := s.Do(http.MethodPost, "/admin/webhooks", map[string]any{"url": rc.URL, "events": all}, admin...)
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("POST /admin/webhooks: %d %s", resp.Status, resp.Body)
+	}
+	resp.Decode(t, &hook)
+	if !strings.HasPrefix(hook.Secret, "whsec_") || !hook.Active || len(hook.Events) != 5 {
+		t.Fatalf("webhook = %+v", hook)
+	}
+	rc.set(hook.Secret, http.StatusOK)
+	// otro webhook, solo de ventas, con su propio secreto
+	sales := newReceiver(t, "0123456789abcdef")
+	s.Do(http.MethodPost, "/admin/webhooks", map[string]any{"url": sales.URL, "events": []string{"sale.created"}, "secret": sales.secret}, admin...)
+
+	var list struct {
+		Webhooks []map[string]any `json:"webhooks"`
+	}
+	s.Do(http.MethodGet, "/admin/webhooks", nil, admin...).Decode(t, &list)
+	if len(list.Webhooks) != 2 || list.Webhooks[0]["secret"] != nil {
+		t.Errorf("GET /admin/webhooks = %+v", list.Webhooks)
+	}
+	for _, body := range []map[string]any{
+		{"url": "ftp://example.org", "events": all},
+		{"url": rc.URL, "events": []string{"book.deleted"}},
+		{"url": rc.URL, "events": []string{}},
+	} {
+		if resp := s.Do(http.MethodPost, "/admin/webhooks", body, admin...); resp.Status != http.StatusUnprocessableEntity {
+			t.Errorf("%v: %d", body, resp.Status)
+		}
+	}
+
+	// cada cambio publica sus eventos; la venta del único ejemplar también agota el libro
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	s.Do(http.MethodPost, "/users", map[string]any{"first_name": "Ana", "last_name": "Pérez", "email": "ana@usm.cl", "password": "clave"}).Decode(t, &user)
+	s.Do(http.MethodPatch, fmt.Sprintf("/users/%d", user.ID), map[string]any{"abonar": 100})
+	book := apitest.NewBook().Stock(1).Price(10).Insert(t, s.DB)
+	rent := apitest.NewBook().ForLoan().Stock(5).Insert(t, s.DB)
+	var loan struct {
+		ID int64 `json:"id"`
+	}
+	s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user.ID, "book_id": book})
+	s.Do(http.MethodPost, "/loans", map[string]any{"user_id": user.ID, "book_id": rent}).Decode(t, &loan)
+	s.Do(http.MethodPatch, fmt.Sprintf("/loans/%d/return", loan.ID), map[string]any{})
+	// una venta que falla no publica nada
+	if resp := s.Do(http.MethodPost, "/sales", map[string]any{"user_id": user.ID, "book_id": book}); resp.Status < 400 {
+		t.Fatalf("venta sin stock: %d", resp.Status)
+	}
+
+	if res := deliver(t, s); res != (deliverResult{Delivered: 6}) {
+		t.Errorf("primera pasada = %+v", res)
+	}
+	if got := rc.take(); got != "user.created,book.out_of_stock,sale.created,loan.created,loan.returned" {
+		t.Errorf("eventos = %s", got)
+	}
+	if got := sales.take(); got != "sale.created" {
+		t.Errorf("eventos del webhook de ventas = %s", got)
+	}
+	if res := deliver(t, s); res != (deliverResult{}) {
+		t.Errorf("nada pendiente: %+v", res)
+	}
+	var (
+		payload string
+		u       map[string]any
+	)
+	if err := s.DB.QueryRow(`SELECT payload FROM outbox WHERE event='user.created'`).Scan(&payload); err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal([]byte(payload), &u)
+	if u["email"] != "ana@usm.cl" || u["password"] != nil {
+		t.Errorf("payload de user.created = %v", u)
+	}
+
+	// el endpoint falla: queda pendiente con backoff y, al agotar los intentos, muerto
+	rc.set(hook.Secret, http.StatusServiceUnavailable)
+	s.Do(http.MethodPost, "/users", map[string]any{"first_name": "Beto", "last_name": "Soto", "email": "beto@usm.cl", "password": "clave"})
+	if res := deliver(t, s); res != (deliverResult{Failed: 1}) {
+		t.Errorf("con el endpoint caído = %+v", res)
+	}
+	if res := deliver(t, s); res != (deliverResult{}) {
+		t.Errorf("reintentó antes del backoff: %+v", res)
+	}
+	// como si hubieran pasado los reintentos: falta el último
+	if _, err := s.DB.Exec(`UPDATE webhook_deliveries SET attempts=?, next_attempt_at=NULL WHERE status='pendiente'`, webhooks.MaxAttempts-1); err != nil {
+		t.Fatal(err)
+	}
+	if res := deliver(t, s); res != (deliverResult{Dead: 1}) {
+		t.Errorf("último intento = %+v", res)
+	}
+	var dead struct {
+		Deliveries []struct {
+			ID         int64  `json:"id"`
+			WebhookID  int64  `json:"webhook_id"`
+			Event      string `json:"event"`
+			Status     string `json:"status"`
+			LastStatus int    `json:"last_status"`
+		} `json:"deliveries"`
+	}
+	s.Do(http.MethodGet, "/admin/webhooks/dead-letters", nil, admin...).Decode(t, &dead)
+	if len(dead.Deliveries) != 1 || dead.Deliveries[0].WebhookID != hook.ID || dead.Deliveries[0].Event != "user.created" ||
+		dead.Deliveries[0].LastStatus != http.StatusServiceUnavailable {
+		t.Fatalf("dead-letters = %+v", dead.Deliveries)
+	}
+
+	// replay de las muertas del webhook, con el endpoint de vuelta
+	rc.set(hook.Secret, http.StatusOK)
+	rc.take()
+	var replay struct {
+		Replayed int64 `json:"replayed"`
+	}
+	s.Do(http.MethodPost, fmt.Sprintf("/admin/webhooks/%d/replay", hook.ID), nil, admin...).Decode(t, &replay)
+	if replay.Replayed != 1 {
+		t.Errorf("replayed = %d", replay.Replayed)
+	}
+	if res := deliver(t, s); res != (deliverResult{Delivered: 1}) || rc.take() != "user.created" {
+		t.Errorf("tras el replay = %+v", res)
+	}
+	// replay de una sola entrega, aunque ya se haya entregado
+	resp = s.Do(http.MethodPost, fmt.Sprintf("/admin/webhooks/deliveries/%d/replay", dead.Deliveries[0].ID), nil, admin...)
+	if resp.Status != http.StatusOK || !strings.Contains(string(resp.Body), `"status":"pendiente"`) {
+		t.Errorf("replay de la entrega: %d %s", resp.Status, resp.Body)
+	}
+	if res := deliver(t, s); res != (deliverResult{Delivered: 1}) || rc.take() != "user.created" {
+		t.Errorf("tras el replay de la entrega = %+v", res)
+	}
+	if resp := s.Do(http.MethodPost, "/admin/webhooks/deliveries/999/replay", nil, admin...); resp.Status != http.StatusNotFound {
+		t.Errorf("replay de entrega inexistente: %d", resp.Status)
+	}
+	var deliveries struct {
+		Deliveries []struct {
+			Status string `json:"status"`
+		} `json:"deliveries"`
+	}
+	s.Do(http.MethodGet, fmt.Sprintf("/admin/webhooks/%d/deliveries?status=entregado&limit=3", hook.ID), nil, admin...).Decode(t, &deliveries)
+	if len(deliveries.Deliveries) != 3 {
+		t.Errorf("entregas = %+v", deliveries.Deliveries)
+	}
+
+	// pausado no recibe eventos nuevos; borrado se lleva sus entregas
+	resp = s.Do(http.MethodPatch, fmt.Sprintf("/admin/webhooks/%d", hook.ID), map[string]any{"active": false}, admin...)
+	if resp.Status != http.StatusOK || !strings.Contains(string(resp.Body), `"active":false`) {
+		t.Errorf("PATCH: %d %s", resp.Status, resp.Body)
+	}
+	s.Do(http.MethodPost, "/users", map[string]any{"first_name": "Caro", "last_name": "Díaz", "email": "caro@usm.cl", "password": "clave"})
+	if res := deliver(t, s); res != (deliverResult{}) || rc.take() != "" {
+		t.Errorf("webhook pausado recibió: %+v", res)
+	}
+	if resp := s.Do(http.MethodDelete, fmt.Sprintf("/admin/webhooks/%d", hook.ID), nil, admin...); resp.Status != http.StatusNoContent {
+		t.Errorf("DELETE: %d", resp.Status)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id=?`, hook.ID); got != 0 {
+		t.Errorf("entregas tras borrar = %d", got)
+	}
+	if resp := s.Do(http.MethodGet, fmt.Sprintf("/admin/webhooks/%d/deliveries", hook.ID), nil, admin...); resp.Status != http.StatusNotFound {
+		t.Errorf("entregas de webhook borrado: %d", resp.Status)
+	}
+	if resp := s.Do(http.MethodGet, "/admin/webhooks", nil); resp.Status != http.StatusForbidden {
+		t.Errorf("sin token: %d", resp.Status)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM audit_log WHERE action LIKE 'webhook.%'`); got != 6 {
+		t.Errorf("webhook.* en audit_log = %d, want 6", got)
+	}
+}
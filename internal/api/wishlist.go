@@ -0,0 +1,128 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+type WishlistItem struct {
+	UserID    int64  `json:"user_id"`
+	CreatedAt string `json:"created_at"`
+	Book      Book   `json:"book"`
+}
+
+func registerWishlistRoutes(r gin.IRouter, db *sql.DB, cfg *config) {
+	st := store.New(db)
+
+	// POST /users/:id/wishlist {book_id}
+	r.POST("/users/:id/wishlist", func(c *gin.Context) {
+		userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			abort(c, http.StatusBadRequest, CodeInvalidParam, "id inválido")
+			return
+		}
+		var in struct {
+			BookID int64 `json:"book_id" binding:"required,gt=0"`
+		}
+		if !bindJSON(c, &in) {
+			return
+		}
+
+		now := cfg.clock.Now()
+		err = st.InTx(c.Request.Context(), func(tx store.Tx) error {
+			if err := service.AddWish(c.Request.Context(), tx, userID, in.BookID, now); err != nil {
+				return err
+			}
+			return cfg.audit(c, tx, change{Action: "wishlist.add", Entity: "user", EntityID: userID, UserID: userID,
+				After: gin.H{"book_id": in.BookID}})
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				abort(c, http.StatusConflict, CodeConflict, "el libro ya está en tu lista de deseos")
+				return
+			}
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"user_id": userID, "book_id": in.BookID, "created_at": now.UTC().Format(eventFmt)})
+	})
+
+	// GET /users/:id/wishlist
+	r.GET("/users/:id/wishlist", func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		wishes, err := st.Read().Wishlist().List(c.Request.Context(), userID)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		out := make([]WishlistItem, len(wishes))
+		for i, w := range wishes {
+			out[i] = WishlistItem{UserID: w.UserID, CreatedAt: w.CreatedAt, Book: bookOut(w.Listing)}
+		}
+		c.JSON(http.StatusOK, gin.H{"wishlist": out})
+	})
+
+	// DELETE /users/:id/wishlist/:book_id
+	r.DELETE("/users/:id/wishlist/:book_id", func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		bookID, _ := strconv.ParseInt(c.Param("book_id"), 10, 64)
+		var removed bool
+		err := st.InTx(c.Request.Context(), func(tx store.Tx) (err error) {
+			if removed, err = tx.Wishlist().Remove(c.Request.Context(), userID, bookID); err != nil || !removed {
+				return err
+			}
+			return cfg.audit(c, tx, change{Action: "wishlist.remove", Entity: "user", EntityID: userID, UserID: userID,
+				Before: gin.H{"book_id": bookID}})
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		if !removed {
+			abort(c, http.StatusNotFound, CodeNotFound, "el libro no está en tu lista de deseos")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// GET /users/:id/notifications?unread=1
+	r.GET("/users/:id/notifications", func(c *gin.Context) {
+		userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		out, err := st.Read().Notifications().List(c.Request.Context(), userID, c.Query("unread") == "1")
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"notifications": out})
+	})
+
+	// POST /users/:id/notifications/read {ids?: [..]}  -> sin ids marca todas
+	r.POST("/users/:id/notifications/read", func(c *gin.Context) {
+		var in struct {
+			IDs []int64 `json:"ids" binding:"dive,gt=0"`
+		}
+		if c.Request.ContentLength != 0 && !bindJSON(c, &in) {
+			return
+		}
+		userID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+		var n int64
+		err := st.InTx(c.Request.Context(), func(tx store.Tx) (err error) {
+			if n, err = tx.Notifications().MarkRead(c.Request.Context(), userID, in.IDs, cfg.clock.Now()); err != nil || n == 0 {
+				return err
+			}
+			return cfg.audit(c, tx, change{Action: "notifications.read", Entity: "user", EntityID: userID, UserID: userID,
+				After: gin.H{"marked": n, "ids": in.IDs}})
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"marked": n})
+	})
+}
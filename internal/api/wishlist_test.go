@@ -0,0 +1,154 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/clock"
+)
+
+type wishlistOut struct {
+	Wishlist []struct {
+		UserID    int64  `json:"user_id"`
+		CreatedAt string `json:"created_at"`
+		Book      struct {
+			ID        int64  `json:"id"`
+			BookName  string `json:"book_name"`
+			Status    string `json:"status"`
+			Inventory struct {
+				AvailableQuantity int64 `json:"available_quantity"`
+			} `json:"inventory"`
+		} `json:"book"`
+	} `json:"wishlist"`
+}
+
+func TestWishlist(t *testing.T) {
+	start := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewTravel(clock.Fixed(start))
+	s := apitest.NewServer(t, api.WithClock(clk))
+	user := apitest.NewUser().Insert(t, s.DB)
+	other := apitest.NewUser().Insert(t, s.DB)
+	soldOut := apitest.NewBook().Name("Rayuela").Stock(0).Insert(t, s.DB)
+	inStock := apitest.NewBook().Name("Ficciones").Stock(3).Insert(t, s.DB)
+	path := fmt.Sprintf("/users/%d/wishlist", user)
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		body   map[string]any
+		status int
+		code   string
+	}{
+		{"id inválido", "/users/abc/wishlist", map[string]any{"book_id": inStock}, http.StatusBadRequest, "invalid_param"},
+		{"sin book_id", path, map[string]any{}, http.StatusUnprocessableEntity, "validation_failed"},
+		{"usuario inexistente", "/users/999/wishlist", map[string]any{"book_id": inStock}, http.StatusNotFound, "not_found"},
+		{"libro inexistente", path, map[string]any{"book_id": 999}, http.StatusNotFound, "not_found"},
+	} {
+		resp := s.Do(http.MethodPost, tt.path, tt.body)
+		if resp.Status != tt.status || resp.APIError().Code != tt.code {
+			t.Errorf("%s: %d %s", tt.name, resp.Status, resp.Body)
+		}
+	}
+
+	// se guardan en el orden en que se agregan, también los agotados
+	if resp := s.Do(http.MethodPost, path, map[string]any{"book_id": soldOut}); resp.Status != http.StatusCreated {
+		t.Fatalf("agregar: %d %s", resp.Status, resp.Body)
+	}
+	clk.Advance(time.Minute)
+	s.Do(http.MethodPost, path, map[string]any{"book_id": inStock})
+	resp := s.Do(http.MethodPost, path, map[string]any{"book_id": inStock})
+	if resp.Status != http.StatusConflict || resp.APIError().Code != "conflict" {
+		t.Errorf("repetido: %d %s", resp.Status, resp.Body)
+	}
+
+	var list wishlistOut
+	s.Do(http.MethodGet, path, nil).Decode(t, &list)
+	if len(list.Wishlist) != 2 {
+		t.Fatalf("lista = %+v", list)
+	}
+	first, second := list.Wishlist[0], list.Wishlist[1]
+	if first.Book.ID != soldOut || first.Book.BookName != "Rayuela" || first.Book.Status != "Agotado" ||
+		first.UserID != user || first.CreatedAt != "2025-03-10T12:00:00Z" {
+		t.Errorf("primero = %+v", first)
+	}
+	if second.Book.ID != inStock || second.Book.Status != "Disponible" || second.Book.Inventory.AvailableQuantity != 3 ||
+		second.CreatedAt != "2025-03-10T12:01:00Z" {
+		t.Errorf("segundo = %+v", second)
+	}
+
+	// cada usuario ve solo la suya
+	list = wishlistOut{}
+	s.Do(http.MethodGet, fmt.Sprintf("/users/%d/wishlist", other), nil).Decode(t, &list)
+	if len(list.Wishlist) != 0 {
+		t.Errorf("lista ajena = %+v", list)
+	}
+
+	one := fmt.Sprintf("%s/%d", path, soldOut)
+	if resp := s.Do(http.MethodDelete, one, nil); resp.Status != http.StatusNoContent {
+		t.Fatalf("quitar: %d %s", resp.Status, resp.Body)
+	}
+	if resp := s.Do(http.MethodDelete, one, nil); resp.Status != http.StatusNotFound {
+		t.Errorf("quitar de nuevo: %d", resp.Status)
+	}
+	if got := s.QueryInt(`SELECT COUNT(*) FROM wishlists WHERE user_id=?`, user); got != 1 {
+		t.Errorf("quedan %d", got)
+	}
+	// agregar y quitar quedan en el audit_log; el intento repetido no
+	if got := s.QueryInt(`SELECT COUNT(*) FROM audit_log WHERE action LIKE 'wishlist.%'`); got != 3 {
+		t.Errorf("audit_log = %d, want 3", got)
+	}
+}
+
+func TestNotificationsRead(t *testing.T) {
+	s := apitest.NewServer(t)
+	fan := apitest.NewUser().Insert(t, s.DB)
+	books := []int64{
+		apitest.NewBook().Price(20).Stock(0).Insert(t, s.DB),
+		apitest.NewBook().Price(20).Stock(0).Insert(t, s.DB),
+	}
+	for _, b := range books {
+		s.Do(http.MethodPost, fmt.Sprintf("/users/%d/wishlist", fan), map[string]any{"book_id": b})
+		s.Do(http.MethodPatch, fmt.Sprintf("/books/%d", b), map[string]any{"available_quantity": 1})
+	}
+
+	var inbox struct {
+		Notifications []struct {
+			ID     int64  `json:"id"`
+			BookID int64  `json:"book_id"`
+			ReadAt string `json:"read_at"`
+		} `json:"notifications"`
+	}
+	path := fmt.Sprintf("/users/%d/notifications", fan)
+	s.Do(http.MethodGet, path+"?unread=1", nil).Decode(t, &inbox)
+	if len(inbox.Notifications) != 2 || inbox.Notifications[0].BookID != books[1] {
+		t.Fatalf("sin leer = %+v", inbox)
+	}
+
+	var marked struct {
+		Marked int64 `json:"marked"`
+	}
+	s.Do(http.MethodPost, path+"/read", map[string]any{"ids": []int64{inbox.Notifications[0].ID}}).Decode(t, &marked)
+	if marked.Marked != 1 {
+		t.Errorf("marcadas = %d, want 1", marked.Marked)
+	}
+	inbox.Notifications = nil
+	s.Do(http.MethodGet, path+"?unread=1", nil).Decode(t, &inbox)
+	if len(inbox.Notifications) != 1 || inbox.Notifications[0].BookID != books[0] {
+		t.Errorf("sin leer tras marcar una = %+v", inbox)
+	}
+
+	// sin cuerpo marca todas las que quedan
+	s.Do(http.MethodPost, path+"/read", nil).Decode(t, &marked)
+	if marked.Marked != 1 {
+		t.Errorf("marcadas = %d, want 1", marked.Marked)
+	}
+	inbox.Notifications = nil
+	s.Do(http.MethodGet, path, nil).Decode(t, &inbox)
+	if len(inbox.Notifications) != 2 || inbox.Notifications[0].ReadAt == "" || inbox.Notifications[1].ReadAt == "" {
+		t.Errorf("todas = %+v", inbox)
+	}
+}
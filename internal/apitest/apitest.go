@@ -0,0 +1,156 @@
+// Package apitest levanta la API completa sobre un SQLite en memoria para los tests
+// (handlers y CLI) y trae builders para sembrar usuarios, libros y préstamos.
+package apitest
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/db"
+)
+
+var dbSeq atomic.Int64
+
+// OpenDB crea una base en memoria, propia de este test, con el esquema migrado.
+func OpenDB(t testing.TB) *sql.DB {
+	t.Helper()
+	name := fmt.Sprintf("apitest%d", dbSeq.Add(1))
+	sqlDB, err := sql.Open(db.DriverName, "file:"+name+"?mode=memory&cache=shared&_txlock=immediate&_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// una sola conexión: la base en memoria vive mientras ella siga abierta
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+// OpenFileDB abre una base en archivo (temporal) con la misma configuración que producción
+// (db.Open: WAL, busy_timeout, BEGIN IMMEDIATE) y varias conexiones: para tests de concurrencia.
+func OpenFileDB(t testing.TB) *sql.DB {
+	t.Helper()
+	sqlDB, err := db.Open(filepath.Join(t.TempDir(), "uzm.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+// Server es la API corriendo en un httptest.Server.
+type Server struct {
+	*httptest.Server
+	DB *sql.DB
+	// Base es el prefijo que Do antepone a las rutas: api.V1Prefix, o "" para probar
+	// los alias antiguos de la raíz.
+	Base string
+	t    testing.TB
+}
+
+// NewServer registra todas las rutas sobre una base nueva y arranca el servidor.
+// opts se pasan a api.RegisterRoutes (ej. api.WithClock para fijar la fecha).
+func NewServer(t testing.TB, opts ...api.Option) *Server {
+	t.Helper()
+	return serve(t, OpenDB(t), opts)
+}
+
+// NewFileServer es NewServer sobre OpenFileDB.
+func NewFileServer(t testing.TB, opts ...api.Option) *Server {
+	t.Helper()
+	return serve(t, OpenFileDB(t), opts)
+}
+
+func serve(t testing.TB, sqlDB *sql.DB, opts []api.Option) *Server {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api.RegisterRoutes(r, sqlDB, opts...)
+	srv := httptest.NewServer(contract(t, r))
+	t.Cleanup(srv.Close)
+	return &Server{Server: srv, DB: sqlDB, Base: api.V1Prefix, t: t}
+}
+
+// Response es la respuesta ya leída.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Decode deserializa el cuerpo en v (falla el test si no se puede).
+func (r Response) Decode(t testing.TB, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("decode %s: %v", r.Body, err)
+	}
+}
+
+// APIError es el cuerpo de error estándar; vacío si la respuesta no es un error.
+func (r Response) APIError() api.APIError {
+	var body struct {
+		Error api.APIError `json:"error"`
+	}
+	json.Unmarshal(r.Body, &body)
+	return body.Error
+}
+
+// Do manda method Base+path con body (cualquier cosa serializable a JSON, o un string tal cual).
+func (s *Server) Do(method, path string, body any, headers ...string) Response {
+	s.t.Helper()
+	var rd io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		rd = strings.NewReader(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			s.t.Fatalf("marshal: %v", err)
+		}
+		rd = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, s.URL+s.Base+path, rd)
+	if err != nil {
+		s.t.Fatalf("request: %v", err)
+	}
+	if rd != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	return Response{Status: resp.StatusCode, Header: resp.Header, Body: raw}
+}
+
+// QueryInt corre una consulta de un solo entero (para verificar el estado de la base).
+func (s *Server) QueryInt(query string, args ...any) int64 {
+	s.t.Helper()
+	var n int64
+	if err := s.DB.QueryRow(query, args...).Scan(&n); err != nil {
+		s.t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
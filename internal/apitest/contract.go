@@ -0,0 +1,99 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/openapi"
+)
+
+var (
+	specOnce sync.Once
+	spec     *openapi.Document
+	specErr  error
+)
+
+// Spec es internal/api/openapi.json ya parseado.
+func Spec(t testing.TB) *openapi.Document {
+	t.Helper()
+	specOnce.Do(func() { spec, specErr = openapi.Parse(api.OpenAPISpec) })
+	if specErr != nil {
+		t.Fatalf("openapi.json: %v", specErr)
+	}
+	return spec
+}
+
+// contract envuelve el handler y revisa cada respuesta contra la especificación: la ruta
+// y el status deben estar declarados y el cuerpo JSON debe calzar con su schema. Así
+// cualquier test que use NewServer falla si un handler se aleja de openapi.json.
+func contract(t testing.TB, next http.Handler) http.Handler {
+	doc := Spec(t)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// /api/v1/books y el alias antiguo /books son la misma operación
+		path := r.URL.Path
+		if base := doc.BasePath(); strings.HasPrefix(path, base+"/") {
+			path = strings.TrimPrefix(path, base)
+		}
+		tmpl, op := doc.Match(r.Method, path)
+		if tmpl == "" {
+			return // NoRoute: 404 con el error estándar, fuera de la especificación
+		}
+		where := r.Method + " " + r.URL.Path
+		if op == nil {
+			if rec.status != http.StatusNotFound && rec.status != http.StatusMethodNotAllowed {
+				t.Errorf("contrato: %s respondió %d pero %s no declara %s", where, rec.status, tmpl, r.Method)
+			}
+			return
+		}
+		schema, ok := doc.ResponseSchema(op, rec.status)
+		if !ok {
+			t.Errorf("contrato: %s respondió %d, status no declarado en %s", where, rec.status, op.OperationID)
+			return
+		}
+		if schema == nil || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+			return
+		}
+		dec := json.NewDecoder(bytes.NewReader(rec.body.Bytes()))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			t.Errorf("contrato: %s (%d) no devolvió JSON: %v", where, rec.status, err)
+			return
+		}
+		for _, problem := range doc.Validate(schema, v) {
+			t.Errorf("contrato: %s (%d) %s: %s", where, rec.status, op.OperationID, problem)
+		}
+	})
+}
+
+// recorder deja pasar la respuesta y guarda una copia del status y el cuerpo.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush deja pasar los flush de los streams (GET /events).
+func (r *recorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,100 @@
+package apitest
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+var seq atomic.Int64
+
+// UserBuilder siembra un usuario directo en la base. Por defecto: saldo 0 y email único.
+type UserBuilder struct {
+	first, last, email, password string
+	balance                      int64
+}
+
+func NewUser() *UserBuilder {
+	n := seq.Add(1)
+	return &UserBuilder{first: "Ana", last: "Rojas", email: fmt.Sprintf("user%d@usm.cl", n), password: "clave123"}
+}
+
+func (b *UserBuilder) Email(s string) *UserBuilder    { b.email = s; return b }
+func (b *UserBuilder) Password(s string) *UserBuilder { b.password = s; return b }
+func (b *UserBuilder) Name(first, last string) *UserBuilder {
+	b.first, b.last = first, last
+	return b
+}
+func (b *UserBuilder) Balance(n int64) *UserBuilder { b.balance = n; return b }
+
+// Insert crea el usuario y devuelve su id.
+func (b *UserBuilder) Insert(t testing.TB, db *sql.DB) int64 {
+	t.Helper()
+	return insert(t, db, `INSERT INTO users(first_name,last_name,email,password,usm_pesos) VALUES(?,?,?,?,?)`,
+		b.first, b.last, b.email, b.password, b.balance)
+}
+
+// BookBuilder siembra un libro con su inventario. Por defecto: Venta, precio 10, stock 1.
+type BookBuilder struct {
+	name, category, kind, isbn, author string
+	price, stock                       int64
+}
+
+func NewBook() *BookBuilder {
+	return &BookBuilder{name: fmt.Sprintf("Libro %d", seq.Add(1)), category: "General", kind: "Venta", price: 10, stock: 1}
+}
+
+func (b *BookBuilder) Name(s string) *BookBuilder     { b.name = s; return b }
+func (b *BookBuilder) Category(s string) *BookBuilder { b.category = s; return b }
+func (b *BookBuilder) ForSale() *BookBuilder          { b.kind = "Venta"; return b }
+func (b *BookBuilder) ForLoan() *BookBuilder          { b.kind = "Arriendo"; return b }
+func (b *BookBuilder) Price(n int64) *BookBuilder     { b.price = n; return b }
+func (b *BookBuilder) Stock(n int64) *BookBuilder     { b.stock = n; return b }
+func (b *BookBuilder) ISBN(s string) *BookBuilder     { b.isbn = s; return b }
+func (b *BookBuilder) Author(s string) *BookBuilder   { b.author = s; return b }
+
+// Insert crea el libro y su inventario y devuelve el id.
+func (b *BookBuilder) Insert(t testing.TB, db *sql.DB) int64 {
+	t.Helper()
+	id := insert(t, db, `INSERT INTO books(book_name,book_category,transaction_type,price,isbn,author) VALUES(?,?,?,?,NULLIF(?,''),NULLIF(?,''))`,
+		b.name, b.category, b.kind, b.price, b.isbn, b.author)
+	insert(t, db, `INSERT INTO inventory(book_id,available_quantity) VALUES(?,?)`, id, b.stock)
+	return id
+}
+
+// LoanBuilder siembra un préstamo (no toca el inventario). Por defecto: pendiente desde el 01/01/2025.
+type LoanBuilder struct {
+	userID, bookID int64
+	start, ret     string
+}
+
+func NewLoan(userID, bookID int64) *LoanBuilder {
+	return &LoanBuilder{userID: userID, bookID: bookID, start: "01/01/2025"}
+}
+
+// Started fija la fecha de inicio (DD/MM/YYYY); vence un mes después.
+func (b *LoanBuilder) Started(date string) *LoanBuilder { b.start = date; return b }
+
+// Returned lo deja finalizado con esa fecha de devolución.
+func (b *LoanBuilder) Returned(date string) *LoanBuilder { b.ret = date; return b }
+
+func (b *LoanBuilder) Insert(t testing.TB, db *sql.DB) int64 {
+	t.Helper()
+	status, ret := "pendiente", any(nil)
+	if b.ret != "" {
+		status, ret = "finalizado", b.ret
+	}
+	return insert(t, db, `INSERT INTO loans(user_id,book_id,start_date,return_date,status) VALUES(?,?,?,?,?)`,
+		b.userID, b.bookID, b.start, ret, status)
+}
+
+func insert(t testing.TB, db *sql.DB, query string, args ...any) int64 {
+	t.Helper()
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		t.Fatalf("fixture: %v", err)
+	}
+	id, _ := res.LastInsertId()
+	return id
+}
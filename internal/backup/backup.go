@@ -0,0 +1,345 @@
+// Package backup saca copias en línea de la base SQLite (comprimidas con gzip y
+// verificadas), las rota según una política de retención y restaura una copia cuando el
+// servidor está abajo.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tarea1-uzm/internal/db"
+	"tarea1-uzm/internal/scheduler"
+)
+
+// Config es dónde quedan las copias y cuántas se guardan.
+type Config struct {
+	Dir string
+	// Keep son las copias más recientes que se guardan siempre.
+	Keep int
+	// Daily guarda además la última copia de cada uno de los últimos Daily días (0 = no).
+	Daily int
+	// Every es cada cuánto saca una copia el scheduler del servidor (0 = solo a mano).
+	Every time.Duration
+}
+
+// Valores por defecto de FromEnv.
+const (
+	DefaultDir   = "data/backups"
+	DefaultKeep  = 7
+	DefaultDaily = 14
+)
+
+// FromEnv lee la configuración de UZM_BACKUP_DIR, UZM_BACKUP_KEEP, UZM_BACKUP_DAILY y
+// UZM_BACKUP_EVERY (una duración de Go, ej. 6h).
+func FromEnv() (Config, error) {
+	cfg := Config{Dir: DefaultDir, Keep: DefaultKeep, Daily: DefaultDaily}
+	if v := os.Getenv("UZM_BACKUP_DIR"); v != "" {
+		cfg.Dir = v
+	}
+	for _, f := range []struct {
+		env string
+		dst *int
+		min int
+	}{{"UZM_BACKUP_KEEP", &cfg.Keep, 1}, {"UZM_BACKUP_DAILY", &cfg.Daily, 0}} {
+		if v := os.Getenv(f.env); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < f.min {
+				return cfg, fmt.Errorf("%s debe ser un entero mayor o igual a %d: %q", f.env, f.min, v)
+			}
+			*f.dst = n
+		}
+	}
+	if v := os.Getenv("UZM_BACKUP_EVERY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Minute {
+			return cfg, fmt.Errorf("UZM_BACKUP_EVERY debe ser una duración de al menos 1m: %q", v)
+		}
+		cfg.Every = d
+	}
+	return cfg, nil
+}
+
+// File es una copia en Dir.
+type File struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Result es lo que hizo Create: la copia nueva y las que borró la retención.
+type Result struct {
+	File
+	Deleted []string `json:"deleted"`
+}
+
+// nameLayout es el nombre de cada copia, con la hora UTC (al milisegundo) en que se sacó.
+const nameLayout = "uzm-20060102-150405.000.db.gz"
+
+// ErrNotFound lo devuelven Verify y Restore si la copia no existe.
+var ErrNotFound = errors.New("backup: la copia no existe")
+
+// mu evita dos copias a la vez en este proceso (ej. el job y POST /admin/backups).
+var mu sync.Mutex
+
+// Create saca una copia de sqlDB en cfg.Dir: la copia en línea, le corre integrity_check,
+// la comprime y después aplica la retención. now es la hora real (la del nombre).
+func Create(ctx context.Context, sqlDB *sql.DB, cfg Config, now time.Time) (Result, error) {
+	return create(ctx, sqlDB, cfg, now, true)
+}
+
+// create es Create; con prune=false no aplica la retención (la copia previa a un restore no
+// debe borrar otras, entre ellas quizás la que se está restaurando).
+func create(ctx context.Context, sqlDB *sql.DB, cfg Config, now time.Time, prune bool) (Result, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return Result{}, err
+	}
+	name := now.UTC().Format(nameLayout)
+	final := filepath.Join(cfg.Dir, name)
+	if _, err := os.Stat(final); err == nil {
+		return Result{}, fmt.Errorf("backup: ya existe %s", name)
+	}
+	raw := filepath.Join(cfg.Dir, ".tmp-"+strings.TrimSuffix(name, ".gz"))
+	os.Remove(raw) // un intento anterior que se cortó
+	defer os.Remove(raw)
+	if err := db.Backup(ctx, sqlDB, raw); err != nil {
+		return Result{}, err
+	}
+	if err := db.IntegrityCheck(raw); err != nil {
+		return Result{}, err
+	}
+	if err := compress(raw, final); err != nil {
+		return Result{}, err
+	}
+	info, err := os.Stat(final)
+	if err != nil {
+		return Result{}, err
+	}
+	res := Result{File: File{Name: name, Size: info.Size(), CreatedAt: now.UTC().Truncate(time.Millisecond)}}
+	if !prune {
+		return res, nil
+	}
+	res.Deleted, err = Prune(cfg, now)
+	return res, err
+}
+
+// compress escribe src con gzip en dst, pasando por un temporal para que dst nunca quede a
+// medias.
+func compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	zw := gzip.NewWriter(out)
+	zw.Name = strings.TrimSuffix(filepath.Base(dst), ".gz")
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// List devuelve las copias de dir, de la más nueva a la más vieja. Ignora los archivos que
+// no tienen el nombre de una copia.
+func List(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []File{}
+	for _, e := range entries {
+		at, err := time.Parse(nameLayout, e.Name())
+		if err != nil || !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, File{Name: e.Name(), Size: info.Size(), CreatedAt: at})
+	}
+	slices.SortFunc(out, func(a, b File) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return out, nil
+}
+
+// Prune borra las copias que la retención no guarda: quedan las cfg.Keep más nuevas y,
+// de cada uno de los últimos cfg.Daily días (UTC) antes de now, la última. Devuelve los
+// nombres borrados.
+func Prune(cfg Config, now time.Time) ([]string, error) {
+	files, err := List(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := now.UTC().AddDate(0, 0, -cfg.Daily)
+	days := map[string]bool{}
+	deleted := []string{}
+	for i, f := range files {
+		day := f.CreatedAt.Format(time.DateOnly)
+		newestOfDay := !days[day]
+		days[day] = true
+		if i < cfg.Keep || (cfg.Daily > 0 && newestOfDay && f.CreatedAt.After(cutoff)) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(cfg.Dir, f.Name)); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, f.Name)
+	}
+	return deleted, nil
+}
+
+// Path es la ruta de la copia name en dir; name no puede salir de dir.
+func Path(dir, name string) (string, error) {
+	if _, err := time.Parse(nameLayout, name); err != nil {
+		return "", ErrNotFound
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrNotFound
+	}
+	return path, nil
+}
+
+// Verify descomprime la copia en path a un temporal y revisa que sea una base de este
+// servidor íntegra.
+func Verify(path string) error {
+	tmp, err := decompress(path, filepath.Join(os.TempDir(), fmt.Sprintf("uzm-verify-%d.db", time.Now().UnixNano())))
+	if tmp != "" {
+		defer os.Remove(tmp)
+	}
+	if err != nil {
+		return err
+	}
+	return check(tmp)
+}
+
+// check revisa integridad y que el archivo tenga las tablas del servidor.
+func check(path string) error {
+	if err := db.IntegrityCheck(path); err != nil {
+		return err
+	}
+	sqlDB, err := sql.Open(db.DriverName, "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	var n int
+	err = sqlDB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name IN ('users','books','inventory')`).Scan(&n)
+	if err == nil && n != 3 {
+		err = errors.New("backup: no es una base de este servidor (faltan tablas)")
+	}
+	return err
+}
+
+// decompress descomprime src en dst y devuelve dst ("" si no llegó a crearlo).
+func decompress(src, dst string) (string, error) {
+	in, err := os.Open(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return "", fmt.Errorf("backup: %s no es un gzip: %w", filepath.Base(src), err)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, zr); err != nil {
+		out.Close()
+		return dst, fmt.Errorf("backup: %s está dañado: %w", filepath.Base(src), err)
+	}
+	return dst, out.Close()
+}
+
+// Restore reemplaza la base en dbPath por la copia en path. Se niega (db.ErrInUse) si el
+// servidor la tiene abierta. Antes de reemplazarla saca una copia de la base actual en
+// cfg.Dir (que vuelve como safety), así el restore también se puede deshacer; esa copia no
+// aplica la retención, no borra ninguna otra.
+func Restore(ctx context.Context, path, dbPath string, cfg Config) (safety *File, err error) {
+	lock, err := db.Lock(dbPath, true)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Close()
+
+	tmp, err := decompress(path, dbPath+".restore")
+	if tmp != "" {
+		defer os.Remove(tmp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := check(tmp); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(dbPath); err == nil {
+		current, err := db.Open(dbPath)
+		if err != nil {
+			return nil, err
+		}
+		res, err := create(ctx, current, cfg, time.Now(), false)
+		current.Close()
+		if err != nil {
+			return nil, fmt.Errorf("backup de la base actual: %w", err)
+		}
+		safety = &res.File
+	}
+	// un -wal que quedara de la base anterior se aplicaría sobre la restaurada
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return safety, err
+		}
+	}
+	return safety, os.Rename(tmp, dbPath)
+}
+
+// Job es el backup periódico para el scheduler del servidor (cfg.Every debe ser > 0).
+func Job(sqlDB *sql.DB, cfg Config) scheduler.Job {
+	return scheduler.Job{Name: "db.backup", Every: cfg.Every, Run: func(ctx context.Context, _ time.Time) (string, error) {
+		// la hora real: el nombre de la copia no se mueve con el viaje en el tiempo
+		res, err := Create(ctx, sqlDB, cfg, time.Now())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s (%d bytes), %d borradas", res.Name, res.Size, len(res.Deleted)), nil
+	}}
+}
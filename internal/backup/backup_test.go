@@ -0,0 +1,229 @@
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/db"
+)
+
+func openDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	sqlDB, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB
+}
+
+func countUsers(t *testing.T, sqlDB *sql.DB) int {
+	t.Helper()
+	var n int
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestCreateVerifyRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "uzm.db")
+	cfg := Config{Dir: filepath.Join(dir, "backups"), Keep: 5}
+	sqlDB := openDB(t, dbPath)
+	if _, err := sqlDB.Exec(`INSERT INTO users(first_name,last_name,email,password) VALUES('Ana','Pérez','ana@usm.cl','x')`); err != nil {
+		t.Fatal(err)
+	}
+
+	// en línea: la base sigue abierta (y en WAL) mientras se copia
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	res, err := Create(ctx, sqlDB, cfg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Name != "uzm-20260301-120000.000.db.gz" || res.Size == 0 || len(res.Deleted) != 0 {
+		t.Errorf("Create = %+v", res)
+	}
+	if _, err := Create(ctx, sqlDB, cfg, now); err == nil {
+		t.Error("dos copias con el mismo nombre")
+	}
+	path, err := Path(cfg.Dir, res.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(path); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	// con el servidor arriba (lock compartido) el restore se niega
+	if _, err := sqlDB.Exec(`INSERT INTO users(first_name,last_name,email,password) VALUES('Beto','Soto','beto@usm.cl','x')`); err != nil {
+		t.Fatal(err)
+	}
+	server, err := db.Lock(dbPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(ctx, path, dbPath, cfg); !errors.Is(err, db.ErrInUse) {
+		t.Fatalf("restore con el servidor arriba: %v", err)
+	}
+	server.Close()
+	sqlDB.Close()
+
+	safety, err := Restore(ctx, path, dbPath, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB = openDB(t, dbPath)
+	defer sqlDB.Close()
+	if n := countUsers(t, sqlDB); n != 1 {
+		t.Errorf("usuarios tras restaurar = %d", n)
+	}
+	// la base de antes (con Beto) quedó como copia
+	if safety == nil {
+		t.Fatal("sin copia de la base anterior")
+	}
+	undo, _ := Path(cfg.Dir, safety.Name)
+	if err := Verify(undo); err != nil {
+		t.Errorf("copia de seguridad: %v", err)
+	}
+
+	if _, err := Path(cfg.Dir, "../uzm.db"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Path fuera del directorio: %v", err)
+	}
+}
+
+// La copia previa al restore no aplica la retención: con Keep=1 borraría la que se restaura.
+func TestRestoreSkipsPrune(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "uzm.db")
+	cfg := Config{Dir: filepath.Join(dir, "backups"), Keep: 1}
+	sqlDB := openDB(t, dbPath)
+	res, err := Create(ctx, sqlDB, cfg, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	sqlDB.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, _ := Path(cfg.Dir, res.Name)
+	safety, err := Restore(ctx, path, dbPath, cfg)
+	if err != nil || safety == nil {
+		t.Fatalf("Restore: %v %v", safety, err)
+	}
+	files, err := List(cfg.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("copias = %+v, want la restaurada y la de seguridad", files)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte, gz bool) string {
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if !gz {
+			f.Write(data)
+			return path
+		}
+		zw := gzip.NewWriter(f)
+		zw.Write(data)
+		zw.Close()
+		return path
+	}
+	sqlDB := openDB(t, filepath.Join(dir, "uzm.db"))
+	res, err := Create(context.Background(), sqlDB, Config{Dir: dir, Keep: 1}, time.Now())
+	sqlDB.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	good, _ := os.ReadFile(filepath.Join(dir, res.Name))
+
+	other := filepath.Join(dir, "otra.db")
+	otherDB, err := db.Open(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherDB.Exec(`CREATE TABLE x(a)`)
+	otherDB.Exec(`PRAGMA journal_mode=DELETE`)
+	otherDB.Close()
+	otherRaw, _ := os.ReadFile(other)
+
+	for name, path := range map[string]string{
+		"no existe":    filepath.Join(dir, "nada.db.gz"),
+		"no es gzip":   write("plano.gz", []byte("hola"), false),
+		"cortado":      write("cortado.gz", good[:len(good)/2], false),
+		"no es sqlite": write("texto.gz", []byte(strings.Repeat("hola ", 1000)), true),
+		"otra base":    write("otra.gz", otherRaw, true),
+	} {
+		if err := Verify(path); err == nil {
+			t.Errorf("%s: Verify sin error", name)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	var names []string
+	for _, at := range []time.Time{
+		now.Add(-1 * time.Hour), now.Add(-2 * time.Hour), now.Add(-3 * time.Hour), // hoy
+		now.Add(-25 * time.Hour), now.Add(-26 * time.Hour), // ayer
+		now.AddDate(0, 0, -2), // antes de ayer
+		now.AddDate(0, 0, -5), // fuera de Daily
+	} {
+		name := at.Format(nameLayout)
+		names = append(names, name)
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644)
+	}
+	os.WriteFile(filepath.Join(dir, "notas.txt"), []byte("x"), 0o644)
+
+	deleted, err := Prune(Config{Dir: dir, Keep: 2, Daily: 3}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// quedan las dos más nuevas, la última de ayer y la de antes de ayer
+	if want := []string{names[2], names[4], names[6]}; !slices.Equal(deleted, want) {
+		t.Errorf("borradas = %v, want %v", deleted, want)
+	}
+	files, _ := List(dir)
+	if len(files) != 4 || files[0].Name != names[0] {
+		t.Errorf("quedan = %+v", files)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notas.txt")); err != nil {
+		t.Error("Prune borró un archivo que no es copia")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("UZM_BACKUP_KEEP", "3")
+	t.Setenv("UZM_BACKUP_EVERY", "6h")
+	cfg, err := FromEnv()
+	if err != nil || cfg != (Config{Dir: DefaultDir, Keep: 3, Daily: DefaultDaily, Every: 6 * time.Hour}) {
+		t.Errorf("FromEnv = %+v, %v", cfg, err)
+	}
+	for env, v := range map[string]string{"UZM_BACKUP_KEEP": "0", "UZM_BACKUP_DAILY": "-1", "UZM_BACKUP_EVERY": "10s"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, v)
+			if _, err := FromEnv(); err == nil {
+				t.Errorf("%s=%s sin error", env, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Package bulk lee y escribe los archivos de importación y exportación masiva: CSV con una
+// fila de encabezado, o JSON Lines (un objeto por línea). Las columnas las define quien
+// llama; aquí solo se traduce entre el formato y filas de valores.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formatos soportados.
+const (
+	CSV   = "csv"
+	JSONL = "jsonl"
+)
+
+var Formats = []string{CSV, JSONL}
+
+// ContentType es el Content-Type de cada formato.
+func ContentType(format string) string {
+	if format == JSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FormatOf reconoce el formato por su Content-Type; "" si no es ninguno.
+func FormatOf(contentType string) string {
+	mt, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mt)) {
+	case "text/csv", "application/csv":
+		return CSV
+	case "application/x-ndjson", "application/jsonl", "application/json-lines", "application/x-jsonlines":
+		return JSONL
+	}
+	return ""
+}
+
+// Writer escribe filas con las columnas dadas.
+type Writer struct {
+	columns []string
+	csv     *csv.Writer
+	jsonl   *bufio.Writer
+}
+
+// NewWriter empieza un archivo en format; en CSV escribe el encabezado.
+func NewWriter(w io.Writer, format string, columns []string) (*Writer, error) {
+	bw := &Writer{columns: columns}
+	switch format {
+	case CSV:
+		bw.csv = csv.NewWriter(w)
+		return bw, bw.csv.Write(columns)
+	case JSONL:
+		bw.jsonl = bufio.NewWriter(w)
+		return bw, nil
+	}
+	return nil, fmt.Errorf("bulk: formato %q desconocido", format)
+}
+
+// Write agrega una fila; vals va en el orden de las columnas. nil queda vacío en CSV y null
+// en JSON Lines.
+func (w *Writer) Write(vals []any) error {
+	if len(vals) != len(w.columns) {
+		return fmt.Errorf("bulk: %d valores para %d columnas", len(vals), len(w.columns))
+	}
+	if w.csv != nil {
+		rec := make([]string, len(vals))
+		for i, v := range vals {
+			if v != nil {
+				rec[i] = fmt.Sprint(v)
+			}
+		}
+		return w.csv.Write(rec)
+	}
+	// el objeto se arma a mano para conservar el orden de las columnas
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, v := range vals {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(w.columns[i])
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(raw)
+	}
+	buf.WriteString("}\n")
+	_, err := w.jsonl.Write(buf.Bytes())
+	return err
+}
+
+// Flush vacía lo pendiente y devuelve el primer error de escritura.
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return w.jsonl.Flush()
+}
+
+// Row es una fila leída: Line es su línea en el archivo (en CSV el encabezado es la 1) y
+// Fields trae solo las columnas que vinieron con valor.
+type Row struct {
+	Line   int
+	Fields map[string]string
+}
+
+// RowError es un problema de una fila; Field vacío = la fila entera.
+type RowError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e RowError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("línea %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("línea %d: %s %s", e.Line, e.Field, e.Message)
+}
+
+// Read lee todo el archivo. Las filas que no se pueden interpretar van a errs y el resto se
+// devuelve igual, para informar todos los problemas de una vez; err es solo para un archivo
+// que no se puede leer (ej. CSV sin encabezado).
+func Read(r io.Reader, format string) (rows []Row, errs []RowError, err error) {
+	switch format {
+	case CSV:
+		return readCSV(r)
+	case JSONL:
+		return readJSONL(r)
+	}
+	return nil, nil, fmt.Errorf("bulk: formato %q desconocido", format)
+}
+
+func readCSV(r io.Reader) ([]Row, []RowError, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("el archivo está vacío")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("encabezado: %w", err)
+	}
+	for i, h := range header { // sin el BOM que agrega Excel
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	}
+	var (
+		rows []Row
+		errs []RowError
+	)
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, errs, nil
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				// tras un error de comillas el lector ya no sabe dónde empieza la fila siguiente
+				errs = append(errs, RowError{Line: pe.StartLine, Message: pe.Err.Error()})
+				return rows, errs, nil
+			}
+			return nil, nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(rec) > len(header) {
+			errs = append(errs, RowError{Line: line, Message: fmt.Sprintf("tiene %d columnas y el encabezado %d", len(rec), len(header))})
+			continue
+		}
+		row := Row{Line: line, Fields: map[string]string{}}
+		for i, v := range rec {
+			if v = strings.TrimSpace(v); v != "" {
+				row.Fields[header[i]] = v
+			}
+		}
+		if len(row.Fields) > 0 {
+			rows = append(rows, row)
+		}
+	}
+}
+
+func readJSONL(r io.Reader) ([]Row, []RowError, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	var (
+		rows []Row
+		errs []RowError
+	)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.UseNumber()
+		var obj map[string]any
+		if err := dec.Decode(&obj); err != nil || obj == nil {
+			errs = append(errs, RowError{Line: line, Message: "no es un objeto JSON"})
+			continue
+		}
+		row, ok := Row{Line: line, Fields: map[string]string{}}, true
+		for k, v := range obj {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				if v = strings.TrimSpace(v); v != "" {
+					row.Fields[strings.ToLower(k)] = v
+				}
+			case json.Number:
+				row.Fields[strings.ToLower(k)] = v.String()
+			case bool:
+				row.Fields[strings.ToLower(k)] = strconv.FormatBool(v)
+			default:
+				errs = append(errs, RowError{Line: line, Field: k, Message: "debe ser un texto o un número"})
+				ok = false
+			}
+		}
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, errs, sc.Err()
+}
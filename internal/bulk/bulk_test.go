@@ -0,0 +1,80 @@
+package bulk
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	columns := []string{"id", "book_name", "isbn", "price"}
+	vals := [][]any{
+		{int64(1), "Rayuela", "9788437604572", int64(30)},
+		{int64(2), `Cien años, "de soledad"`, nil, int64(0)},
+	}
+	want := []Row{
+		{Fields: map[string]string{"id": "1", "book_name": "Rayuela", "isbn": "9788437604572", "price": "30"}},
+		{Fields: map[string]string{"id": "2", "book_name": `Cien años, "de soledad"`, "price": "0"}},
+	}
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, format, columns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range vals {
+			if err := w.Write(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if format == JSONL && !strings.HasPrefix(buf.String(), `{"id":1,"book_name":"Rayuela","isbn":"9788437604572","price":30}`+"\n") {
+			t.Errorf("jsonl = %s", buf.String())
+		}
+
+		rows, errs, err := Read(&buf, format)
+		if err != nil || len(errs) > 0 {
+			t.Fatalf("%s: %v %v", format, errs, err)
+		}
+		for i := range want {
+			want[i].Line = i + 2 // CSV: después del encabezado
+			if format == JSONL {
+				want[i].Line = i + 1
+			}
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("%s: filas = %+v, want %+v", format, rows, want)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	rows, errs, err := Read(strings.NewReader("\ufeffBook_Name,price\nRayuela,30\nFicciones,10,extra\n\n,\nAleph,\"5\n"), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Fields["book_name"] != "Rayuela" {
+		t.Errorf("filas = %+v", rows)
+	}
+	if len(errs) != 2 || errs[0].Line != 3 || errs[1].Line != 6 {
+		t.Errorf("errores = %+v", errs)
+	}
+
+	rows, errs, err = Read(strings.NewReader("{\"book_name\":\"Rayuela\",\"price\":30}\n[1]\n\n{\"book_name\":{\"x\":1}}\n{\"isbn\":null,\"price\":1.5}\n"), JSONL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1].Line != 5 || rows[1].Fields["price"] != "1.5" || len(rows[1].Fields) != 1 {
+		t.Errorf("filas = %+v", rows)
+	}
+	if len(errs) != 2 || errs[0].Line != 2 || errs[1].Field != "book_name" {
+		t.Errorf("errores = %+v", errs)
+	}
+
+	if _, _, err := Read(strings.NewReader(""), CSV); err == nil {
+		t.Error("CSV vacío sin error")
+	}
+}
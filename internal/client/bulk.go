@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Content-Type de cada formato de importación y exportación.
+var bulkTypes = map[string]string{"csv": "text/csv", "jsonl": "application/x-ndjson",
+	"marc": "application/marc", "marcxml": "application/marcxml+xml"}
+
+// ImportBooks sube el archivo r (format csv, jsonl, marc o marcxml) a POST /admin/books/import; con dryRun
+// solo se valida. Si alguna fila tiene errores devuelve un *Error 422 con la lista en
+// Details["errors"] (ver RowErrors).
+func (c *Client) ImportBooks(ctx context.Context, format string, r io.Reader, dryRun bool, opts ...Option) (*BookImportResult, error) {
+	q := url.Values{"format": {format}}
+	if dryRun {
+		q.Set("dry_run", "1")
+	}
+	resp, err := c.send(ctx, http.MethodPost, "/admin/books/import", q, bulkTypes[format], r, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out BookImportResult
+	return &out, json.NewDecoder(resp.Body).Decode(&out)
+}
+
+// RowError es un error de una fila de la importación.
+type RowError struct {
+	Line    int64  `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// RowErrors saca los errores por fila de un 422 de ImportBooks (nil si err no es eso).
+func RowErrors(err error) []RowError {
+	var e *Error
+	if !errors.As(err, &e) || e.Details["errors"] == nil {
+		return nil
+	}
+	raw, _ := json.Marshal(e.Details["errors"])
+	var out []RowError
+	if json.Unmarshal(raw, &out) != nil {
+		return nil
+	}
+	return out
+}
+
+// Export descarga GET /admin/<set>/export (set: books, users, sales o loans) en format y lo
+// copia a w. books además se puede pedir en marc, marcxml o dc.
+func (c *Client) Export(ctx context.Context, set, format string, w io.Writer, opts ...Option) error {
+	resp, err := c.send(ctx, http.MethodGet, "/admin/"+set+"/export", url.Values{"format": {format}}, "", nil, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
@@ -0,0 +1,1291 @@
+// Code generated by cmd/genclient from internal/api/openapi.json. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// basePath es el prefijo de todas las rutas (servers[0].url de la especificación).
+const basePath = "/api/v1"
+
+// APIError: #/components/schemas/APIError.
+type APIError struct {
+	Code      string         `json:"code"`    // código estable (invalid_json, validation_failed, not_found, conflict, ...)
+	Message   string         `json:"message"` // para mostrar
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+// AppliedPromotion: #/components/schemas/AppliedPromotion.
+type AppliedPromotion struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Code     string `json:"code,omitempty"`
+	Discount int64  `json:"discount"`
+}
+
+// AuditCheck: Resultado de recorrer la cadena de hashes del audit_log.
+type AuditCheck struct {
+	Ok       bool   `json:"ok"`
+	Entries  int64  `json:"entries"`             // entradas íntegras revisadas
+	Head     string `json:"head"`                // hash de la última entrada íntegra
+	BrokenAt int64  `json:"broken_at,omitempty"` // id de la primera entrada alterada
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AuditEntry: Entrada del audit_log: quién hizo qué, antes y después. hash = sha256 de prev_hash y los demás campos (salvo id).
+type AuditEntry struct {
+	ID        int64  `json:"id"`
+	CreatedAt string `json:"created_at"` // RFC3339 UTC
+	Actor     string `json:"actor"`      // admin | user:<id> | anónimo
+	Action    string `json:"action"`     // ej. book.update, user.update, sale.create, loan.return
+	Entity    string `json:"entity"`
+	EntityID  int64  `json:"entity_id,omitempty"`
+	Before    any    `json:"before,omitempty"` // campos que cambió, como estaban (sin contraseñas)
+	After     any    `json:"after,omitempty"`  // campos que cambió, como quedaron (sin contraseñas)
+	RequestID string `json:"request_id,omitempty"`
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash"`
+}
+
+// AuditList: #/components/schemas/AuditList.
+type AuditList struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+// Backup: #/components/schemas/Backup.
+type Backup struct {
+	Name      string `json:"name"`       // uzm-AAAAMMDD-HHMMSS.mmm.db.gz (hora UTC)
+	SizeBytes int64  `json:"size_bytes"` // tamaño comprimido
+	CreatedAt string `json:"created_at"`
+}
+
+// BackupList: #/components/schemas/BackupList.
+type BackupList struct {
+	Backups []Backup `json:"backups"` // de la más nueva a la más vieja
+	Keep    int64    `json:"keep"`    // copias más recientes que se guardan siempre (UZM_BACKUP_KEEP)
+	Daily   int64    `json:"daily"`   // días de los que se guarda además la última copia (UZM_BACKUP_DAILY)
+}
+
+// BackupResult: Copia nueva, ya verificada con integrity_check.
+type BackupResult struct {
+	Name      string   `json:"name"`       // uzm-AAAAMMDD-HHMMSS.mmm.db.gz (hora UTC)
+	SizeBytes int64    `json:"size_bytes"` // tamaño comprimido
+	CreatedAt string   `json:"created_at"`
+	Deleted   []string `json:"deleted"` // copias que borró la retención
+}
+
+// BackupVerification: #/components/schemas/BackupVerification.
+type BackupVerification struct {
+	Name  string `json:"name"`
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"` // por qué la copia no sirve (solo si ok es false)
+}
+
+// Book: #/components/schemas/Book.
+type Book struct {
+	ID              int64     `json:"id"`
+	Isbn            string    `json:"isbn,omitempty"` // ISBN-10 o ISBN-13 normalizado (solo dígitos y X); no viene si el libro no tiene
+	BookName        string    `json:"book_name"`
+	Author          string    `json:"author,omitempty"` // autor principal (MARC 100); no viene si no se conoce
+	BookCategory    string    `json:"book_category"`
+	TransactionType string    `json:"transaction_type"`
+	Price           int64     `json:"price"`
+	Status          string    `json:"status"`
+	PopularityScore int64     `json:"popularity_score"`
+	TrendingScore   float64   `json:"trending_score,omitempty"` // solo en /books/trending
+	AverageRating   float64   `json:"average_rating"`           // promedio de reseñas visibles
+	ReviewCount     int64     `json:"review_count"`
+	Inventory       Inventory `json:"inventory"`
+}
+
+// BookImportResult: Resultado de una importación sin errores (con errores responde 422 y details.errors lista cada uno: {line, field, message}).
+type BookImportResult struct {
+	DryRun    bool  `json:"dry_run"` // true = solo se validó, no se guardó nada
+	Rows      int64 `json:"rows"`
+	Created   int64 `json:"created"`
+	Updated   int64 `json:"updated"`
+	Unchanged int64 `json:"unchanged"`
+}
+
+// BookList: #/components/schemas/BookList.
+type BookList struct {
+	Books []Book `json:"books"`
+}
+
+// CartRequest: #/components/schemas/CartRequest.
+type CartRequest struct {
+	UserID  int64   `json:"user_id"`
+	BookIDs []int64 `json:"book_ids"`
+	Code    *string `json:"code,omitempty"`
+}
+
+// CheckoutResult: #/components/schemas/CheckoutResult.
+type CheckoutResult struct {
+	Sales []Sale `json:"sales"`
+	Quote Quote  `json:"quote"`
+}
+
+// Clock: #/components/schemas/Clock.
+type Clock struct {
+	Now    string `json:"now"`              // RFC3339 UTC
+	Today  string `json:"today"`            // DD/MM/YYYY
+	Zone   string `json:"zone"`             // ej. America/Santiago
+	Travel bool   `json:"travel"`           // true si el server partió con UZM_TIME_TRAVEL=1
+	Offset string `json:"offset,omitempty"` // respecto de la hora real (solo en viaje)
+	Frozen bool   `json:"frozen,omitempty"`
+}
+
+// CreateBookRequest: #/components/schemas/CreateBookRequest.
+type CreateBookRequest struct {
+	Isbn              *string `json:"isbn,omitempty"` // ISBN-10 o ISBN-13, con o sin guiones; se valida el dígito de control
+	BookName          string  `json:"book_name"`
+	Author            *string `json:"author,omitempty"`
+	BookCategory      string  `json:"book_category"`
+	TransactionType   string  `json:"transaction_type"`
+	Price             *int64  `json:"price,omitempty"`
+	AvailableQuantity *int64  `json:"available_quantity,omitempty"`
+}
+
+// CreatePromotionRequest: #/components/schemas/CreatePromotionRequest.
+type CreatePromotionRequest struct {
+	Name           string  `json:"name"`
+	Code           *string `json:"code,omitempty"` // vacío = se aplica sola
+	Kind           string  `json:"kind"`
+	Value          *int64  `json:"value,omitempty"` // % (porcentaje) o usm pesos (monto)
+	Category       *string `json:"category,omitempty"`
+	BuyQty         *int64  `json:"buy_qty,omitempty"`           // bundle: lleva buy_qty...
+	PayQty         *int64  `json:"pay_qty,omitempty"`           // ...y paga pay_qty
+	StartsAt       string  `json:"starts_at"`                   // DD/MM/YYYY
+	EndsAt         string  `json:"ends_at"`                     // DD/MM/YYYY
+	MaxUses        *int64  `json:"max_uses,omitempty"`          // 0 = sin límite
+	MaxUsesPerUser *int64  `json:"max_uses_per_user,omitempty"` // 0 = sin límite
+	Active         *bool   `json:"active,omitempty"`
+}
+
+// CreateReviewRequest: #/components/schemas/CreateReviewRequest.
+type CreateReviewRequest struct {
+	UserID  int64   `json:"user_id"`
+	Rating  int64   `json:"rating"`
+	Comment *string `json:"comment,omitempty"`
+}
+
+// CreateUserRequest: #/components/schemas/CreateUserRequest.
+type CreateUserRequest struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+}
+
+// CreateWebhookRequest: #/components/schemas/CreateWebhookRequest.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"` // URL http(s)
+	Events []string `json:"events"`
+	Secret *string  `json:"secret,omitempty"` // si no viene se genera uno
+}
+
+// ErrorResponse: #/components/schemas/ErrorResponse.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// Health: #/components/schemas/Health.
+type Health struct {
+	Status string `json:"status"`
+}
+
+// Inventory: #/components/schemas/Inventory.
+type Inventory struct {
+	AvailableQuantity int64 `json:"available_quantity"`
+}
+
+// Job: #/components/schemas/Job.
+type Job struct {
+	Name    string `json:"name"`  // ej. loans.overdue
+	Every   string `json:"every"` // intervalo, ej. 1h0m0s
+	LastRun JobRun `json:"last_run,omitempty"`
+}
+
+// JobList: #/components/schemas/JobList.
+type JobList struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// JobRun: Una ejecución de un job programado.
+type JobRun struct {
+	ID         int64  `json:"id"`
+	Job        string `json:"job"`
+	Instance   string `json:"instance"` // servidor que lo corrió
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"` // resumen de lo que hizo
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at,omitempty"`
+}
+
+// JobRunList: #/components/schemas/JobRunList.
+type JobRunList struct {
+	Job  string   `json:"job"`
+	Runs []JobRun `json:"runs"`
+}
+
+// Loan: #/components/schemas/Loan.
+type Loan struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	BookID     int64  `json:"book_id"`
+	StartDate  string `json:"start_date"`          // DD/MM/YYYY
+	ReturnDate string `json:"return_date"`         // DD/MM/YYYY; vacío si sigue sin devolver
+	Status     string `json:"status"`              // vencido lo marca el job loans.overdue cuando pasa due_date
+	DueDate    string `json:"due_date,omitempty"`  // DD/MM/YYYY
+	DaysLeft   int64  `json:"days_left,omitempty"` // solo si sigue sin devolver (negativo si está vencido)
+	DaysLate   int64  `json:"days_late,omitempty"` // al devolver
+	Penalty    int64  `json:"penalty,omitempty"`   // al devolver: 2 usm pesos por día de atraso
+	Fine       int64  `json:"fine,omitempty"`      // multa acumulada mientras está vencido (job loans.fines); al devolver, la cobrada
+}
+
+// LoanList: #/components/schemas/LoanList.
+type LoanList struct {
+	Loans []Loan `json:"loans"`
+}
+
+// LoanRequest: #/components/schemas/LoanRequest.
+type LoanRequest struct {
+	UserID int64 `json:"user_id"`
+	BookID int64 `json:"book_id"`
+}
+
+// LoginRequest: #/components/schemas/LoginRequest.
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// MarkReadRequest: #/components/schemas/MarkReadRequest.
+type MarkReadRequest struct {
+	IDs []int64 `json:"ids,omitempty"` // sin ids marca todas
+}
+
+// MarkReadResult: #/components/schemas/MarkReadResult.
+type MarkReadResult struct {
+	Marked int64 `json:"marked"`
+}
+
+// ModerateReviewRequest: #/components/schemas/ModerateReviewRequest.
+type ModerateReviewRequest struct {
+	Status string `json:"status"`
+}
+
+// Notification: #/components/schemas/Notification.
+type Notification struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	BookID    int64  `json:"book_id,omitempty"`
+	Kind      string `json:"kind"` // precio | stock | vence_pronto | vence_hoy | vencido
+	Message   string `json:"message"`
+	CreatedAt string `json:"created_at"`        // RFC3339 UTC
+	ReadAt    string `json:"read_at,omitempty"` // RFC3339 UTC
+}
+
+// NotificationList: #/components/schemas/NotificationList.
+type NotificationList struct {
+	Notifications []Notification `json:"notifications"`
+}
+
+// Promotion: #/components/schemas/Promotion.
+type Promotion struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	Code           string `json:"code,omitempty"` // vacío = se aplica sola
+	Kind           string `json:"kind"`
+	Value          int64  `json:"value"` // % (porcentaje) o usm pesos (monto)
+	Category       string `json:"category,omitempty"`
+	BuyQty         int64  `json:"buy_qty,omitempty"` // bundle: lleva buy_qty...
+	PayQty         int64  `json:"pay_qty,omitempty"` // ...y paga pay_qty
+	StartsAt       string `json:"starts_at"`         // DD/MM/YYYY
+	EndsAt         string `json:"ends_at"`           // DD/MM/YYYY
+	MaxUses        int64  `json:"max_uses"`          // 0 = sin límite
+	MaxUsesPerUser int64  `json:"max_uses_per_user"` // 0 = sin límite
+	Uses           int64  `json:"uses"`
+	Active         bool   `json:"active"`
+}
+
+// PromotionList: #/components/schemas/PromotionList.
+type PromotionList struct {
+	Promotions []Promotion `json:"promotions"`
+}
+
+// Quote: #/components/schemas/Quote.
+type Quote struct {
+	Lines     []QuoteLine        `json:"lines"`
+	Subtotal  int64              `json:"subtotal"`
+	Discount  int64              `json:"discount"`
+	Total     int64              `json:"total"`
+	Applied   []AppliedPromotion `json:"applied"`
+	CodeError string             `json:"code_error,omitempty"` // solo en /sales/quote: el código no aplica y se cotizó sin él
+}
+
+// QuoteLine: #/components/schemas/QuoteLine.
+type QuoteLine struct {
+	BookID       int64  `json:"book_id"`
+	BookName     string `json:"book_name"`
+	BookCategory string `json:"book_category"`
+	Price        int64  `json:"price"`
+	Discount     int64  `json:"discount"`
+	Final        int64  `json:"final"`
+}
+
+// Recommendation: #/components/schemas/Recommendation.
+type Recommendation struct {
+	Book   Book    `json:"book"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// RecommendationList: #/components/schemas/RecommendationList.
+type RecommendationList struct {
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+// RecomputeResult: #/components/schemas/RecomputeResult.
+type RecomputeResult struct {
+	Status  string   `json:"status"`
+	Windows []string `json:"windows"`
+}
+
+// ReminderChannels: true = el usuario recibe recordatorios de préstamos por ese canal.
+type ReminderChannels struct {
+	Email   bool `json:"email"`
+	Webhook bool `json:"webhook"`
+	Inbox   bool `json:"inbox"`
+}
+
+// ReminderSettings: #/components/schemas/ReminderSettings.
+type ReminderSettings struct {
+	UserID   int64            `json:"user_id"`
+	Channels ReminderChannels `json:"channels"`
+}
+
+// ReturnLoanRequest: #/components/schemas/ReturnLoanRequest.
+type ReturnLoanRequest struct {
+	ReturnDate *string `json:"return_date,omitempty"` // DD/MM/YYYY; sin fecha = hoy según el server
+}
+
+// Review: #/components/schemas/Review.
+type Review struct {
+	ID        int64  `json:"id"`
+	BookID    int64  `json:"book_id"`
+	UserID    int64  `json:"user_id"`
+	UserName  string `json:"user_name"` // "Nombre A."
+	Rating    int64  `json:"rating"`
+	Comment   string `json:"comment"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"` // RFC3339 UTC
+	UpdatedAt string `json:"updated_at"` // RFC3339 UTC
+}
+
+// ReviewList: #/components/schemas/ReviewList.
+type ReviewList struct {
+	Reviews []Review `json:"reviews"`
+}
+
+// Sale: #/components/schemas/Sale.
+type Sale struct {
+	ID       int64  `json:"id"`
+	UserID   int64  `json:"user_id"`
+	BookID   int64  `json:"book_id"`
+	SaleDate string `json:"sale_date"` // DD/MM/YYYY
+	Price    int64  `json:"price"`     // precio de lista al momento de la venta
+	Discount int64  `json:"discount"`  // descuento aplicado por promociones
+}
+
+// SaleList: #/components/schemas/SaleList.
+type SaleList struct {
+	Sales []Sale `json:"sales"`
+}
+
+// SaleRequest: #/components/schemas/SaleRequest.
+type SaleRequest struct {
+	UserID int64   `json:"user_id"`
+	BookID int64   `json:"book_id"`
+	Code   *string `json:"code,omitempty"`
+}
+
+// SetClockRequest: #/components/schemas/SetClockRequest.
+type SetClockRequest struct {
+	At      *string `json:"at,omitempty"`      // DD/MM/YYYY o RFC3339
+	Advance *string `json:"advance,omitempty"` // duración: "72h", "-90m" o "3d"
+	Freeze  *bool   `json:"freeze,omitempty"`
+}
+
+// StreamEvent: Un evento de GET /events (campo data de cada mensaje SSE; el nombre del evento SSE es event y su id, id).
+type StreamEvent struct {
+	ID        int64  `json:"id"` // id del evento; reconectar con Last-Event-ID para seguir desde aquí
+	Event     string `json:"event"`
+	CreatedAt string `json:"created_at"`
+	Data      any    `json:"data"` // book.stock y book.price: {book_id, book_name, book_category, transaction_type, price, old_price, available, old_available}; book.out_of_stock: {book_id, book_name}; sale.created: la venta; loan.created y loan.returned: el préstamo
+}
+
+// Transaction: #/components/schemas/Transaction.
+type Transaction struct {
+	ID     int64  `json:"id"`
+	Type   string `json:"type"`
+	UserID int64  `json:"user_id"`
+	BookID int64  `json:"book_id"`
+	Date   string `json:"date"` // DD/MM/YYYY
+}
+
+// TransactionList: #/components/schemas/TransactionList.
+type TransactionList struct {
+	Transactions []Transaction `json:"transactions"`
+}
+
+// TrendingBooks: #/components/schemas/TrendingBooks.
+type TrendingBooks struct {
+	Window     string `json:"window"`
+	ComputedAt string `json:"computed_at"` // vacío si se calculó al vuelo
+	Books      []Book `json:"books"`
+}
+
+// TrendingCategories: #/components/schemas/TrendingCategories.
+type TrendingCategories struct {
+	Window     string            `json:"window"`
+	ComputedAt string            `json:"computed_at"`
+	Categories map[string][]Book `json:"categories"`
+}
+
+// UpdateBookRequest: #/components/schemas/UpdateBookRequest.
+type UpdateBookRequest struct {
+	Price             *int64 `json:"price,omitempty"`
+	AvailableQuantity *int64 `json:"available_quantity,omitempty"`
+}
+
+// UpdatePromotionRequest: #/components/schemas/UpdatePromotionRequest.
+type UpdatePromotionRequest struct {
+	Active  *bool   `json:"active,omitempty"`
+	EndsAt  *string `json:"ends_at,omitempty"` // DD/MM/YYYY
+	MaxUses *int64  `json:"max_uses,omitempty"`
+}
+
+// UpdateRemindersRequest: Solo los canales presentes cambian; false apaga el canal.
+type UpdateRemindersRequest struct {
+	Email   *bool `json:"email,omitempty"`
+	Webhook *bool `json:"webhook,omitempty"`
+	Inbox   *bool `json:"inbox,omitempty"`
+}
+
+// UpdateReviewRequest: #/components/schemas/UpdateReviewRequest.
+type UpdateReviewRequest struct {
+	UserID  int64   `json:"user_id"`
+	Rating  *int64  `json:"rating,omitempty"`
+	Comment *string `json:"comment,omitempty"`
+}
+
+// UpdateUserRequest: #/components/schemas/UpdateUserRequest.
+type UpdateUserRequest struct {
+	FirstName *string `json:"first_name,omitempty"`
+	LastName  *string `json:"last_name,omitempty"`
+	Password  *string `json:"password,omitempty"`
+	Abonar    *int64  `json:"abonar,omitempty"` // se suma a usm_pesos
+}
+
+// UpdateWebhookRequest: #/components/schemas/UpdateWebhookRequest.
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Active *bool    `json:"active,omitempty"` // false pausa las entregas
+}
+
+// User: #/components/schemas/User.
+type User struct {
+	ID        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	USMPesos  int64  `json:"usm_pesos"` // saldo
+}
+
+// UserList: #/components/schemas/UserList.
+type UserList struct {
+	Users []User `json:"users"`
+}
+
+// Webhook: Endpoint externo suscrito a eventos de dominio. Cada entrega es un POST JSON {id, event, created_at, data} con X-UZM-Event, X-UZM-Delivery, X-UZM-Timestamp y X-UZM-Signature (sha256=HMAC-SHA256 del secreto sobre "<timestamp>.<cuerpo>").
+type Webhook struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"` // clave del HMAC; solo viene al crearlo
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// WebhookDeliverResult: #/components/schemas/WebhookDeliverResult.
+type WebhookDeliverResult struct {
+	Delivered int64 `json:"delivered"`
+	Failed    int64 `json:"failed"` // quedan pendientes para reintentar
+	Dead      int64 `json:"dead"`
+}
+
+// WebhookDelivery: Envío de un evento a un webhook; tras 8 intentos fallidos (con backoff exponencial) queda muerto.
+type WebhookDelivery struct {
+	ID            int64  `json:"id"`
+	WebhookID     int64  `json:"webhook_id"`
+	OutboxID      int64  `json:"outbox_id"` // id del evento (el id del cuerpo enviado)
+	Event         string `json:"event"`
+	Payload       any    `json:"payload"` // data del evento
+	EventAt       string `json:"event_at"`
+	Status        string `json:"status"`
+	Attempts      int64  `json:"attempts"`
+	NextAttemptAt string `json:"next_attempt_at,omitempty"` // próximo intento, si está pendiente
+	LastStatus    int64  `json:"last_status,omitempty"`     // código HTTP del último intento
+	LastError     string `json:"last_error,omitempty"`
+	DeliveredAt   string `json:"delivered_at,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// WebhookDeliveryList: #/components/schemas/WebhookDeliveryList.
+type WebhookDeliveryList struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+// WebhookList: #/components/schemas/WebhookList.
+type WebhookList struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// WebhookReplay: #/components/schemas/WebhookReplay.
+type WebhookReplay struct {
+	WebhookID int64 `json:"webhook_id"`
+	Replayed  int64 `json:"replayed"` // entregas muertas que volvieron a quedar pendientes
+}
+
+// Wishlist: #/components/schemas/Wishlist.
+type Wishlist struct {
+	Wishlist []WishlistItem `json:"wishlist"`
+}
+
+// WishlistEntry: #/components/schemas/WishlistEntry.
+type WishlistEntry struct {
+	UserID    int64  `json:"user_id"`
+	BookID    int64  `json:"book_id"`
+	CreatedAt string `json:"created_at"` // RFC3339 UTC
+}
+
+// WishlistItem: #/components/schemas/WishlistItem.
+type WishlistItem struct {
+	UserID    int64  `json:"user_id"`
+	CreatedAt string `json:"created_at"` // RFC3339 UTC
+	Book      Book   `json:"book"`
+}
+
+// WishlistRequest: #/components/schemas/WishlistRequest.
+type WishlistRequest struct {
+	BookID int64 `json:"book_id"`
+}
+
+// AddToWishlist: Seguir un libro (POST /users/{id}/wishlist → 201).
+func (c *Client) AddToWishlist(ctx context.Context, id int64, body WishlistRequest, opts ...Option) (*WishlistEntry, error) {
+	var out WishlistEntry
+	if err := c.do(ctx, http.MethodPost, "/users/"+strconv.FormatInt(id, 10)+"/wishlist", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminCreateBackup: Copia en línea de la base: verificada, comprimida con gzip y con la retención aplicada (POST /admin/backups → 201).
+func (c *Client) AdminCreateBackup(ctx context.Context, opts ...Option) (*BackupResult, error) {
+	var out BackupResult
+	if err := c.do(ctx, http.MethodPost, "/admin/backups", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminCreatePromotion: Crear promoción (POST /admin/promotions → 201).
+func (c *Client) AdminCreatePromotion(ctx context.Context, body CreatePromotionRequest, opts ...Option) (*Promotion, error) {
+	var out Promotion
+	if err := c.do(ctx, http.MethodPost, "/admin/promotions", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminCreateWebhook: Registrar un webhook; la respuesta trae el secreto para verificar X-UZM-Signature (POST /admin/webhooks → 201).
+func (c *Client) AdminCreateWebhook(ctx context.Context, body CreateWebhookRequest, opts ...Option) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, http.MethodPost, "/admin/webhooks", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminDeleteReview: Borrar reseña (DELETE /admin/reviews/{id} → 204).
+func (c *Client) AdminDeleteReview(ctx context.Context, id int64, opts ...Option) error {
+	return c.do(ctx, http.MethodDelete, "/admin/reviews/"+strconv.FormatInt(id, 10), nil, nil, nil, opts)
+}
+
+// AdminDeleteWebhook: Borrar un webhook y sus entregas (DELETE /admin/webhooks/{id} → 204).
+func (c *Client) AdminDeleteWebhook(ctx context.Context, id int64, opts ...Option) error {
+	return c.do(ctx, http.MethodDelete, "/admin/webhooks/"+strconv.FormatInt(id, 10), nil, nil, nil, opts)
+}
+
+// AdminDeliverWebhooks: Enviar ya las entregas pendientes que tocan, sin esperar al despachador (POST /admin/webhooks/deliver → 200).
+func (c *Client) AdminDeliverWebhooks(ctx context.Context, opts ...Option) (*WebhookDeliverResult, error) {
+	var out WebhookDeliverResult
+	if err := c.do(ctx, http.MethodPost, "/admin/webhooks/deliver", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminGetClock: Hora del negocio (GET /admin/clock → 200).
+func (c *Client) AdminGetClock(ctx context.Context, opts ...Option) (*Clock, error) {
+	var out Clock
+	if err := c.do(ctx, http.MethodGet, "/admin/clock", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListAuditParams son los parámetros de query de AdminListAudit; los vacíos no se envían.
+type AdminListAuditParams struct {
+	Actor    string // admin, user:<id> o anónimo
+	Action   string // ej. book.update
+	Entity   string // ej. book, user, loan
+	EntityID int64  // id de la entidad
+	From     string // desde este día, DD/MM/YYYY
+	To       string // hasta este día inclusive, DD/MM/YYYY
+	BeforeID int64  // paginación: entradas con id menor
+	Limit    int64  // 1..1000 (por defecto 100)
+}
+
+// AdminListAudit: Bitácora de acciones (más nuevas primero) (GET /admin/audit → 200).
+func (c *Client) AdminListAudit(ctx context.Context, params AdminListAuditParams, opts ...Option) (*AuditList, error) {
+	q := url.Values{}
+	if params.Actor != "" {
+		q.Set("actor", params.Actor)
+	}
+	if params.Action != "" {
+		q.Set("action", params.Action)
+	}
+	if params.Entity != "" {
+		q.Set("entity", params.Entity)
+	}
+	if params.EntityID != 0 {
+		q.Set("entity_id", strconv.FormatInt(params.EntityID, 10))
+	}
+	if params.From != "" {
+		q.Set("from", params.From)
+	}
+	if params.To != "" {
+		q.Set("to", params.To)
+	}
+	if params.BeforeID != 0 {
+		q.Set("before_id", strconv.FormatInt(params.BeforeID, 10))
+	}
+	if params.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(params.Limit, 10))
+	}
+	var out AuditList
+	if err := c.do(ctx, http.MethodGet, "/admin/audit", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListBackups: Lista las copias de la base y la política de retención (GET /admin/backups → 200).
+func (c *Client) AdminListBackups(ctx context.Context, opts ...Option) (*BackupList, error) {
+	var out BackupList
+	if err := c.do(ctx, http.MethodGet, "/admin/backups", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListDeadLettersParams son los parámetros de query de AdminListDeadLetters; los vacíos no se envían.
+type AdminListDeadLettersParams struct {
+	Limit int64 // 1..1000 (por defecto 50)
+}
+
+// AdminListDeadLetters: Entregas muertas de todos los webhooks (GET /admin/webhooks/dead-letters → 200).
+func (c *Client) AdminListDeadLetters(ctx context.Context, params AdminListDeadLettersParams, opts ...Option) (*WebhookDeliveryList, error) {
+	q := url.Values{}
+	if params.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(params.Limit, 10))
+	}
+	var out WebhookDeliveryList
+	if err := c.do(ctx, http.MethodGet, "/admin/webhooks/dead-letters", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListJobRunsParams son los parámetros de query de AdminListJobRuns; los vacíos no se envían.
+type AdminListJobRunsParams struct {
+	Limit int64 // 1..1000 (por defecto 20)
+}
+
+// AdminListJobRuns: Últimas ejecuciones de un job (más nuevas primero) (GET /admin/jobs/{name}/runs → 200).
+func (c *Client) AdminListJobRuns(ctx context.Context, name string, params AdminListJobRunsParams, opts ...Option) (*JobRunList, error) {
+	q := url.Values{}
+	if params.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(params.Limit, 10))
+	}
+	var out JobRunList
+	if err := c.do(ctx, http.MethodGet, "/admin/jobs/"+url.PathEscape(name)+"/runs", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListJobs: Jobs programados y su última ejecución (GET /admin/jobs → 200).
+func (c *Client) AdminListJobs(ctx context.Context, opts ...Option) (*JobList, error) {
+	var out JobList
+	if err := c.do(ctx, http.MethodGet, "/admin/jobs", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListPromotions: Listar promociones (GET /admin/promotions → 200).
+func (c *Client) AdminListPromotions(ctx context.Context, opts ...Option) (*PromotionList, error) {
+	var out PromotionList
+	if err := c.do(ctx, http.MethodGet, "/admin/promotions", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListReviewsParams son los parámetros de query de AdminListReviews; los vacíos no se envían.
+type AdminListReviewsParams struct {
+	Status string // filtrar por estado
+}
+
+// AdminListReviews: Moderación de reseñas (GET /admin/reviews → 200).
+func (c *Client) AdminListReviews(ctx context.Context, params AdminListReviewsParams, opts ...Option) (*ReviewList, error) {
+	q := url.Values{}
+	if params.Status != "" {
+		q.Set("status", params.Status)
+	}
+	var out ReviewList
+	if err := c.do(ctx, http.MethodGet, "/admin/reviews", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListWebhookDeliveriesParams son los parámetros de query de AdminListWebhookDeliveries; los vacíos no se envían.
+type AdminListWebhookDeliveriesParams struct {
+	Status string // pendiente | entregado | muerto
+	Limit  int64  // 1..1000 (por defecto 50)
+}
+
+// AdminListWebhookDeliveries: Entregas de un webhook, de la más nueva a la más antigua (GET /admin/webhooks/{id}/deliveries → 200).
+func (c *Client) AdminListWebhookDeliveries(ctx context.Context, id int64, params AdminListWebhookDeliveriesParams, opts ...Option) (*WebhookDeliveryList, error) {
+	q := url.Values{}
+	if params.Status != "" {
+		q.Set("status", params.Status)
+	}
+	if params.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(params.Limit, 10))
+	}
+	var out WebhookDeliveryList
+	if err := c.do(ctx, http.MethodGet, "/admin/webhooks/"+strconv.FormatInt(id, 10)+"/deliveries", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminListWebhooks: Webhooks registrados (sin secretos) (GET /admin/webhooks → 200).
+func (c *Client) AdminListWebhooks(ctx context.Context, opts ...Option) (*WebhookList, error) {
+	var out WebhookList
+	if err := c.do(ctx, http.MethodGet, "/admin/webhooks", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminModerateReview: Ocultar o mostrar reseña (PATCH /admin/reviews/{id} → 200).
+func (c *Client) AdminModerateReview(ctx context.Context, id int64, body ModerateReviewRequest, opts ...Option) (*Review, error) {
+	var out Review
+	if err := c.do(ctx, http.MethodPatch, "/admin/reviews/"+strconv.FormatInt(id, 10), nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminReplayDelivery: Reenviar una entrega (muerta o ya entregada) con los intentos en cero (POST /admin/webhooks/deliveries/{id}/replay → 200).
+func (c *Client) AdminReplayDelivery(ctx context.Context, id int64, opts ...Option) (*WebhookDelivery, error) {
+	var out WebhookDelivery
+	if err := c.do(ctx, http.MethodPost, "/admin/webhooks/deliveries/"+strconv.FormatInt(id, 10)+"/replay", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminReplayWebhook: Reenviar todas las entregas muertas del webhook (POST /admin/webhooks/{id}/replay → 200).
+func (c *Client) AdminReplayWebhook(ctx context.Context, id int64, opts ...Option) (*WebhookReplay, error) {
+	var out WebhookReplay
+	if err := c.do(ctx, http.MethodPost, "/admin/webhooks/"+strconv.FormatInt(id, 10)+"/replay", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminResetClock: Volver a la hora real (DELETE /admin/clock → 200).
+func (c *Client) AdminResetClock(ctx context.Context, opts ...Option) (*Clock, error) {
+	var out Clock
+	if err := c.do(ctx, http.MethodDelete, "/admin/clock", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminRunJob: Corre un job ahora, aunque no le toque (409 si ya está corriendo) (POST /admin/jobs/{name}/run → 200).
+func (c *Client) AdminRunJob(ctx context.Context, name string, opts ...Option) (*JobRun, error) {
+	var out JobRun
+	if err := c.do(ctx, http.MethodPost, "/admin/jobs/"+url.PathEscape(name)+"/run", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminSetClock: Mover la hora (UZM_TIME_TRAVEL=1) (PUT /admin/clock → 200).
+func (c *Client) AdminSetClock(ctx context.Context, body SetClockRequest, opts ...Option) (*Clock, error) {
+	var out Clock
+	if err := c.do(ctx, http.MethodPut, "/admin/clock", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminUpdatePromotion: Editar promoción (PATCH /admin/promotions/{id} → 200).
+func (c *Client) AdminUpdatePromotion(ctx context.Context, id int64, body UpdatePromotionRequest, opts ...Option) (*Promotion, error) {
+	var out Promotion
+	if err := c.do(ctx, http.MethodPatch, "/admin/promotions/"+strconv.FormatInt(id, 10), nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminUpdateWebhook: Editar o pausar un webhook (PATCH /admin/webhooks/{id} → 200).
+func (c *Client) AdminUpdateWebhook(ctx context.Context, id int64, body UpdateWebhookRequest, opts ...Option) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, http.MethodPatch, "/admin/webhooks/"+strconv.FormatInt(id, 10), nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminVerifyAudit: Verifica la cadena de hashes del audit_log (GET /admin/audit/verify → 200).
+func (c *Client) AdminVerifyAudit(ctx context.Context, opts ...Option) (*AuditCheck, error) {
+	var out AuditCheck
+	if err := c.do(ctx, http.MethodGet, "/admin/audit/verify", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdminVerifyBackup: Descomprime una copia y corre integrity_check (una copia dañada responde 200 con ok=false) (POST /admin/backups/{name}/verify → 200).
+func (c *Client) AdminVerifyBackup(ctx context.Context, name string, opts ...Option) (*BackupVerification, error) {
+	var out BackupVerification
+	if err := c.do(ctx, http.MethodPost, "/admin/backups/"+url.PathEscape(name)+"/verify", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Checkout: Comprar el carro completo (POST /sales/checkout → 201).
+func (c *Client) Checkout(ctx context.Context, body CartRequest, opts ...Option) (*CheckoutResult, error) {
+	var out CheckoutResult
+	if err := c.do(ctx, http.MethodPost, "/sales/checkout", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateBook: Crear libro con su inventario (POST /books → 201).
+func (c *Client) CreateBook(ctx context.Context, body CreateBookRequest, opts ...Option) (*Book, error) {
+	var out Book
+	if err := c.do(ctx, http.MethodPost, "/books", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateLoan: Arrendar un libro (POST /loans → 201).
+func (c *Client) CreateLoan(ctx context.Context, body LoanRequest, opts ...Option) (*Loan, error) {
+	var out Loan
+	if err := c.do(ctx, http.MethodPost, "/loans", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateReview: Publicar reseña (solo quien compró o arrendó) (POST /books/{id}/reviews → 201).
+func (c *Client) CreateReview(ctx context.Context, id int64, body CreateReviewRequest, opts ...Option) (*Review, error) {
+	var out Review
+	if err := c.do(ctx, http.MethodPost, "/books/"+strconv.FormatInt(id, 10)+"/reviews", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateSale: Comprar un libro (POST /sales → 201).
+func (c *Client) CreateSale(ctx context.Context, body SaleRequest, opts ...Option) (*Sale, error) {
+	var out Sale
+	if err := c.do(ctx, http.MethodPost, "/sales", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateUser: Registrar usuario (POST /users → 201).
+func (c *Client) CreateUser(ctx context.Context, body CreateUserRequest, opts ...Option) (*User, error) {
+	var out User
+	if err := c.do(ctx, http.MethodPost, "/users", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteReviewParams son los parámetros de query de DeleteReview; los vacíos no se envían.
+type DeleteReviewParams struct {
+	UserID int64 // autor
+}
+
+// DeleteReview: Borrar reseña (solo el autor) (DELETE /books/{id}/reviews/{review_id} → 204).
+func (c *Client) DeleteReview(ctx context.Context, id int64, reviewID int64, params DeleteReviewParams, opts ...Option) error {
+	q := url.Values{}
+	q.Set("user_id", strconv.FormatInt(params.UserID, 10))
+	return c.do(ctx, http.MethodDelete, "/books/"+strconv.FormatInt(id, 10)+"/reviews/"+strconv.FormatInt(reviewID, 10), q, nil, nil, opts)
+}
+
+// GetReminderSettings: Canales por los que el usuario recibe recordatorios de préstamos (GET /users/{id}/reminders → 200).
+func (c *Client) GetReminderSettings(ctx context.Context, id int64, opts ...Option) (*ReminderSettings, error) {
+	var out ReminderSettings
+	if err := c.do(ctx, http.MethodGet, "/users/"+strconv.FormatInt(id, 10)+"/reminders", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUser: Ver usuario (GET /users/{id} → 200).
+func (c *Client) GetUser(ctx context.Context, id int64, opts ...Option) (*User, error) {
+	var out User
+	if err := c.do(ctx, http.MethodGet, "/users/"+strconv.FormatInt(id, 10), nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWishlist: Lista de deseos (GET /users/{id}/wishlist → 200).
+func (c *Client) GetWishlist(ctx context.Context, id int64, opts ...Option) (*Wishlist, error) {
+	var out Wishlist
+	if err := c.do(ctx, http.MethodGet, "/users/"+strconv.FormatInt(id, 10)+"/wishlist", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Health: Salud del servidor (GET /health → 200).
+func (c *Client) Health(ctx context.Context, opts ...Option) (*Health, error) {
+	var out Health
+	if err := c.do(ctx, http.MethodGet, "/health", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListBooksParams son los parámetros de query de ListBooks; los vacíos no se envían.
+type ListBooksParams struct {
+	Sort string // orden
+}
+
+// ListBooks: Catálogo (solo con stock) (GET /books → 200).
+func (c *Client) ListBooks(ctx context.Context, params ListBooksParams, opts ...Option) (*BookList, error) {
+	q := url.Values{}
+	if params.Sort != "" {
+		q.Set("sort", params.Sort)
+	}
+	var out BookList
+	if err := c.do(ctx, http.MethodGet, "/books", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListLoans: Listar préstamos (GET /loans → 200).
+func (c *Client) ListLoans(ctx context.Context, opts ...Option) (*LoanList, error) {
+	var out LoanList
+	if err := c.do(ctx, http.MethodGet, "/loans", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListNotificationsParams son los parámetros de query de ListNotifications; los vacíos no se envían.
+type ListNotificationsParams struct {
+	Unread string // 1 = solo no leídas
+}
+
+// ListNotifications: Avisos de precio y stock (GET /users/{id}/notifications → 200).
+func (c *Client) ListNotifications(ctx context.Context, id int64, params ListNotificationsParams, opts ...Option) (*NotificationList, error) {
+	q := url.Values{}
+	if params.Unread != "" {
+		q.Set("unread", params.Unread)
+	}
+	var out NotificationList
+	if err := c.do(ctx, http.MethodGet, "/users/"+strconv.FormatInt(id, 10)+"/notifications", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPopularBooksParams son los parámetros de query de ListPopularBooks; los vacíos no se envían.
+type ListPopularBooksParams struct {
+	Limit    int64  // máximo de resultados
+	Category string // filtrar por categoría
+}
+
+// ListPopularBooks: Ranking histórico (GET /books/popular → 200).
+func (c *Client) ListPopularBooks(ctx context.Context, params ListPopularBooksParams, opts ...Option) (*BookList, error) {
+	q := url.Values{}
+	if params.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(params.Limit, 10))
+	}
+	if params.Category != "" {
+		q.Set("category", params.Category)
+	}
+	var out BookList
+	if err := c.do(ctx, http.MethodGet, "/books/popular", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRecommendationsParams son los parámetros de query de ListRecommendations; los vacíos no se envían.
+type ListRecommendationsParams struct {
+	Limit int64 // máximo de resultados
+}
+
+// ListRecommendations: Recomendaciones para el usuario (GET /users/{id}/recommendations → 200).
+func (c *Client) ListRecommendations(ctx context.Context, id int64, params ListRecommendationsParams, opts ...Option) (*RecommendationList, error) {
+	q := url.Values{}
+	if params.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(params.Limit, 10))
+	}
+	var out RecommendationList
+	if err := c.do(ctx, http.MethodGet, "/users/"+strconv.FormatInt(id, 10)+"/recommendations", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRelatedBooksParams son los parámetros de query de ListRelatedBooks; los vacíos no se envían.
+type ListRelatedBooksParams struct {
+	Limit int64 // máximo de resultados
+}
+
+// ListRelatedBooks: Libros relacionados (GET /books/{id}/related → 200).
+func (c *Client) ListRelatedBooks(ctx context.Context, id int64, params ListRelatedBooksParams, opts ...Option) (*RecommendationList, error) {
+	q := url.Values{}
+	if params.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(params.Limit, 10))
+	}
+	var out RecommendationList
+	if err := c.do(ctx, http.MethodGet, "/books/"+strconv.FormatInt(id, 10)+"/related", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListReviews: Reseñas visibles del libro (GET /books/{id}/reviews → 200).
+func (c *Client) ListReviews(ctx context.Context, id int64, opts ...Option) (*ReviewList, error) {
+	var out ReviewList
+	if err := c.do(ctx, http.MethodGet, "/books/"+strconv.FormatInt(id, 10)+"/reviews", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSales: Listar ventas (GET /sales → 200).
+func (c *Client) ListSales(ctx context.Context, opts ...Option) (*SaleList, error) {
+	var out SaleList
+	if err := c.do(ctx, http.MethodGet, "/sales", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTransactions: Ventas y arriendos por fecha (GET /transactions → 200).
+func (c *Client) ListTransactions(ctx context.Context, opts ...Option) (*TransactionList, error) {
+	var out TransactionList
+	if err := c.do(ctx, http.MethodGet, "/transactions", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTrendingBooksParams son los parámetros de query de ListTrendingBooks; los vacíos no se envían.
+type ListTrendingBooksParams struct {
+	Window   string // ventana: 24h, 7d, 2w
+	Limit    int64  // máximo de resultados
+	Category string // filtrar por categoría
+	Fresh    string // 1 = calcular al vuelo
+}
+
+// ListTrendingBooks: Tendencias con decaimiento (GET /books/trending → 200).
+func (c *Client) ListTrendingBooks(ctx context.Context, params ListTrendingBooksParams, opts ...Option) (*TrendingBooks, error) {
+	q := url.Values{}
+	if params.Window != "" {
+		q.Set("window", params.Window)
+	}
+	if params.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(params.Limit, 10))
+	}
+	if params.Category != "" {
+		q.Set("category", params.Category)
+	}
+	if params.Fresh != "" {
+		q.Set("fresh", params.Fresh)
+	}
+	var out TrendingBooks
+	if err := c.do(ctx, http.MethodGet, "/books/trending", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTrendingByCategoryParams son los parámetros de query de ListTrendingByCategory; los vacíos no se envían.
+type ListTrendingByCategoryParams struct {
+	Window string // ventana: 24h, 7d, 2w
+	Limit  int64  // máximo por categoría
+	Fresh  string // 1 = calcular al vuelo
+}
+
+// ListTrendingByCategory: Tendencias por categoría (GET /books/trending/categories → 200).
+func (c *Client) ListTrendingByCategory(ctx context.Context, params ListTrendingByCategoryParams, opts ...Option) (*TrendingCategories, error) {
+	q := url.Values{}
+	if params.Window != "" {
+		q.Set("window", params.Window)
+	}
+	if params.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(params.Limit, 10))
+	}
+	if params.Fresh != "" {
+		q.Set("fresh", params.Fresh)
+	}
+	var out TrendingCategories
+	if err := c.do(ctx, http.MethodGet, "/books/trending/categories", q, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListUserTransactions: Historial de un usuario (GET /users/{id}/transactions → 200).
+func (c *Client) ListUserTransactions(ctx context.Context, id int64, opts ...Option) (*TransactionList, error) {
+	var out TransactionList
+	if err := c.do(ctx, http.MethodGet, "/users/"+strconv.FormatInt(id, 10)+"/transactions", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListUsers: Listar usuarios (GET /users → 200).
+func (c *Client) ListUsers(ctx context.Context, opts ...Option) (*UserList, error) {
+	var out UserList
+	if err := c.do(ctx, http.MethodGet, "/users", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Login: Iniciar sesión (POST /login → 200).
+func (c *Client) Login(ctx context.Context, body LoginRequest, opts ...Option) (*User, error) {
+	var out User
+	if err := c.do(ctx, http.MethodPost, "/login", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MarkNotificationsRead: Marcar avisos como leídos (POST /users/{id}/notifications/read → 200).
+func (c *Client) MarkNotificationsRead(ctx context.Context, id int64, body MarkReadRequest, opts ...Option) (*MarkReadResult, error) {
+	var out MarkReadResult
+	if err := c.do(ctx, http.MethodPost, "/users/"+strconv.FormatInt(id, 10)+"/notifications/read", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// QuoteCart: Cotizar el carro con promociones (POST /sales/quote → 200).
+func (c *Client) QuoteCart(ctx context.Context, body CartRequest, opts ...Option) (*Quote, error) {
+	var out Quote
+	if err := c.do(ctx, http.MethodPost, "/sales/quote", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RecomputeTrending: Recalcular tendencias (admin) (POST /books/trending/recompute → 200).
+func (c *Client) RecomputeTrending(ctx context.Context, opts ...Option) (*RecomputeResult, error) {
+	var out RecomputeResult
+	if err := c.do(ctx, http.MethodPost, "/books/trending/recompute", nil, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveFromWishlist: Dejar de seguir un libro (DELETE /users/{id}/wishlist/{book_id} → 204).
+func (c *Client) RemoveFromWishlist(ctx context.Context, id int64, bookID int64, opts ...Option) error {
+	return c.do(ctx, http.MethodDelete, "/users/"+strconv.FormatInt(id, 10)+"/wishlist/"+strconv.FormatInt(bookID, 10), nil, nil, nil, opts)
+}
+
+// ReturnLoan: Devolver (multa por atraso) (PATCH /loans/{id}/return → 200).
+func (c *Client) ReturnLoan(ctx context.Context, id int64, body ReturnLoanRequest, opts ...Option) (*Loan, error) {
+	var out Loan
+	if err := c.do(ctx, http.MethodPatch, "/loans/"+strconv.FormatInt(id, 10)+"/return", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateBook: Cambiar precio o stock (avisa a la lista de deseos) (PATCH /books/{id} → 204).
+func (c *Client) UpdateBook(ctx context.Context, id int64, body UpdateBookRequest, opts ...Option) error {
+	return c.do(ctx, http.MethodPatch, "/books/"+strconv.FormatInt(id, 10), nil, body, nil, opts)
+}
+
+// UpdateReminderSettings: Apaga o enciende canales de recordatorio (PUT /users/{id}/reminders → 200).
+func (c *Client) UpdateReminderSettings(ctx context.Context, id int64, body UpdateRemindersRequest, opts ...Option) (*ReminderSettings, error) {
+	var out ReminderSettings
+	if err := c.do(ctx, http.MethodPut, "/users/"+strconv.FormatInt(id, 10)+"/reminders", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateReview: Editar reseña (solo el autor) (PATCH /books/{id}/reviews/{review_id} → 200).
+func (c *Client) UpdateReview(ctx context.Context, id int64, reviewID int64, body UpdateReviewRequest, opts ...Option) (*Review, error) {
+	var out Review
+	if err := c.do(ctx, http.MethodPatch, "/books/"+strconv.FormatInt(id, 10)+"/reviews/"+strconv.FormatInt(reviewID, 10), nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateUser: Editar datos o abonar usm pesos (PATCH /users/{id} → 200).
+func (c *Client) UpdateUser(ctx context.Context, id int64, body UpdateUserRequest, opts ...Option) (*User, error) {
+	var out User
+	if err := c.do(ctx, http.MethodPatch, "/users/"+strconv.FormatInt(id, 10), nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,113 @@
+// Package client es el cliente Go de la API UZM. Los tipos y un método por operación
+// se generan desde internal/api/openapi.json (client.gen.go); aquí va el transporte.
+package client
+
+//go:generate go run ../../cmd/genclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client habla con la API en BaseURL (ej. http://localhost:8080); las rutas van bajo basePath.
+type Client struct {
+	BaseURL    string
+	HTTP       *http.Client
+	AdminToken string // se manda como X-Admin-Token en todas las requests si no es vacío
+}
+
+// New crea un cliente con timeout: en la red de la VM una respuesta se puede perder.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Option ajusta una request puntual.
+type Option func(*http.Request)
+
+// WithIdempotencyKey manda Idempotency-Key: reintentar con la misma key no repite el cobro.
+func WithIdempotencyKey(key string) Option {
+	return func(r *http.Request) { r.Header.Set("Idempotency-Key", key) }
+}
+
+// Error es una respuesta no 2xx. Si el cuerpo trae el formato de error de la API,
+// Code y Message vienen de ahí; si no, Message describe el status.
+type Error struct {
+	Status int
+	APIError
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any, opts []Option) error {
+	var (
+		reader      io.Reader
+		contentType string
+	)
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader, contentType = bytes.NewReader(b), "application/json"
+	}
+	resp, err := c.send(ctx, method, path, query, contentType, reader, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send hace la request y devuelve la respuesta si es 2xx (quien llama cierra el Body);
+// si no, un *Error.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, contentType string, body io.Reader, opts []Option) (*http.Response, error) {
+	target := c.BaseURL + basePath + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.AdminToken != "" {
+		req.Header.Set("X-Admin-Token", c.AdminToken)
+	}
+	for _, o := range opts {
+		o(req)
+	}
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var e ErrorResponse
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error.Message != "" {
+			return nil, &Error{Status: resp.StatusCode, APIError: e.Error}
+		}
+		return nil, &Error{Status: resp.StatusCode, APIError: APIError{
+			Message: fmt.Sprintf("%s %s → status %s", method, path, resp.Status),
+		}}
+	}
+	return resp, nil
+}
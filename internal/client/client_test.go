@@ -0,0 +1,79 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/apitest"
+	"tarea1-uzm/internal/client"
+	"tarea1-uzm/internal/openapi"
+)
+
+// TestGeneratedUpToDate falla si openapi.json cambió y no se corrió go generate.
+func TestGeneratedUpToDate(t *testing.T) {
+	doc, err := openapi.Parse(api.OpenAPISpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := openapi.Generate(doc, "client", "internal/api/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("client.gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("client.gen.go no corresponde a openapi.json: corre go generate ./internal/client")
+	}
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	s := apitest.NewServer(t)
+	c := client.New(s.URL)
+	ctx := context.Background()
+
+	u, err := c.CreateUser(ctx, client.CreateUserRequest{FirstName: "Ana", LastName: "Rojas", Email: "ana@usm.cl", Password: "clave123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposit := int64(40)
+	u, err = c.UpdateUser(ctx, u.ID, client.UpdateUserRequest{Abonar: &deposit}, client.WithIdempotencyKey("abono-1"))
+	if err != nil || u.USMPesos != 40 {
+		t.Fatalf("abono: %+v %v", u, err)
+	}
+
+	book := apitest.NewBook().Price(25).Insert(t, s.DB)
+	list, err := c.ListBooks(ctx, client.ListBooksParams{Sort: "price"})
+	if err != nil || len(list.Books) != 1 || list.Books[0].ID != book {
+		t.Fatalf("catálogo: %+v %v", list, err)
+	}
+	if _, err := c.CreateSale(ctx, client.SaleRequest{UserID: u.ID, BookID: book}); err != nil {
+		t.Fatal(err)
+	}
+
+	// los errores traen el código estable de la API
+	_, err = c.CreateSale(ctx, client.SaleRequest{UserID: u.ID, BookID: book})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Status != 409 || apiErr.Code != "out_of_stock" {
+		t.Fatalf("sin stock: %#v", err)
+	}
+	if apiErr.RequestID == "" {
+		t.Error("el error no trae request_id")
+	}
+
+	// los parámetros de ruta string van escapados
+	t.Setenv("UZM_ADMIN_TOKEN", "secreto")
+	c.AdminToken = "secreto"
+	run, err := c.AdminRunJob(ctx, "idempotency.purge")
+	if err != nil || run.Status != "ok" {
+		t.Errorf("AdminRunJob: %+v %v", run, err)
+	}
+	if _, err := c.AdminVerifyBackup(ctx, "../uzm.db"); !errors.As(err, &apiErr) || apiErr.Status != 404 {
+		t.Errorf("AdminVerifyBackup: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// EventsParams son los filtros de Events; los campos en cero no filtran.
+type EventsParams struct {
+	BookID int64
+	UserID int64
+	Types  []string
+	Since  int64 // seguir después de este id; 0 = solo eventos nuevos
+	// OnOpen, si no es nil, se llama cuando el servidor aceptó el stream: desde ahí no se
+	// pierde ningún evento.
+	OnOpen func()
+}
+
+// Decode deserializa e.Data en v (ej. un struct con book_id y available para book.stock).
+func (e StreamEvent) Decode(v any) error {
+	raw, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Events abre el stream GET /events (no está en el cliente generado: no es JSON) y llama a
+// fn con cada evento hasta que ctx termine, fn devuelva un error o se corte la conexión.
+// Devuelve el id del último evento recibido, para reconectar con Since sin perder nada.
+func (c *Client) Events(ctx context.Context, p EventsParams, fn func(StreamEvent) error) (int64, error) {
+	q := url.Values{}
+	if p.BookID != 0 {
+		q.Set("book_id", strconv.FormatInt(p.BookID, 10))
+	}
+	if p.UserID != 0 {
+		q.Set("user_id", strconv.FormatInt(p.UserID, 10))
+	}
+	if len(p.Types) > 0 {
+		q.Set("types", strings.Join(p.Types, ","))
+	}
+	if p.Since != 0 {
+		q.Set("since", strconv.FormatInt(p.Since, 10))
+	}
+	target := c.BaseURL + basePath + "/events"
+	if len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return p.Since, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	// sin el Timeout de c.HTTP: el stream dura lo que dure ctx
+	httpClient := &http.Client{}
+	if c.HTTP != nil {
+		httpClient.Transport = c.HTTP.Transport
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return p.Since, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e ErrorResponse
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error.Message != "" {
+			return p.Since, &Error{Status: resp.StatusCode, APIError: e.Error}
+		}
+		return p.Since, &Error{Status: resp.StatusCode, APIError: APIError{Message: "GET /events → status " + resp.Status}}
+	}
+
+	if p.OnOpen != nil {
+		p.OnOpen()
+	}
+	last := p.Since
+	var data strings.Builder
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			// fin del mensaje
+			if data.Len() == 0 {
+				continue
+			}
+			var e StreamEvent
+			if err := json.Unmarshal([]byte(data.String()), &e); err != nil {
+				return last, fmt.Errorf("GET /events: %w", err)
+			}
+			data.Reset()
+			last = e.ID
+			if err := fn(e); err != nil {
+				return last, err
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// los comentarios (": ping") y los campos id/event/retry no hacen falta: van en data
+	}
+	if err := ctx.Err(); err != nil {
+		return last, err
+	}
+	if err := sc.Err(); err != nil {
+		return last, err
+	}
+	return last, fmt.Errorf("GET /events: el servidor cerró el stream")
+}
@@ -0,0 +1,105 @@
+// Package clock da la hora del negocio. Nada fuera de aquí debería llamar a time.Now:
+// así los vencimientos, multas y promociones se pueden reproducir en tests y demos.
+package clock
+
+import (
+	"sync"
+	"time"
+	_ "time/tzdata" // la VM puede no traer la base de zonas horarias
+)
+
+// DefaultZone es la zona horaria del negocio si no se configura otra (UZM_TZ).
+const DefaultZone = "America/Santiago"
+
+type Clock interface {
+	Now() time.Time
+}
+
+// LoadZone carga la zona name ("" = DefaultZone).
+func LoadZone(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultZone
+	}
+	return time.LoadLocation(name)
+}
+
+// System es el reloj real, expresado en la zona del negocio.
+type System struct {
+	Loc *time.Location
+}
+
+func (s System) Now() time.Time { return time.Now().In(s.Loc) }
+
+// Fixed siempre devuelve la misma hora.
+type Fixed time.Time
+
+func (f Fixed) Now() time.Time { return time.Time(f) }
+
+// Travel envuelve otro reloj y permite adelantarlo, atrasarlo o congelarlo
+// ("viaje en el tiempo" para demos y tests; lo maneja /admin/clock).
+type Travel struct {
+	mu     sync.RWMutex
+	base   Clock
+	offset time.Duration
+	frozen *time.Time
+}
+
+func NewTravel(base Clock) *Travel { return &Travel{base: base} }
+
+func (t *Travel) Now() time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.frozen != nil {
+		return *t.frozen
+	}
+	return t.base.Now().Add(t.offset)
+}
+
+// Set hace que Now() sea at y siga corriendo desde ahí.
+func (t *Travel) Set(at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.frozen = nil
+	t.offset = at.Sub(t.base.Now())
+}
+
+// Freeze detiene el reloj en at.
+func (t *Travel) Freeze(at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	at = at.In(t.base.Now().Location())
+	t.frozen = &at
+}
+
+// Advance mueve el reloj d (negativo = hacia atrás), esté congelado o no.
+func (t *Travel) Advance(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.frozen != nil {
+		at := t.frozen.Add(d)
+		t.frozen = &at
+		return
+	}
+	t.offset += d
+}
+
+// Reset vuelve a la hora real.
+func (t *Travel) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.offset, t.frozen = 0, nil
+}
+
+// State resume el reloj para mostrarlo.
+type State struct {
+	Now    time.Time
+	Offset time.Duration // respecto de la hora real
+	Frozen bool
+}
+
+func (t *Travel) State() State {
+	now := t.Now()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return State{Now: now, Offset: now.Sub(t.base.Now()), Frozen: t.frozen != nil}
+}
@@ -0,0 +1,46 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTravel(t *testing.T) {
+	loc, err := LoadZone("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, loc)
+	tr := NewTravel(Fixed(base))
+
+	if got := tr.Now(); !got.Equal(base) {
+		t.Fatalf("sin viajar: %v, want %v", got, base)
+	}
+	tr.Advance(72 * time.Hour)
+	if got := tr.Now(); !got.Equal(base.Add(72 * time.Hour)) {
+		t.Errorf("Advance: %v", got)
+	}
+	at := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	tr.Freeze(at)
+	tr.Advance(time.Hour)
+	if got := tr.Now(); !got.Equal(at.Add(time.Hour)) || got.Location() != loc {
+		t.Errorf("Freeze+Advance: %v (zona %v)", got, got.Location())
+	}
+	if s := tr.State(); !s.Frozen {
+		t.Errorf("State = %+v, want congelado", s)
+	}
+	tr.Reset()
+	if s := tr.State(); !s.Now.Equal(base) || s.Offset != 0 || s.Frozen {
+		t.Errorf("Reset: %+v", s)
+	}
+}
+
+func TestLoadZone(t *testing.T) {
+	loc, err := LoadZone("")
+	if err != nil || loc.String() != DefaultZone {
+		t.Fatalf("LoadZone(\"\") = %v, %v", loc, err)
+	}
+	if _, err := LoadZone("Marte/Olympus"); err == nil {
+		t.Error("zona inexistente sin error")
+	}
+}
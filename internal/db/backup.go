@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+var errNoBackup = errors.New("db: el driver no soporta backups")
+
+// Backup copia la base de sqlDB al archivo dst (que no debe existir) con la API de backup
+// de SQLite: es una foto consistente y no detiene a los escritores (en WAL la copia lee una
+// instantánea). La copia queda en modo journal DELETE, un solo archivo sin -wal.
+func Backup(ctx context.Context, sqlDB *sql.DB, dst string) error {
+	c, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Raw(func(dc any) error {
+		// solo las conexiones de DriverName (sql.Open con otro driver no trae *conn)
+		wrapped, ok := dc.(*conn)
+		if !ok {
+			return errNoBackup
+		}
+		src, ok := wrapped.Raw().(interface {
+			NewBackup(dstUri string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return errNoBackup
+		}
+		b, err := src.NewBackup(dst)
+		if err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+		for more := true; more; {
+			if more, err = b.Step(-1); err != nil {
+				b.Finish()
+				return fmt.Errorf("backup: %w", err)
+			}
+		}
+		out, err := b.Commit()
+		if err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+		defer out.Close()
+		exec, ok := out.(driver.ExecerContext)
+		if !ok {
+			return errNoBackup
+		}
+		_, err = exec.ExecContext(ctx, `PRAGMA journal_mode=DELETE`, nil)
+		return err
+	})
+}
+
+// IntegrityCheck corre PRAGMA integrity_check sobre el archivo en path, abierto solo para
+// lectura (no migra ni toca nada). Devuelve un error con los primeros problemas encontrados.
+func IntegrityCheck(path string) error {
+	sqlDB, err := sql.Open(DriverName, "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	rows, err := sqlDB.Query(`PRAGMA integrity_check(10)`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return err
+		}
+		if s != "ok" {
+			problems = append(problems, s)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity_check: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
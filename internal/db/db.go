@@ -5,16 +5,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	_ "modernc.org/sqlite"
+	"strings"
 )
 
+// Open abre (o crea) la base en path. Las transacciones parten con BEGIN IMMEDIATE: toman
+// el lock de escritura de entrada, así dos requests no leen el mismo saldo o stock y luego
+// ambos lo descuentan. WAL deja leer mientras alguien escribe y busy_timeout hace que los
+// escritores esperen su turno; si la cola es tan larga que lo agotan, conn.BeginTx reintenta
+// en vez de fallar con SQLITE_BUSY.
 func Open(path string) (*sql.DB, error) {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, fmt.Errorf("mkdir: %w", err)
 	}
-	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)", path)
-	sqlDB, err := sql.Open("sqlite", dsn)
+	dsn := fmt.Sprintf("file:%s?_txlock=immediate"+
+		"&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)", path)
+	sqlDB, err := sql.Open(DriverName, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open: %w", err)
 	}
@@ -60,17 +65,331 @@ CREATE TABLE IF NOT EXISTS sales (
   FOREIGN KEY(book_id) REFERENCES books(id)
 );
 
--- prestamos (lo implementamos después)
-CREATE TABLE IF NOT EXISTS loans (
+-- prestamos: pendiente -> vencido (lo marca el job loans.overdue) -> finalizado
+CREATE TABLE IF NOT EXISTS loans (` + loansColumns + `);
+
+-- eventos fechados de popularidad (una fila por venta o arriendo)
+CREATE TABLE IF NOT EXISTS popularity_events (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  book_id    INTEGER NOT NULL,
+  kind       TEXT    NOT NULL CHECK (kind IN ('Venta','Arriendo')),
+  created_at TEXT    NOT NULL, -- RFC3339 UTC
+  FOREIGN KEY(book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_popularity_events_created ON popularity_events(created_at);
+
+-- puntajes con decaimiento precalculados por el job (span = ventana, ej. 7d)
+CREATE TABLE IF NOT EXISTS book_popularity (
+  book_id     INTEGER NOT NULL,
+  span        TEXT    NOT NULL,
+  score       REAL    NOT NULL,
+  computed_at TEXT    NOT NULL, -- RFC3339 UTC
+  PRIMARY KEY(book_id, span),
+  FOREIGN KEY(book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+-- reseñas: una por usuario y libro; status lo modera un administrador
+CREATE TABLE IF NOT EXISTS reviews (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  book_id    INTEGER NOT NULL,
+  user_id    INTEGER NOT NULL,
+  rating     INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  comment    TEXT    NOT NULL DEFAULT '',
+  status     TEXT    NOT NULL DEFAULT 'visible' CHECK (status IN ('visible','oculta')),
+  created_at TEXT    NOT NULL, -- RFC3339 UTC
+  updated_at TEXT    NOT NULL, -- RFC3339 UTC
+  UNIQUE(book_id, user_id),
+  FOREIGN KEY(book_id) REFERENCES books(id) ON DELETE CASCADE,
+  FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+-- lista de deseos: libros que un usuario sigue
+CREATE TABLE IF NOT EXISTS wishlists (
+  user_id    INTEGER NOT NULL,
+  book_id    INTEGER NOT NULL,
+  created_at TEXT    NOT NULL, -- RFC3339 UTC
+  PRIMARY KEY(user_id, book_id),
+  FOREIGN KEY(user_id) REFERENCES users(id),
+  FOREIGN KEY(book_id) REFERENCES books(id) ON DELETE CASCADE
+);
+
+-- bandeja de notificaciones (kind: precio | stock | vence_pronto | vence_hoy | vencido)
+CREATE TABLE IF NOT EXISTS notifications (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id    INTEGER NOT NULL,
+  book_id    INTEGER,
+  kind       TEXT    NOT NULL,
+  message    TEXT    NOT NULL,
+  created_at TEXT    NOT NULL, -- RFC3339 UTC
+  read_at    TEXT,             -- NULL = no leída
+  FOREIGN KEY(user_id) REFERENCES users(id),
+  FOREIGN KEY(book_id) REFERENCES books(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, read_at);
+
+-- promociones: con code = NULL se aplican solas (ej. rebaja de categoría, "3 por 2");
+-- con code requieren ingresar el código en el checkout
+CREATE TABLE IF NOT EXISTS promotions (
+  id                INTEGER PRIMARY KEY AUTOINCREMENT,
+  name              TEXT    NOT NULL,
+  code              TEXT    UNIQUE,
+  kind              TEXT    NOT NULL CHECK (kind IN ('porcentaje','monto','bundle')),
+  value             INTEGER NOT NULL DEFAULT 0, -- % (porcentaje) o usm pesos (monto)
+  category          TEXT    NOT NULL DEFAULT '', -- '' = todo el catálogo
+  buy_qty           INTEGER NOT NULL DEFAULT 0, -- bundle: lleva buy_qty...
+  pay_qty           INTEGER NOT NULL DEFAULT 0, -- ...y paga pay_qty
+  starts_at         TEXT    NOT NULL, -- DD/MM/YYYY (inclusive)
+  ends_at           TEXT    NOT NULL, -- DD/MM/YYYY (inclusive)
+  max_uses          INTEGER NOT NULL DEFAULT 0, -- 0 = sin límite
+  max_uses_per_user INTEGER NOT NULL DEFAULT 0, -- 0 = sin límite
+  uses              INTEGER NOT NULL DEFAULT 0,
+  active            INTEGER NOT NULL DEFAULT 1
+);
+
+-- un canje por promoción y checkout (para límites por usuario)
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+  id           INTEGER PRIMARY KEY AUTOINCREMENT,
+  promotion_id INTEGER NOT NULL,
+  user_id      INTEGER NOT NULL,
+  discount     INTEGER NOT NULL,
+  created_at   TEXT    NOT NULL, -- RFC3339 UTC
+  FOREIGN KEY(promotion_id) REFERENCES promotions(id),
+  FOREIGN KEY(user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_redemptions_user ON promotion_redemptions(promotion_id, user_id);
+
+-- detalle de descuentos aplicados a cada venta
+CREATE TABLE IF NOT EXISTS sale_discounts (
+  sale_id      INTEGER NOT NULL,
+  promotion_id INTEGER NOT NULL,
+  amount       INTEGER NOT NULL,
+  PRIMARY KEY(sale_id, promotion_id),
+  FOREIGN KEY(sale_id) REFERENCES sales(id),
+  FOREIGN KEY(promotion_id) REFERENCES promotions(id)
+);
+
+-- respuestas guardadas por Idempotency-Key (POST /sales, /loans, abonos...) para repetirlas
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  key          TEXT    PRIMARY KEY,
+  request_hash TEXT    NOT NULL, -- sha256 de método, ruta y cuerpo
+  status       INTEGER NOT NULL DEFAULT 0, -- 0 = todavía en curso
+  response     BLOB,
+  created_at   TEXT    NOT NULL -- RFC3339 UTC
+);
+
+-- ejecuciones de los jobs programados (status: en_curso | ok | error | abandonado)
+CREATE TABLE IF NOT EXISTS job_runs (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  job         TEXT    NOT NULL,
+  instance    TEXT    NOT NULL, -- qué servidor lo corrió
+  status      TEXT    NOT NULL DEFAULT 'en_curso',
+  detail      TEXT    NOT NULL DEFAULT '',
+  error       TEXT    NOT NULL DEFAULT '',
+  started_at  TEXT    NOT NULL, -- RFC3339 UTC
+  finished_at TEXT              -- RFC3339 UTC o NULL
+);
+CREATE INDEX IF NOT EXISTS idx_job_runs_job ON job_runs(job, id);
+
+-- un lease por job: solo la instancia que lo tiene (y mientras no venza) corre el job
+CREATE TABLE IF NOT EXISTS job_locks (
+  job        TEXT PRIMARY KEY,
+  holder     TEXT NOT NULL,
+  expires_at TEXT NOT NULL -- RFC3339 UTC
+);
+
+-- recordatorios de préstamos ya enviados: uno por préstamo, tipo y canal (email | webhook | inbox)
+CREATE TABLE IF NOT EXISTS loan_reminders (
+  loan_id INTEGER NOT NULL,
+  kind    TEXT    NOT NULL, -- vence_pronto | vence_hoy | vencido
+  channel TEXT    NOT NULL,
+  sent_at TEXT    NOT NULL, -- RFC3339 UTC
+  PRIMARY KEY(loan_id, kind, channel)
+);
+
+-- canales de recordatorio que cada usuario apagó
+CREATE TABLE IF NOT EXISTS reminder_optouts (
+  user_id INTEGER NOT NULL,
+  channel TEXT    NOT NULL,
+  PRIMARY KEY(user_id, channel),
+  FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+-- webhooks salientes: endpoints externos suscritos a eventos de dominio (events: arreglo JSON)
+CREATE TABLE IF NOT EXISTS webhooks (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  url        TEXT    NOT NULL,
+  secret     TEXT    NOT NULL, -- clave del HMAC de X-UZM-Signature
+  events     TEXT    NOT NULL DEFAULT '[]',
+  active     INTEGER NOT NULL DEFAULT 1,
+  created_at TEXT    NOT NULL -- RFC3339 UTC
+);
+
+-- eventos de dominio, escritos en la misma transacción que el cambio que los produce
+CREATE TABLE IF NOT EXISTS outbox (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  event      TEXT    NOT NULL, -- sale.created | loan.created | loan.returned | book.out_of_stock | user.created
+  payload    TEXT    NOT NULL, -- JSON
+  created_at TEXT    NOT NULL  -- RFC3339 UTC
+);
+
+-- una entrega por evento y webhook suscrito (status: pendiente | entregado | muerto)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id              INTEGER PRIMARY KEY AUTOINCREMENT,
+  webhook_id      INTEGER NOT NULL,
+  outbox_id       INTEGER NOT NULL,
+  status          TEXT    NOT NULL DEFAULT 'pendiente' CHECK (status IN ('pendiente','entregado','muerto')),
+  attempts        INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TEXT,                        -- RFC3339 UTC; NULL = cuanto antes
+  last_status     INTEGER NOT NULL DEFAULT 0,  -- código HTTP del último intento
+  last_error      TEXT    NOT NULL DEFAULT '',
+  delivered_at    TEXT,                        -- RFC3339 UTC
+  created_at      TEXT    NOT NULL,            -- RFC3339 UTC
+  FOREIGN KEY(webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+  FOREIGN KEY(outbox_id) REFERENCES outbox(id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, status);
+
+-- bitácora de acciones que modifican datos: solo se agregan filas (los triggers impiden
+-- editar o borrar) y cada una lleva el hash de la anterior (ver store.AuditEntry)
+CREATE TABLE IF NOT EXISTS audit_log (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  created_at  TEXT    NOT NULL, -- RFC3339 UTC
+  actor       TEXT    NOT NULL, -- admin | user:<id> | anónimo
+  action      TEXT    NOT NULL, -- ej. book.update
+  entity      TEXT    NOT NULL,
+  entity_id   INTEGER,
+  before_json TEXT,             -- JSON o NULL
+  after_json  TEXT,             -- JSON o NULL
+  request_id  TEXT    NOT NULL DEFAULT '',
+  prev_hash   TEXT    NOT NULL,
+  hash        TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_log(entity, entity_id);
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN SELECT RAISE(ABORT, 'audit_log es solo de anexado'); END;
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN SELECT RAISE(ABORT, 'audit_log es solo de anexado'); END;
+
+-- backfill: si aún no hay eventos, se reconstruyen desde ventas y préstamos (a mediodía UTC)
+INSERT INTO popularity_events(book_id, kind, created_at)
+SELECT book_id, kind, d FROM (
+  SELECT book_id, 'Venta' AS kind,
+         substr(sale_date, 7, 4) || '-' || substr(sale_date, 4, 2) || '-' || substr(sale_date, 1, 2) || 'T12:00:00Z' AS d
+  FROM sales
+  UNION ALL
+  SELECT book_id, 'Arriendo' AS kind,
+         substr(start_date, 7, 4) || '-' || substr(start_date, 4, 2) || '-' || substr(start_date, 1, 2) || 'T12:00:00Z' AS d
+  FROM loans
+)
+WHERE NOT EXISTS (SELECT 1 FROM popularity_events)
+ORDER BY d;
+`)
+	if err != nil {
+		return err
+	}
+
+	// precio cobrado y descuento por venta; las ventas antiguas quedan con el precio actual del libro
+	added, err := addColumn(db, "sales", "price", "INTEGER")
+	if err != nil {
+		return err
+	}
+	if added {
+		if _, err := db.Exec(`UPDATE sales SET price = (SELECT price FROM books WHERE books.id = sales.book_id)`); err != nil {
+			return err
+		}
+	}
+	if _, err := addColumn(db, "sales", "discount", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	// ISBN normalizado (solo dígitos y X); NULL en los libros que no lo tienen
+	if _, err := addColumn(db, "books", "isbn", "TEXT"); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn ON books(isbn) WHERE isbn IS NOT NULL`); err != nil {
+		return err
+	}
+	// autor principal (MARC 100); NULL si no se conoce
+	if _, err := addColumn(db, "books", "author", "TEXT"); err != nil {
+		return err
+	}
+	return rebuildLoans(db)
+}
+
+// loansColumns es la definición actual de loans (fine = multa acumulada; al devolver queda
+// la que se cobró).
+const loansColumns = `
   id          INTEGER PRIMARY KEY AUTOINCREMENT,
   user_id     INTEGER NOT NULL,
   book_id     INTEGER NOT NULL,
   start_date  TEXT    NOT NULL, -- DD/MM/YYYY
   return_date TEXT,              -- DD/MM/YYYY o NULL
-  status      TEXT    NOT NULL CHECK (status IN ('pendiente','finalizado')),
+  status      TEXT    NOT NULL CHECK (status IN ('pendiente','vencido','finalizado')),
+  fine        INTEGER NOT NULL DEFAULT 0,
   FOREIGN KEY(user_id) REFERENCES users(id),
   FOREIGN KEY(book_id) REFERENCES books(id)
-);
-`)
-	return err
+`
+
+// rebuildLoans lleva una tabla loans antigua (sin el estado vencido) a loansColumns. SQLite no
+// deja cambiar un CHECK con ALTER TABLE: se copia a una tabla nueva dentro de una transacción.
+func rebuildLoans(db *sql.DB) error {
+	var ddl string
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type='table' AND name='loans'`).Scan(&ddl); err != nil {
+		return err
+	}
+	if strings.Contains(ddl, "'vencido'") {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		`CREATE TABLE loans_new (` + loansColumns + `)`,
+		`INSERT INTO loans_new(id,user_id,book_id,start_date,return_date,status)
+		 SELECT id,user_id,book_id,start_date,return_date,status FROM loans`,
+		`DROP TABLE loans`,
+		`ALTER TABLE loans_new RENAME TO loans`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("rebuild loans: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// addColumn agrega table.column si aún no existe (SQLite no tiene ADD COLUMN IF NOT EXISTS).
+// Devuelve true si la columna se creó ahora.
+func addColumn(db *sql.DB, table, column, def string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid     int
+			name    string
+			typ     string
+			notnull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notnull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return false, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	rows.Close()
+
+	if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, def)); err != nil {
+		return false, fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
+	return true, nil
 }
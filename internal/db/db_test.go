@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Una base creada antes del estado vencido conserva sus préstamos al migrar.
+func TestMigrateRebuildsLoans(t *testing.T) {
+	sqlDB, err := Open(filepath.Join(t.TempDir(), "uzm.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if err := Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqlDB.Exec(`
+DROP TABLE loans;
+CREATE TABLE loans (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id     INTEGER NOT NULL,
+  book_id     INTEGER NOT NULL,
+  start_date  TEXT    NOT NULL,
+  return_date TEXT,
+  status      TEXT    NOT NULL CHECK (status IN ('pendiente','finalizado')),
+  FOREIGN KEY(user_id) REFERENCES users(id),
+  FOREIGN KEY(book_id) REFERENCES books(id)
+);
+INSERT INTO users(first_name,last_name,email,password) VALUES('Ana','Pérez','ana@usm.cl','x');
+INSERT INTO books(book_name,book_category,transaction_type,price) VALUES('Rayuela','Novela','Arriendo',10);
+INSERT INTO loans(user_id,book_id,start_date,return_date,status) VALUES
+  (1,1,'01/01/2025','15/01/2025','finalizado'),
+  (1,1,'01/02/2025',NULL,'pendiente');`); err != nil {
+		t.Fatal(err)
+	}
+
+	// dos veces: la segunda no debe reconstruir de nuevo
+	for range 2 {
+		if err := Migrate(sqlDB); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var n, fines int64
+	if err := sqlDB.QueryRow(`SELECT COUNT(*), SUM(fine) FROM loans`).Scan(&n, &fines); err != nil || n != 2 || fines != 0 {
+		t.Fatalf("loans tras migrar: %d filas, multas %d, %v", n, fines, err)
+	}
+	if _, err := sqlDB.Exec(`UPDATE loans SET status='vencido' WHERE id=2`); err != nil {
+		t.Errorf("vencido rechazado tras migrar: %v", err)
+	}
+	var id int64
+	if err := sqlDB.QueryRow(`INSERT INTO loans(user_id,book_id,start_date,status) VALUES(1,1,'01/03/2025','pendiente') RETURNING id`).Scan(&id); err != nil || id != 3 {
+		t.Errorf("siguiente id = %d, %v", id, err)
+	}
+}
+
+// Muchas transacciones a la vez esperan su turno por el lock de escritura: ninguna falla
+// con SQLITE_BUSY.
+func TestOpenConcurrentWriters(t *testing.T) {
+	sqlDB, err := Open(filepath.Join(t.TempDir(), "uzm.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if err := Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 200)
+	for i := range 200 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := sqlDB.Begin()
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer tx.Rollback()
+			if _, err := tx.Exec(`INSERT INTO users(first_name,last_name,email,password) VALUES('A','B',?,'x')`,
+				fmt.Sprintf("u%d@usm.cl", i)); err != nil {
+				errs <- err
+				return
+			}
+			errs <- tx.Commit()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var n int
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&n); err != nil || n != 200 {
+		t.Errorf("usuarios = %d (%v)", n, err)
+	}
+}
+
+// Con busy_timeout corto el BEGIN lo agota mientras otro escribe: se reintenta hasta que el
+// lock se suelta o se cancela el contexto, y las lecturas no esperan al escritor.
+func TestBeginRetriesWhenBusy(t *testing.T) {
+	sqlDB, err := sql.Open(DriverName, "file:"+filepath.Join(t.TempDir(), "uzm.db")+
+		"?_txlock=immediate&_pragma=busy_timeout(10)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if err := Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	insert := `INSERT INTO users(first_name,last_name,email,password) VALUES('A','B',?,'x')`
+
+	holder, err := sqlDB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Rollback()
+	if _, err := holder.Exec(insert, "a@usm.cl"); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("lectura con un escritor abierto = %d (%v)", n, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := sqlDB.BeginTx(ctx, nil); err == nil {
+		t.Fatal("BEGIN con el lock tomado y el contexto vencido no falló")
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		holder.Commit()
+	}()
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		t.Fatalf("BEGIN tras agotar busy_timeout: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(insert, "b@usm.cl"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&n); err != nil || n != 2 {
+		t.Errorf("usuarios = %d (%v)", n, err)
+	}
+}
+
+// Una base abierta con otro driver no trae la conexión envuelta: error, no panic.
+func TestBackupOtherDriver(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "otra.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if err := Backup(context.Background(), sqlDB, filepath.Join(t.TempDir(), "copia.db")); !errors.Is(err, errNoBackup) {
+		t.Errorf("err = %v, want %v", err, errNoBackup)
+	}
+}
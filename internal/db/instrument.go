@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"tarea1-uzm/internal/metrics"
+)
+
+// DriverName es el driver SQLite de modernc envuelto para medir cada consulta
+// (uzm_db_query_duration_seconds). Open lo usa; los tests que abren su propia base también.
+const DriverName = "sqlite-uzm"
+
+var (
+	queryDuration = metrics.Default.NewHistogram("uzm_db_query_duration_seconds",
+		"Duración de las consultas a SQLite por tipo de sentencia.",
+		[]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}, "op")
+	queryErrors = metrics.Default.NewCounter("uzm_db_query_errors_total",
+		"Consultas a SQLite que terminaron en error, por tipo de sentencia.", "op")
+)
+
+func init() {
+	sql.Register(DriverName, instrumented{&sqlite.Driver{}})
+}
+
+// observe registra una consulta de tipo op que empezó en start.
+func observe(op string, start time.Time, err error) {
+	queryDuration.Observe(time.Since(start).Seconds(), op)
+	if err != nil && err != driver.ErrSkip {
+		queryErrors.Inc(op)
+	}
+}
+
+// statementOp es la etiqueta op: la primera palabra de la sentencia (select, insert, ...).
+func statementOp(query string) string {
+	word, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	switch op := strings.ToLower(strings.TrimSpace(word)); op {
+	case "select", "insert", "update", "delete":
+		return op
+	case "with":
+		return "select"
+	}
+	return "other"
+}
+
+type instrumented struct{ driver.Driver }
+
+func (d instrumented) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{c.(sqliteConn)}, nil
+}
+
+// sqliteConn es lo que implementa la conexión de modernc y reexponemos.
+type sqliteConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+type conn struct{ sqliteConn }
+
+// Raw es la conexión de modernc, para lo que no pasa por database/sql (ej. backups).
+func (c *conn) Raw() driver.Conn { return c.sqliteConn }
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	res, err := c.sqliteConn.ExecContext(ctx, query, args)
+	observe(statementOp(query), start, err)
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.sqliteConn.QueryContext(ctx, query, args)
+	observe(statementOp(query), start, err)
+	return rows, err
+}
+
+// BeginTx reintenta mientras SQLite responda SQLITE_BUSY: con _txlock=immediate el BEGIN
+// espera el lock de escritura hasta busy_timeout y, si hay muchos escritores en cola, puede
+// agotarlo. Como el BEGIN falló no hay nada que deshacer; se sigue esperando hasta que ctx
+// se cancele.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	start := time.Now()
+	t, err := c.sqliteConn.BeginTx(ctx, opts)
+	for isBusy(err) && ctx.Err() == nil {
+		t, err = c.sqliteConn.BeginTx(ctx, opts)
+	}
+	observe("begin", start, err) // incluye la espera por el lock de escritura
+	if err != nil {
+		return nil, err
+	}
+	return tx{t}, nil
+}
+
+// isBusy indica si err es SQLITE_BUSY (o una de sus variantes extendidas).
+func isBusy(err error) bool {
+	var se *sqlite.Error
+	return errors.As(err, &se) && se.Code()&0xff == sqlite3.SQLITE_BUSY
+}
+
+type tx struct{ driver.Tx }
+
+func (t tx) Commit() error {
+	start := time.Now()
+	err := t.Tx.Commit()
+	observe("commit", start, err)
+	return err
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrInUse lo devuelve Lock exclusivo si otro proceso (el servidor) tiene la base abierta.
+var ErrInUse = errors.New("la base está en uso por otro proceso")
+
+// FileLock es un lock de proceso sobre <base>.lock; se suelta con Close o al terminar el
+// proceso (aunque sea a la fuerza).
+type FileLock struct{ f *os.File }
+
+// Lock toma el lock de la base en path. El servidor lo toma compartido (varias instancias
+// pueden usar la misma base) y lo que no debe correr con él arriba, como un restore,
+// exclusivo: si no se puede de inmediato devuelve ErrInUse.
+func Lock(path string, exclusive bool) (*FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileLock{f}, nil
+}
+
+func (l *FileLock) Close() error { return l.f.Close() }
@@ -0,0 +1,51 @@
+// Package logging arma el *slog.Logger del servidor: nivel y formato configurables y
+// sin campos sensibles (contraseñas, tokens) aunque alguien los pase por error.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Redacted reemplaza el valor de los atributos sensibles.
+const Redacted = "[redactado]"
+
+// sensitive son fragmentos de nombre de atributo cuyo valor nunca se escribe.
+var sensitive = []string{"password", "token", "secret", "authorization"}
+
+// New crea un logger que escribe en w. level es debug, info, warn o error (vacío = info);
+// format es json o text (vacío = json).
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("nivel de log %q: usa debug, info, warn o error", level)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redact}
+	switch strings.ToLower(format) {
+	case "", "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("formato de log %q: usa json o text", format)
+}
+
+// FromEnv es New sobre stderr con UZM_LOG_LEVEL y UZM_LOG_FORMAT.
+func FromEnv() (*slog.Logger, error) {
+	return New(os.Stderr, os.Getenv("UZM_LOG_LEVEL"), os.Getenv("UZM_LOG_FORMAT"))
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, s := range sensitive {
+		if strings.Contains(key, s) {
+			return slog.String(a.Key, Redacted)
+		}
+	}
+	return a
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, "warn", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("no se escribe")
+	l.Warn("login", "user_id", 7, "password", "clave123", "admin_token", "abc", slog.Group("datos", "secret", "xyz"))
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("una sola línea JSON: %v\n%s", err, buf.String())
+	}
+	if line["msg"] != "login" || line["level"] != "WARN" || line["user_id"] != float64(7) {
+		t.Errorf("línea = %v", line)
+	}
+	if line["password"] != Redacted || line["admin_token"] != Redacted {
+		t.Errorf("campos sensibles sin redactar: %v", line)
+	}
+	for _, secret := range []string{"clave123", "abc", "xyz"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("el log contiene %q:\n%s", secret, buf.String())
+		}
+	}
+}
+
+func TestNewText(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, "", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("no se escribe")
+	l.Info("venta", "sale_id", 3)
+	if got := buf.String(); !strings.Contains(got, "level=INFO msg=venta sale_id=3") || strings.Contains(got, "DEBUG") {
+		t.Errorf("salida = %q", got)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "verbose", ""); err == nil {
+		t.Error("nivel inválido aceptado")
+	}
+	if _, err := New(&bytes.Buffer{}, "", "xml"); err == nil {
+		t.Error("formato inválido aceptado")
+	}
+}
@@ -0,0 +1,116 @@
+package marc
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"tarea1-uzm/internal/bulk"
+)
+
+// Book son los datos de un libro que viajan en MARC y Dublin Core.
+type Book struct {
+	ID      int64
+	ISBN    string
+	Title   string
+	Author  string
+	Subject string
+}
+
+// leader de los registros exportados: libro (nam), UTF-8 (a), nivel mínimo (7) y sin
+// puntuación ISBD (c). Largo y dirección base los completa WriteISO2709.
+const leader = "00000nam a22000007c 4500"
+
+// FromBook arma el registro MARC21 de b: 001 id, 020 $a ISBN, 100 $a autor, 245 $a título
+// y 650 $a materia (la categoría).
+func FromBook(b Book) Record {
+	rec := Record{Leader: leader, Control: []ControlField{{Tag: "001", Value: strconv.FormatInt(b.ID, 10)}}}
+	if b.ISBN != "" {
+		rec.Data = append(rec.Data, DataField{Tag: "020", Ind1: " ", Ind2: " ", Subfields: []Subfield{{"a", b.ISBN}}})
+	}
+	// 245 ind1: 1 si hay autor principal
+	ind1 := "0"
+	if b.Author != "" {
+		ind1 = "1"
+		rec.Data = append(rec.Data, DataField{Tag: "100", Ind1: "1", Ind2: " ", Subfields: []Subfield{{"a", b.Author}}})
+	}
+	rec.Data = append(rec.Data, DataField{Tag: "245", Ind1: ind1, Ind2: "0", Subfields: []Subfield{{"a", b.Title}}})
+	if b.Subject != "" {
+		rec.Data = append(rec.Data, DataField{Tag: "650", Ind1: " ", Ind2: "4", Subfields: []Subfield{{"a", b.Subject}}})
+	}
+	return rec
+}
+
+// Fields traduce el registro a columnas de la importación de libros (isbn, book_name,
+// author, book_category); solo trae las que el registro tiene. Si el líder dice que hay
+// puntuación ISBD (posición 18 = a o i), se le quita a cada valor la del final.
+func (r Record) Fields() map[string]string {
+	isbd := len(r.Leader) == 24 && (r.Leader[18] == 'a' || r.Leader[18] == 'i')
+	clean := func(s string) string {
+		s = strings.TrimSpace(s)
+		if isbd {
+			s = strings.TrimSpace(strings.TrimRight(s, " /:;,=."))
+		}
+		return s
+	}
+	out := map[string]string{}
+	set := func(col, v string) {
+		if v = clean(v); v != "" {
+			out[col] = v
+		}
+	}
+	if f, ok := r.Field("020"); ok {
+		// "8437604575 (rústica)": el ISBN es lo primero
+		if v := strings.Fields(f.Sub("a")); len(v) > 0 {
+			out["isbn"] = v[0]
+		}
+	}
+	if f, ok := r.Field("245"); ok {
+		title := clean(f.Sub("a"))
+		if sub := clean(f.Sub("b")); sub != "" && title != "" {
+			title += " : " + sub
+		}
+		set("book_name", title)
+	}
+	if f, ok := r.Field("100"); ok {
+		set("author", f.Sub("a"))
+	}
+	if f, ok := r.Field("650"); ok {
+		set("book_category", f.Sub("a"))
+	}
+	return out
+}
+
+// Rows lee un archivo ISO2709 o XML y devuelve una fila por registro, con Line = el número
+// de registro (desde 1). Un registro sin título es un error de esa fila.
+func Rows(r io.Reader, format string) ([]bulk.Row, []bulk.RowError, error) {
+	var (
+		recs []Record
+		err  error
+	)
+	switch format {
+	case ISO2709:
+		recs, err = ReadISO2709(r)
+	case XML:
+		recs, err = ReadXML(r)
+	default:
+		return nil, nil, fmt.Errorf("marc: no se puede importar %q", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		rows []bulk.Row
+		errs []bulk.RowError
+	)
+	for i, rec := range recs {
+		fields := rec.Fields()
+		if fields["book_name"] == "" {
+			errs = append(errs, bulk.RowError{Line: i + 1, Field: "245", Message: "falta el título (245 $a)"})
+			continue
+		}
+		rows = append(rows, bulk.Row{Line: i + 1, Fields: fields})
+	}
+	return rows, errs, nil
+}
@@ -0,0 +1,59 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Espacios de nombres de Dublin Core simple en su forma OAI (oai_dc).
+const (
+	NamespaceDC    = "http://purl.org/dc/elements/1.1/"
+	NamespaceOAIDC = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+)
+
+// dcRecord es un <oai_dc:dc>. encoding/xml no maneja prefijos, así que van escritos en el
+// nombre y declarados una vez en la raíz.
+type dcRecord struct {
+	XMLName     xml.Name `xml:"oai_dc:dc"`
+	Title       string   `xml:"dc:title"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Subject     string   `xml:"dc:subject,omitempty"`
+	Type        string   `xml:"dc:type"`
+	Identifiers []string `xml:"dc:identifier"`
+}
+
+// WriteDC escribe books como una <collection> de registros Dublin Core: título, autor
+// (creator), categoría (subject) e identificadores (urn:isbn y uzm:book:<id> del catálogo).
+func WriteDC(w io.Writer, books []Book) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	root := xml.StartElement{Name: xml.Name{Local: "collection"}, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "xmlns:oai_dc"}, Value: NamespaceOAIDC},
+		{Name: xml.Name{Local: "xmlns:dc"}, Value: NamespaceDC},
+	}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+	for _, b := range books {
+		rec := dcRecord{Title: b.Title, Creator: b.Author, Subject: b.Subject, Type: "Text"}
+		if b.ISBN != "" {
+			rec.Identifiers = append(rec.Identifiers, "urn:isbn:"+b.ISBN)
+		}
+		rec.Identifiers = append(rec.Identifiers, fmt.Sprintf("uzm:book:%d", b.ID))
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,220 @@
+// Package marc lee y escribe registros bibliográficos MARC21, en ISO 2709 (el binario .mrc)
+// y en MARCXML, y exporta Dublin Core. Los registros se traducen a las mismas filas
+// (bulk.Row) que la importación CSV, así que validar y aplicar al catálogo es igual.
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Formatos soportados.
+const (
+	ISO2709 = "marc"    // MARC21 binario (ISO 2709), solo UTF-8
+	XML     = "marcxml" // MARCXML (http://www.loc.gov/MARC21/slim)
+	DC      = "dc"      // Dublin Core (oai_dc), solo exportación
+)
+
+// ContentType es el Content-Type de cada formato.
+func ContentType(format string) string {
+	switch format {
+	case ISO2709:
+		return "application/marc"
+	case XML:
+		return "application/marcxml+xml"
+	}
+	return "application/xml"
+}
+
+// Ext es la extensión de archivo de cada formato.
+func Ext(format string) string {
+	if format == ISO2709 {
+		return "mrc"
+	}
+	return "xml"
+}
+
+// FormatOf reconoce un formato importable por su Content-Type; "" si no es ninguno.
+func FormatOf(contentType string) string {
+	mt, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mt)) {
+	case "application/marc":
+		return ISO2709
+	case "application/marcxml+xml":
+		return XML
+	}
+	return ""
+}
+
+// Record es un registro MARC: el líder y sus campos de control (001-009) y de datos, en el
+// orden en que vienen.
+type Record struct {
+	Leader  string         `xml:"leader"`
+	Control []ControlField `xml:"controlfield"`
+	Data    []DataField    `xml:"datafield"`
+}
+
+type ControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type DataField struct {
+	Tag       string     `xml:"tag,attr"`
+	Ind1      string     `xml:"ind1,attr"`
+	Ind2      string     `xml:"ind2,attr"`
+	Subfields []Subfield `xml:"subfield"`
+}
+
+type Subfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Field devuelve el primer campo de datos con tag, si hay.
+func (r Record) Field(tag string) (DataField, bool) {
+	for _, f := range r.Data {
+		if f.Tag == tag {
+			return f, true
+		}
+	}
+	return DataField{}, false
+}
+
+// Sub devuelve el primer subcampo code ("" si no está).
+func (f DataField) Sub(code string) string {
+	for _, s := range f.Subfields {
+		if s.Code == code {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// Separadores de ISO 2709.
+const (
+	fieldEnd  = 0x1E
+	recordEnd = 0x1D
+	subfield  = 0x1F
+)
+
+// ReadISO2709 lee todos los registros de r. Un registro mal formado corta la lectura: sin
+// un largo confiable no hay cómo seguir con el siguiente.
+func ReadISO2709(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var recs []Record
+	for _, raw := range bytes.Split(data, []byte{recordEnd}) {
+		// entre registros puede haber saltos de línea
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		rec, err := parseISO2709(bytes.TrimLeft(raw, "\r\n"))
+		if err != nil {
+			return nil, fmt.Errorf("registro %d: %w", len(recs)+1, err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+func parseISO2709(raw []byte) (Record, error) {
+	if len(raw) < 25 {
+		return Record{}, errors.New("registro demasiado corto")
+	}
+	rec := Record{Leader: string(raw[:24])}
+	if !utf8.Valid(raw) {
+		return rec, errors.New("el registro no está en UTF-8 (MARC-8 no se soporta)")
+	}
+	base, ok := number(raw[12:17])
+	if !ok || base < 25 || base > len(raw) {
+		return rec, errors.New("líder inválido: dirección base de los datos")
+	}
+	dir := raw[24 : base-1]
+	if raw[base-1] != fieldEnd || len(dir)%12 != 0 {
+		return rec, errors.New("directorio inválido")
+	}
+	for i := 0; i < len(dir); i += 12 {
+		tag := string(dir[i : i+3])
+		n, ok1 := number(dir[i+3 : i+7])
+		start, ok2 := number(dir[i+7 : i+12])
+		if !ok1 || !ok2 || n < 1 || start < 0 || base+start+n > len(raw) {
+			return rec, fmt.Errorf("directorio inválido en el campo %s", tag)
+		}
+		field := raw[base+start : base+start+n-1] // sin el fin de campo
+		if tag < "010" {
+			rec.Control = append(rec.Control, ControlField{Tag: tag, Value: string(field)})
+			continue
+		}
+		if len(field) < 2 {
+			return rec, fmt.Errorf("campo %s sin indicadores", tag)
+		}
+		df := DataField{Tag: tag, Ind1: string(field[0]), Ind2: string(field[1])}
+		for _, sf := range bytes.Split(field[2:], []byte{subfield})[1:] {
+			if len(sf) > 0 {
+				df.Subfields = append(df.Subfields, Subfield{Code: string(sf[0]), Value: string(sf[1:])})
+			}
+		}
+		rec.Data = append(rec.Data, df)
+	}
+	return rec, nil
+}
+
+// number lee un número del líder o del directorio: solo dígitos ASCII, sin signo ni espacios
+// (strconv.Atoi aceptaría "-0040" y el corte del campo saldría de rango).
+func number(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, len(b) > 0
+}
+
+// WriteISO2709 escribe rec en ISO 2709, recalculando el largo y la dirección base del líder.
+func WriteISO2709(w io.Writer, rec Record) error {
+	var dir, data bytes.Buffer
+	add := func(tag string, field []byte) {
+		fmt.Fprintf(&dir, "%3s%04d%05d", tag, len(field)+1, data.Len())
+		data.Write(field)
+		data.WriteByte(fieldEnd)
+	}
+	for _, f := range rec.Control {
+		add(f.Tag, []byte(f.Value))
+	}
+	for _, f := range rec.Data {
+		var b bytes.Buffer
+		b.WriteString(indicator(f.Ind1) + indicator(f.Ind2))
+		for _, s := range f.Subfields {
+			b.WriteByte(subfield)
+			b.WriteString(s.Code + s.Value)
+		}
+		add(f.Tag, b.Bytes())
+	}
+	dir.WriteByte(fieldEnd)
+	base := 24 + dir.Len()
+	leader := []byte(fmt.Sprintf("%-24s", rec.Leader)[:24])
+	copy(leader[0:5], fmt.Sprintf("%05d", base+data.Len()+1))
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+	for _, part := range [][]byte{leader, dir.Bytes(), data.Bytes(), {recordEnd}} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func indicator(s string) string {
+	if s == "" {
+		return " "
+	}
+	return s[:1]
+}
@@ -0,0 +1,169 @@
+package marc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// muestra.mrc y muestra.xml son los mismos tres registros, como los mandaría otra biblioteca:
+// con puntuación ISBD, subcampos que no usamos y el 020 con calificador.
+var sample = []map[string]string{
+	{"isbn": "9788437604572", "author": "Cortázar, Julio", "book_name": "Rayuela", "book_category": "Novela argentina"},
+	{"isbn": "978-0-307-47472-8", "author": "García Márquez, Gabriel", "book_name": "Cien años de soledad : novela", "book_category": "Novela colombiana"},
+	{"author": "Borges, Jorge Luis", "book_name": "El Aleph", "book_category": "Cuentos argentinos"},
+}
+
+func TestReadSamples(t *testing.T) {
+	for _, tt := range []struct{ file, format string }{{"testdata/muestra.mrc", ISO2709}, {"testdata/muestra.xml", XML}} {
+		f, err := os.Open(tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, errs, err := Rows(f, tt.format)
+		f.Close()
+		if err != nil || len(errs) > 0 {
+			t.Fatalf("%s: %v %v", tt.file, errs, err)
+		}
+		if len(rows) != len(sample) {
+			t.Fatalf("%s: %d filas", tt.file, len(rows))
+		}
+		for i, row := range rows {
+			if row.Line != i+1 || !reflect.DeepEqual(row.Fields, sample[i]) {
+				t.Errorf("%s: registro %d = %+v", tt.file, i+1, row)
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	// el binario se reescribe byte a byte igual
+	raw, err := os.ReadFile("testdata/muestra.mrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs, err := ReadISO2709(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	for _, rec := range recs {
+		if err := WriteISO2709(&buf, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(buf.Bytes(), raw) {
+		t.Errorf("reescrito:\n%q\nwant:\n%q", buf.Bytes(), raw)
+	}
+	// y en XML queda el mismo registro
+	buf.Reset()
+	if err := WriteXML(&buf, recs); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadXML(&buf)
+	if err != nil || !reflect.DeepEqual(again, recs) {
+		t.Errorf("MARCXML: %+v (%v)", again, err)
+	}
+
+	// nuestros libros (sin ISBD: las comas y puntos son parte del dato) vuelven iguales
+	books := []Book{
+		{ID: 1, ISBN: "9788437604572", Title: "Rayuela, 2ª ed.", Author: "Cortázar, Julio", Subject: "Novela"},
+		{ID: 2, Title: "Etc.", Subject: "Ensayo"},
+	}
+	for _, format := range []string{ISO2709, XML} {
+		var buf bytes.Buffer
+		recs := []Record{FromBook(books[0]), FromBook(books[1])}
+		if format == XML {
+			err = WriteXML(&buf, recs)
+		} else {
+			for _, rec := range recs {
+				err = WriteISO2709(&buf, rec)
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, errs, err := Rows(&buf, format)
+		if err != nil || len(errs) > 0 || len(rows) != 2 {
+			t.Fatalf("%s: %+v %v %v", format, rows, errs, err)
+		}
+		want := map[string]string{"isbn": "9788437604572", "book_name": "Rayuela, 2ª ed.", "author": "Cortázar, Julio", "book_category": "Novela"}
+		if !reflect.DeepEqual(rows[0].Fields, want) || rows[1].Fields["book_name"] != "Etc." || len(rows[1].Fields) != 2 {
+			t.Errorf("%s: %+v", format, rows)
+		}
+	}
+}
+
+func TestWriteDC(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteDC(&buf, []Book{
+		{ID: 7, ISBN: "9788437604572", Title: "Rayuela", Author: "Cortázar, Julio", Subject: "Novela"},
+		{ID: 8, Title: "El Aleph & otros"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// se lee con los namespaces de verdad, no con los prefijos
+	var doc struct {
+		Records []struct {
+			XMLName     xml.Name
+			Title       string   `xml:"http://purl.org/dc/elements/1.1/ title"`
+			Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			Subject     string   `xml:"http://purl.org/dc/elements/1.1/ subject"`
+			Identifiers []string `xml:"http://purl.org/dc/elements/1.1/ identifier"`
+		} `xml:"http://www.openarchives.org/OAI/2.0/oai_dc/ dc"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("%v\n%s", err, buf.String())
+	}
+	if len(doc.Records) != 2 {
+		t.Fatalf("registros = %s", buf.String())
+	}
+	r := doc.Records[0]
+	if r.Title != "Rayuela" || r.Creator != "Cortázar, Julio" || r.Subject != "Novela" ||
+		strings.Join(r.Identifiers, " ") != "urn:isbn:9788437604572 uzm:book:7" {
+		t.Errorf("dc = %+v", r)
+	}
+	if r := doc.Records[1]; r.Title != "El Aleph & otros" || r.Creator != "" || strings.Join(r.Identifiers, " ") != "uzm:book:8" {
+		t.Errorf("dc = %+v", r)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	raw, _ := os.ReadFile("testdata/muestra.mrc")
+	// patch cambia raw[at:] por s en una copia (la primera entrada del directorio va en 24:36)
+	patch := func(at int, s string) []byte {
+		out := bytes.Clone(raw)
+		copy(out[at:], s)
+		return out
+	}
+	for name, data := range map[string][]byte{
+		"cortado":          raw[:100],
+		"latin-1":          bytes.Replace(raw, []byte("á"), []byte{0xE1, ' '}, 1),
+		"sin base":         append([]byte("00030nam a22xxxxx   4500"), raw[24:]...),
+		"base con signo":   patch(12, "+0"),
+		"offset negativo":  patch(31, "-0040"),
+		"largo con signo":  patch(27, "+001"),
+		"largo cero":       patch(27, "0000"),
+		"offset con letra": patch(31, "0x010"),
+	} {
+		if _, err := ReadISO2709(bytes.NewReader(data)); err == nil || !strings.HasPrefix(err.Error(), "registro 1: ") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+	if _, err := ReadXML(strings.NewReader(`<collection xmlns="http://www.loc.gov/MARC21/slim"/>`)); err == nil {
+		t.Error("MARCXML sin registros sin error")
+	}
+
+	// un registro sin título es un error de ese registro, no del archivo
+	var buf bytes.Buffer
+	WriteISO2709(&buf, FromBook(Book{ID: 1, Title: "Rayuela"}))
+	WriteISO2709(&buf, Record{Leader: leader, Data: []DataField{{Tag: "100", Subfields: []Subfield{{"a", "Anónimo"}}}}})
+	rows, errs, err := Rows(&buf, ISO2709)
+	if err != nil || len(rows) != 1 || len(errs) != 1 || errs[0].Line != 2 || errs[0].Field != "245" {
+		t.Errorf("filas = %+v, errores = %+v (%v)", rows, errs, err)
+	}
+}
@@ -0,0 +1,63 @@
+package marc
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// Namespace es el espacio de nombres de MARCXML.
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+// ReadXML lee los <record> de un documento MARCXML, sea una <collection> o un registro suelto.
+// Acepta el namespace por defecto o con prefijo (marc:record).
+func ReadXML(r io.Reader) ([]Record, error) {
+	dec := xml.NewDecoder(r)
+	var recs []Record
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "record" {
+			var rec Record
+			if err := dec.DecodeElement(&rec, &se); err != nil {
+				return nil, err
+			}
+			recs = append(recs, rec)
+		}
+	}
+	if recs == nil {
+		return nil, errors.New("el documento no trae elementos <record>")
+	}
+	return recs, nil
+}
+
+// WriteXML escribe recs como una <collection> MARCXML.
+func WriteXML(w io.Writer, recs []Record) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	root := xml.StartElement{Name: xml.Name{Local: "collection"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: Namespace}}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		if err := enc.EncodeElement(rec, xml.StartElement{Name: xml.Name{Local: "record"}}); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
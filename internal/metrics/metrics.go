@@ -0,0 +1,270 @@
+// Package metrics es un registro mínimo de métricas en el formato de texto de Prometheus
+// (contadores, histogramas y valores que se leen al momento del scrape), sin dependencias.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Buckets por defecto de Prometheus, en segundos: sirven para latencias HTTP.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default es el registro del proceso: HTTP y base de datos se registran aquí.
+var Default = NewRegistry()
+
+// Registry agrupa métricas y las escribe ordenadas por nombre.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	write(w *bufio.Writer) error
+}
+
+func NewRegistry() *Registry { return &Registry{metrics: map[string]metric{}} }
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.metrics[name]; dup {
+		panic("metrics: " + name + " registrada dos veces")
+	}
+	r.metrics[name] = m
+}
+
+// Write escribe todas las métricas en formato de texto (versión 0.0.4).
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]metric, len(names))
+	for i, name := range names {
+		list[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range list {
+		if err := m.write(bw); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ContentType es el Content-Type del formato de texto.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Counter es un contador con etiquetas (puede no tener ninguna).
+type Counter struct {
+	vec
+}
+
+// NewCounter registra un contador; por convención el nombre termina en _total.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec{name: name, help: help, kind: "counter", labels: labels, series: map[string]*series{}}}
+	r.register(name, c)
+	return c
+}
+
+// Inc suma 1 a la serie de esos valores de etiqueta.
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add suma v (>= 0) a la serie de esos valores de etiqueta.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	s := c.get(labelValues)
+	c.mu.Lock()
+	s.sum += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w *bufio.Writer) error {
+	c.header(w)
+	c.each(func(labels string, s *series) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labels, num(s.sum))
+	})
+	return nil
+}
+
+// Histogram cuenta observaciones por bucket (acumulado, como pide Prometheus).
+type Histogram struct {
+	vec
+	buckets []float64
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{vec{name: name, help: help, kind: "histogram", labels: labels, series: map[string]*series{}}, buckets}
+	r.register(name, h)
+	return h
+}
+
+// Observe registra v en la serie de esos valores de etiqueta.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	s := h.get(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+	for i, le := range h.buckets {
+		if v <= le {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) error {
+	h.header(w)
+	h.each(func(labels string, s *series) {
+		for i, le := range h.buckets {
+			var n uint64
+			if s.counts != nil {
+				n = s.counts[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(labels, "le", num(le)), n)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labels, num(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labels, s.count)
+	})
+	return nil
+}
+
+// Func es una métrica sin etiquetas cuyo valor se calcula en cada scrape (ej. consultando
+// la base). Si f falla, la métrica se omite de ese scrape y el error se pasa a onError.
+type Func struct {
+	name, help, kind string
+	f                func() (float64, error)
+	onError          func(name string, err error)
+}
+
+// NewGaugeFunc registra un gauge calculado al momento del scrape.
+func (r *Registry) NewGaugeFunc(name, help string, f func() (float64, error)) *Func {
+	m := &Func{name: name, help: help, kind: "gauge", f: f}
+	r.register(name, m)
+	return m
+}
+
+// NewCounterFunc es NewGaugeFunc para un valor que solo crece (ej. un COUNT(*) de ventas).
+func (r *Registry) NewCounterFunc(name, help string, f func() (float64, error)) *Func {
+	m := &Func{name: name, help: help, kind: "counter", f: f}
+	r.register(name, m)
+	return m
+}
+
+// OnError define qué hacer cuando el cálculo falla (por defecto, nada).
+func (m *Func) OnError(fn func(name string, err error)) *Func {
+	m.onError = fn
+	return m
+}
+
+func (m *Func) write(w *bufio.Writer) error {
+	v, err := m.f()
+	if err != nil {
+		if m.onError != nil {
+			m.onError(m.name, err)
+		}
+		return nil
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", m.name, escapeHelp(m.help), m.name, m.kind, m.name, num(v))
+	return nil
+}
+
+// vec es lo común de las métricas con etiquetas: una serie por combinación de valores.
+type vec struct {
+	name, help, kind string
+	labels           []string
+	mu               sync.Mutex
+	series           map[string]*series
+}
+
+type series struct {
+	values []string
+	sum    float64
+	count  uint64
+	counts []uint64 // solo histogramas
+}
+
+func (v *vec) get(values []string) *series {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s espera %d etiquetas, llegaron %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+func (v *vec) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, escapeHelp(v.help), v.name, v.kind)
+}
+
+// each recorre las series en orden estable, con la tabla bloqueada.
+func (v *vec) each(fn func(labels string, s *series)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := v.series[k]
+		fn(v.format(s.values), s)
+	}
+}
+
+func (v *vec) format(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	parts := make([]string, len(values))
+	for i, val := range values {
+		parts[i] = v.labels[i] + `="` + escapeLabel(val) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + value + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+func num(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("app_requests_total", "Requests.", "route", "status")
+	c.Inc("/books", "200")
+	c.Add(2, "/books", "200")
+	c.Inc(`/a"b`, "500")
+	h := r.NewHistogram("app_latency_seconds", "Latencia\ncon salto.", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+	r.NewGaugeFunc("app_up", "Arriba.", func() (float64, error) { return 1, nil })
+	var failed string
+	r.NewGaugeFunc("app_broken", "Falla.", func() (float64, error) { return 0, errors.New("sin base") }).
+		OnError(func(name string, err error) { failed = name })
+
+	var out strings.Builder
+	if err := r.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP app_latency_seconds Latencia\ncon salto.
+# TYPE app_latency_seconds histogram
+app_latency_seconds_bucket{le="0.1"} 1
+app_latency_seconds_bucket{le="1"} 2
+app_latency_seconds_bucket{le="+Inf"} 3
+app_latency_seconds_sum 3.55
+app_latency_seconds_count 3
+# HELP app_requests_total Requests.
+# TYPE app_requests_total counter
+app_requests_total{route="/a\"b",status="500"} 1
+app_requests_total{route="/books",status="200"} 3
+# HELP app_up Arriba.
+# TYPE app_up gauge
+app_up 1
+`
+	if out.String() != want {
+		t.Errorf("salida:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if failed != "app_broken" {
+		t.Errorf("OnError no se llamó (%q)", failed)
+	}
+}
+
+func TestDuplicateNamePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("x_total", "x")
+	defer func() {
+		if recover() == nil {
+			t.Error("registrar dos veces el mismo nombre debería fallar")
+		}
+	}()
+	r.NewCounter("x_total", "x")
+}
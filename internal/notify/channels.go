@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"time"
+
+	"tarea1-uzm/internal/store"
+)
+
+// DefaultFrom es el remitente de los correos si no se configura UZM_SMTP_FROM.
+const DefaultFrom = "biblioteca@uzm.cl"
+
+// timeout acota cada envío cuando ctx no trae plazo.
+const timeout = 30 * time.Second
+
+// SMTP manda el recordatorio por correo. Usa STARTTLS si el servidor lo ofrece y
+// autenticación PLAIN si hay Username (net/smtp la rechaza sin TLS, salvo en localhost).
+type SMTP struct {
+	Addr               string // host:puerto
+	From               string
+	Username, Password string
+}
+
+func (SMTP) Name() string { return "email" }
+
+func (s SMTP) Send(ctx context.Context, m Message) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	host, _, _ := net.SplitHostPort(s.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return fmt.Errorf("smtp MAIL: %w", err)
+	}
+	if err := c.Rcpt(m.Email); err != nil {
+		return fmt.Errorf("smtp RCPT %s: %w", m.Email, err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(s.mail(m)); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	return c.Quit()
+}
+
+// mail arma el correo: asunto codificado (lleva tildes) y cuerpo quoted-printable en UTF-8.
+func (s SMTP) mail(m Message) []byte {
+	var b bytes.Buffer
+	to := mail.Address{Name: m.Name, Address: m.Email}
+	from := mail.Address{Name: "Biblioteca UZM", Address: s.From}
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n", from.String(), to.String(),
+		mime.QEncoding.Encode("utf-8", m.Subject), m.At.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(m.Body))
+	qp.Close()
+	return b.Bytes()
+}
+
+// Webhook publica el recordatorio como JSON (Message) con un POST a URL; cualquier respuesta
+// fuera de 2xx cuenta como fallo y se reintenta en la próxima pasada del job.
+type Webhook struct {
+	URL    string
+	Client *http.Client // nil = http.Client con timeout de 30s
+}
+
+func (Webhook) Name() string { return "webhook" }
+
+func (w Webhook) Send(ctx context.Context, m Message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s respondió %d", w.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// Inbox deja el recordatorio (solo el asunto) en la bandeja GET /users/:id/notifications.
+type Inbox struct {
+	DB *sql.DB
+}
+
+func (Inbox) Name() string { return "inbox" }
+
+func (i Inbox) Send(ctx context.Context, m Message) error {
+	return store.New(i.DB).Read().Notifications().Create(ctx, m.UserID, m.BookID, m.Kind, m.Subject, m.At)
+}
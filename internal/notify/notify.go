@@ -0,0 +1,67 @@
+// Package notify envía los recordatorios de préstamos por los canales configurados:
+// correo (SMTP), webhook saliente y la bandeja de notificaciones de la app.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Tipos de recordatorio (también son el kind de la notificación en la bandeja).
+const (
+	KindDueSoon  = "vence_pronto" // faltan DaysBefore días o menos
+	KindDueToday = "vence_hoy"
+	KindOverdue  = "vencido"
+)
+
+// DaysBefore es con cuántos días de anticipación se avisa que un préstamo vence.
+const DaysBefore = 3
+
+// Channels son los nombres de canal que un usuario puede apagar (estén configurados o no).
+var Channels = []string{"email", "webhook", "inbox"}
+
+// Message es un recordatorio ya redactado para un usuario.
+type Message struct {
+	Kind    string    `json:"kind"`
+	UserID  int64     `json:"user_id"`
+	Name    string    `json:"name"`
+	Email   string    `json:"email"`
+	LoanID  int64     `json:"loan_id"`
+	BookID  int64     `json:"book_id"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	At      time.Time `json:"at"`
+}
+
+// Notifier es un canal de recordatorios.
+type Notifier interface {
+	// Name identifica el canal (email, webhook, inbox): es lo que el usuario puede apagar.
+	Name() string
+	Send(ctx context.Context, m Message) error
+}
+
+// FromEnv arma los canales externos según el entorno (la bandeja no se configura: va
+// siempre): el correo si hay UZM_SMTP_ADDR (con UZM_SMTP_FROM, UZM_SMTP_USER y
+// UZM_SMTP_PASSWORD) y el webhook si hay UZM_REMINDER_WEBHOOK_URL.
+func FromEnv() ([]Notifier, error) {
+	var out []Notifier
+	if addr := os.Getenv("UZM_SMTP_ADDR"); addr != "" {
+		from := os.Getenv("UZM_SMTP_FROM")
+		if from == "" {
+			from = DefaultFrom
+		}
+		out = append(out, SMTP{Addr: addr, From: from,
+			Username: os.Getenv("UZM_SMTP_USER"), Password: os.Getenv("UZM_SMTP_PASSWORD")})
+	}
+	if raw := os.Getenv("UZM_REMINDER_WEBHOOK_URL"); raw != "" {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("UZM_REMINDER_WEBHOOK_URL inválida: %q", raw)
+		}
+		out = append(out, Webhook{URL: raw})
+	}
+	return out, nil
+}
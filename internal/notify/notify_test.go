@@ -0,0 +1,214 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpServer es un servidor SMTP mínimo en localhost que guarda lo que recibe (sin TLS ni
+// autenticación, como un MailHog).
+type smtpServer struct {
+	Addr string
+	got  chan string // "MAIL FROM|RCPT TO|DATA"
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpServer{Addr: ln.Addr().String(), got: make(chan string, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	say := func(line string) { io.WriteString(conn, line+"\r\n") }
+	say("220 localhost ESMTP prueba")
+	var from, to string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			say("250-localhost")
+			say("250 8BITMIME")
+		case "MAIL":
+			from = cmd
+			say("250 OK")
+		case "RCPT":
+			to = cmd
+			say("250 OK")
+		case "DATA":
+			say("354 fin con <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.got <- from + "|" + to + "|" + data.String()
+			say("250 OK")
+		case "QUIT":
+			say("221 chao")
+			return
+		default:
+			say("250 OK")
+		}
+	}
+}
+
+func reminder(kind string, daysLeft int64) Reminder {
+	return Reminder{Kind: kind, UserID: 7, Name: "Ana", Email: "ana@usm.cl", LoanID: 3, BookID: 2, Book: "Rayuela",
+		StartDate: "01/02/2025", DueDate: "01/03/2025", DaysLeft: daysLeft, Fine: max(-daysLeft, 0) * 2, PenaltyPerDay: 2}
+}
+
+func TestCompose(t *testing.T) {
+	tests := []struct {
+		r       Reminder
+		subject string
+		body    string
+	}{
+		{reminder(KindDueSoon, 3), "Tu préstamo de «Rayuela» vence en 3 días", "vence el 01/03/2025 (en 3 días)"},
+		{reminder(KindDueSoon, 1), "Tu préstamo de «Rayuela» vence en 1 día", "multa de 2 usm pesos"},
+		{reminder(KindDueToday, 0), "Hoy vence tu préstamo de «Rayuela»", "vence hoy, 01/03/2025"},
+		{reminder(KindOverdue, -4), "Tu préstamo de «Rayuela» está vencido", "lleva 4 días de atraso.\nLa multa acumulada es de 8 usm pesos"},
+	}
+	for _, tt := range tests {
+		m, err := Compose(tt.r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Subject != tt.subject || !strings.Contains(m.Body, tt.body) || !strings.HasPrefix(m.Body, "Hola Ana:") {
+			t.Errorf("%s (%d): %q\n%s", tt.r.Kind, tt.r.DaysLeft, m.Subject, m.Body)
+		}
+		if m.UserID != 7 || m.LoanID != 3 || m.Email != "ana@usm.cl" {
+			t.Errorf("%s: %+v", tt.r.Kind, m)
+		}
+	}
+	if _, err := Compose(reminder("otro", 0)); err == nil {
+		t.Error("tipo desconocido aceptado")
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	srv := newSMTPServer(t)
+	m, _ := Compose(reminder(KindOverdue, -4))
+	m.At = time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)
+	if err := (SMTP{Addr: srv.Addr, From: DefaultFrom}).Send(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	select {
+	case got = <-srv.got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("el servidor SMTP no recibió nada")
+	}
+	parts := strings.SplitN(got, "|", 3)
+	if parts[0] != "MAIL FROM:<biblioteca@uzm.cl> BODY=8BITMIME" || parts[1] != "RCPT TO:<ana@usm.cl>" {
+		t.Errorf("sobre = %q %q", parts[0], parts[1])
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(parts[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	to, _ := msg.Header.AddressList("To")
+	if subject != m.Subject || len(to) != 1 || to[0].Address != "ana@usm.cl" {
+		t.Errorf("cabeceras: %q %v", subject, to)
+	}
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	// el fin de DATA agrega un salto de línea al final
+	if got := strings.TrimSuffix(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n"); got != m.Body {
+		t.Errorf("cuerpo =\n%s\nwant\n%s", got, m.Body)
+	}
+
+	// sin servidor: error, para reintentar en la próxima pasada
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := (SMTP{Addr: "127.0.0.1:1", From: DefaultFrom}).Send(ctx, m); err == nil {
+		t.Error("envío sin servidor SMTP sin error")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got Message
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	m, _ := Compose(reminder(KindDueToday, 0))
+	if err := (Webhook{URL: srv.URL}).Send(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != KindDueToday || got.LoanID != 3 || got.Subject != m.Subject {
+		t.Errorf("payload = %+v", got)
+	}
+	status = http.StatusBadGateway
+	if err := (Webhook{URL: srv.URL}).Send(context.Background(), m); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("502: %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("UZM_SMTP_ADDR", "")
+	t.Setenv("UZM_REMINDER_WEBHOOK_URL", "")
+	names := func() []string {
+		t.Helper()
+		list, err := FromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, n := range list {
+			out = append(out, n.Name())
+		}
+		return out
+	}
+	if got := strings.Join(names(), ","); got != "" {
+		t.Errorf("sin configurar: %s", got)
+	}
+	t.Setenv("UZM_SMTP_ADDR", "localhost:1025")
+	t.Setenv("UZM_REMINDER_WEBHOOK_URL", "https://example.org/recordatorios")
+	if got := strings.Join(names(), ","); got != "email,webhook" {
+		t.Errorf("configurado: %s", got)
+	}
+	t.Setenv("UZM_REMINDER_WEBHOOK_URL", "example.org")
+	if _, err := FromEnv(); err == nil {
+		t.Error("URL sin esquema aceptada")
+	}
+}
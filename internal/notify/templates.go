@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Reminder son los datos de un préstamo con los que se redacta un recordatorio.
+type Reminder struct {
+	Kind          string
+	UserID        int64
+	Name, Email   string
+	LoanID        int64
+	BookID        int64
+	Book          string
+	StartDate     string // DD/MM/YYYY
+	DueDate       string // DD/MM/YYYY
+	DaysLeft      int64  // negativo si está vencido
+	Fine          int64  // multa acumulada, en usm pesos
+	PenaltyPerDay int64
+}
+
+// DaysLate son los días de atraso (0 si todavía no vence).
+func (r Reminder) DaysLate() int64 { return max(-r.DaysLeft, 0) }
+
+var funcs = template.FuncMap{
+	// dias escribe "1 día" o "N días"
+	"dias": func(n int64) string {
+		if n == 1 {
+			return "1 día"
+		}
+		return fmt.Sprintf("%d días", n)
+	},
+}
+
+// templates tiene, por tipo, el asunto y el cuerpo (separados por la primera línea en blanco).
+var templates = map[string]*template.Template{
+	KindDueSoon: parse(KindDueSoon, `Tu préstamo de «{{.Book}}» vence en {{dias .DaysLeft}}
+
+Hola {{.Name}}:
+
+El libro «{{.Book}}» que arrendaste el {{.StartDate}} vence el {{.DueDate}} (en {{dias .DaysLeft}}).
+Devuélvelo a tiempo: cada día de atraso suma una multa de {{.PenaltyPerDay}} usm pesos.
+
+Biblioteca UZM`),
+	KindDueToday: parse(KindDueToday, `Hoy vence tu préstamo de «{{.Book}}»
+
+Hola {{.Name}}:
+
+El libro «{{.Book}}» que arrendaste el {{.StartDate}} vence hoy, {{.DueDate}}.
+Si lo devuelves mañana o después se cobran {{.PenaltyPerDay}} usm pesos por cada día de atraso.
+
+Biblioteca UZM`),
+	KindOverdue: parse(KindOverdue, `Tu préstamo de «{{.Book}}» está vencido
+
+Hola {{.Name}}:
+
+El libro «{{.Book}}» venció el {{.DueDate}} y lleva {{dias .DaysLate}} de atraso.
+La multa acumulada es de {{.Fine}} usm pesos y sube {{.PenaltyPerDay}} por cada día más;
+se descuenta de tu saldo cuando lo devuelvas.
+
+Biblioteca UZM`),
+}
+
+func parse(name, text string) *template.Template {
+	return template.Must(template.New(name).Funcs(funcs).Parse(text))
+}
+
+// Compose redacta el recordatorio r.Kind.
+func Compose(r Reminder) (Message, error) {
+	t, ok := templates[r.Kind]
+	if !ok {
+		return Message{}, fmt.Errorf("notify: tipo de recordatorio desconocido %q", r.Kind)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, r); err != nil {
+		return Message{}, err
+	}
+	subject, body, _ := strings.Cut(b.String(), "\n\n")
+	return Message{Kind: r.Kind, UserID: r.UserID, Name: r.Name, Email: r.Email, LoanID: r.LoanID,
+		BookID: r.BookID, Subject: subject, Body: body}, nil
+}
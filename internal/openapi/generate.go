@@ -0,0 +1,301 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// initialisms se escriben en mayúsculas en los nombres Go (user_id -> UserID).
+var initialisms = map[string]string{"id": "ID", "ids": "IDs", "usm": "USM", "url": "URL", "api": "API"}
+
+// GoName pasa un nombre snake_case o camelCase a un identificador Go exportado.
+func GoName(s string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		if up, ok := initialisms[strings.ToLower(part)]; ok {
+			b.WriteString(up)
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// localName es GoName sin exportar, para parámetros (book_id -> bookID).
+func localName(s string) string {
+	first := strings.FieldsFunc(s, func(r rune) bool { return r == '_' })[0]
+	name := GoName(s)
+	if _, ok := initialisms[strings.ToLower(first)]; ok {
+		return strings.ToLower(name[:len(first)]) + name[len(first):]
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+type operation struct {
+	method, path string
+	*Operation
+}
+
+// Generate escribe el cliente Go (tipos de components.schemas y un método por operación)
+// para el paquete pkg. El resultado es determinista: mismo spec, mismos bytes.
+func Generate(d *Document, pkg, source string) ([]byte, error) {
+	g := &generator{doc: d, requests: map[string]bool{}}
+	var ops []operation
+	for path, methods := range d.Paths {
+		for method, op := range methods {
+			if op.Skip {
+				continue
+			}
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s %s: falta operationId", strings.ToUpper(method), path)
+			}
+			ops = append(ops, operation{strings.ToUpper(method), path, op})
+			if op.RequestBody != nil {
+				if s := op.RequestBody.Content["application/json"].Schema; s != nil && s.Ref != "" {
+					g.requests[RefName(s.Ref)] = true
+				}
+			}
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].OperationID < ops[j].OperationID })
+
+	names := make([]string, 0, len(d.Components.Schemas))
+	for name := range d.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := g.schemaType(name, d.Components.Schemas[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, op := range ops {
+		if err := g.method(op); err != nil {
+			return nil, err
+		}
+	}
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by cmd/genclient from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&src, "package %s\n\nimport (\n", pkg)
+	for _, imp := range []string{"context", "net/http", "net/url", "strconv"} {
+		if len(ops) > 0 && (imp == "context" || imp == "net/http") || bytes.Contains(g.buf.Bytes(), []byte(imp[strings.LastIndex(imp, "/")+1:]+".")) {
+			fmt.Fprintf(&src, "\t%q\n", imp)
+		}
+	}
+	src.WriteString(")\n\n")
+	fmt.Fprintf(&src, "// basePath es el prefijo de todas las rutas (servers[0].url de la especificación).\nconst basePath = %q\n", d.BasePath())
+	src.Write(g.buf.Bytes())
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("gofmt del cliente generado: %w\n%s", err, src.Bytes())
+	}
+	return out, nil
+}
+
+type generator struct {
+	doc      *Document
+	requests map[string]bool // schemas usados como cuerpo: los opcionales van como punteros
+	buf      bytes.Buffer
+}
+
+func comment(prefix, text string) string {
+	if text == "" {
+		return ""
+	}
+	return prefix + strings.ReplaceAll(text, "\n", " ")
+}
+
+func (g *generator) schemaType(name string, s *Schema) error {
+	desc := s.Description
+	if desc == "" {
+		desc = "#/components/schemas/" + name + "."
+	}
+	fmt.Fprintf(&g.buf, "\n// %s: %s\n", GoName(name), desc)
+	if s.Type != "object" || len(s.Properties.Names) == 0 {
+		t, err := g.goType(s)
+		if err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+		fmt.Fprintf(&g.buf, "type %s %s\n", GoName(name), t)
+		return nil
+	}
+	required := map[string]bool{}
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	fmt.Fprintf(&g.buf, "type %s struct {\n", GoName(name))
+	for _, prop := range s.Properties.Names {
+		ps := s.Properties.ByName[prop]
+		t, err := g.goType(ps)
+		if err != nil {
+			return fmt.Errorf("schema %s.%s: %w", name, prop, err)
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+			kind := g.doc.Schema(ps).Type
+			if g.requests[name] && kind != "array" && kind != "object" {
+				t = "*" + t
+			}
+		}
+		desc := ps.Description
+		if desc == "" && ps.Ref == "" {
+			desc = g.doc.Schema(ps).Description
+		}
+		fmt.Fprintf(&g.buf, "\t%s %s `json:\"%s\"`%s\n", GoName(prop), t, tag, comment(" // ", desc))
+	}
+	g.buf.WriteString("}\n")
+	return nil
+}
+
+func (g *generator) goType(s *Schema) (string, error) {
+	if s == nil {
+		return "any", nil
+	}
+	if s.Ref != "" {
+		return GoName(RefName(s.Ref)), nil
+	}
+	switch s.Type {
+	case "integer":
+		return "int64", nil
+	case "number":
+		return "float64", nil
+	case "string":
+		return "string", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		t, err := g.goType(s.Items)
+		return "[]" + t, err
+	case "":
+		if s.ExtraProperties() == nil && len(s.Properties.Names) == 0 {
+			return "any", nil // schema libre: {}
+		}
+		fallthrough
+	case "object":
+		if extra := s.ExtraProperties(); extra != nil {
+			t, err := g.goType(extra)
+			return "map[string]" + t, err
+		}
+		if len(s.Properties.Names) == 0 {
+			return "map[string]any", nil
+		}
+	}
+	return "", fmt.Errorf("tipo no soportado (objetos anidados deben ir en components.schemas)")
+}
+
+// successSchema es la primera respuesta 2xx: su schema (nil si no tiene cuerpo).
+func (g *generator) successSchema(op operation) (*Schema, string) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	if len(codes) == 0 {
+		return nil, ""
+	}
+	r := g.doc.response(op.Responses[codes[0]])
+	return r.Content["application/json"].Schema, codes[0]
+}
+
+func (g *generator) method(op operation) error {
+	name := GoName(op.OperationID)
+	var pathParams, queryParams []*Parameter
+	for _, p := range op.Parameters {
+		switch p = g.doc.Param(p); p.In {
+		case "path":
+			pathParams = append(pathParams, p)
+		case "query":
+			queryParams = append(queryParams, p)
+		}
+	}
+
+	if len(queryParams) > 0 {
+		fmt.Fprintf(&g.buf, "\n// %sParams son los parámetros de query de %s; los vacíos no se envían.\n", name, name)
+		fmt.Fprintf(&g.buf, "type %sParams struct {\n", name)
+		for _, p := range queryParams {
+			t := "string"
+			if p.Schema != nil && p.Schema.Type == "integer" {
+				t = "int64"
+			}
+			fmt.Fprintf(&g.buf, "\t%s %s%s\n", GoName(p.Name), t, comment(" // ", p.Description))
+		}
+		g.buf.WriteString("}\n")
+	}
+
+	args := []string{"ctx context.Context"}
+	path := `"` + op.path + `"`
+	for _, p := range pathParams {
+		local := localName(p.Name)
+		if p.Schema != nil && p.Schema.Type == "string" {
+			args = append(args, local+" string")
+			path = strings.Replace(path, "{"+p.Name+"}", `"+url.PathEscape(`+local+`)+"`, 1)
+			continue
+		}
+		args = append(args, local+" int64")
+		path = strings.Replace(path, "{"+p.Name+"}", `"+strconv.FormatInt(`+local+`, 10)+"`, 1)
+	}
+	path = strings.TrimSuffix(strings.ReplaceAll(path, `+""`, ""), `+""`)
+	if len(queryParams) > 0 {
+		args = append(args, "params "+name+"Params")
+	}
+	bodyArg := "nil"
+	if op.RequestBody != nil {
+		t, err := g.goType(op.RequestBody.Content["application/json"].Schema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op.OperationID, err)
+		}
+		args = append(args, "body "+t)
+		bodyArg = "body"
+	}
+	args = append(args, "opts ...Option")
+
+	out, code := g.successSchema(op)
+	ret, outType := "error", ""
+	if out != nil {
+		t, err := g.goType(out)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op.OperationID, err)
+		}
+		outType = t
+		ret = "(*" + t + ", error)"
+	}
+
+	fmt.Fprintf(&g.buf, "\n// %s: %s (%s %s → %s).\n", name, op.Summary, op.method, op.path, code)
+	fmt.Fprintf(&g.buf, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), ret)
+	queryArg := "nil"
+	if len(queryParams) > 0 {
+		queryArg = "q"
+		g.buf.WriteString("\tq := url.Values{}\n")
+		for _, p := range queryParams {
+			field := "params." + GoName(p.Name)
+			isInt := p.Schema != nil && p.Schema.Type == "integer"
+			value := field
+			if isInt {
+				value = "strconv.FormatInt(" + field + ", 10)"
+			}
+			switch {
+			case p.Required:
+				fmt.Fprintf(&g.buf, "\tq.Set(%q, %s)\n", p.Name, value)
+			case isInt:
+				fmt.Fprintf(&g.buf, "\tif %s != 0 {\n\t\tq.Set(%q, %s)\n\t}\n", field, p.Name, value)
+			default:
+				fmt.Fprintf(&g.buf, "\tif %s != \"\" {\n\t\tq.Set(%q, %s)\n\t}\n", field, p.Name, value)
+			}
+		}
+	}
+	method := "http.Method" + strings.ToUpper(op.method[:1]) + strings.ToLower(op.method[1:])
+	if outType == "" {
+		fmt.Fprintf(&g.buf, "\treturn c.do(ctx, %s, %s, %s, %s, nil, opts)\n}\n", method, path, queryArg, bodyArg)
+		return nil
+	}
+	fmt.Fprintf(&g.buf, "\tvar out %s\n", outType)
+	fmt.Fprintf(&g.buf, "\tif err := c.do(ctx, %s, %s, %s, %s, &out, opts); err != nil {\n\t\treturn nil, err\n\t}\n", method, path, queryArg, bodyArg)
+	g.buf.WriteString("\treturn &out, nil\n}\n")
+	return nil
+}
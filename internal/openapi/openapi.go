@@ -0,0 +1,296 @@
+// Package openapi lee el subconjunto de OpenAPI 3 que usa internal/api/openapi.json:
+// valida respuestas contra la especificación (tests de contrato) y genera el cliente Go
+// de internal/client.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Document struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas    map[string]*Schema    `json:"schemas"`
+		Parameters map[string]*Parameter `json:"parameters"`
+		Responses  map[string]*Response  `json:"responses"`
+	} `json:"components"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Parameters  []*Parameter          `json:"parameters"`
+	RequestBody *RequestBody          `json:"requestBody"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+	Skip        bool                  `json:"x-go-skip"` // no va en el cliente generado
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path | query | header
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref"`
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string          `json:"$ref"`
+	Type                 string          `json:"type"`
+	Format               string          `json:"format"`
+	Description          string          `json:"description"`
+	Enum                 []any           `json:"enum"`
+	Required             []string        `json:"required"`
+	Properties           Properties      `json:"properties"`
+	AdditionalProperties json.RawMessage `json:"additionalProperties"` // true o un schema
+	Items                *Schema         `json:"items"`
+}
+
+// Properties conserva el orden en que aparecen en el JSON (para generar structs legibles).
+type Properties struct {
+	Names  []string
+	ByName map[string]*Schema
+}
+
+func (p *Properties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &p.ByName); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.Token() // {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		p.Names = append(p.Names, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Parse(data []byte) (*Document, error) {
+	var d Document
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	return &d, nil
+}
+
+// BasePath es el prefijo de las rutas según el primer servers[].url relativo ("/api/v1").
+func (d *Document) BasePath() string {
+	if len(d.Servers) == 0 || !strings.HasPrefix(d.Servers[0].URL, "/") {
+		return ""
+	}
+	return strings.TrimRight(d.Servers[0].URL, "/")
+}
+
+// RefName es el nombre del componente al que apunta ref ("#/components/schemas/Book" -> "Book").
+func RefName(ref string) string { return ref[strings.LastIndex(ref, "/")+1:] }
+
+// Schema sigue los $ref hasta el schema concreto.
+func (d *Document) Schema(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[RefName(s.Ref)]
+	}
+	return s
+}
+
+// Param resuelve un parámetro que puede ser $ref a components.parameters.
+func (d *Document) Param(p *Parameter) *Parameter {
+	if p.Ref != "" {
+		return d.Components.Parameters[RefName(p.Ref)]
+	}
+	return p
+}
+
+func (d *Document) response(r *Response) *Response {
+	if r != nil && r.Ref != "" {
+		return d.Components.Responses[RefName(r.Ref)]
+	}
+	return r
+}
+
+// ExtraProperties es el schema de las claves no declaradas: nil si no se permiten,
+// un schema vacío si se permite cualquier cosa.
+func (s *Schema) ExtraProperties() *Schema {
+	raw := bytes.TrimSpace(s.AdditionalProperties)
+	switch {
+	case len(raw) == 0 || string(raw) == "false":
+		return nil
+	case string(raw) == "true":
+		return &Schema{}
+	}
+	var extra Schema
+	if json.Unmarshal(raw, &extra) != nil {
+		return nil
+	}
+	return &extra
+}
+
+// Match busca la ruta de la especificación para method y path (ej. GET /users/7 ->
+// "/users/{id}"). Si varias calzan gana la con más segmentos fijos, como en el router.
+func (d *Document) Match(method, path string) (string, *Operation) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	best, bestFixed := "", -1
+	for tmpl := range d.Paths {
+		parts := strings.Split(strings.Trim(tmpl, "/"), "/")
+		if len(parts) != len(segs) {
+			continue
+		}
+		fixed := 0
+		for i, p := range parts {
+			switch {
+			case strings.HasPrefix(p, "{"):
+			case p == segs[i]:
+				fixed++
+			default:
+				fixed = -1
+			}
+			if fixed < 0 {
+				break
+			}
+		}
+		if fixed > bestFixed {
+			best, bestFixed = tmpl, fixed
+		}
+	}
+	if best == "" {
+		return "", nil
+	}
+	return best, d.Paths[best][strings.ToLower(method)]
+}
+
+// ResponseSchema es el schema JSON que op declara para status (o su "default").
+func (d *Document) ResponseSchema(op *Operation, status int) (*Schema, bool) {
+	r, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		r, ok = op.Responses["default"]
+	}
+	if !ok {
+		return nil, false
+	}
+	r = d.response(r)
+	if mt, ok := r.Content["application/json"]; ok {
+		return mt.Schema, true
+	}
+	return nil, true
+}
+
+// Validate revisa v (decodificado con json.Decoder.UseNumber) contra s y devuelve los
+// problemas encontrados, cada uno con la ruta del valor ("$.books[0].price: ...").
+// Es estricto con los objetos: una clave que la especificación no declara es un error.
+func (d *Document) Validate(s *Schema, v any) []string {
+	var errs []string
+	d.validate(s, v, "$", &errs)
+	return errs
+}
+
+func (d *Document) validate(s *Schema, v any, at string, errs *[]string) {
+	s = d.Schema(s)
+	if s == nil {
+		return
+	}
+	bad := func(format string, args ...any) {
+		*errs = append(*errs, at+": "+fmt.Sprintf(format, args...))
+	}
+	if v == nil {
+		bad("null no permitido (se esperaba %s)", s.Type)
+		return
+	}
+	switch s.Type {
+	case "object":
+		m, ok := v.(map[string]any)
+		if !ok {
+			bad("se esperaba objeto, llegó %T", v)
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := m[name]; !ok {
+				bad("falta %q", name)
+			}
+		}
+		extra := s.ExtraProperties()
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := s.Properties.ByName[k]; ok {
+				d.validate(ps, m[k], at+"."+k, errs)
+			} else if extra != nil {
+				d.validate(extra, m[k], at+"."+k, errs)
+			} else if len(s.Properties.Names) > 0 {
+				bad("%q no está en la especificación", k)
+			}
+		}
+	case "array":
+		list, ok := v.([]any)
+		if !ok {
+			bad("se esperaba arreglo, llegó %T", v)
+			return
+		}
+		for i, item := range list {
+			d.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i), errs)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			bad("se esperaba string, llegó %T", v)
+			return
+		}
+		if len(s.Enum) > 0 && !inEnum(s.Enum, str) {
+			bad("%q no es uno de %v", str, s.Enum)
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			bad("se esperaba entero, llegó %v", v)
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			bad("se esperaba número, llegó %T", v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			bad("se esperaba boolean, llegó %T", v)
+		}
+	}
+}
+
+func inEnum(enum []any, s string) bool {
+	for _, e := range enum {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testSpec = `{
+  "paths": {
+    "/books/{id}": {"get": {"operationId": "getBook", "responses": {"200": {"description": "ok"}}}},
+    "/books/popular": {"get": {"operationId": "popular", "responses": {"200": {"description": "ok"}}}}
+  },
+  "components": {"schemas": {
+    "Book": {
+      "type": "object",
+      "required": ["id", "name"],
+      "properties": {
+        "id": {"type": "integer"},
+        "name": {"type": "string"},
+        "mode": {"type": "string", "enum": ["Venta", "Arriendo"]},
+        "tags": {"type": "array", "items": {"type": "string"}}
+      }
+    }
+  }}
+}`
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	doc, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := &Schema{Ref: "#/components/schemas/Book"}
+	if got := doc.Schema(book).Properties.Names; strings.Join(got, ",") != "id,name,mode,tags" {
+		t.Errorf("orden de propiedades = %v", got)
+	}
+	tests := []struct {
+		body string
+		want string // substring del primer problema; vacío = válido
+	}{
+		{`{"id": 1, "name": "Rayuela", "mode": "Venta", "tags": ["a"]}`, ""},
+		{`{"id": 1.5, "name": "Rayuela"}`, "$.id: se esperaba entero"},
+		{`{"name": "Rayuela"}`, `falta "id"`},
+		{`{"id": 1, "name": "Rayuela", "mode": "Trueque"}`, "$.mode:"},
+		{`{"id": 1, "name": "Rayuela", "tags": [3]}`, "$.tags[0]: se esperaba string"},
+		{`{"id": 1, "name": null}`, "$.name: null no permitido"},
+		{`{"id": 1, "name": "Rayuela", "isbn": "x"}`, `"isbn" no está en la especificación`},
+	}
+	for _, tt := range tests {
+		errs := doc.Validate(book, decode(t, tt.body))
+		switch {
+		case tt.want == "" && len(errs) > 0:
+			t.Errorf("%s: %v", tt.body, errs)
+		case tt.want != "" && (len(errs) == 0 || !strings.Contains(errs[0], tt.want)):
+			t.Errorf("%s: errores %v, want %q", tt.body, errs, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	doc, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ method, path, tmpl, op string }{
+		{"GET", "/books/7", "/books/{id}", "getBook"},
+		{"GET", "/books/popular", "/books/popular", "popular"},
+		{"POST", "/books/7", "/books/{id}", ""},
+		{"GET", "/users/7", "", ""},
+	}
+	for _, tt := range tests {
+		tmpl, op := doc.Match(tt.method, tt.path)
+		name := ""
+		if op != nil {
+			name = op.OperationID
+		}
+		if tmpl != tt.tmpl || name != tt.op {
+			t.Errorf("Match(%s %s) = %q %q, want %q %q", tt.method, tt.path, tmpl, name, tt.tmpl, tt.op)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"user_id": "UserID", "book_ids": "BookIDs", "usm_pesos": "USMPesos", "listBooks": "ListBooks",
+	} {
+		if got := GoName(in); got != want {
+			t.Errorf("GoName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := localName("review_id"); got != "reviewID" {
+		t.Errorf("localName = %q", got)
+	}
+	if got := localName("id"); got != "id" {
+		t.Errorf("localName(id) = %q", got)
+	}
+}
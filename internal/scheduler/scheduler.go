@@ -0,0 +1,210 @@
+// Package scheduler corre jobs periódicos dentro del servidor. Cada ejecución queda en
+// job_runs y un lease en job_locks evita que dos instancias que comparten la base corran
+// el mismo job a la vez (o dos veces dentro del mismo intervalo).
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"tarea1-uzm/internal/clock"
+	"tarea1-uzm/internal/store"
+)
+
+// DefaultTick es cada cuánto Start revisa qué jobs tocan.
+const DefaultTick = time.Minute
+
+// LeaseTTL es cuánto dura el lease de un job: si la instancia que lo tiene se cae, otra
+// puede tomarlo pasado este tiempo. Debe ser mayor que lo que tarde el job más lento.
+const LeaseTTL = 10 * time.Minute
+
+var (
+	// ErrUnknownJob lo devuelve Run si no hay un job con ese nombre.
+	ErrUnknownJob = errors.New("scheduler: job desconocido")
+	// ErrLocked lo devuelve Run si el job ya está corriendo, en esta u otra instancia.
+	ErrLocked = errors.New("scheduler: el job ya está corriendo")
+)
+
+// Job es una tarea periódica. Run recibe la hora del negocio (el reloj de la API, que en
+// demos puede estar adelantado) y devuelve un resumen corto de lo que hizo.
+type Job struct {
+	Name  string
+	Every time.Duration
+	Run   func(ctx context.Context, now time.Time) (string, error)
+}
+
+type Scheduler struct {
+	db       *sql.DB
+	clock    clock.Clock
+	log      *slog.Logger
+	instance string
+	// now es la hora real: la de los leases y de job_runs, que no debe moverse con el
+	// viaje en el tiempo.
+	now func() time.Time
+
+	mu      sync.Mutex
+	jobs    []Job
+	running map[string]bool // el lease es por instancia: esto evita correrlo dos veces dentro de ella
+}
+
+// New arma un scheduler sin jobs; clk es la hora que reciben los jobs.
+func New(db *sql.DB, clk clock.Clock, log *slog.Logger) *Scheduler {
+	return &Scheduler{db: db, clock: clk, log: log, instance: instanceID(), now: time.Now,
+		running: map[string]bool{}}
+}
+
+// instanceID identifica a este proceso en job_locks y job_runs: host, pid y un sufijo al
+// azar (dos contenedores pueden tener el mismo host y pid).
+func instanceID() string {
+	host, _ := os.Hostname()
+	var b [3]byte
+	rand.Read(b[:])
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b[:]))
+}
+
+// Add registra jobs (un nombre repetido reemplaza al anterior).
+func (s *Scheduler) Add(jobs ...Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range jobs {
+		if i := s.index(j.Name); i >= 0 {
+			s.jobs[i] = j
+			continue
+		}
+		s.jobs = append(s.jobs, j)
+	}
+}
+
+func (s *Scheduler) index(name string) int {
+	for i, j := range s.jobs {
+		if j.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Jobs devuelve los jobs registrados, en el orden en que se agregaron.
+func (s *Scheduler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Job(nil), s.jobs...)
+}
+
+// Start corre RunDue al partir y luego cada tick (DefaultTick si es 0), hasta que ctx termine.
+func (s *Scheduler) Start(ctx context.Context, tick time.Duration) {
+	if tick <= 0 {
+		tick = DefaultTick
+	}
+	go func() {
+		t := time.NewTicker(tick)
+		defer t.Stop()
+		for {
+			s.RunDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// RunDue corre los jobs a los que ya les toca. Un job que tiene otra instancia se salta
+// sin error: lo vuelve a intentar en el próximo tick.
+func (s *Scheduler) RunDue(ctx context.Context) {
+	for _, j := range s.Jobs() {
+		if _, err := s.Run(ctx, j.Name, false); err != nil && !errors.Is(err, ErrLocked) {
+			s.log.Error("job", "job", j.Name, "err", err)
+		}
+	}
+}
+
+// Run corre el job name si le toca (o siempre, con force) y devuelve la ejecución
+// registrada; si no le tocaba, devuelve un JobRun vacío. El error de Run es el de la
+// infraestructura (base, lease): el del job queda en la ejecución con status error.
+func (s *Scheduler) Run(ctx context.Context, name string, force bool) (store.JobRun, error) {
+	s.mu.Lock()
+	i := s.index(name)
+	busy := s.running[name]
+	var job Job
+	if i >= 0 && !busy {
+		job = s.jobs[i]
+		s.running[name] = true
+	}
+	s.mu.Unlock()
+	switch {
+	case i < 0:
+		return store.JobRun{}, ErrUnknownJob
+	case busy:
+		return store.JobRun{}, ErrLocked
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, name)
+		s.mu.Unlock()
+	}()
+
+	repo := store.New(s.db).Read().Jobs()
+	start := s.now()
+	ok, err := repo.Lock(ctx, name, s.instance, start, start.Add(LeaseTTL))
+	if err != nil {
+		return store.JobRun{}, err
+	}
+	if !ok {
+		return store.JobRun{}, ErrLocked
+	}
+	// con el lease en mano, la revisión de la última ejecución no compite con otra instancia
+	defer repo.Unlock(context.WithoutCancel(ctx), name, s.instance)
+
+	last, err := repo.LastRun(ctx, name)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+	case err != nil:
+		return store.JobRun{}, err
+	case !force && (last.Status == "ok" || last.Status == "error"):
+		// una ejecución en curso o abandonada no cuenta: su instancia se cayó
+		at, _ := time.Parse(store.EventFmt, last.StartedAt)
+		if start.Sub(at) < job.Every {
+			return store.JobRun{}, nil
+		}
+	}
+	// lo que quedó en curso es de una instancia que murió con el lease puesto
+	if err := repo.AbandonRunning(ctx, name, start); err != nil {
+		return store.JobRun{}, err
+	}
+	id, err := repo.StartRun(ctx, name, s.instance, start)
+	if err != nil {
+		return store.JobRun{}, err
+	}
+
+	detail, jobErr := s.call(ctx, job)
+	run := store.JobRun{ID: id, Job: name, Instance: s.instance, Status: "ok", Detail: detail,
+		StartedAt: start.UTC().Format(store.EventFmt), FinishedAt: s.now().UTC().Format(store.EventFmt)}
+	if jobErr != nil {
+		run.Status, run.Error = "error", jobErr.Error()
+		s.log.Error("job", "job", name, "run_id", id, "err", jobErr)
+	} else {
+		s.log.Info("job", "job", name, "run_id", id, "detail", detail)
+	}
+	finished, _ := time.Parse(store.EventFmt, run.FinishedAt)
+	return run, repo.FinishRun(context.WithoutCancel(ctx), id, run.Status, run.Detail, run.Error, finished)
+}
+
+// call corre el job convirtiendo un panic en error, para que la ejecución quede cerrada.
+func (s *Scheduler) call(ctx context.Context, job Job) (detail string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx, s.clock.Now())
+}
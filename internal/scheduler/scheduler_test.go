@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/clock"
+	"tarea1-uzm/internal/db"
+	"tarea1-uzm/internal/store"
+)
+
+// openDB abre una base en archivo nueva y la migra; cada llamada es una conexión distinta
+// al mismo archivo, como dos servidores que la comparten.
+func openDB(t *testing.T, path string) *store.Store {
+	t.Helper()
+	sqlDB, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	return store.New(sqlDB)
+}
+
+func newScheduler(t *testing.T, path string, now *time.Time, jobs ...Job) *Scheduler {
+	t.Helper()
+	sqlDB, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	s := New(sqlDB, clock.Fixed(*now), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.now = func() time.Time { return *now }
+	s.Add(jobs...)
+	return s
+}
+
+func TestRunOncePerInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uzm.db")
+	st := openDB(t, path)
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	var calls atomic.Int64
+	job := Job{Name: "contar", Every: time.Hour, Run: func(ctx context.Context, _ time.Time) (string, error) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond) // que la otra instancia alcance a chocar con el lease
+		return "listo", nil
+	}}
+	a := newScheduler(t, path, &now, job)
+	b := newScheduler(t, path, &now, job)
+
+	// dos instancias revisando a la vez: el job corre una sola vez
+	var wg sync.WaitGroup
+	for range 5 {
+		for _, s := range []*Scheduler{a, b} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.RunDue(context.Background())
+			}()
+		}
+	}
+	wg.Wait()
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("el job corrió %d veces, want 1", got)
+	}
+
+	// dentro del intervalo no vuelve a correr; cumplido, sí (en cualquiera de las dos)
+	now = now.Add(30 * time.Minute)
+	if run, err := b.Run(context.Background(), "contar", false); err != nil || run.ID != 0 {
+		t.Errorf("a los 30m: %+v %v", run, err)
+	}
+	now = now.Add(30 * time.Minute)
+	run, err := b.Run(context.Background(), "contar", false)
+	if err != nil || run.Status != "ok" || run.Detail != "listo" || run.Instance != b.instance {
+		t.Errorf("a la hora: %+v %v", run, err)
+	}
+	// force no espera el intervalo
+	if run, err := a.Run(context.Background(), "contar", true); err != nil || run.Status != "ok" {
+		t.Errorf("force: %+v %v", run, err)
+	}
+	runs, err := st.Read().Jobs().Runs(context.Background(), "contar", 10)
+	if err != nil || len(runs) != 3 || calls.Load() != 3 {
+		t.Errorf("job_runs = %+v (%v), llamadas %d", runs, err, calls.Load())
+	}
+}
+
+func TestLeaseAndFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uzm.db")
+	st := openDB(t, path)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	s := newScheduler(t, path, &now,
+		Job{Name: "ok", Every: time.Hour, Run: func(context.Context, time.Time) (string, error) { return "", nil }},
+		Job{Name: "falla", Every: time.Hour, Run: func(context.Context, time.Time) (string, error) { return "", errors.New("sin base") }},
+		Job{Name: "panic", Every: time.Hour, Run: func(context.Context, time.Time) (string, error) { panic("boom") }},
+	)
+
+	// otra instancia se cayó con el lease puesto y la ejecución a medias
+	jobs := st.Read().Jobs()
+	if ok, err := jobs.Lock(ctx, "ok", "caída", now, now.Add(LeaseTTL)); !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	if _, err := jobs.StartRun(ctx, "ok", "caída", now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Run(ctx, "ok", true); !errors.Is(err, ErrLocked) {
+		t.Errorf("con el lease ajeno vigente: %v, want ErrLocked", err)
+	}
+	now = now.Add(LeaseTTL + time.Second)
+	if run, err := s.Run(ctx, "ok", false); err != nil || run.Status != "ok" {
+		t.Errorf("con el lease vencido: %+v %v", run, err)
+	}
+	runs, _ := jobs.Runs(ctx, "ok", 10)
+	if len(runs) != 2 || runs[1].Status != "abandonado" {
+		t.Errorf("job_runs = %+v, want la de la instancia caída abandonada", runs)
+	}
+
+	if run, err := s.Run(ctx, "falla", false); err != nil || run.Status != "error" || run.Error != "sin base" {
+		t.Errorf("falla: %+v %v", run, err)
+	}
+	if run, err := s.Run(ctx, "panic", false); err != nil || run.Status != "error" || run.Error != "panic: boom" {
+		t.Errorf("panic: %+v %v", run, err)
+	}
+	if _, err := s.Run(ctx, "nada", false); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("job desconocido: %v", err)
+	}
+}
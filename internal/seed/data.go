@@ -0,0 +1,57 @@
+package seed
+
+var firstNames = []string{
+	"Ana", "Benjamín", "Camila", "Diego", "Valentina", "Matías", "Fernanda", "Tomás", "Javiera", "Sebastián",
+	"Catalina", "Joaquín", "Isidora", "Vicente", "Martina", "Agustín", "Antonia", "Nicolás", "Florencia", "Cristóbal",
+	"Constanza", "Felipe", "Josefa", "Ignacio", "Trinidad", "Maximiliano", "Renata", "Lucas", "Emilia", "Gabriel",
+}
+
+var lastNames = []string{
+	"González", "Muñoz", "Rojas", "Díaz", "Pérez", "Soto", "Contreras", "Silva", "Martínez", "Sepúlveda",
+	"Morales", "Rodríguez", "López", "Fuentes", "Hernández", "Torres", "Araya", "Flores", "Espinoza", "Valenzuela",
+	"Castillo", "Tapia", "Reyes", "Gutiérrez", "Castro", "Pizarro", "Álvarez", "Vásquez", "Sánchez", "Fernández",
+}
+
+// titles es el catálogo base: la categoría es la de la biblioteca, no la del libro original.
+var titles = []struct{ name, author, category string }{
+	{"Cien años de soledad", "García Márquez, Gabriel", "Novela"},
+	{"Rayuela", "Cortázar, Julio", "Novela"},
+	{"La casa de los espíritus", "Allende, Isabel", "Novela"},
+	{"Pedro Páramo", "Rulfo, Juan", "Novela"},
+	{"La ciudad y los perros", "Vargas Llosa, Mario", "Novela"},
+	{"Hijo de ladrón", "Rojas, Manuel", "Novela"},
+	{"Martín Rivas", "Blest Gana, Alberto", "Novela"},
+	{"Ficciones", "Borges, Jorge Luis", "Cuentos"},
+	{"El Aleph", "Borges, Jorge Luis", "Cuentos"},
+	{"Bestiario", "Cortázar, Julio", "Cuentos"},
+	{"El llano en llamas", "Rulfo, Juan", "Cuentos"},
+	{"Cuentos de la selva", "Quiroga, Horacio", "Cuentos"},
+	{"Veinte poemas de amor y una canción desesperada", "Neruda, Pablo", "Poesía"},
+	{"Canto general", "Neruda, Pablo", "Poesía"},
+	{"Desolación", "Mistral, Gabriela", "Poesía"},
+	{"Altazor", "Huidobro, Vicente", "Poesía"},
+	{"Poemas y antipoemas", "Parra, Nicanor", "Poesía"},
+	{"Fundación", "Asimov, Isaac", "Ciencia ficción"},
+	{"Yo, robot", "Asimov, Isaac", "Ciencia ficción"},
+	{"Crónicas marcianas", "Bradbury, Ray", "Ciencia ficción"},
+	{"Fahrenheit 451", "Bradbury, Ray", "Ciencia ficción"},
+	{"Dune", "Herbert, Frank", "Ciencia ficción"},
+	{"El principito", "Saint-Exupéry, Antoine de", "Infantil"},
+	{"Papelucho", "Paz, Marcela", "Infantil"},
+	{"Alicia en el país de las maravillas", "Carroll, Lewis", "Infantil"},
+	{"Historia de Chile", "Encina, Francisco Antonio", "Historia"},
+	{"Las venas abiertas de América Latina", "Galeano, Eduardo", "Historia"},
+	{"Sapiens: de animales a dioses", "Harari, Yuval Noah", "Historia"},
+	{"El laberinto de la soledad", "Paz, Octavio", "Ensayo"},
+	{"Breve historia del tiempo", "Hawking, Stephen", "Ciencia"},
+	{"Cosmos", "Sagan, Carl", "Ciencia"},
+	{"El origen de las especies", "Darwin, Charles", "Ciencia"},
+	{"Cálculo de una variable", "Stewart, James", "Matemáticas"},
+	{"Álgebra lineal y sus aplicaciones", "Lay, David C.", "Matemáticas"},
+	{"Física universitaria", "Sears, Francis W.", "Física"},
+	{"Física para ciencias e ingeniería", "Serway, Raymond A.", "Física"},
+	{"El lenguaje de programación C", "Kernighan, Brian W.", "Programación"},
+	{"Estructura e interpretación de programas de computadora", "Abelson, Harold", "Programación"},
+	{"Introducción a los algoritmos", "Cormen, Thomas H.", "Programación"},
+	{"El lenguaje de programación Go", "Donovan, Alan A. A.", "Programación"},
+}
@@ -0,0 +1,333 @@
+// Package seed llena una base vacía con datos de demo: usuarios con saldo, un catálogo en
+// varias categorías y ambas modalidades, e historia de ventas y préstamos (incluidos
+// vencidos). Todo sale de un generador con semilla: misma semilla y mismo día, mismos datos.
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"tarea1-uzm/internal/service"
+	"tarea1-uzm/internal/store"
+)
+
+// Options es el tamaño del set.
+type Options struct {
+	Seed  uint64
+	Users int
+	Books int
+	Sales int
+	Loans int
+	// Days son los días de historia antes de hoy en que caen ventas y préstamos.
+	Days int
+}
+
+// Defaults es un set chico, suficiente para una demo.
+var Defaults = Options{Seed: 1, Users: 20, Books: 60, Sales: 150, Loans: 80, Days: 120}
+
+// Password es la contraseña de todos los usuarios sembrados.
+const Password = "demo1234"
+
+// ErrNotEmpty lo devuelve Run si la base ya tiene usuarios o libros.
+var ErrNotEmpty = errors.New("seed: la base no está vacía")
+
+// Summary cuenta lo que quedó en la base.
+type Summary struct {
+	Users    int `json:"users"`
+	ForSale  int `json:"for_sale"`
+	ForLoan  int `json:"for_loan"`
+	Sales    int `json:"sales"`
+	Loans    int `json:"loans"`
+	Returned int `json:"returned"` // devueltos, a tiempo o no
+	Late     int `json:"returned_late"`
+	Overdue  int `json:"overdue"` // vencidos sin devolver a now
+	Restocks int `json:"restocks"`
+	Deposits int `json:"deposits"`
+}
+
+func (o Options) validate() error {
+	switch {
+	case o.Users < 1:
+		return errors.New("seed: se necesita al menos 1 usuario")
+	case o.Books < 2:
+		return errors.New("seed: se necesitan al menos 2 libros (uno por modalidad)")
+	case o.Sales < 0 || o.Loans < 0:
+		return errors.New("seed: ventas y préstamos no pueden ser negativos")
+	case o.Days < 1:
+		return errors.New("seed: se necesita al menos 1 día de historia")
+	}
+	return nil
+}
+
+// Run siembra sqlDB (ya migrada y vacía) en una sola transacción. La historia termina ayer
+// (en la zona de now) y las ventas, arriendos y devoluciones pasan por internal/service con
+// su fecha, así stock, popularidad, multas y outbox quedan como si hubieran ocurrido por la
+// API. Al final marca vencidos y multas a now, como los jobs.
+func Run(ctx context.Context, sqlDB *sql.DB, opts Options, now time.Time) (Summary, error) {
+	if err := opts.validate(); err != nil {
+		return Summary{}, err
+	}
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer tx.Rollback()
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT (SELECT COUNT(*) FROM users) + (SELECT COUNT(*) FROM books)`).Scan(&n); err != nil {
+		return Summary{}, err
+	}
+	if n > 0 {
+		return Summary{}, ErrNotEmpty
+	}
+
+	y, m, d := now.Date()
+	g := &gen{
+		tx:    store.Bind(tx),
+		rng:   rand.New(rand.NewPCG(opts.Seed, 0x757a6d)),
+		opts:  opts,
+		today: time.Date(y, m, d, 0, 0, 0, 0, now.Location()),
+	}
+	for _, step := range []func(context.Context) error{g.createUsers, g.createBooks, g.history} {
+		if err := step(ctx); err != nil {
+			return Summary{}, err
+		}
+	}
+	if _, err := service.MarkOverdue(ctx, g.tx, now); err != nil {
+		return Summary{}, err
+	}
+	if _, err := service.AccrueFines(ctx, g.tx, now); err != nil {
+		return Summary{}, err
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM loans WHERE status='vencido'`).Scan(&g.sum.Overdue); err != nil {
+		return Summary{}, err
+	}
+	return g.sum, tx.Commit()
+}
+
+type gen struct {
+	tx    store.Tx
+	rng   *rand.Rand
+	opts  Options
+	today time.Time
+	sum   Summary
+
+	userIDs []int64
+	forSale []int64
+	forLoan []int64
+}
+
+func (g *gen) pick(xs []string) string { return xs[g.rng.IntN(len(xs))] }
+
+// between devuelve un entero al azar en [lo, hi].
+func (g *gen) between(lo, hi int) int { return lo + g.rng.IntN(hi-lo+1) }
+
+// at es una hora de atención (9:00 a 20:59) del día que está days antes de hoy.
+func (g *gen) at(days int) time.Time {
+	return g.today.AddDate(0, 0, -days).Add(time.Duration(g.between(9*60, 21*60-1)) * time.Minute)
+}
+
+// createUsers crea los usuarios con un saldo inicial (múltiplo de 10) y publica
+// user.created igual que POST /users.
+func (g *gen) createUsers(ctx context.Context) error {
+	seen := map[string]int{}
+	for range g.opts.Users {
+		p := store.Profile{FirstName: g.pick(firstNames), LastName: g.pick(lastNames) + " " + g.pick(lastNames)}
+		local := fold(p.FirstName + "." + strings.Fields(p.LastName)[0])
+		if seen[local]++; seen[local] > 1 {
+			local = fmt.Sprintf("%s%d", local, seen[local])
+		}
+		p.Email = local + "@usm.cl"
+		p.USMPesos = int64(g.between(5, 40) * 10)
+		if err := g.tx.Users().Create(ctx, &p, Password); err != nil {
+			return err
+		}
+		event := map[string]any{"id": p.ID, "first_name": p.FirstName, "last_name": p.LastName, "email": p.Email, "usm_pesos": p.USMPesos}
+		if err := g.tx.Outbox().Publish(ctx, store.EventUserCreated, event, g.at(g.opts.Days)); err != nil {
+			return err
+		}
+		g.userIDs = append(g.userIDs, p.ID)
+		g.sum.Users++
+	}
+	return nil
+}
+
+// createBooks arma el catálogo desde titles (en un orden al azar; si se piden más libros
+// que títulos, se repiten como otros volúmenes). El 80% lleva ISBN.
+func (g *gen) createBooks(ctx context.Context) error {
+	order := g.rng.Perm(len(titles))
+	isbnBase := g.rng.IntN(1e8)
+	for i := range g.opts.Books {
+		t := titles[order[i%len(titles)]]
+		b := store.BookStock{Name: t.name, Author: t.author, Category: t.category, TransactionType: "Venta"}
+		if i >= len(titles) {
+			b.Name = fmt.Sprintf("%s, vol. %d", t.name, i/len(titles)+1)
+		}
+		// los dos primeros aseguran ambas modalidades; después, 60% Venta
+		if i == 1 || (i > 1 && g.rng.IntN(10) >= 6) {
+			b.TransactionType = "Arriendo"
+		}
+		if b.TransactionType == "Venta" {
+			b.Price, b.Available = int64(g.between(8, 45)), int64(g.between(1, 10))
+		} else {
+			b.Price, b.Available = int64(g.between(3, 12)), int64(g.between(1, 4))
+		}
+		if g.rng.IntN(10) < 8 {
+			b.ISBN = isbn13(isbnBase + i)
+		}
+		if err := g.tx.Books().Create(ctx, &b); err != nil {
+			return err
+		}
+		if b.TransactionType == "Venta" {
+			g.forSale = append(g.forSale, b.ID)
+		} else {
+			g.forLoan = append(g.forLoan, b.ID)
+		}
+	}
+	g.sum.ForSale, g.sum.ForLoan = len(g.forSale), len(g.forLoan)
+	return nil
+}
+
+// isbn13 arma un ISBN-13 válido con prefijo 978 y n como número de 9 dígitos.
+func isbn13(n int) string {
+	digits := fmt.Sprintf("978%09d", n)
+	sum := 0
+	for i, c := range digits {
+		sum += int(c-'0') * (1 + 2*(i%2))
+	}
+	return digits + string(rune('0'+(10-sum%10)%10))
+}
+
+// event es una venta, un arriendo o la devolución del arriendo número loan.
+type event struct {
+	at   time.Time
+	kind string // venta | arriendo | devolucion
+	loan int
+}
+
+// history genera las ventas y préstamos con su fecha y los aplica en orden. Cada préstamo
+// decide al crearse si se devuelve a tiempo, tarde o nunca; si ya venció, queda vencido.
+func (g *gen) history(ctx context.Context) error {
+	var events []event
+	for range g.opts.Sales {
+		events = append(events, event{at: g.at(g.between(1, g.opts.Days)), kind: "venta"})
+	}
+	for loan := range g.opts.Loans {
+		ago := g.between(1, g.opts.Days)
+		start := g.today.AddDate(0, 0, -ago)
+		dueIn := int(math.Round(service.DueDate(start.Format(store.DateFmt), start.Location()).Sub(start).Hours() / 24))
+		events = append(events, event{at: g.at(ago), kind: "arriendo", loan: loan})
+		var back int // días desde el inicio hasta la devolución; 0 = no se devuelve
+		switch r := g.rng.IntN(100); {
+		case ago > dueIn && r < 65:
+			back = g.between(1, dueIn)
+		case ago > dueIn && r < 82:
+			back = dueIn + g.between(1, 25)
+		case ago <= dueIn && r < 40:
+			back = g.between(1, ago)
+		}
+		if back > 0 && back < ago {
+			events = append(events, event{at: g.at(ago - back), kind: "devolucion", loan: loan})
+		}
+	}
+	slices.SortStableFunc(events, func(a, b event) int { return a.at.Compare(b.at) })
+
+	loanIDs := make([]int64, g.opts.Loans)
+	for _, e := range events {
+		var err error
+		switch e.kind {
+		case "venta":
+			err = g.sell(ctx, e.at)
+		case "arriendo":
+			loanIDs[e.loan], err = g.lend(ctx, e.at)
+		case "devolucion":
+			err = g.giveBack(ctx, loanIDs[e.loan], e.at)
+		}
+		if err != nil {
+			return fmt.Errorf("seed: %s del %s: %w", e.kind, e.at.Format(store.DateFmt), err)
+		}
+	}
+	return nil
+}
+
+// sell vende un libro al azar a un usuario al azar; si no le alcanza, primero abona lo que
+// falta más un poco (en múltiplos de 10), como haría en la caja.
+func (g *gen) sell(ctx context.Context, at time.Time) error {
+	userID := g.userIDs[g.rng.IntN(len(g.userIDs))]
+	b, err := g.stocked(ctx, g.forSale, at)
+	if err != nil {
+		return err
+	}
+	balance, err := g.tx.Users().Balance(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if balance < b.Price {
+		deposit := (b.Price-balance+9)/10*10 + int64(g.between(1, 10)*10)
+		if err := g.tx.Users().AddBalance(ctx, userID, deposit); err != nil {
+			return err
+		}
+		g.sum.Deposits++
+	}
+	if _, err := service.SellBook(ctx, g.tx, userID, b.ID, "", at); err != nil {
+		return err
+	}
+	g.sum.Sales++
+	return nil
+}
+
+// lend arrienda un libro al azar a un usuario al azar y devuelve el id del préstamo.
+func (g *gen) lend(ctx context.Context, at time.Time) (int64, error) {
+	userID := g.userIDs[g.rng.IntN(len(g.userIDs))]
+	b, err := g.stocked(ctx, g.forLoan, at)
+	if err != nil {
+		return 0, err
+	}
+	l, err := service.LendBook(ctx, g.tx, userID, b.ID, at)
+	if err != nil {
+		return 0, err
+	}
+	g.sum.Loans++
+	return l.ID, nil
+}
+
+// giveBack devuelve el préstamo id en at (cobra la multa si viene atrasado).
+func (g *gen) giveBack(ctx context.Context, id int64, at time.Time) error {
+	l, err := service.ReturnLoan(ctx, g.tx, id, at, at)
+	if err != nil {
+		return err
+	}
+	g.sum.Returned++
+	if l.DaysLate > 0 {
+		g.sum.Late++
+	}
+	return nil
+}
+
+// stocked elige un libro de ids y, si está agotado, lo repone (como PATCH /books/:id) para
+// que la historia no se quede sin stock.
+func (g *gen) stocked(ctx context.Context, ids []int64, at time.Time) (store.BookStock, error) {
+	b, err := g.tx.Books().Stock(ctx, ids[g.rng.IntN(len(ids))])
+	if err != nil || b.Available > 0 {
+		return b, err
+	}
+	qty := int64(g.between(3, 10))
+	if err := service.UpdateBook(ctx, g.tx, b.ID, nil, &qty, at); err != nil {
+		return b, err
+	}
+	g.sum.Restocks++
+	b.Available = qty
+	return b, nil
+}
+
+// fold pasa un nombre a la parte local de un email: minúsculas, sin tildes ni espacios.
+func fold(s string) string {
+	return strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ñ", "n", "ü", "u", " ", "").
+		Replace(strings.ToLower(s))
+}
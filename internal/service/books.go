@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tarea1-uzm/internal/store"
+)
+
+// UpdateBook cambia precio y/o stock (nil = no tocar) y avisa a la lista de deseos.
+func UpdateBook(ctx context.Context, tx store.Tx, bookID int64, price, available *int64, now time.Time) error {
+	b, err := tx.Books().Stock(ctx, bookID)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrBookNotFound
+	}
+	if err != nil {
+		return err
+	}
+	newPrice, newQty := b.Price, b.Available
+	if price != nil {
+		if err := tx.Books().SetPrice(ctx, bookID, *price); err != nil {
+			return err
+		}
+		newPrice = *price
+	}
+	if available != nil {
+		if err := tx.Books().SetAvailable(ctx, bookID, *available); err != nil {
+			return err
+		}
+		newQty = *available
+	}
+	return NotifyBookChanges(ctx, tx, b, newPrice, newQty, now)
+}
+
+// NotifyBookChanges compara el libro antes (before) con el nuevo precio/stock, avisa a quienes
+// lo siguen si bajó el precio o si volvió a haber stock, y publica en el outbox book.stock,
+// book.price y, si el stock se acabó, book.out_of_stock.
+func NotifyBookChanges(ctx context.Context, tx store.Tx, before store.BookStock, newPrice, newQty int64, now time.Time) error {
+	if newPrice < before.Price {
+		msg := fmt.Sprintf("«%s» bajó de %d a %d usm pesos", before.Name, before.Price, newPrice)
+		if err := tx.Notifications().NotifyFollowers(ctx, before.ID, "precio", msg, now); err != nil {
+			return err
+		}
+	}
+	if before.Available <= 0 && newQty > 0 {
+		msg := fmt.Sprintf("«%s» volvió a tener stock (%d disponibles)", before.Name, newQty)
+		if err := tx.Notifications().NotifyFollowers(ctx, before.ID, "stock", msg, now); err != nil {
+			return err
+		}
+	}
+	change := BookChange{BookID: before.ID, BookName: before.Name, BookCategory: before.Category,
+		TransactionType: before.TransactionType, Price: newPrice, OldPrice: before.Price,
+		Available: newQty, OldAvailable: before.Available}
+	if newQty != before.Available {
+		if err := tx.Outbox().Publish(ctx, EventBookStock, change, now); err != nil {
+			return err
+		}
+	}
+	if newPrice != before.Price {
+		if err := tx.Outbox().Publish(ctx, EventBookPrice, change, now); err != nil {
+			return err
+		}
+	}
+	if before.Available > 0 && newQty <= 0 {
+		return tx.Outbox().Publish(ctx, store.EventOutOfStock, OutOfStock{BookID: before.ID, BookName: before.Name}, now)
+	}
+	return nil
+}
+
+// AddWish agrega bookID a la lista de deseos de userID, que desde entonces recibe los
+// avisos de precio y stock del libro.
+func AddWish(ctx context.Context, tx store.Tx, userID, bookID int64, now time.Time) error {
+	if _, err := tx.Users().Get(ctx, userID); errors.Is(err, store.ErrNotFound) {
+		return ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	if _, err := tx.Books().Stock(ctx, bookID); errors.Is(err, store.ErrNotFound) {
+		return ErrBookNotFound
+	} else if err != nil {
+		return err
+	}
+	return tx.Wishlist().Add(ctx, userID, bookID, now)
+}
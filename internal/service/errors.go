@@ -0,0 +1,30 @@
+// Package service tiene las reglas de negocio de ventas y arriendos. Cada operación
+// recibe un context y un store.Tx, así se puede llamar desde HTTP, un job o los tests
+// y quien llama decide dónde empieza y termina la transacción.
+package service
+
+import "errors"
+
+// Errores de negocio; la capa HTTP los traduce a status y código.
+var (
+	ErrUserNotFound      = errors.New("usuario no existe")
+	ErrBookNotFound      = errors.New("libro no existe")
+	ErrLoanNotFound      = errors.New("préstamo no existe")
+	ErrEmptyCart         = errors.New("carro vacío")
+	ErrNotForSale        = errors.New("el libro no está en modalidad Venta")
+	ErrNotForLoan        = errors.New("el libro no está en modalidad Arriendo")
+	ErrOutOfStock        = errors.New("sin stock")
+	ErrInsufficientFunds = errors.New("fondos insuficientes")
+	ErrAlreadyReturned   = errors.New("ya devuelto")
+	ErrReviewNotFound    = errors.New("reseña no existe")
+	ErrNotReviewer       = errors.New("solo quien compró o arrendó el libro puede reseñarlo")
+	ErrNotAuthor         = errors.New("solo el autor puede editar o borrar la reseña")
+)
+
+// PromotionError explica por qué no se pudo usar una promoción.
+type PromotionError struct {
+	Msg       string
+	Exhausted bool // se agotó mientras se compraba
+}
+
+func (e *PromotionError) Error() string { return e.Msg }
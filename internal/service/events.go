@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"tarea1-uzm/internal/store"
+)
+
+// Eventos del outbox que no van a webhooks, solo al stream GET /events.
+const (
+	EventBookStock = "book.stock"
+	EventBookPrice = "book.price"
+)
+
+// OutOfStock es el payload de book.out_of_stock.
+type OutOfStock struct {
+	BookID   int64  `json:"book_id"`
+	BookName string `json:"book_name"`
+}
+
+// BookChange es el payload de book.stock y book.price: el libro después del cambio y los
+// valores que tenía antes.
+type BookChange struct {
+	BookID          int64  `json:"book_id"`
+	BookName        string `json:"book_name"`
+	BookCategory    string `json:"book_category"`
+	TransactionType string `json:"transaction_type"`
+	Price           int64  `json:"price"`
+	OldPrice        int64  `json:"old_price"`
+	Available       int64  `json:"available"`
+	OldAvailable    int64  `json:"old_available"`
+}
+
+// takeOne baja el stock de b en 1 (false = no quedaba) y publica el cambio.
+func takeOne(ctx context.Context, tx store.Tx, b store.BookStock, now time.Time) (bool, error) {
+	ok, err := tx.Books().TakeOne(ctx, b.ID)
+	if err != nil || !ok {
+		return ok, err
+	}
+	after, err := tx.Books().Stock(ctx, b.ID)
+	if err != nil {
+		return false, err
+	}
+	before := after
+	before.Available++
+	return true, NotifyBookChanges(ctx, tx, before, after.Price, after.Available, now)
+}
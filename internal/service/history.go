@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"tarea1-uzm/internal/store"
+)
+
+// Holding devuelve los libros que no tiene sentido recomendarle a userID: los que compró y
+// los que tiene en préstamo. Los que ya devolvió sí pueden volver a sugerirse.
+func Holding(ctx context.Context, tx store.Tx, userID int64) (map[int64]bool, error) {
+	if _, err := tx.Users().Get(ctx, userID); errors.Is(err, store.ErrNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	bought, err := tx.Sales().ByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loans, err := tx.Loans().ByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := map[int64]bool{}
+	for _, s := range bought {
+		out[s.BookID] = true
+	}
+	for _, l := range loans {
+		if isOpen(l.Status) {
+			out[l.BookID] = true
+		}
+	}
+	return out, nil
+}
+
+// touched indica si userID compró o arrendó alguna vez bookID.
+func touched(ctx context.Context, tx store.Tx, userID, bookID int64) (bool, error) {
+	bought, err := tx.Sales().ByUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range bought {
+		if s.BookID == bookID {
+			return true, nil
+		}
+	}
+	loans, err := tx.Loans().ByUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, l := range loans {
+		if l.BookID == bookID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
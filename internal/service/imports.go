@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tarea1-uzm/internal/bulk"
+	"tarea1-uzm/internal/store"
+)
+
+// BookColumns son las columnas que entiende ImportBooks (las mismas que exporta
+// GET /admin/books/export, que además trae id y popularity_score: se ignoran).
+var BookColumns = []string{"isbn", "book_name", "author", "book_category", "transaction_type", "price", "available_quantity"}
+
+var ignoredBookColumns = []string{"id", "popularity_score"}
+
+// BookImport resume una importación de libros. Si Errors no está vacío no se debe confirmar
+// la transacción: Created y Updated cuentan lo que se habría hecho con las filas válidas.
+type BookImport struct {
+	Rows      int             `json:"rows"`
+	Created   int             `json:"created"`
+	Updated   int             `json:"updated"`
+	Unchanged int             `json:"unchanged"`
+	Errors    []bulk.RowError `json:"-"`
+}
+
+// ImportBooks aplica rows sobre el catálogo en tx. Cada fila se busca por isbn; si no trae
+// (o no calza con ninguno), por book_name entre los libros sin ISBN. Si existe se actualizan
+// las columnas que vinieron, si no se crea (book_name, book_category y transaction_type son
+// obligatorias, salvo que vengan en defaults: columnas que se usan solo al crear, si la fila
+// no las trae). Los cambios de precio y stock avisan y publican igual que PATCH /books/:id.
+func ImportBooks(ctx context.Context, tx store.Tx, rows []bulk.Row, defaults map[string]string, now time.Time) (BookImport, error) {
+	res := BookImport{Rows: len(rows)}
+	for _, row := range rows {
+		in, errs := parseBookRow(row)
+		if len(errs) > 0 {
+			res.Errors = append(res.Errors, errs...)
+			continue
+		}
+		old, found, err := findBook(ctx, tx, in)
+		if err != nil {
+			var re bulk.RowError
+			if errors.As(err, &re) {
+				re.Line = row.Line
+				res.Errors = append(res.Errors, re)
+				continue
+			}
+			return res, err
+		}
+		if !found {
+			if len(defaults) > 0 {
+				fields := maps.Clone(defaults)
+				maps.Copy(fields, row.Fields)
+				if in, errs = parseBookRow(bulk.Row{Line: row.Line, Fields: fields}); len(errs) > 0 {
+					res.Errors = append(res.Errors, errs...)
+					continue
+				}
+			}
+			if errs := in.missing(row.Line); len(errs) > 0 {
+				res.Errors = append(res.Errors, errs...)
+				continue
+			}
+			b := in.apply(store.BookStock{})
+			if err := tx.Books().Create(ctx, &b); err != nil {
+				return res, err
+			}
+			change := BookChange{BookID: b.ID, BookName: b.Name, BookCategory: b.Category,
+				TransactionType: b.TransactionType, Price: b.Price, OldPrice: b.Price, Available: b.Available}
+			if err := tx.Outbox().Publish(ctx, EventBookStock, change, now); err != nil {
+				return res, err
+			}
+			res.Created++
+			continue
+		}
+		b := in.apply(old)
+		if b == old {
+			res.Unchanged++
+			continue
+		}
+		if b.Name != old.Name || b.Author != old.Author || b.Category != old.Category || b.TransactionType != old.TransactionType || b.ISBN != old.ISBN {
+			if err := tx.Books().SetDetails(ctx, b); err != nil {
+				return res, err
+			}
+		}
+		if b.Price != old.Price {
+			if err := tx.Books().SetPrice(ctx, b.ID, b.Price); err != nil {
+				return res, err
+			}
+		}
+		if b.Available != old.Available {
+			if err := tx.Books().SetAvailable(ctx, b.ID, b.Available); err != nil {
+				return res, err
+			}
+		}
+		if err := NotifyBookChanges(ctx, tx, old, b.Price, b.Available, now); err != nil {
+			return res, err
+		}
+		res.Updated++
+	}
+	return res, nil
+}
+
+// bookRow son los valores de una fila; nil = la columna no vino.
+type bookRow struct {
+	isbn, name, author, category, kind *string
+	price, available                   *int64
+}
+
+func parseBookRow(row bulk.Row) (bookRow, []bulk.RowError) {
+	var (
+		in   bookRow
+		errs []bulk.RowError
+	)
+	bad := func(field, msg string) {
+		errs = append(errs, bulk.RowError{Line: row.Line, Field: field, Message: msg})
+	}
+	text := func(field string, max int) *string {
+		v, ok := row.Fields[field]
+		if !ok {
+			return nil
+		}
+		if utf8.RuneCountInString(v) > max {
+			bad(field, fmt.Sprintf("debe tener como máximo %d caracteres", max))
+		}
+		return &v
+	}
+	amount := func(field string) *int64 {
+		v, ok := row.Fields[field]
+		if !ok {
+			return nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			bad(field, "debe ser un entero mayor o igual a 0")
+		}
+		return &n
+	}
+
+	for _, field := range slices.Sorted(maps.Keys(row.Fields)) {
+		if !slices.Contains(BookColumns, field) && !slices.Contains(ignoredBookColumns, field) {
+			bad(field, "columna desconocida (se aceptan: "+strings.Join(BookColumns, ", ")+")")
+		}
+	}
+	if v, ok := row.Fields["isbn"]; ok {
+		isbn, err := NormalizeISBN(v)
+		if err != nil {
+			bad("isbn", err.Error())
+		}
+		in.isbn = &isbn
+	}
+	in.name = text("book_name", 200)
+	in.author = text("author", 200)
+	in.category = text("book_category", 100)
+	if in.kind = text("transaction_type", 20); in.kind != nil && *in.kind != "Venta" && *in.kind != "Arriendo" {
+		bad("transaction_type", "debe ser Venta o Arriendo")
+	}
+	in.price = amount("price")
+	in.available = amount("available_quantity")
+	return in, errs
+}
+
+// missing son los errores de las columnas obligatorias para crear un libro.
+func (in bookRow) missing(line int) []bulk.RowError {
+	var errs []bulk.RowError
+	for _, f := range []struct {
+		name string
+		v    *string
+	}{{"book_name", in.name}, {"book_category", in.category}, {"transaction_type", in.kind}} {
+		if f.v == nil {
+			errs = append(errs, bulk.RowError{Line: line, Field: f.name, Message: "es obligatorio para un libro nuevo"})
+		}
+	}
+	return errs
+}
+
+// apply devuelve b con los valores que trae la fila.
+func (in bookRow) apply(b store.BookStock) store.BookStock {
+	for _, f := range []struct {
+		dst *string
+		v   *string
+	}{{&b.ISBN, in.isbn}, {&b.Name, in.name}, {&b.Author, in.author}, {&b.Category, in.category}, {&b.TransactionType, in.kind}} {
+		if f.v != nil {
+			*f.dst = *f.v
+		}
+	}
+	if in.price != nil {
+		b.Price = *in.price
+	}
+	if in.available != nil {
+		b.Available = *in.available
+	}
+	return b
+}
+
+// findBook busca el libro de la fila. Los errores de la fila (ej. un nombre ambiguo) vuelven
+// como bulk.RowError.
+func findBook(ctx context.Context, tx store.Tx, in bookRow) (store.BookStock, bool, error) {
+	if in.isbn != nil {
+		b, err := tx.Books().ByISBN(ctx, *in.isbn)
+		if err == nil {
+			return b, true, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return b, false, err
+		}
+	}
+	if in.name == nil {
+		return store.BookStock{}, false, nil
+	}
+	list, err := tx.Books().ByName(ctx, *in.name)
+	switch {
+	case err != nil:
+		return store.BookStock{}, false, err
+	case len(list) > 1:
+		return store.BookStock{}, false, bulk.RowError{Field: "book_name",
+			Message: fmt.Sprintf("hay %d libros «%s» sin ISBN; agrega la columna isbn para distinguirlos", len(list), *in.name)}
+	case len(list) == 1:
+		return list[0], true, nil
+	}
+	return store.BookStock{}, false, nil
+}
+
+// NormalizeISBN quita guiones y espacios y verifica el dígito de control de un ISBN-10 o
+// ISBN-13.
+func NormalizeISBN(s string) (string, error) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i, r := range isbn {
+			d := int(r - '0')
+			if r == 'X' && i == 9 {
+				d = 10
+			} else if r < '0' || r > '9' {
+				return "", errors.New("ISBN-10 inválido")
+			}
+			sum += (10 - i) * d
+		}
+		if sum%11 != 0 {
+			return "", errors.New("dígito de control del ISBN-10 inválido")
+		}
+	case 13:
+		sum := 0
+		for i, r := range isbn {
+			if r < '0' || r > '9' {
+				return "", errors.New("ISBN-13 inválido")
+			}
+			sum += int(r-'0') * (1 + 2*(i%2))
+		}
+		if sum%10 != 0 {
+			return "", errors.New("dígito de control del ISBN-13 inválido")
+		}
+	default:
+		return "", errors.New("debe tener 10 o 13 dígitos")
+	}
+	return isbn, nil
+}
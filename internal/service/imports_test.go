@@ -0,0 +1,25 @@
+package service
+
+import "testing"
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"978-84-376-0457-2", "9788437604572", true},
+		{"84 376 0457 5", "8437604575", true},
+		{"0-8044-2957-x", "080442957X", true},
+		{"9788437604571", "", false}, // dígito de control
+		{"8437604574", "", false},
+		{"X804429570", "", false}, // X solo al final
+		{"978843760457", "", false},
+		{"97884376O4572", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeISBN(tt.in)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("NormalizeISBN(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"tarea1-uzm/internal/store"
+)
+
+// PenaltyPerDay es la multa en usm pesos por cada día de atraso.
+const PenaltyPerDay = 2
+
+// DueDate es el vencimiento de un préstamo que empezó en start (DD/MM/YYYY, en loc): un mes después.
+func DueDate(start string, loc *time.Location) time.Time {
+	s, _ := time.ParseInLocation(store.DateFmt, start, loc)
+	return s.AddDate(0, 1, 0)
+}
+
+// days cuenta los días de calendario de from a to, cada uno en su zona: así un cambio de
+// horario (días de 23 o 25 horas) no suma ni resta un día de atraso.
+func days(from, to time.Time) int64 {
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	a := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	b := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int64(b.Sub(a).Hours() / 24)
+}
+
+// isOpen indica si el préstamo sigue sin devolver (pendiente o ya vencido).
+func isOpen(status string) bool {
+	return status == "pendiente" || status == "vencido"
+}
+
+// Schedule completa due_date y, si sigue abierto, los días que le quedan a now (negativos
+// si está vencido).
+func Schedule(l store.Loan, now time.Time) store.Loan {
+	due := DueDate(l.StartDate, now.Location())
+	l.DueDate = due.Format(store.DateFmt)
+	if isOpen(l.Status) {
+		l.DaysLeft = days(now, due)
+	}
+	return l
+}
+
+// LendBook arrienda un ejemplar: baja stock, suma popularidad, crea el préstamo pendiente y
+// publica loan.created.
+func LendBook(ctx context.Context, tx store.Tx, userID, bookID int64, now time.Time) (store.Loan, error) {
+	b, err := tx.Books().Stock(ctx, bookID)
+	if errors.Is(err, store.ErrNotFound) {
+		return store.Loan{}, ErrBookNotFound
+	}
+	if err != nil {
+		return store.Loan{}, err
+	}
+	if b.TransactionType != "Arriendo" {
+		return store.Loan{}, ErrNotForLoan
+	}
+	ok, err := takeOne(ctx, tx, b, now)
+	if err != nil {
+		return store.Loan{}, err
+	}
+	if !ok {
+		return store.Loan{}, ErrOutOfStock
+	}
+	if err := tx.Books().BumpPopularity(ctx, bookID); err != nil {
+		return store.Loan{}, err
+	}
+	if err := tx.Events().Record(ctx, bookID, "Arriendo", now); err != nil {
+		return store.Loan{}, err
+	}
+
+	start := now.Format(store.DateFmt)
+	id, err := tx.Loans().Create(ctx, userID, bookID, start)
+	if err != nil {
+		return store.Loan{}, err
+	}
+	l := Schedule(store.Loan{ID: id, UserID: userID, BookID: bookID, StartDate: start, Status: "pendiente"}, now)
+	return l, tx.Outbox().Publish(ctx, store.EventLoanCreated, l, now)
+}
+
+// ReturnLoan cierra el préstamo con fecha returned, devuelve el stock (avisando a la lista
+// de deseos si estaba agotado) y cobra PenaltyPerDay por día de atraso; el saldo puede
+// quedar negativo. La multa acumulada por el job loans.fines es solo informativa: la que
+// se cobra se recalcula con la fecha de devolución. Publica loan.returned.
+func ReturnLoan(ctx context.Context, tx store.Tx, loanID int64, returned, now time.Time) (store.Loan, error) {
+	l, err := tx.Loans().Get(ctx, loanID)
+	if errors.Is(err, store.ErrNotFound) {
+		return store.Loan{}, ErrLoanNotFound
+	}
+	if err != nil {
+		return store.Loan{}, err
+	}
+	if !isOpen(l.Status) {
+		return store.Loan{}, ErrAlreadyReturned
+	}
+
+	due := DueDate(l.StartDate, now.Location())
+	daysLate := max(days(due, returned), 0)
+	penalty := daysLate * PenaltyPerDay
+
+	b, err := tx.Books().Stock(ctx, l.BookID)
+	if err != nil {
+		return store.Loan{}, err
+	}
+	if err := tx.Books().PutBack(ctx, l.BookID); err != nil {
+		return store.Loan{}, err
+	}
+	if err := NotifyBookChanges(ctx, tx, b, b.Price, b.Available+1, now); err != nil {
+		return store.Loan{}, err
+	}
+	l.ReturnDate = returned.Format(store.DateFmt)
+	ok, err := tx.Loans().Close(ctx, loanID, l.ReturnDate, penalty)
+	if err != nil {
+		return store.Loan{}, err
+	}
+	if !ok {
+		return store.Loan{}, ErrAlreadyReturned
+	}
+	if penalty > 0 {
+		if err := tx.Users().AddBalance(ctx, l.UserID, -penalty); err != nil {
+			return store.Loan{}, err
+		}
+	}
+
+	l.Status = "finalizado"
+	l.Fine = penalty
+	l.DueDate = due.Format(store.DateFmt)
+	l.DaysLate = daysLate
+	l.Penalty = penalty
+	return l, tx.Outbox().Publish(ctx, store.EventLoanReturned, l, now)
+}
+
+// MarkOverdue pasa a vencido todo préstamo pendiente cuyo vencimiento ya quedó atrás a now
+// y devuelve cuántos marcó. Correrlo de nuevo no cambia nada.
+func MarkOverdue(ctx context.Context, tx store.Tx, now time.Time) (int64, error) {
+	open, err := tx.Loans().Open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, l := range open {
+		if l.Status != "pendiente" || days(DueDate(l.StartDate, now.Location()), now) <= 0 {
+			continue
+		}
+		ok, err := tx.Loans().MarkOverdue(ctx, l.ID)
+		if err != nil {
+			return n, err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// AccrueFines deja en cada préstamo vencido la multa que llevaría si se devolviera now
+// (PenaltyPerDay por día de atraso) y devuelve cuántos cambiaron. No toca saldos: la multa
+// se cobra al devolver.
+func AccrueFines(ctx context.Context, tx store.Tx, now time.Time) (int64, error) {
+	open, err := tx.Loans().Open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, l := range open {
+		if l.Status != "vencido" {
+			continue
+		}
+		fine := max(days(DueDate(l.StartDate, now.Location()), now), 0) * PenaltyPerDay
+		ok, err := tx.Loans().SetFine(ctx, l.ID, fine)
+		if err != nil {
+			return n, err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"tarea1-uzm/internal/store"
+)
+
+// QuoteLine es un libro del carro con su descuento; Promotions detalla cuánto aportó cada promoción.
+type QuoteLine struct {
+	BookID     int64           `json:"book_id"`
+	BookName   string          `json:"book_name"`
+	Category   string          `json:"book_category"`
+	Price      int64           `json:"price"`
+	Discount   int64           `json:"discount"`
+	Final      int64           `json:"final"`
+	Promotions map[int64]int64 `json:"-"`
+}
+
+type AppliedPromotion struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Code     string `json:"code,omitempty"`
+	Discount int64  `json:"discount"`
+}
+
+type Quote struct {
+	Lines     []QuoteLine        `json:"lines"`
+	Subtotal  int64              `json:"subtotal"`
+	Discount  int64              `json:"discount"`
+	Total     int64              `json:"total"`
+	Applied   []AppliedPromotion `json:"applied"`
+	CodeError string             `json:"code_error,omitempty"` // solo en /sales/quote
+}
+
+// usable indica si la promoción rige hoy y le quedan usos (userUses = canjes del usuario).
+func usable(p store.Promotion, today time.Time, userUses int64) error {
+	start, err1 := time.ParseInLocation(store.DateFmt, p.StartsAt, today.Location())
+	end, err2 := time.ParseInLocation(store.DateFmt, p.EndsAt, today.Location())
+	switch {
+	case !p.Active:
+		return fmt.Errorf("la promoción %q no está activa", p.Name)
+	case err1 != nil || err2 != nil || today.Before(start) || today.After(end):
+		return fmt.Errorf("la promoción %q no está vigente (%s a %s)", p.Name, p.StartsAt, p.EndsAt)
+	case p.MaxUses > 0 && p.Uses >= p.MaxUses:
+		return fmt.Errorf("la promoción %q se agotó", p.Name)
+	case p.MaxUsesPerUser > 0 && userUses >= p.MaxUsesPerUser:
+		return fmt.Errorf("ya usaste la promoción %q el máximo de veces", p.Name)
+	}
+	return nil
+}
+
+// discounts calcula el descuento de p sobre cada línea, partiendo de base (lo que queda por pagar).
+func discounts(p store.Promotion, lines []QuoteLine, base []int64) []int64 {
+	out := make([]int64, len(lines))
+	var idx []int
+	for i, l := range lines {
+		if (p.Category == "" || p.Category == l.Category) && base[i] > 0 {
+			idx = append(idx, i)
+		}
+	}
+	switch p.Kind {
+	case "porcentaje":
+		for _, i := range idx {
+			out[i] = base[i] * p.Value / 100
+		}
+	case "monto":
+		var sum int64
+		for _, i := range idx {
+			sum += base[i]
+		}
+		if sum == 0 {
+			return out
+		}
+		total := min(p.Value, sum)
+		var given int64
+		for _, i := range idx {
+			out[i] = total * base[i] / sum
+			given += out[i]
+		}
+		// el resto del redondeo va a la primera línea con espacio
+		for _, i := range idx {
+			if given == total {
+				break
+			}
+			extra := min(total-given, base[i]-out[i])
+			out[i] += extra
+			given += extra
+		}
+	case "bundle":
+		// de cada grupo de buy_qty (más caros primero) se regalan los buy_qty-pay_qty más baratos
+		sort.SliceStable(idx, func(a, b int) bool { return base[idx[a]] > base[idx[b]] })
+		for g := 0; p.BuyQty > 0 && g+int(p.BuyQty) <= len(idx); g += int(p.BuyQty) {
+			for _, i := range idx[g+int(p.PayQty) : g+int(p.BuyQty)] {
+				out[i] = base[i]
+			}
+		}
+	}
+	return out
+}
+
+// PriceCart aplica las promociones automáticas (por línea gana la de mayor descuento;
+// no se acumulan entre sí) y luego el código, encima del precio ya rebajado.
+func PriceCart(lines []QuoteLine, auto []store.Promotion, coded *store.Promotion) Quote {
+	q := Quote{Lines: lines, Applied: []AppliedPromotion{}}
+	base := make([]int64, len(lines))
+	best := make([]int64, len(lines))
+	bestID := make([]int64, len(lines))
+	for i := range lines {
+		lines[i].Promotions = map[int64]int64{}
+		base[i] = lines[i].Price
+	}
+	for _, p := range auto {
+		for i, d := range discounts(p, lines, base) {
+			if d > best[i] {
+				best[i], bestID[i] = d, p.ID
+			}
+		}
+	}
+	for i := range lines {
+		if best[i] > 0 {
+			lines[i].Promotions[bestID[i]] = best[i]
+			base[i] -= best[i]
+		}
+	}
+	if coded != nil {
+		for i, d := range discounts(*coded, lines, base) {
+			if d > 0 {
+				lines[i].Promotions[coded.ID] += d
+				base[i] -= d
+			}
+		}
+	}
+
+	all := append([]store.Promotion(nil), auto...)
+	if coded != nil {
+		all = append(all, *coded)
+	}
+	byID := map[int64]int64{}
+	for i := range lines {
+		lines[i].Final = base[i]
+		lines[i].Discount = lines[i].Price - base[i]
+		q.Subtotal += lines[i].Price
+		q.Discount += lines[i].Discount
+		for id, d := range lines[i].Promotions {
+			byID[id] += d
+		}
+	}
+	q.Total = q.Subtotal - q.Discount
+	for _, p := range all {
+		if d := byID[p.ID]; d > 0 {
+			q.Applied = append(q.Applied, AppliedPromotion{ID: p.ID, Name: p.Name, Code: p.Code, Discount: d})
+		}
+	}
+	return q
+}
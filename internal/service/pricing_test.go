@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+
+	"tarea1-uzm/internal/store"
+)
+
+func cart(prices ...int64) []QuoteLine {
+	lines := make([]QuoteLine, len(prices))
+	for i, p := range prices {
+		lines[i] = QuoteLine{BookID: int64(i + 1), Category: "Novela", Price: p}
+	}
+	return lines
+}
+
+func TestPriceCart(t *testing.T) {
+	pct := store.Promotion{ID: 1, Name: "10%", Kind: "porcentaje", Value: 10}
+	pct50 := store.Promotion{ID: 2, Name: "50% ensayo", Kind: "porcentaje", Value: 50, Category: "Ensayo"}
+	bundle := store.Promotion{ID: 3, Name: "3x2", Kind: "bundle", BuyQty: 3, PayQty: 2}
+	amount := store.Promotion{ID: 4, Name: "-10", Code: "DIEZ", Kind: "monto", Value: 10}
+
+	tests := []struct {
+		name    string
+		lines   []QuoteLine
+		auto    []store.Promotion
+		coded   *store.Promotion
+		total   int64
+		applied int
+	}{
+		{"sin promociones", cart(10, 20), nil, nil, 30, 0},
+		{"porcentaje", cart(10, 20), []store.Promotion{pct}, nil, 27, 1},
+		{"otra categoría no aplica", cart(10, 20), []store.Promotion{pct50}, nil, 30, 0},
+		{"3x2 regala el más barato", cart(30, 10, 20), []store.Promotion{bundle}, nil, 50, 1},
+		{"automáticas no se suman: gana la mayor por línea", cart(30, 10, 20), []store.Promotion{pct, bundle}, nil, 45, 2},
+		{"el monto reparte y respeta el total", cart(3, 4), nil, &amount, 0, 1},
+		{"código encima de la automática", cart(100), []store.Promotion{pct}, &amount, 80, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := PriceCart(tt.lines, tt.auto, tt.coded)
+			if q.Total != tt.total || len(q.Applied) != tt.applied {
+				t.Fatalf("total=%d applied=%v, want total=%d applied=%d", q.Total, q.Applied, tt.total, tt.applied)
+			}
+			if q.Subtotal-q.Discount != q.Total {
+				t.Errorf("subtotal %d - descuento %d != total %d", q.Subtotal, q.Discount, q.Total)
+			}
+			for _, l := range q.Lines {
+				if l.Final < 0 || l.Price-l.Discount != l.Final {
+					t.Errorf("línea inconsistente: %+v", l)
+				}
+			}
+		})
+	}
+}
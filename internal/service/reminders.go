@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tarea1-uzm/internal/notify"
+	"tarea1-uzm/internal/store"
+)
+
+// reminderKind es el recordatorio que le toca a un préstamo abierto al que le quedan
+// daysLeft días ("" = todavía ninguno).
+func reminderKind(daysLeft int64) string {
+	switch {
+	case daysLeft < 0:
+		return notify.KindOverdue
+	case daysLeft == 0:
+		return notify.KindDueToday
+	case daysLeft <= notify.DaysBefore:
+		return notify.KindDueSoon
+	}
+	return ""
+}
+
+// SendReminders avisa por cada canal de channels a los usuarios con préstamos que vencen
+// en notify.DaysBefore días o menos, que vencen hoy o que ya vencieron. Cada recordatorio
+// sale una sola vez por préstamo y canal, y nunca por un canal que el usuario apagó.
+// Devuelve cuántos envió; los envíos fallidos se juntan en el error y se reintentan en la
+// próxima pasada. Los canales no corren dentro de una transacción: tx debería ser Read().
+func SendReminders(ctx context.Context, tx store.Tx, channels []notify.Notifier, now time.Time) (int64, error) {
+	open, err := tx.Loans().Open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		sent   int64
+		failed []error
+	)
+	for _, l := range open {
+		l = Schedule(l, now)
+		kind := reminderKind(l.DaysLeft)
+		if kind == "" {
+			continue
+		}
+		optOut, err := tx.Reminders().OptedOut(ctx, l.UserID)
+		if err != nil {
+			return sent, err
+		}
+		var msg *notify.Message
+		for _, ch := range channels {
+			if optOut[ch.Name()] {
+				continue
+			}
+			done, err := tx.Reminders().Sent(ctx, l.ID, kind, ch.Name())
+			if err != nil {
+				return sent, err
+			}
+			if done {
+				continue
+			}
+			if msg == nil {
+				if msg, err = reminder(ctx, tx, l, kind, now); err != nil {
+					return sent, err
+				}
+			}
+			if err := ch.Send(ctx, *msg); err != nil {
+				failed = append(failed, fmt.Errorf("%s préstamo %d: %w", ch.Name(), l.ID, err))
+				continue
+			}
+			if _, err := tx.Reminders().MarkSent(ctx, l.ID, kind, ch.Name(), now); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+	return sent, errors.Join(failed...)
+}
+
+// reminder redacta el recordatorio kind del préstamo l (ya pasado por Schedule).
+func reminder(ctx context.Context, tx store.Tx, l store.Loan, kind string, now time.Time) (*notify.Message, error) {
+	u, err := tx.Users().Get(ctx, l.UserID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := tx.Books().Stock(ctx, l.BookID)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := notify.Compose(notify.Reminder{
+		Kind: kind, UserID: u.ID, Name: u.FirstName, Email: u.Email, LoanID: l.ID, BookID: b.ID, Book: b.Name,
+		StartDate: l.StartDate, DueDate: l.DueDate, DaysLeft: l.DaysLeft,
+		Fine: max(-l.DaysLeft, 0) * PenaltyPerDay, PenaltyPerDay: PenaltyPerDay,
+	})
+	msg.At = now
+	return &msg, err
+}
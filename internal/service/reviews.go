@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"tarea1-uzm/internal/store"
+)
+
+// ReviewChanges son los cambios que el autor pide sobre su reseña; nil = no tocar.
+type ReviewChanges struct {
+	Rating  *int64
+	Comment *string
+}
+
+// CreateReview publica la reseña de r.UserID sobre r.BookID: solo quien compró o arrendó el
+// libro puede reseñarlo, una vez. Devuelve la reseña guardada, con el nombre del autor.
+func CreateReview(ctx context.Context, tx store.Tx, r store.Review, now time.Time) (store.Review, error) {
+	if _, err := tx.Books().Stock(ctx, r.BookID); errors.Is(err, store.ErrNotFound) {
+		return store.Review{}, ErrBookNotFound
+	} else if err != nil {
+		return store.Review{}, err
+	}
+	owned, err := touched(ctx, tx, r.UserID, r.BookID)
+	if err != nil {
+		return store.Review{}, err
+	}
+	if !owned {
+		return store.Review{}, ErrNotReviewer
+	}
+	r.Comment = strings.TrimSpace(r.Comment)
+	r.CreatedAt = now.UTC().Format(store.EventFmt)
+	r.UpdatedAt = r.CreatedAt
+	if err := tx.Reviews().Create(ctx, &r); err != nil {
+		return store.Review{}, err
+	}
+	return tx.Reviews().Get(ctx, r.ID)
+}
+
+// UpdateReview aplica ch a la reseña reviewID del libro bookID; solo su autor userID puede
+// editarla. Devuelve cómo quedó y cómo estaba.
+func UpdateReview(ctx context.Context, tx store.Tx, bookID, reviewID, userID int64, ch ReviewChanges, now time.Time) (after, before store.Review, err error) {
+	before, err = authored(ctx, tx, bookID, reviewID, userID)
+	if err != nil {
+		return store.Review{}, store.Review{}, err
+	}
+	after = before
+	if ch.Rating != nil {
+		after.Rating = *ch.Rating
+	}
+	if ch.Comment != nil {
+		after.Comment = strings.TrimSpace(*ch.Comment)
+	}
+	after.UpdatedAt = now.UTC().Format(store.EventFmt)
+	return after, before, tx.Reviews().Update(ctx, after)
+}
+
+// DeleteReview borra la reseña reviewID y devuelve cómo era. userID es el autor cuando la
+// borra él mismo (y bookID el libro de la ruta); 0 cuando la borra un administrador.
+func DeleteReview(ctx context.Context, tx store.Tx, bookID, reviewID, userID int64) (store.Review, error) {
+	r, err := authored(ctx, tx, bookID, reviewID, userID)
+	if err != nil {
+		return store.Review{}, err
+	}
+	return r, tx.Reviews().Delete(ctx, r.ID)
+}
+
+// ModerateReview deja la reseña id visible u oculta y devuelve el status que tenía.
+func ModerateReview(ctx context.Context, tx store.Tx, id int64, status string) (string, error) {
+	r, err := tx.Reviews().Get(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return "", ErrReviewNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return r.Status, tx.Reviews().SetStatus(ctx, id, status)
+}
+
+// authored busca la reseña reviewID y revisa que sea del libro bookID y del autor userID
+// (0 = cualquiera, para los administradores).
+func authored(ctx context.Context, tx store.Tx, bookID, reviewID, userID int64) (store.Review, error) {
+	r, err := tx.Reviews().Get(ctx, reviewID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && bookID != 0 && r.BookID != bookID) {
+		return store.Review{}, ErrReviewNotFound
+	}
+	if err != nil {
+		return store.Review{}, err
+	}
+	if userID != 0 && r.UserID != userID {
+		return store.Review{}, ErrNotAuthor
+	}
+	return r, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tarea1-uzm/internal/store"
+)
+
+// Checkout cotiza (dryRun) o compra bookIDs (un ejemplar por id, se pueden repetir) para userID.
+// En dryRun no escribe nada y un código inválido no falla: queda en Quote.CodeError.
+func Checkout(ctx context.Context, tx store.Tx, userID int64, bookIDs []int64, code string, dryRun bool, now time.Time) (Quote, []store.Sale, error) {
+	if len(bookIDs) == 0 {
+		return Quote{}, nil, ErrEmptyCart
+	}
+	_, err := tx.Users().Balance(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return Quote{}, nil, ErrUserNotFound
+	}
+	if err != nil {
+		return Quote{}, nil, err
+	}
+
+	lines := make([]QuoteLine, 0, len(bookIDs))
+	wanted := map[int64]int64{}
+	for _, id := range bookIDs {
+		b, err := tx.Books().Stock(ctx, id)
+		if errors.Is(err, store.ErrNotFound) {
+			return Quote{}, nil, ErrBookNotFound
+		}
+		if err != nil {
+			return Quote{}, nil, err
+		}
+		if b.TransactionType != "Venta" {
+			return Quote{}, nil, ErrNotForSale
+		}
+		wanted[id]++
+		if wanted[id] > b.Available {
+			return Quote{}, nil, ErrOutOfStock
+		}
+		lines = append(lines, QuoteLine{BookID: b.ID, BookName: b.Name, Category: b.Category, Price: b.Price})
+	}
+
+	// promociones vigentes
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	used, err := tx.Promotions().UserRedemptions(ctx, userID)
+	if err != nil {
+		return Quote{}, nil, err
+	}
+	active, err := tx.Promotions().Active(ctx)
+	if err != nil {
+		return Quote{}, nil, err
+	}
+	var auto []store.Promotion
+	var coded *store.Promotion
+	var codeErr error
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, p := range active {
+		switch {
+		case p.Code == "":
+			if usable(p, today, used[p.ID]) == nil {
+				auto = append(auto, p)
+			}
+		case code != "" && p.Code == code:
+			if codeErr = usable(p, today, used[p.ID]); codeErr == nil {
+				coded = &p
+			}
+		}
+	}
+	if code != "" && coded == nil && codeErr == nil {
+		codeErr = fmt.Errorf("código %q no existe", code)
+	}
+
+	q := PriceCart(lines, auto, coded)
+	if dryRun {
+		if codeErr != nil {
+			q.CodeError = codeErr.Error()
+		}
+		return q, nil, nil
+	}
+	if codeErr != nil {
+		return Quote{}, nil, &PromotionError{Msg: codeErr.Error()}
+	}
+
+	// ejecutar: descuenta saldo, stock, aumenta popularidad e inserta ventas con su descuento.
+	// Saldo y stock se descuentan con UPDATE condicional: aunque otra compra se cuele entre
+	// la lectura y la escritura, nunca quedan negativos.
+	ok, err := tx.Users().Charge(ctx, userID, q.Total)
+	if err != nil {
+		return Quote{}, nil, err
+	}
+	if !ok {
+		return Quote{}, nil, ErrInsufficientFunds
+	}
+	date := now.Format(store.DateFmt)
+	sales := make([]store.Sale, 0, len(q.Lines))
+	for _, l := range q.Lines {
+		ok, err := takeOne(ctx, tx, store.BookStock{ID: l.BookID, Name: l.BookName}, now)
+		if err != nil {
+			return Quote{}, nil, err
+		}
+		if !ok {
+			return Quote{}, nil, ErrOutOfStock
+		}
+		if err := tx.Books().BumpPopularity(ctx, l.BookID); err != nil {
+			return Quote{}, nil, err
+		}
+		if err := tx.Events().Record(ctx, l.BookID, "Venta", now); err != nil {
+			return Quote{}, nil, err
+		}
+		s := store.Sale{UserID: userID, BookID: l.BookID, SaleDate: date, Price: l.Price, Discount: l.Discount}
+		if err := tx.Sales().Create(ctx, &s); err != nil {
+			return Quote{}, nil, err
+		}
+		for promoID, amount := range l.Promotions {
+			if err := tx.Sales().AddDiscount(ctx, s.ID, promoID, amount); err != nil {
+				return Quote{}, nil, err
+			}
+		}
+		if err := tx.Outbox().Publish(ctx, store.EventSaleCreated, s, now); err != nil {
+			return Quote{}, nil, err
+		}
+		sales = append(sales, s)
+	}
+	for _, a := range q.Applied {
+		ok, err := tx.Promotions().Redeem(ctx, a.ID, userID, a.Discount, now)
+		if err != nil {
+			return Quote{}, nil, err
+		}
+		if !ok {
+			return Quote{}, nil, &PromotionError{Msg: fmt.Sprintf("la promoción %q se agotó", a.Name), Exhausted: true}
+		}
+	}
+	return q, sales, nil
+}
+
+// SellBook compra un ejemplar de bookID; mismo flujo (y promociones) que Checkout.
+func SellBook(ctx context.Context, tx store.Tx, userID, bookID int64, code string, now time.Time) (store.Sale, error) {
+	_, sales, err := Checkout(ctx, tx, userID, []int64{bookID}, code, false, now)
+	if err != nil {
+		return store.Sale{}, err
+	}
+	return sales[0], nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"tarea1-uzm/internal/store"
+)
+
+// Stats es el estado del negocio que se publica en /metrics. Todo sale de la base, así los
+// contadores no vuelven a cero cuando se reinicia el servidor.
+type Stats struct {
+	Sales            int64 // ventas registradas
+	LoansOpened      int64 // préstamos creados
+	LoansReturned    int64 // préstamos finalizados
+	LoansOverdue     int64 // sin devolver con due_date ya pasado (marcados vencido o no todavía)
+	PenaltiesCharged int64 // usm pesos cobrados en multas (PenaltyPerDay por día de atraso)
+	USMPesos         int64 // saldo total de los usuarios
+}
+
+// CollectStats calcula Stats a la fecha de now (su zona define el día del negocio).
+func CollectStats(ctx context.Context, tx store.Tx, now time.Time) (Stats, error) {
+	var st Stats
+	var err error
+	if st.Sales, err = tx.Sales().Count(ctx); err != nil {
+		return st, err
+	}
+	if st.USMPesos, err = tx.Users().TotalBalance(ctx); err != nil {
+		return st, err
+	}
+	loans, err := tx.Loans().List(ctx)
+	if err != nil {
+		return st, err
+	}
+	for _, l := range loans {
+		st.LoansOpened++
+		due := DueDate(l.StartDate, now.Location())
+		if !isOpen(l.Status) {
+			st.LoansReturned++
+			returned, err := time.ParseInLocation(store.DateFmt, l.ReturnDate, now.Location())
+			if err == nil {
+				st.PenaltiesCharged += max(days(due, returned), 0) * PenaltyPerDay
+			}
+			continue
+		}
+		if days(due, now) > 0 {
+			st.LoansOverdue++
+		}
+	}
+	return st, nil
+}
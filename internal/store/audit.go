@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditEntry es una fila de audit_log: quién hizo qué sobre qué entidad, y cómo estaba antes
+// y cómo quedó. Hash encadena la fila con la anterior (PrevHash) para detectar alteraciones.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	CreatedAt string          `json:"created_at"` // RFC3339 UTC
+	Actor     string          `json:"actor"`      // admin | user:<id> | anónimo
+	Action    string          `json:"action"`     // ej. book.update, user.update, sale.create
+	Entity    string          `json:"entity"`
+	EntityID  int64           `json:"entity_id,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// Sum calcula el hash de la entrada: sha256 de PrevHash y de todos los campos salvo ID y Hash.
+func (e AuditEntry) Sum() string {
+	raw, _ := json.Marshal(struct {
+		PrevHash, CreatedAt, Actor, Action, Entity string
+		EntityID                                   int64
+		Before, After                              json.RawMessage
+		RequestID                                  string
+	}{e.PrevHash, e.CreatedAt, e.Actor, e.Action, e.Entity, e.EntityID, e.Before, e.After, e.RequestID})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditFilter son los filtros de List; los campos vacíos no filtran.
+type AuditFilter struct {
+	Actor, Action, Entity string
+	EntityID              int64
+	From, To              time.Time // [From, To)
+	BeforeID              int64     // paginación: solo entradas con id < BeforeID
+	Limit                 int
+}
+
+// AuditCheck es el resultado de Verify.
+type AuditCheck struct {
+	OK       bool   `json:"ok"`
+	Entries  int64  `json:"entries"`
+	Head     string `json:"head"`                // hash de la última entrada revisada
+	BrokenAt int64  `json:"broken_at,omitempty"` // id de la primera entrada alterada (0 = cadena íntegra)
+	Reason   string `json:"reason,omitempty"`
+}
+
+type Audit interface {
+	// Append encadena e a la última entrada y la inserta (completa ID, PrevHash y Hash).
+	// Debe correr en la misma transacción que el cambio que registra.
+	Append(ctx context.Context, e *AuditEntry) error
+	// List devuelve las entradas que cumplen f, de la más nueva a la más antigua.
+	List(ctx context.Context, f AuditFilter) ([]AuditEntry, error)
+	// Verify recorre la cadena completa y se detiene en la primera entrada que no calza.
+	Verify(ctx context.Context) (AuditCheck, error)
+}
+
+type audit struct{ q DBTX }
+
+const auditColumns = `id, created_at, actor, action, entity, COALESCE(entity_id, 0),
+  COALESCE(before_json, ''), COALESCE(after_json, ''), request_id, prev_hash, hash`
+
+func scanAudit(rows *sql.Rows) (AuditEntry, error) {
+	var (
+		e             AuditEntry
+		before, after string
+	)
+	err := rows.Scan(&e.ID, &e.CreatedAt, &e.Actor, &e.Action, &e.Entity, &e.EntityID,
+		&before, &after, &e.RequestID, &e.PrevHash, &e.Hash)
+	if before != "" {
+		e.Before = json.RawMessage(before)
+	}
+	if after != "" {
+		e.After = json.RawMessage(after)
+	}
+	return e, err
+}
+
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func (a audit) Append(ctx context.Context, e *AuditEntry) error {
+	err := a.q.QueryRowContext(ctx, `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&e.PrevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	e.Hash = e.Sum()
+	var entityID any
+	if e.EntityID != 0 {
+		entityID = e.EntityID
+	}
+	res, err := a.q.ExecContext(ctx, `
+INSERT INTO audit_log(created_at,actor,action,entity,entity_id,before_json,after_json,request_id,prev_hash,hash)
+VALUES(?,?,?,?,?,?,?,?,?,?)`,
+		e.CreatedAt, e.Actor, e.Action, e.Entity, entityID, nullJSON(e.Before), nullJSON(e.After), e.RequestID, e.PrevHash, e.Hash)
+	if err != nil {
+		return err
+	}
+	e.ID, err = res.LastInsertId()
+	return err
+}
+
+func (a audit) List(ctx context.Context, f AuditFilter) ([]AuditEntry, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		where = append(where, cond)
+		args = append(args, arg)
+	}
+	if f.Actor != "" {
+		add("actor = ?", f.Actor)
+	}
+	if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+	if f.Entity != "" {
+		add("entity = ?", f.Entity)
+	}
+	if f.EntityID != 0 {
+		add("entity_id = ?", f.EntityID)
+	}
+	if !f.From.IsZero() {
+		add("created_at >= ?", f.From.UTC().Format(EventFmt))
+	}
+	if !f.To.IsZero() {
+		add("created_at < ?", f.To.UTC().Format(EventFmt))
+	}
+	if f.BeforeID != 0 {
+		add("id < ?", f.BeforeID)
+	}
+	query := `SELECT ` + auditColumns + ` FROM audit_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, f.Limit)
+
+	rows, err := a.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []AuditEntry{}
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (a audit) Verify(ctx context.Context) (AuditCheck, error) {
+	rows, err := a.q.QueryContext(ctx, `SELECT `+auditColumns+` FROM audit_log ORDER BY id`)
+	if err != nil {
+		return AuditCheck{}, err
+	}
+	defer rows.Close()
+	var check AuditCheck
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return check, err
+		}
+		switch {
+		case e.PrevHash != check.Head:
+			check.BrokenAt, check.Reason = e.ID, fmt.Sprintf("prev_hash no coincide con el hash de la entrada anterior (falta o se alteró una entrada antes de %d)", e.ID)
+		case e.Sum() != e.Hash:
+			check.BrokenAt, check.Reason = e.ID, "el contenido no coincide con su hash (entrada modificada)"
+		}
+		if check.BrokenAt != 0 {
+			return check, nil
+		}
+		check.Entries++
+		check.Head = e.Hash
+	}
+	check.OK = true
+	return check, rows.Err()
+}
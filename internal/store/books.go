@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+// BookStock es lo que necesitan las operaciones de venta y arriendo de un libro.
+type BookStock struct {
+	ID              int64
+	Name            string
+	Category        string
+	TransactionType string // Venta | Arriendo
+	Price           int64
+	Available       int64
+	ISBN            string // "" si no tiene
+	Author          string // "" si no se conoce
+}
+
+// Listing es un libro del catálogo público: su stock, popularidad histórica y el
+// promedio de reseñas visibles.
+type Listing struct {
+	BookStock
+	Popularity    int64
+	AverageRating float64
+	ReviewCount   int64
+}
+
+// ListingFilter acota Catalog; el valor cero lista todo el catálogo por id.
+type ListingFilter struct {
+	InStock  bool   // solo con stock
+	Category string // "" = todas
+	Order    string // una clave de ListingOrders; "" = id
+	Limit    int    // 0 = sin límite
+}
+
+// ListingOrders son los órdenes que acepta Catalog (GET /books?sort=...).
+var ListingOrders = map[string]string{
+	"id":         "b.id",
+	"rating":     "COALESCE(r.avg_rating, 0) DESC, COALESCE(r.review_count, 0) DESC, b.id",
+	"price":      "b.price, b.id",
+	"popularity": "b.popularity_score DESC, b.id",
+}
+
+type Books interface {
+	Stock(ctx context.Context, id int64) (BookStock, error)
+	// TakeOne baja el stock en 1 solo si queda; false = sin stock.
+	TakeOne(ctx context.Context, id int64) (bool, error)
+	PutBack(ctx context.Context, id int64) error
+	SetAvailable(ctx context.Context, id, qty int64) error
+	SetPrice(ctx context.Context, id, price int64) error
+	BumpPopularity(ctx context.Context, id int64) error
+	// Create inserta el libro con su inventario y le asigna ID.
+	Create(ctx context.Context, b *BookStock) error
+	// SetDetails cambia nombre, autor, categoría, modalidad e ISBN (no precio ni stock).
+	SetDetails(ctx context.Context, b BookStock) error
+	// ByISBN busca por ISBN normalizado (ErrNotFound si no hay).
+	ByISBN(ctx context.Context, isbn string) (BookStock, error)
+	// ByName lista los libros sin ISBN con ese nombre (sin distinguir mayúsculas).
+	ByName(ctx context.Context, name string) ([]BookStock, error)
+	// All lista el catálogo completo por id, con o sin stock.
+	All(ctx context.Context) ([]BookStock, error)
+	// Catalog lista el catálogo con rating según f.
+	Catalog(ctx context.Context, f ListingFilter) ([]Listing, error)
+}
+
+type books struct{ q DBTX }
+
+func (b books) Stock(ctx context.Context, id int64) (BookStock, error) {
+	var s BookStock
+	err := b.q.QueryRowContext(ctx, `
+SELECT `+bookStockCols+`
+FROM books b
+JOIN inventory i ON i.book_id = b.id
+WHERE b.id = ?`, id).Scan(s.fields()...)
+	return s, notFound(err)
+}
+
+// bookStockCols son las columnas que lee fields.
+const bookStockCols = `b.id, b.book_name, b.book_category, b.transaction_type, b.price, i.available_quantity,
+COALESCE(b.isbn, ''), COALESCE(b.author, '')`
+
+func (s *BookStock) fields() []any {
+	return []any{&s.ID, &s.Name, &s.Category, &s.TransactionType, &s.Price, &s.Available, &s.ISBN, &s.Author}
+}
+
+func (b books) TakeOne(ctx context.Context, id int64) (bool, error) {
+	return affected(b.q.ExecContext(ctx,
+		`UPDATE inventory SET available_quantity = available_quantity - 1 WHERE book_id=? AND available_quantity > 0`, id))
+}
+
+func (b books) PutBack(ctx context.Context, id int64) error {
+	_, err := b.q.ExecContext(ctx, `UPDATE inventory SET available_quantity = available_quantity + 1 WHERE book_id=?`, id)
+	return err
+}
+
+func (b books) SetAvailable(ctx context.Context, id, qty int64) error {
+	_, err := b.q.ExecContext(ctx, `UPDATE inventory SET available_quantity=? WHERE book_id=?`, qty, id)
+	return err
+}
+
+func (b books) SetPrice(ctx context.Context, id, price int64) error {
+	_, err := b.q.ExecContext(ctx, `UPDATE books SET price=? WHERE id=?`, price, id)
+	return err
+}
+
+func (b books) BumpPopularity(ctx context.Context, id int64) error {
+	_, err := b.q.ExecContext(ctx, `UPDATE books SET popularity_score = popularity_score + 1 WHERE id=?`, id)
+	return err
+}
+
+func (b books) Create(ctx context.Context, s *BookStock) error {
+	res, err := b.q.ExecContext(ctx, `INSERT INTO books(book_name,book_category,transaction_type,price,isbn,author) VALUES(?,?,?,?,?,?)`,
+		s.Name, s.Category, s.TransactionType, s.Price, nullable(s.ISBN), nullable(s.Author))
+	if err != nil {
+		return err
+	}
+	if s.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+	_, err = b.q.ExecContext(ctx, `INSERT INTO inventory(book_id,available_quantity) VALUES(?,?)`, s.ID, s.Available)
+	return err
+}
+
+func (b books) SetDetails(ctx context.Context, s BookStock) error {
+	_, err := b.q.ExecContext(ctx, `UPDATE books SET book_name=?, book_category=?, transaction_type=?, isbn=?, author=? WHERE id=?`,
+		s.Name, s.Category, s.TransactionType, nullable(s.ISBN), nullable(s.Author), s.ID)
+	return err
+}
+
+func (b books) ByISBN(ctx context.Context, isbn string) (BookStock, error) {
+	var s BookStock
+	err := b.q.QueryRowContext(ctx, `SELECT `+bookStockCols+` FROM books b JOIN inventory i ON i.book_id = b.id WHERE b.isbn = ?`, isbn).
+		Scan(s.fields()...)
+	return s, notFound(err)
+}
+
+func (b books) ByName(ctx context.Context, name string) ([]BookStock, error) {
+	return b.list(ctx, `WHERE b.isbn IS NULL AND lower(b.book_name) = lower(?) ORDER BY b.id`, name)
+}
+
+func (b books) All(ctx context.Context) ([]BookStock, error) {
+	return b.list(ctx, `ORDER BY b.id`)
+}
+
+func (b books) list(ctx context.Context, where string, args ...any) ([]BookStock, error) {
+	rows, err := b.q.QueryContext(ctx, `SELECT `+bookStockCols+` FROM books b JOIN inventory i ON i.book_id = b.id `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []BookStock
+	for rows.Next() {
+		var s BookStock
+		if err := rows.Scan(s.fields()...); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// listingCols y listingFrom arman el SELECT del catálogo: inventario + rating (solo
+// reseñas visibles). Se leen con Listing.fields.
+const (
+	listingCols = bookStockCols + `, b.popularity_score, COALESCE(r.avg_rating, 0), COALESCE(r.review_count, 0)`
+	listingFrom = `
+FROM books b
+JOIN inventory i ON i.book_id = b.id
+LEFT JOIN (
+  SELECT book_id, ROUND(AVG(rating), 2) AS avg_rating, COUNT(*) AS review_count
+  FROM reviews WHERE status = 'visible' GROUP BY book_id
+) r ON r.book_id = b.id`
+)
+
+func (l *Listing) fields() []any {
+	return append(l.BookStock.fields(), &l.Popularity, &l.AverageRating, &l.ReviewCount)
+}
+
+func (b books) Catalog(ctx context.Context, f ListingFilter) ([]Listing, error) {
+	order, ok := ListingOrders[f.Order]
+	if !ok {
+		order = ListingOrders["id"]
+	}
+	q, args := `SELECT `+listingCols+listingFrom+`
+WHERE (? = 0 OR i.available_quantity > 0) AND (? = '' OR b.book_category = ?)
+ORDER BY `+order, []any{f.InStock, f.Category, f.Category}
+	if f.Limit > 0 {
+		q += ` LIMIT ?`
+		args = append(args, f.Limit)
+	}
+	rows, err := b.q.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Listing{}
+	for rows.Next() {
+		var l Listing
+		if err := rows.Scan(l.fields()...); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// nullable guarda "" como NULL.
+func nullable(s string) sql.NullString { return sql.NullString{String: s, Valid: s != ""} }
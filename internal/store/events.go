@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Event es una venta o un arriendo de un libro, con su fecha.
+type Event struct {
+	BookID int64
+	At     time.Time
+}
+
+// Events guarda los eventos fechados que alimentan el ranking de tendencias.
+type Events interface {
+	// Record deja un evento kind (Venta | Arriendo) para el libro.
+	Record(ctx context.Context, bookID int64, kind string, at time.Time) error
+	// Since lista los eventos ocurridos desde from (los de fecha ilegible se omiten).
+	Since(ctx context.Context, from time.Time) ([]Event, error)
+}
+
+type events struct{ q DBTX }
+
+func (e events) Record(ctx context.Context, bookID int64, kind string, at time.Time) error {
+	_, err := e.q.ExecContext(ctx, `INSERT INTO popularity_events(book_id,kind,created_at) VALUES(?,?,?)`,
+		bookID, kind, at.UTC().Format(EventFmt))
+	return err
+}
+
+func (e events) Since(ctx context.Context, from time.Time) ([]Event, error) {
+	rows, err := e.q.QueryContext(ctx, `SELECT book_id, created_at FROM popularity_events WHERE created_at >= ?`,
+		from.UTC().Format(EventFmt))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Event
+	for rows.Next() {
+		var ev Event
+		var at string
+		if err := rows.Scan(&ev.BookID, &at); err != nil {
+			return nil, err
+		}
+		if ev.At, err = time.Parse(EventFmt, at); err != nil {
+			continue
+		}
+		out = append(out, ev)
+	}
+	return out, rows.Err()
+}
+
+type Notification struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	BookID    int64  `json:"book_id,omitempty"`
+	Kind      string `json:"kind"` // precio | stock | vence_pronto | vence_hoy | vencido
+	Message   string `json:"message"`
+	CreatedAt string `json:"created_at"`
+	ReadAt    string `json:"read_at,omitempty"`
+}
+
+// Notifications es la bandeja de avisos a usuarios.
+type Notifications interface {
+	// NotifyFollowers crea un aviso para cada usuario que tiene el libro en su lista de deseos.
+	NotifyFollowers(ctx context.Context, bookID int64, kind, message string, at time.Time) error
+	// Create deja un aviso para un usuario (bookID 0 = sin libro).
+	Create(ctx context.Context, userID, bookID int64, kind, message string, at time.Time) error
+	// List lista los avisos del usuario, del más nuevo al más antiguo; unread = solo los no leídos.
+	List(ctx context.Context, userID int64, unread bool) ([]Notification, error)
+	// MarkRead marca leídos en at los avisos no leídos del usuario que estén en ids (vacío =
+	// todos) y dice cuántos marcó.
+	MarkRead(ctx context.Context, userID int64, ids []int64, at time.Time) (int64, error)
+}
+
+type notifications struct{ q DBTX }
+
+func (n notifications) NotifyFollowers(ctx context.Context, bookID int64, kind, message string, at time.Time) error {
+	_, err := n.q.ExecContext(ctx, `
+INSERT INTO notifications(user_id, book_id, kind, message, created_at)
+SELECT user_id, book_id, ?, ?, ? FROM wishlists WHERE book_id=?`,
+		kind, message, at.UTC().Format(EventFmt), bookID)
+	return err
+}
+
+func (n notifications) Create(ctx context.Context, userID, bookID int64, kind, message string, at time.Time) error {
+	var book any
+	if bookID != 0 {
+		book = bookID
+	}
+	_, err := n.q.ExecContext(ctx, `INSERT INTO notifications(user_id, book_id, kind, message, created_at) VALUES(?,?,?,?,?)`,
+		userID, book, kind, message, at.UTC().Format(EventFmt))
+	return err
+}
+
+func (n notifications) List(ctx context.Context, userID int64, unread bool) ([]Notification, error) {
+	q := `SELECT id, user_id, COALESCE(book_id,0), kind, message, created_at, COALESCE(read_at,'') FROM notifications WHERE user_id=?`
+	if unread {
+		q += ` AND read_at IS NULL`
+	}
+	rows, err := n.q.QueryContext(ctx, q+` ORDER BY id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Notification{}
+	for rows.Next() {
+		var x Notification
+		if err := rows.Scan(&x.ID, &x.UserID, &x.BookID, &x.Kind, &x.Message, &x.CreatedAt, &x.ReadAt); err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+func (n notifications) MarkRead(ctx context.Context, userID int64, ids []int64, at time.Time) (int64, error) {
+	q := `UPDATE notifications SET read_at=? WHERE user_id=? AND read_at IS NULL`
+	args := []any{at.UTC().Format(EventFmt), userID}
+	if len(ids) > 0 {
+		q += ` AND id IN (?` + strings.Repeat(",?", len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	res, err := n.q.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
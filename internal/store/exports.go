@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExportSet es una tabla exportable: sus columnas, en orden, y la consulta que las trae.
+type ExportSet struct {
+	Columns []string
+	query   string
+}
+
+// ExportSets son las tablas de GET /admin/<nombre>/export. users va sin contraseñas y books
+// con el mismo formato que acepta la importación.
+var ExportSets = map[string]ExportSet{
+	"books": {
+		Columns: []string{"id", "isbn", "book_name", "author", "book_category", "transaction_type", "price", "available_quantity", "popularity_score"},
+		query: `SELECT b.id, b.isbn, b.book_name, b.author, b.book_category, b.transaction_type, b.price, i.available_quantity, b.popularity_score
+FROM books b JOIN inventory i ON i.book_id = b.id ORDER BY b.id`,
+	},
+	"users": {
+		Columns: []string{"id", "first_name", "last_name", "email", "usm_pesos"},
+		query:   `SELECT id, first_name, last_name, email, usm_pesos FROM users ORDER BY id`,
+	},
+	"sales": {
+		Columns: []string{"id", "user_id", "book_id", "sale_date", "price", "discount"},
+		query:   `SELECT id, user_id, book_id, sale_date, price, discount FROM sales ORDER BY id`,
+	},
+	"loans": {
+		Columns: []string{"id", "user_id", "book_id", "start_date", "return_date", "status", "fine"},
+		query:   `SELECT id, user_id, book_id, start_date, return_date, status, fine FROM loans ORDER BY id`,
+	},
+}
+
+// Export lee todas las filas del conjunto name: los valores de cada una (int64, string o
+// nil), en el orden de sus Columns. Las deja en memoria para no tener el cursor (y su
+// conexión) abierto mientras se escriben a un cliente lento.
+func (s *Store) Export(ctx context.Context, name string) ([][]any, error) {
+	set, ok := ExportSets[name]
+	if !ok {
+		return nil, fmt.Errorf("store: no se puede exportar %q", name)
+	}
+	rows, err := s.db.QueryContext(ctx, set.query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out [][]any
+	for rows.Next() {
+		vals := make([]any, len(set.Columns))
+		ptrs := make([]any, len(vals))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		out = append(out, vals)
+	}
+	return out, rows.Err()
+}
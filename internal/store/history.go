@@ -0,0 +1,89 @@
+package store
+
+import "context"
+
+// Transaction es una venta o un préstamo visto como movimiento del historial.
+type Transaction struct {
+	ID     int64  `json:"id"`
+	Type   string `json:"type"` // Venta | Arriendo
+	UserID int64  `json:"user_id"`
+	BookID int64  `json:"book_id"`
+	Date   string `json:"date"` // DD/MM/YYYY
+}
+
+// Touch es un par usuario-libro con al menos una compra o un arriendo.
+type Touch struct {
+	UserID, BookID int64
+}
+
+// History junta ventas y préstamos: lo que cada usuario compró o arrendó.
+type History interface {
+	// Transactions lista ventas y préstamos de todos, por fecha y luego id.
+	Transactions(ctx context.Context) ([]Transaction, error)
+	// ByUser es Transactions de un solo usuario.
+	ByUser(ctx context.Context, userID int64) ([]Transaction, error)
+	// Touches lista cada par usuario-libro una vez, sin importar cuántas veces se repitió.
+	Touches(ctx context.Context) ([]Touch, error)
+}
+
+type history struct{ q DBTX }
+
+// transactionSelect une ventas y préstamos; where filtra ambas partes con los mismos args.
+func transactionSelect(where string) string {
+	return `
+SELECT id, type, user_id, book_id, date FROM (
+  SELECT id, 'Venta'    AS type, user_id, book_id, sale_date  AS date FROM sales ` + where + `
+  UNION ALL
+  SELECT id, 'Arriendo' AS type, user_id, book_id, start_date AS date FROM loans ` + where + `
+)
+ORDER BY
+  substr(date, 7, 4) || '-' || substr(date, 4, 2) || '-' || substr(date, 1, 2),
+  id`
+}
+
+func (h history) Transactions(ctx context.Context) ([]Transaction, error) {
+	return h.transactions(ctx, transactionSelect(""))
+}
+
+func (h history) ByUser(ctx context.Context, userID int64) ([]Transaction, error) {
+	return h.transactions(ctx, transactionSelect("WHERE user_id = ?"), userID, userID)
+}
+
+func (h history) transactions(ctx context.Context, query string, args ...any) ([]Transaction, error) {
+	rows, err := h.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Transaction{}
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.Type, &t.UserID, &t.BookID, &t.Date); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (h history) Touches(ctx context.Context) ([]Touch, error) {
+	rows, err := h.q.QueryContext(ctx, `
+SELECT DISTINCT user_id, book_id FROM (
+  SELECT user_id, book_id FROM sales
+  UNION ALL
+  SELECT user_id, book_id FROM loans
+)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Touch
+	for rows.Next() {
+		var t Touch
+		if err := rows.Scan(&t.UserID, &t.BookID); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// SavedResponse es lo que quedó guardado para una Idempotency-Key.
+type SavedResponse struct {
+	RequestHash string
+	Status      int // 0 = la primera request todavía no termina
+	Body        []byte
+}
+
+type Idempotency interface {
+	// Claim reserva key para la request con hash; si ya existía (y no vence antes de
+	// expiredBefore) devuelve lo guardado y false.
+	Claim(ctx context.Context, key, hash string, now, expiredBefore time.Time) (SavedResponse, bool, error)
+	// Save guarda la respuesta para repetirla.
+	Save(ctx context.Context, key string, status int, body []byte) error
+	// Release borra la reserva si aún está en curso (la request falló y se puede reintentar
+	// con la misma key); una respuesta ya guardada se mantiene.
+	Release(ctx context.Context, key string) error
+	// Purge borra las keys creadas antes de expiredBefore y devuelve cuántas eran.
+	Purge(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+type idempotency struct{ q DBTX }
+
+func (i idempotency) Claim(ctx context.Context, key, hash string, now, expiredBefore time.Time) (SavedResponse, bool, error) {
+	if _, err := i.q.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key=? AND created_at < ?`,
+		key, expiredBefore.UTC().Format(EventFmt)); err != nil {
+		return SavedResponse{}, false, err
+	}
+	ok, err := affected(i.q.ExecContext(ctx,
+		`INSERT INTO idempotency_keys(key,request_hash,created_at) VALUES(?,?,?) ON CONFLICT(key) DO NOTHING`,
+		key, hash, now.UTC().Format(EventFmt)))
+	if err != nil || ok {
+		return SavedResponse{}, ok, err
+	}
+	var s SavedResponse
+	err = i.q.QueryRowContext(ctx, `SELECT request_hash, status, COALESCE(response, x'') FROM idempotency_keys WHERE key=?`, key).
+		Scan(&s.RequestHash, &s.Status, &s.Body)
+	return s, false, notFound(err)
+}
+
+func (i idempotency) Save(ctx context.Context, key string, status int, body []byte) error {
+	_, err := i.q.ExecContext(ctx, `UPDATE idempotency_keys SET status=?, response=? WHERE key=?`, status, body, key)
+	return err
+}
+
+func (i idempotency) Release(ctx context.Context, key string) error {
+	_, err := i.q.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key=? AND status=0`, key)
+	return err
+}
+
+func (i idempotency) Purge(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	res, err := i.q.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < ?`, expiredBefore.UTC().Format(EventFmt))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// JobRun es una ejecución de un job programado (ver internal/scheduler).
+type JobRun struct {
+	ID         int64  `json:"id"`
+	Job        string `json:"job"`
+	Instance   string `json:"instance"`
+	Status     string `json:"status"` // en_curso | ok | error | abandonado
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"started_at"`            // RFC3339 UTC
+	FinishedAt string `json:"finished_at,omitempty"` // RFC3339 UTC
+}
+
+type Jobs interface {
+	// Lock toma (o renueva) el lease de job para holder hasta until; false = lo tiene otra
+	// instancia y todavía no vence a now.
+	Lock(ctx context.Context, job, holder string, now, until time.Time) (bool, error)
+	// Unlock suelta el lease si todavía es de holder.
+	Unlock(ctx context.Context, job, holder string) error
+	// StartRun registra una ejecución en curso y devuelve su id.
+	StartRun(ctx context.Context, job, instance string, at time.Time) (int64, error)
+	// FinishRun cierra la ejecución id con status ok o error.
+	FinishRun(ctx context.Context, id int64, status, detail, errMsg string, at time.Time) error
+	// AbandonRunning marca abandonado lo que quedó en curso de job (una instancia que se cayó
+	// a mitad de camino); solo debe llamarlo quien tiene el lease.
+	AbandonRunning(ctx context.Context, job string, at time.Time) error
+	// LastRun es la ejecución más reciente de job (ErrNotFound si nunca corrió).
+	LastRun(ctx context.Context, job string) (JobRun, error)
+	// Runs lista las últimas limit ejecuciones de job, de la más nueva a la más antigua.
+	Runs(ctx context.Context, job string, limit int) ([]JobRun, error)
+}
+
+type jobs struct{ q DBTX }
+
+func (j jobs) Lock(ctx context.Context, job, holder string, now, until time.Time) (bool, error) {
+	return affected(j.q.ExecContext(ctx, `
+INSERT INTO job_locks(job,holder,expires_at) VALUES(?,?,?)
+ON CONFLICT(job) DO UPDATE SET holder=excluded.holder, expires_at=excluded.expires_at
+WHERE job_locks.holder=excluded.holder OR job_locks.expires_at < ?`,
+		job, holder, until.UTC().Format(EventFmt), now.UTC().Format(EventFmt)))
+}
+
+func (j jobs) Unlock(ctx context.Context, job, holder string) error {
+	_, err := j.q.ExecContext(ctx, `DELETE FROM job_locks WHERE job=? AND holder=?`, job, holder)
+	return err
+}
+
+func (j jobs) StartRun(ctx context.Context, job, instance string, at time.Time) (int64, error) {
+	res, err := j.q.ExecContext(ctx, `INSERT INTO job_runs(job,instance,started_at) VALUES(?,?,?)`,
+		job, instance, at.UTC().Format(EventFmt))
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (j jobs) FinishRun(ctx context.Context, id int64, status, detail, errMsg string, at time.Time) error {
+	_, err := j.q.ExecContext(ctx, `UPDATE job_runs SET status=?, detail=?, error=?, finished_at=? WHERE id=?`,
+		status, detail, errMsg, at.UTC().Format(EventFmt), id)
+	return err
+}
+
+func (j jobs) AbandonRunning(ctx context.Context, job string, at time.Time) error {
+	_, err := j.q.ExecContext(ctx, `UPDATE job_runs SET status='abandonado', finished_at=? WHERE job=? AND status='en_curso'`,
+		at.UTC().Format(EventFmt), job)
+	return err
+}
+
+const jobRunSelect = `SELECT id, job, instance, status, detail, error, started_at, COALESCE(finished_at,'') FROM job_runs`
+
+func scanJobRun(row interface{ Scan(...any) error }) (JobRun, error) {
+	var r JobRun
+	err := row.Scan(&r.ID, &r.Job, &r.Instance, &r.Status, &r.Detail, &r.Error, &r.StartedAt, &r.FinishedAt)
+	return r, err
+}
+
+func (j jobs) LastRun(ctx context.Context, job string) (JobRun, error) {
+	r, err := scanJobRun(j.q.QueryRowContext(ctx, jobRunSelect+` WHERE job=? ORDER BY id DESC LIMIT 1`, job))
+	return r, notFound(err)
+}
+
+func (j jobs) Runs(ctx context.Context, job string, limit int) ([]JobRun, error) {
+	rows, err := j.q.QueryContext(ctx, jobRunSelect+` WHERE job=? ORDER BY id DESC LIMIT ?`, job, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []JobRun{}
+	for rows.Next() {
+		r, err := scanJobRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,98 @@
+package store
+
+import "context"
+
+type Loan struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	BookID     int64  `json:"book_id"`
+	StartDate  string `json:"start_date"`
+	ReturnDate string `json:"return_date"`
+	Status     string `json:"status"`         // pendiente | vencido | finalizado
+	Fine       int64  `json:"fine,omitempty"` // multa acumulada (job loans.fines); al devolver, la cobrada
+	DueDate    string `json:"due_date,omitempty"`
+	DaysLeft   int64  `json:"days_left,omitempty"`
+	DaysLate   int64  `json:"days_late,omitempty"`
+	Penalty    int64  `json:"penalty,omitempty"`
+}
+
+type Loans interface {
+	Get(ctx context.Context, id int64) (Loan, error)
+	List(ctx context.Context) ([]Loan, error)
+	// ByUser lista los préstamos del usuario por id, devueltos o no.
+	ByUser(ctx context.Context, userID int64) ([]Loan, error)
+	// Create registra un préstamo pendiente desde start (DD/MM/YYYY).
+	Create(ctx context.Context, userID, bookID int64, start string) (int64, error)
+	// Close lo marca finalizado con la multa cobrada solo si seguía abierto (pendiente o
+	// vencido); false = ya estaba devuelto.
+	Close(ctx context.Context, id int64, returnDate string, fine int64) (bool, error)
+	// Open lista los préstamos sin devolver (pendientes y vencidos).
+	Open(ctx context.Context) ([]Loan, error)
+	// MarkOverdue pasa un préstamo de pendiente a vencido; false = no estaba pendiente.
+	MarkOverdue(ctx context.Context, id int64) (bool, error)
+	// SetFine actualiza la multa acumulada de un vencido; false = no cambió.
+	SetFine(ctx context.Context, id, fine int64) (bool, error)
+}
+
+type loans struct{ q DBTX }
+
+const loanSelect = `SELECT id, user_id, book_id, start_date, COALESCE(return_date,''), status, fine FROM loans`
+
+func (l loans) Get(ctx context.Context, id int64) (Loan, error) {
+	var x Loan
+	err := l.q.QueryRowContext(ctx, loanSelect+` WHERE id=?`, id).
+		Scan(&x.ID, &x.UserID, &x.BookID, &x.StartDate, &x.ReturnDate, &x.Status, &x.Fine)
+	return x, notFound(err)
+}
+
+func (l loans) List(ctx context.Context) ([]Loan, error) {
+	return l.query(ctx, loanSelect+` ORDER BY id`)
+}
+
+func (l loans) ByUser(ctx context.Context, userID int64) ([]Loan, error) {
+	return l.query(ctx, loanSelect+` WHERE user_id=? ORDER BY id`, userID)
+}
+
+func (l loans) Open(ctx context.Context) ([]Loan, error) {
+	return l.query(ctx, loanSelect+` WHERE status IN ('pendiente','vencido') ORDER BY id`)
+}
+
+func (l loans) query(ctx context.Context, query string, args ...any) ([]Loan, error) {
+	rows, err := l.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Loan{}
+	for rows.Next() {
+		var x Loan
+		if err := rows.Scan(&x.ID, &x.UserID, &x.BookID, &x.StartDate, &x.ReturnDate, &x.Status, &x.Fine); err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+func (l loans) Create(ctx context.Context, userID, bookID int64, start string) (int64, error) {
+	res, err := l.q.ExecContext(ctx, `INSERT INTO loans(user_id,book_id,start_date,status) VALUES(?,?,?,'pendiente')`,
+		userID, bookID, start)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (l loans) Close(ctx context.Context, id int64, returnDate string, fine int64) (bool, error) {
+	return affected(l.q.ExecContext(ctx,
+		`UPDATE loans SET return_date=?, status='finalizado', fine=? WHERE id=? AND status IN ('pendiente','vencido')`,
+		returnDate, fine, id))
+}
+
+func (l loans) MarkOverdue(ctx context.Context, id int64) (bool, error) {
+	return affected(l.q.ExecContext(ctx, `UPDATE loans SET status='vencido' WHERE id=? AND status='pendiente'`, id))
+}
+
+func (l loans) SetFine(ctx context.Context, id, fine int64) (bool, error) {
+	return affected(l.q.ExecContext(ctx, `UPDATE loans SET fine=? WHERE id=? AND status='vencido' AND fine<>?`, fine, id, fine))
+}
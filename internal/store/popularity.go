@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Popularity guarda el ranking de tendencias ya calculado de cada ventana (book_popularity).
+type Popularity interface {
+	// Replace deja scores como el ranking de span, calculado en at.
+	Replace(ctx context.Context, span string, scores map[int64]float64, at time.Time) error
+	// Scores devuelve el ranking de span y cuándo se calculó ("" = nunca).
+	Scores(ctx context.Context, span string) (map[int64]float64, string, error)
+}
+
+type popularity struct{ q DBTX }
+
+func (p popularity) Replace(ctx context.Context, span string, scores map[int64]float64, at time.Time) error {
+	if _, err := p.q.ExecContext(ctx, `DELETE FROM book_popularity WHERE span=?`, span); err != nil {
+		return err
+	}
+	computed := at.UTC().Format(EventFmt)
+	for bookID, score := range scores {
+		if _, err := p.q.ExecContext(ctx, `INSERT INTO book_popularity(book_id,span,score,computed_at) VALUES(?,?,?,?)`,
+			bookID, span, score, computed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p popularity) Scores(ctx context.Context, span string) (map[int64]float64, string, error) {
+	var computed sql.NullString
+	if err := p.q.QueryRowContext(ctx, `SELECT MAX(computed_at) FROM book_popularity WHERE span=?`, span).Scan(&computed); err != nil {
+		return nil, "", err
+	}
+	if !computed.Valid {
+		return nil, "", nil
+	}
+	rows, err := p.q.QueryContext(ctx, `SELECT book_id, score FROM book_popularity WHERE span=?`, span)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+	scores := map[int64]float64{}
+	for rows.Next() {
+		var id int64
+		var s float64
+		if err := rows.Scan(&id, &s); err != nil {
+			return nil, "", err
+		}
+		scores[id] = s
+	}
+	return scores, computed.String, rows.Err()
+}
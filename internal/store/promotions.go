@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type Promotion struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name" binding:"required,max=100"`
+	Code           string `json:"code,omitempty" binding:"max=40"`                       // vacío = se aplica sola
+	Kind           string `json:"kind" binding:"required,oneof=porcentaje monto bundle"` // porcentaje | monto | bundle
+	Value          int64  `json:"value"`                                                 // % (porcentaje) o usm pesos (monto)
+	Category       string `json:"category,omitempty"`
+	BuyQty         int64  `json:"buy_qty,omitempty" binding:"gte=0"`  // bundle: lleva buy_qty...
+	PayQty         int64  `json:"pay_qty,omitempty" binding:"gte=0"`  // ...y paga pay_qty
+	StartsAt       string `json:"starts_at" binding:"required,fecha"` // DD/MM/YYYY
+	EndsAt         string `json:"ends_at" binding:"required,fecha"`   // DD/MM/YYYY
+	MaxUses        int64  `json:"max_uses" binding:"gte=0"`           // 0 = sin límite
+	MaxUsesPerUser int64  `json:"max_uses_per_user" binding:"gte=0"`  // 0 = sin límite
+	Uses           int64  `json:"uses"`
+	Active         bool   `json:"active"`
+}
+
+type Promotions interface {
+	Get(ctx context.Context, id int64) (Promotion, error)
+	List(ctx context.Context) ([]Promotion, error)
+	Active(ctx context.Context) ([]Promotion, error)
+	// Create inserta p (Code vacío se guarda como NULL) y le asigna ID.
+	Create(ctx context.Context, p *Promotion) error
+	// Update cambia solo los campos no nil; false = no existe.
+	Update(ctx context.Context, id int64, active *bool, endsAt *string, maxUses *int64) (bool, error)
+	// UserRedemptions cuenta los canjes del usuario por promoción.
+	UserRedemptions(ctx context.Context, userID int64) (map[int64]int64, error)
+	// Redeem suma un uso si quedan y registra el canje; false = se agotó.
+	Redeem(ctx context.Context, promotionID, userID, discount int64, at time.Time) (bool, error)
+}
+
+type promotions struct{ q DBTX }
+
+const promotionSelect = `
+SELECT id, name, COALESCE(code,''), kind, value, category, buy_qty, pay_qty, starts_at, ends_at,
+       max_uses, max_uses_per_user, uses, active
+FROM promotions`
+
+func scanPromotion(row interface{ Scan(...any) error }) (Promotion, error) {
+	var p Promotion
+	err := row.Scan(&p.ID, &p.Name, &p.Code, &p.Kind, &p.Value, &p.Category, &p.BuyQty, &p.PayQty,
+		&p.StartsAt, &p.EndsAt, &p.MaxUses, &p.MaxUsesPerUser, &p.Uses, &p.Active)
+	return p, err
+}
+
+func (r promotions) Get(ctx context.Context, id int64) (Promotion, error) {
+	p, err := scanPromotion(r.q.QueryRowContext(ctx, promotionSelect+` WHERE id=?`, id))
+	return p, notFound(err)
+}
+
+func (r promotions) List(ctx context.Context) ([]Promotion, error) {
+	return r.query(ctx, promotionSelect+` ORDER BY id`)
+}
+
+func (r promotions) Active(ctx context.Context) ([]Promotion, error) {
+	return r.query(ctx, promotionSelect+` WHERE active = 1 ORDER BY id`)
+}
+
+func (r promotions) query(ctx context.Context, q string) ([]Promotion, error) {
+	rows, err := r.q.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Promotion{}
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r promotions) Create(ctx context.Context, p *Promotion) error {
+	var code any
+	if p.Code != "" {
+		code = p.Code
+	}
+	res, err := r.q.ExecContext(ctx, `
+INSERT INTO promotions(name, code, kind, value, category, buy_qty, pay_qty, starts_at, ends_at, max_uses, max_uses_per_user, active)
+VALUES(?,?,?,?,?,?,?,?,?,?,?,?)`,
+		p.Name, code, p.Kind, p.Value, p.Category, p.BuyQty, p.PayQty, p.StartsAt, p.EndsAt, p.MaxUses, p.MaxUsesPerUser, p.Active)
+	if err != nil {
+		return err
+	}
+	p.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r promotions) Update(ctx context.Context, id int64, active *bool, endsAt *string, maxUses *int64) (bool, error) {
+	return affected(r.q.ExecContext(ctx, `
+UPDATE promotions SET
+  active   = COALESCE(?, active),
+  ends_at  = COALESCE(?, ends_at),
+  max_uses = COALESCE(?, max_uses)
+WHERE id = ?`, active, endsAt, maxUses, id))
+}
+
+func (r promotions) UserRedemptions(ctx context.Context, userID int64) (map[int64]int64, error) {
+	rows, err := r.q.QueryContext(ctx, `SELECT promotion_id, COUNT(*) FROM promotion_redemptions WHERE user_id=? GROUP BY promotion_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]int64{}
+	for rows.Next() {
+		var id, n int64
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		out[id] = n
+	}
+	return out, rows.Err()
+}
+
+func (r promotions) Redeem(ctx context.Context, promotionID, userID, discount int64, at time.Time) (bool, error) {
+	ok, err := affected(r.q.ExecContext(ctx,
+		`UPDATE promotions SET uses = uses + 1 WHERE id=? AND (max_uses = 0 OR uses < max_uses)`, promotionID))
+	if err != nil || !ok {
+		return false, err
+	}
+	_, err = r.q.ExecContext(ctx, `INSERT INTO promotion_redemptions(promotion_id, user_id, discount, created_at) VALUES(?,?,?,?)`,
+		promotionID, userID, discount, at.UTC().Format(EventFmt))
+	return err == nil, err
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Reminders registra los recordatorios de préstamos enviados y los canales que cada
+// usuario apagó.
+type Reminders interface {
+	// Sent indica si el recordatorio kind del préstamo ya salió por channel.
+	Sent(ctx context.Context, loanID int64, kind, channel string) (bool, error)
+	// MarkSent lo deja enviado; false = ya lo estaba.
+	MarkSent(ctx context.Context, loanID int64, kind, channel string, at time.Time) (bool, error)
+	// OptedOut devuelve los canales que el usuario apagó.
+	OptedOut(ctx context.Context, userID int64) (map[string]bool, error)
+	// SetOptOut apaga (off = true) o vuelve a encender un canal para el usuario.
+	SetOptOut(ctx context.Context, userID int64, channel string, off bool) error
+}
+
+type reminders struct{ q DBTX }
+
+func (r reminders) Sent(ctx context.Context, loanID int64, kind, channel string) (bool, error) {
+	var n int
+	err := r.q.QueryRowContext(ctx, `SELECT COUNT(*) FROM loan_reminders WHERE loan_id=? AND kind=? AND channel=?`,
+		loanID, kind, channel).Scan(&n)
+	return n > 0, err
+}
+
+func (r reminders) MarkSent(ctx context.Context, loanID int64, kind, channel string, at time.Time) (bool, error) {
+	return affected(r.q.ExecContext(ctx,
+		`INSERT INTO loan_reminders(loan_id,kind,channel,sent_at) VALUES(?,?,?,?) ON CONFLICT DO NOTHING`,
+		loanID, kind, channel, at.UTC().Format(EventFmt)))
+}
+
+func (r reminders) OptedOut(ctx context.Context, userID int64) (map[string]bool, error) {
+	rows, err := r.q.QueryContext(ctx, `SELECT channel FROM reminder_optouts WHERE user_id=?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]bool{}
+	for rows.Next() {
+		var ch string
+		if err := rows.Scan(&ch); err != nil {
+			return nil, err
+		}
+		out[ch] = true
+	}
+	return out, rows.Err()
+}
+
+func (r reminders) SetOptOut(ctx context.Context, userID int64, channel string, off bool) error {
+	q := `DELETE FROM reminder_optouts WHERE user_id=? AND channel=?`
+	if off {
+		q = `INSERT INTO reminder_optouts(user_id,channel) VALUES(?,?) ON CONFLICT DO NOTHING`
+	}
+	_, err := r.q.ExecContext(ctx, q, userID, channel)
+	return err
+}
@@ -0,0 +1,99 @@
+package store
+
+import "context"
+
+type Review struct {
+	ID        int64  `json:"id"`
+	BookID    int64  `json:"book_id"`
+	UserID    int64  `json:"user_id"`
+	UserName  string `json:"user_name"`
+	Rating    int64  `json:"rating"` // 1..5
+	Comment   string `json:"comment"`
+	Status    string `json:"status"` // visible | oculta
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ReviewFilter acota List; el valor cero lista todas.
+type ReviewFilter struct {
+	BookID int64  // 0 = todos los libros
+	Status string // "" = visibles y ocultas
+}
+
+type Reviews interface {
+	// Get devuelve la reseña con el nombre del autor (ErrNotFound si no existe).
+	Get(ctx context.Context, id int64) (Review, error)
+	// List lista por id las reseñas que cumplen f.
+	List(ctx context.Context, f ReviewFilter) ([]Review, error)
+	// Create inserta la reseña (visible) y le asigna ID; falla por UNIQUE si el usuario ya
+	// reseñó el libro.
+	Create(ctx context.Context, r *Review) error
+	// Update guarda rating, comentario y updated_at.
+	Update(ctx context.Context, r Review) error
+	SetStatus(ctx context.Context, id int64, status string) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type reviews struct{ q DBTX }
+
+// reviewSelect trae la reseña con el nombre del autor ("Nombre A.").
+const reviewSelect = `
+SELECT r.id, r.book_id, r.user_id, u.first_name || ' ' || substr(u.last_name, 1, 1) || '.',
+       r.rating, r.comment, r.status, r.created_at, r.updated_at
+FROM reviews r
+JOIN users u ON u.id = r.user_id`
+
+func (r *Review) fields() []any {
+	return []any{&r.ID, &r.BookID, &r.UserID, &r.UserName, &r.Rating, &r.Comment, &r.Status, &r.CreatedAt, &r.UpdatedAt}
+}
+
+func (v reviews) Get(ctx context.Context, id int64) (Review, error) {
+	var r Review
+	err := v.q.QueryRowContext(ctx, reviewSelect+` WHERE r.id=?`, id).Scan(r.fields()...)
+	return r, notFound(err)
+}
+
+func (v reviews) List(ctx context.Context, f ReviewFilter) ([]Review, error) {
+	rows, err := v.q.QueryContext(ctx, reviewSelect+`
+WHERE (? = 0 OR r.book_id = ?) AND (? = '' OR r.status = ?)
+ORDER BY r.id`, f.BookID, f.BookID, f.Status, f.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Review{}
+	for rows.Next() {
+		var r Review
+		if err := rows.Scan(r.fields()...); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (v reviews) Create(ctx context.Context, r *Review) error {
+	res, err := v.q.ExecContext(ctx, `INSERT INTO reviews(book_id,user_id,rating,comment,created_at,updated_at) VALUES(?,?,?,?,?,?)`,
+		r.BookID, r.UserID, r.Rating, r.Comment, r.CreatedAt, r.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	r.ID, err = res.LastInsertId()
+	return err
+}
+
+func (v reviews) Update(ctx context.Context, r Review) error {
+	_, err := v.q.ExecContext(ctx, `UPDATE reviews SET rating=?, comment=?, updated_at=? WHERE id=?`,
+		r.Rating, r.Comment, r.UpdatedAt, r.ID)
+	return err
+}
+
+func (v reviews) SetStatus(ctx context.Context, id int64, status string) error {
+	_, err := v.q.ExecContext(ctx, `UPDATE reviews SET status=? WHERE id=?`, status, id)
+	return err
+}
+
+func (v reviews) Delete(ctx context.Context, id int64) error {
+	_, err := v.q.ExecContext(ctx, `DELETE FROM reviews WHERE id=?`, id)
+	return err
+}
@@ -0,0 +1,74 @@
+package store
+
+import "context"
+
+type Sale struct {
+	ID       int64  `json:"id"`
+	UserID   int64  `json:"user_id"`
+	BookID   int64  `json:"book_id"`
+	SaleDate string `json:"sale_date"` // DD/MM/YYYY
+	Price    int64  `json:"price"`     // precio de lista al momento de la venta
+	Discount int64  `json:"discount"`  // descuento aplicado por promociones
+}
+
+type Sales interface {
+	// Create inserta la venta y le asigna ID.
+	Create(ctx context.Context, s *Sale) error
+	// AddDiscount guarda cuánto aportó una promoción al descuento de la venta.
+	AddDiscount(ctx context.Context, saleID, promotionID, amount int64) error
+	List(ctx context.Context) ([]Sale, error)
+	// ByUser lista las compras del usuario por id.
+	ByUser(ctx context.Context, userID int64) ([]Sale, error)
+	Count(ctx context.Context) (int64, error)
+}
+
+type sales struct{ q DBTX }
+
+func (s sales) Create(ctx context.Context, x *Sale) error {
+	res, err := s.q.ExecContext(ctx, `INSERT INTO sales(user_id, book_id, sale_date, price, discount) VALUES(?,?,?,?,?)`,
+		x.UserID, x.BookID, x.SaleDate, x.Price, x.Discount)
+	if err != nil {
+		return err
+	}
+	x.ID, err = res.LastInsertId()
+	return err
+}
+
+func (s sales) AddDiscount(ctx context.Context, saleID, promotionID, amount int64) error {
+	_, err := s.q.ExecContext(ctx, `INSERT INTO sale_discounts(sale_id, promotion_id, amount) VALUES(?,?,?)`,
+		saleID, promotionID, amount)
+	return err
+}
+
+const saleSelect = `SELECT id, user_id, book_id, sale_date, COALESCE(price,0), discount FROM sales`
+
+func (s sales) List(ctx context.Context) ([]Sale, error) {
+	return s.query(ctx, saleSelect+` ORDER BY id`)
+}
+
+func (s sales) ByUser(ctx context.Context, userID int64) ([]Sale, error) {
+	return s.query(ctx, saleSelect+` WHERE user_id=? ORDER BY id`, userID)
+}
+
+func (s sales) query(ctx context.Context, query string, args ...any) ([]Sale, error) {
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Sale{}
+	for rows.Next() {
+		var x Sale
+		if err := rows.Scan(&x.ID, &x.UserID, &x.BookID, &x.SaleDate, &x.Price, &x.Discount); err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
+
+func (s sales) Count(ctx context.Context) (int64, error) {
+	var n int64
+	err := s.q.QueryRowContext(ctx, `SELECT COUNT(*) FROM sales`).Scan(&n)
+	return n, err
+}
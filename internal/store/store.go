@@ -0,0 +1,107 @@
+// Package store agrupa el acceso a SQLite detrás de interfaces por entidad, para que
+// la lógica de negocio (internal/service) no dependa de SQL ni de HTTP.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	DateFmt  = "02/01/2006" // fechas de negocio: DD/MM/YYYY
+	EventFmt = time.RFC3339 // timestamps nuevos, en UTC
+)
+
+// ErrNotFound lo devuelven los Get cuando no hay fila.
+var ErrNotFound = errors.New("store: no encontrado")
+
+// DBTX es lo común entre *sql.DB y *sql.Tx.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Tx da los repositorios ligados a una misma transacción (o a la base, en lecturas).
+type Tx interface {
+	Users() Users
+	Books() Books
+	Loans() Loans
+	Sales() Sales
+	Promotions() Promotions
+	Events() Events
+	Notifications() Notifications
+	Idempotency() Idempotency
+	Audit() Audit
+	Jobs() Jobs
+	Reminders() Reminders
+	Outbox() Outbox
+	Webhooks() Webhooks
+	Reviews() Reviews
+	Wishlist() Wishlist
+	History() History
+	Popularity() Popularity
+}
+
+type repos struct{ q DBTX }
+
+func (r repos) Users() Users                 { return users{r.q} }
+func (r repos) Books() Books                 { return books{r.q} }
+func (r repos) Loans() Loans                 { return loans{r.q} }
+func (r repos) Sales() Sales                 { return sales{r.q} }
+func (r repos) Promotions() Promotions       { return promotions{r.q} }
+func (r repos) Events() Events               { return events{r.q} }
+func (r repos) Notifications() Notifications { return notifications{r.q} }
+func (r repos) Idempotency() Idempotency     { return idempotency{r.q} }
+func (r repos) Audit() Audit                 { return audit{r.q} }
+func (r repos) Jobs() Jobs                   { return jobs{r.q} }
+func (r repos) Reminders() Reminders         { return reminders{r.q} }
+func (r repos) Outbox() Outbox               { return outbox{r.q} }
+func (r repos) Webhooks() Webhooks           { return webhooks{r.q} }
+func (r repos) Reviews() Reviews             { return reviews{r.q} }
+func (r repos) Wishlist() Wishlist           { return wishlist{r.q} }
+func (r repos) History() History             { return history{r.q} }
+func (r repos) Popularity() Popularity       { return popularity{r.q} }
+
+// Bind arma los repositorios sobre q; sirve tanto con *sql.DB como con *sql.Tx.
+func Bind(q DBTX) Tx { return repos{q} }
+
+type Store struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) *Store { return &Store{db: db} }
+
+// Read entrega repositorios sin transacción, para consultas.
+func (s *Store) Read() Tx { return repos{s.db} }
+
+// InTx corre fn dentro de una transacción: commit si fn devuelve nil, rollback si no.
+func (s *Store) InTx(ctx context.Context, fn func(Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(repos{tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// affected indica si el UPDATE condicional tocó alguna fila.
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,133 @@
+package store
+
+import "context"
+
+// UserChanges son los cambios de PATCH /users/:id; nil = no tocar.
+type UserChanges struct {
+	FirstName, LastName, Password *string
+	Deposit                       int64 // se suma al saldo
+}
+
+// Profile son los datos de un usuario que se pueden mostrar (sin contraseña).
+type Profile struct {
+	ID                         int64
+	FirstName, LastName, Email string
+	USMPesos                   int64
+}
+
+// Account es el usuario tal como lo devuelven /users y /login, con contraseña.
+type Account struct {
+	Profile
+	Password string
+}
+
+type Users interface {
+	// List devuelve todas las cuentas por id.
+	List(ctx context.Context) ([]Account, error)
+	// Account devuelve la cuenta completa (ErrNotFound si no existe).
+	Account(ctx context.Context, id int64) (Account, error)
+	// Login busca la cuenta por email y contraseña (ErrNotFound si no calzan).
+	Login(ctx context.Context, email, password string) (Account, error)
+	// Create inserta el usuario con su contraseña y saldo p.USMPesos y le asigna ID.
+	Create(ctx context.Context, p *Profile, password string) error
+	// Get devuelve el perfil (ErrNotFound si no existe).
+	Get(ctx context.Context, id int64) (Profile, error)
+	// Balance devuelve el saldo en usm pesos (ErrNotFound si no existe).
+	Balance(ctx context.Context, id int64) (int64, error)
+	// AddBalance suma delta (negativo para cobrar; el saldo puede quedar negativo).
+	AddBalance(ctx context.Context, id, delta int64) error
+	// Charge descuenta amount solo si alcanza el saldo; false = saldo insuficiente.
+	Charge(ctx context.Context, id, amount int64) (bool, error)
+	// Update aplica ch en un solo UPDATE; false = el usuario no existe.
+	Update(ctx context.Context, id int64, ch UserChanges) (bool, error)
+	// TotalBalance suma los saldos de todos los usuarios (usm pesos en circulación).
+	TotalBalance(ctx context.Context) (int64, error)
+}
+
+type users struct{ q DBTX }
+
+func (u users) Create(ctx context.Context, p *Profile, password string) error {
+	res, err := u.q.ExecContext(ctx, `INSERT INTO users(first_name,last_name,email,password,usm_pesos) VALUES(?,?,?,?,?)`,
+		p.FirstName, p.LastName, p.Email, password, p.USMPesos)
+	if err != nil {
+		return err
+	}
+	p.ID, err = res.LastInsertId()
+	return err
+}
+
+// accountCols son las columnas que lee Account.fields.
+const accountCols = `id,first_name,last_name,email,password,usm_pesos`
+
+func (a *Account) fields() []any {
+	return []any{&a.ID, &a.FirstName, &a.LastName, &a.Email, &a.Password, &a.USMPesos}
+}
+
+func (u users) List(ctx context.Context) ([]Account, error) {
+	rows, err := u.q.QueryContext(ctx, `SELECT `+accountCols+` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Account
+	for rows.Next() {
+		var a Account
+		if err := rows.Scan(a.fields()...); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (u users) Account(ctx context.Context, id int64) (Account, error) {
+	var a Account
+	err := u.q.QueryRowContext(ctx, `SELECT `+accountCols+` FROM users WHERE id=?`, id).Scan(a.fields()...)
+	return a, notFound(err)
+}
+
+func (u users) Login(ctx context.Context, email, password string) (Account, error) {
+	var a Account
+	err := u.q.QueryRowContext(ctx, `SELECT `+accountCols+` FROM users WHERE email=? AND password=?`, email, password).
+		Scan(a.fields()...)
+	return a, notFound(err)
+}
+
+func (u users) Get(ctx context.Context, id int64) (Profile, error) {
+	var p Profile
+	err := u.q.QueryRowContext(ctx, `SELECT id,first_name,last_name,email,usm_pesos FROM users WHERE id=?`, id).
+		Scan(&p.ID, &p.FirstName, &p.LastName, &p.Email, &p.USMPesos)
+	return p, notFound(err)
+}
+
+func (u users) Balance(ctx context.Context, id int64) (int64, error) {
+	var saldo int64
+	err := u.q.QueryRowContext(ctx, `SELECT usm_pesos FROM users WHERE id=?`, id).Scan(&saldo)
+	return saldo, notFound(err)
+}
+
+func (u users) AddBalance(ctx context.Context, id, delta int64) error {
+	_, err := u.q.ExecContext(ctx, `UPDATE users SET usm_pesos = usm_pesos + ? WHERE id=?`, delta, id)
+	return err
+}
+
+func (u users) Charge(ctx context.Context, id, amount int64) (bool, error) {
+	return affected(u.q.ExecContext(ctx,
+		`UPDATE users SET usm_pesos = usm_pesos - ? WHERE id=? AND usm_pesos >= ?`, amount, id, amount))
+}
+
+func (u users) Update(ctx context.Context, id int64, ch UserChanges) (bool, error) {
+	return affected(u.q.ExecContext(ctx, `
+UPDATE users SET
+  first_name = COALESCE(?, first_name),
+  last_name  = COALESCE(?, last_name),
+  password   = COALESCE(?, password),
+  usm_pesos  = usm_pesos + ?
+WHERE id=?`, ch.FirstName, ch.LastName, ch.Password, ch.Deposit, id))
+}
+
+func (u users) TotalBalance(ctx context.Context) (int64, error) {
+	var total int64
+	err := u.q.QueryRowContext(ctx, `SELECT COALESCE(SUM(usm_pesos),0) FROM users`).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,380 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Eventos de dominio que se pueden suscribir con un webhook.
+const (
+	EventSaleCreated  = "sale.created"
+	EventLoanCreated  = "loan.created"
+	EventLoanReturned = "loan.returned"
+	EventOutOfStock   = "book.out_of_stock"
+	EventUserCreated  = "user.created"
+)
+
+// WebhookEvents son todos los eventos, en el orden en que se documentan.
+var WebhookEvents = []string{EventSaleCreated, EventLoanCreated, EventLoanReturned, EventOutOfStock, EventUserCreated}
+
+// Webhook es un endpoint externo suscrito a eventos.
+type Webhook struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"` // clave del HMAC; solo se muestra al crearlo
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+	CreatedAt string   `json:"created_at"` // RFC3339 UTC
+}
+
+// Delivery es el envío de un evento del outbox a un webhook.
+type Delivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int64           `json:"webhook_id"`
+	OutboxID      int64           `json:"outbox_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	EventAt       string          `json:"event_at"` // RFC3339 UTC, cuándo ocurrió el evento
+	Status        string          `json:"status"`   // pendiente | entregado | muerto
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt string          `json:"next_attempt_at,omitempty"` // vacío = cuanto antes
+	LastStatus    int             `json:"last_status,omitempty"`     // código HTTP del último intento (0 = sin respuesta)
+	LastError     string          `json:"last_error,omitempty"`
+	DeliveredAt   string          `json:"delivered_at,omitempty"`
+	CreatedAt     string          `json:"created_at"`
+
+	// URL y Secret del webhook, para el despachador (no salen en la API).
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// DeliveryFilter son los filtros de Deliveries; los campos vacíos no filtran.
+type DeliveryFilter struct {
+	WebhookID int64
+	Status    string
+	Limit     int
+}
+
+// OutboxEvent es un evento del outbox, tal como lo recibe GET /events.
+type OutboxEvent struct {
+	ID        int64           `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt string          `json:"created_at"` // RFC3339 UTC
+	Data      json.RawMessage `json:"data"`
+}
+
+// OutboxFilter son los filtros de After; BookID y UserID comparan con los campos book_id
+// y user_id del payload (0 = no filtra).
+type OutboxFilter struct {
+	AfterID int64
+	Events  []string
+	BookID  int64
+	UserID  int64
+	Limit   int
+}
+
+// Outbox guarda los eventos de dominio en la misma transacción que el cambio que los produce;
+// el despachador (internal/webhooks) los entrega después, así un evento nunca sale de un
+// cambio que terminó en rollback ni se pierde si el proceso se cae.
+type Outbox interface {
+	// Publish registra el evento con data (se serializa a JSON) y deja una entrega pendiente
+	// para cada webhook activo suscrito a él.
+	Publish(ctx context.Context, event string, data any, at time.Time) error
+	// After lista, del más antiguo al más nuevo, los eventos posteriores a f.AfterID.
+	After(ctx context.Context, f OutboxFilter) ([]OutboxEvent, error)
+	// LastID es el id del último evento (0 si no hay).
+	LastID(ctx context.Context) (int64, error)
+	// Purge borra las entregas ya entregadas y los eventos creados antes de before; los
+	// eventos con entregas pendientes o muertas se quedan (aún se envían o reenvían).
+	// Devuelve cuántos eventos y entregas borró.
+	Purge(ctx context.Context, before time.Time) (events, deliveries int64, err error)
+}
+
+type Webhooks interface {
+	Create(ctx context.Context, w *Webhook) error
+	// List devuelve los webhooks sin su secreto.
+	List(ctx context.Context) ([]Webhook, error)
+	// Get devuelve el webhook sin su secreto (ErrNotFound si no existe).
+	Get(ctx context.Context, id int64) (Webhook, error)
+	// Update guarda URL, eventos y activo; false = no existe.
+	Update(ctx context.Context, w Webhook) (bool, error)
+	// Delete lo borra junto con sus entregas; false = no existe.
+	Delete(ctx context.Context, id int64) (bool, error)
+
+	// Claim reserva hasta limit entregas pendientes que ya tocan a now, corriendo su próximo
+	// intento a until: si el proceso se cae a mitad de camino, se reintentan pasado until.
+	Claim(ctx context.Context, now, until time.Time, limit int) ([]Delivery, error)
+	// Delivered marca la entrega como entregada.
+	Delivered(ctx context.Context, id int64, httpStatus int, at time.Time) error
+	// Failed anota un intento fallido: queda pendiente para next o, si dead, muerta.
+	Failed(ctx context.Context, id int64, httpStatus int, errMsg string, next time.Time, dead bool) error
+	// Delivery devuelve una entrega (ErrNotFound si no existe).
+	Delivery(ctx context.Context, id int64) (Delivery, error)
+	// Deliveries lista entregas de la más nueva a la más antigua.
+	Deliveries(ctx context.Context, f DeliveryFilter) ([]Delivery, error)
+	// Replay vuelve a dejar pendiente una entrega (muerta o ya entregada) con los intentos en
+	// cero; false = no existe.
+	Replay(ctx context.Context, id int64) (bool, error)
+	// ReplayDead hace Replay de todas las entregas muertas del webhook y devuelve cuántas.
+	ReplayDead(ctx context.Context, webhookID int64) (int64, error)
+}
+
+type outbox struct{ q DBTX }
+
+func (o outbox) Publish(ctx context.Context, event string, data any, at time.Time) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	created := at.UTC().Format(EventFmt)
+	res, err := o.q.ExecContext(ctx, `INSERT INTO outbox(event,payload,created_at) VALUES(?,?,?)`, event, string(payload), created)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	_, err = o.q.ExecContext(ctx, `
+INSERT INTO webhook_deliveries(webhook_id,outbox_id,created_at)
+SELECT w.id, ?, ? FROM webhooks w
+WHERE w.active=1 AND EXISTS (SELECT 1 FROM json_each(w.events) WHERE value=?)`, id, created, event)
+	return err
+}
+
+func (o outbox) After(ctx context.Context, f OutboxFilter) ([]OutboxEvent, error) {
+	where, args := []string{"id > ?"}, []any{f.AfterID}
+	if len(f.Events) > 0 {
+		where = append(where, "event IN (?"+strings.Repeat(",?", len(f.Events)-1)+")")
+		for _, e := range f.Events {
+			args = append(args, e)
+		}
+	}
+	if f.BookID != 0 {
+		where, args = append(where, "json_extract(payload,'$.book_id') = ?"), append(args, f.BookID)
+	}
+	if f.UserID != 0 {
+		where, args = append(where, "json_extract(payload,'$.user_id') = ?"), append(args, f.UserID)
+	}
+	rows, err := o.q.QueryContext(ctx, `SELECT id, event, created_at, payload FROM outbox WHERE `+
+		strings.Join(where, " AND ")+` ORDER BY id LIMIT ?`, append(args, f.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []OutboxEvent{}
+	for rows.Next() {
+		var (
+			e       OutboxEvent
+			payload string
+		)
+		if err := rows.Scan(&e.ID, &e.Event, &e.CreatedAt, &payload); err != nil {
+			return nil, err
+		}
+		e.Data = json.RawMessage(payload)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (o outbox) Purge(ctx context.Context, before time.Time) (int64, int64, error) {
+	cutoff := before.UTC().Format(EventFmt)
+	res, err := o.q.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE status='entregado' AND created_at < ?`, cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+	deliveries, err := res.RowsAffected()
+	if err != nil {
+		return 0, 0, err
+	}
+	res, err = o.q.ExecContext(ctx, `
+DELETE FROM outbox WHERE created_at < ?
+AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.outbox_id = outbox.id)`, cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+	events, err := res.RowsAffected()
+	return events, deliveries, err
+}
+
+func (o outbox) LastID(ctx context.Context) (int64, error) {
+	var id int64
+	err := o.q.QueryRowContext(ctx, `SELECT COALESCE(MAX(id),0) FROM outbox`).Scan(&id)
+	return id, err
+}
+
+type webhooks struct{ q DBTX }
+
+func (w webhooks) Create(ctx context.Context, h *Webhook) error {
+	events, _ := json.Marshal(h.Events)
+	res, err := w.q.ExecContext(ctx, `INSERT INTO webhooks(url,secret,events,active,created_at) VALUES(?,?,?,?,?)`,
+		h.URL, h.Secret, string(events), h.Active, h.CreatedAt)
+	if err != nil {
+		return err
+	}
+	h.ID, err = res.LastInsertId()
+	return err
+}
+
+const webhookSelect = `SELECT id, url, events, active, created_at FROM webhooks`
+
+func scanWebhook(row interface{ Scan(...any) error }) (Webhook, error) {
+	var (
+		h      Webhook
+		events string
+	)
+	if err := row.Scan(&h.ID, &h.URL, &events, &h.Active, &h.CreatedAt); err != nil {
+		return h, err
+	}
+	return h, json.Unmarshal([]byte(events), &h.Events)
+}
+
+func (w webhooks) List(ctx context.Context) ([]Webhook, error) {
+	rows, err := w.q.QueryContext(ctx, webhookSelect+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Webhook{}
+	for rows.Next() {
+		h, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+func (w webhooks) Get(ctx context.Context, id int64) (Webhook, error) {
+	h, err := scanWebhook(w.q.QueryRowContext(ctx, webhookSelect+` WHERE id=?`, id))
+	return h, notFound(err)
+}
+
+func (w webhooks) Update(ctx context.Context, h Webhook) (bool, error) {
+	events, _ := json.Marshal(h.Events)
+	return affected(w.q.ExecContext(ctx, `UPDATE webhooks SET url=?, events=?, active=? WHERE id=?`,
+		h.URL, string(events), h.Active, h.ID))
+}
+
+func (w webhooks) Delete(ctx context.Context, id int64) (bool, error) {
+	return affected(w.q.ExecContext(ctx, `DELETE FROM webhooks WHERE id=?`, id))
+}
+
+const deliverySelect = `
+SELECT d.id, d.webhook_id, d.outbox_id, o.event, o.payload, o.created_at, d.status, d.attempts,
+       COALESCE(d.next_attempt_at,''), d.last_status, d.last_error, COALESCE(d.delivered_at,''), d.created_at,
+       w.url, w.secret
+FROM webhook_deliveries d
+JOIN outbox o ON o.id = d.outbox_id
+JOIN webhooks w ON w.id = d.webhook_id`
+
+func (w webhooks) deliveries(ctx context.Context, query string, args ...any) ([]Delivery, error) {
+	rows, err := w.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Delivery{}
+	for rows.Next() {
+		var (
+			d       Delivery
+			payload string
+		)
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.OutboxID, &d.Event, &payload, &d.EventAt, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastStatus, &d.LastError, &d.DeliveredAt, &d.CreatedAt, &d.URL, &d.Secret); err != nil {
+			return nil, err
+		}
+		d.Payload = json.RawMessage(payload)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (w webhooks) Claim(ctx context.Context, now, until time.Time, limit int) ([]Delivery, error) {
+	due := now.UTC().Format(EventFmt)
+	list, err := w.deliveries(ctx, deliverySelect+`
+WHERE d.status='pendiente' AND w.active=1 AND (d.next_attempt_at IS NULL OR d.next_attempt_at <= ?)
+ORDER BY d.id LIMIT ?`, due, limit)
+	if err != nil {
+		return nil, err
+	}
+	// otra instancia pudo tomar la misma entrega entre la lectura y el UPDATE: gana una sola
+	claimed := list[:0]
+	for _, d := range list {
+		ok, err := affected(w.q.ExecContext(ctx, `
+UPDATE webhook_deliveries SET next_attempt_at=?
+WHERE id=? AND status='pendiente' AND (next_attempt_at IS NULL OR next_attempt_at <= ?)`,
+			until.UTC().Format(EventFmt), d.ID, due))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			claimed = append(claimed, d)
+		}
+	}
+	return claimed, nil
+}
+
+func (w webhooks) Delivered(ctx context.Context, id int64, httpStatus int, at time.Time) error {
+	_, err := w.q.ExecContext(ctx, `
+UPDATE webhook_deliveries SET status='entregado', attempts=attempts+1, last_status=?, last_error='',
+  next_attempt_at=NULL, delivered_at=? WHERE id=?`, httpStatus, at.UTC().Format(EventFmt), id)
+	return err
+}
+
+func (w webhooks) Failed(ctx context.Context, id int64, httpStatus int, errMsg string, next time.Time, dead bool) error {
+	status, nextAt := "pendiente", any(next.UTC().Format(EventFmt))
+	if dead {
+		status, nextAt = "muerto", nil
+	}
+	_, err := w.q.ExecContext(ctx, `
+UPDATE webhook_deliveries SET status=?, attempts=attempts+1, last_status=?, last_error=?, next_attempt_at=?
+WHERE id=?`, status, httpStatus, errMsg, nextAt, id)
+	return err
+}
+
+func (w webhooks) Deliveries(ctx context.Context, f DeliveryFilter) ([]Delivery, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if f.WebhookID != 0 {
+		where, args = append(where, "d.webhook_id = ?"), append(args, f.WebhookID)
+	}
+	if f.Status != "" {
+		where, args = append(where, "d.status = ?"), append(args, f.Status)
+	}
+	query := deliverySelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	return w.deliveries(ctx, query+" ORDER BY d.id DESC LIMIT ?", append(args, f.Limit)...)
+}
+
+func (w webhooks) Delivery(ctx context.Context, id int64) (Delivery, error) {
+	list, err := w.deliveries(ctx, deliverySelect+` WHERE d.id=?`, id)
+	if err != nil {
+		return Delivery{}, err
+	}
+	if len(list) == 0 {
+		return Delivery{}, ErrNotFound
+	}
+	return list[0], nil
+}
+
+const replay = `UPDATE webhook_deliveries SET status='pendiente', attempts=0, next_attempt_at=NULL, last_error='', delivered_at=NULL`
+
+func (w webhooks) Replay(ctx context.Context, id int64) (bool, error) {
+	return affected(w.q.ExecContext(ctx, replay+` WHERE id=?`, id))
+}
+
+func (w webhooks) ReplayDead(ctx context.Context, webhookID int64) (int64, error) {
+	res, err := w.q.ExecContext(ctx, replay+` WHERE webhook_id=? AND status='muerto'`, webhookID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
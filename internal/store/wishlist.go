@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Wish es un libro en la lista de deseos de un usuario.
+type Wish struct {
+	Listing
+	UserID    int64
+	CreatedAt string
+}
+
+// Wishlist es la lista de deseos de cada usuario; quienes siguen un libro reciben los
+// avisos de NotifyFollowers.
+type Wishlist interface {
+	// Add agrega el libro a la lista del usuario; falla por la PRIMARY KEY si ya estaba.
+	Add(ctx context.Context, userID, bookID int64, at time.Time) error
+	// Remove lo quita; false = no estaba.
+	Remove(ctx context.Context, userID, bookID int64) (bool, error)
+	// List lista la lista de deseos del usuario, en el orden en que se agregaron.
+	List(ctx context.Context, userID int64) ([]Wish, error)
+}
+
+type wishlist struct{ q DBTX }
+
+func (w wishlist) Add(ctx context.Context, userID, bookID int64, at time.Time) error {
+	_, err := w.q.ExecContext(ctx, `INSERT INTO wishlists(user_id,book_id,created_at) VALUES(?,?,?)`,
+		userID, bookID, at.UTC().Format(EventFmt))
+	return err
+}
+
+func (w wishlist) Remove(ctx context.Context, userID, bookID int64) (bool, error) {
+	return affected(w.q.ExecContext(ctx, `DELETE FROM wishlists WHERE user_id=? AND book_id=?`, userID, bookID))
+}
+
+func (w wishlist) List(ctx context.Context, userID int64) ([]Wish, error) {
+	rows, err := w.q.QueryContext(ctx, `SELECT `+listingCols+`, w.created_at`+listingFrom+`
+JOIN wishlists w ON w.book_id = b.id
+WHERE w.user_id = ?
+ORDER BY w.created_at, w.book_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Wish{}
+	for rows.Next() {
+		x := Wish{UserID: userID}
+		if err := rows.Scan(append(x.Listing.fields(), &x.CreatedAt)...); err != nil {
+			return nil, err
+		}
+		out = append(out, x)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,191 @@
+// Package webhooks entrega a los endpoints externos los eventos de dominio que quedaron en
+// el outbox (ver store.Outbox). Cada entrega va firmada con HMAC-SHA256, se reintenta con
+// backoff exponencial y, tras MaxAttempts intentos fallidos, queda muerta hasta que un
+// admin la reenvíe.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"tarea1-uzm/internal/store"
+)
+
+const (
+	// DefaultInterval es cada cuánto Start busca entregas pendientes.
+	DefaultInterval = 5 * time.Second
+	// MaxAttempts son los intentos antes de dar una entrega por muerta.
+	MaxAttempts = 8
+	// BaseDelay es la espera tras el primer fallo; se duplica en cada intento hasta MaxDelay.
+	BaseDelay = time.Minute
+	MaxDelay  = 6 * time.Hour
+	// ClaimTTL es cuánto queda reservada una entrega mientras se envía: si la instancia se
+	// cae a mitad de camino, otra la reintenta pasado este tiempo. Mayor que Timeout.
+	ClaimTTL = 2 * time.Minute
+	// Timeout es lo máximo que se espera la respuesta de un endpoint.
+	Timeout = 10 * time.Second
+	// batch son las entregas que toma cada pasada.
+	batch = 50
+)
+
+// Cabeceras de cada entrega.
+const (
+	HeaderEvent     = "X-UZM-Event"
+	HeaderDelivery  = "X-UZM-Delivery"
+	HeaderTimestamp = "X-UZM-Timestamp" // segundos Unix
+	HeaderSignature = "X-UZM-Signature" // sha256=<hex>, ver Sign
+)
+
+// Envelope es el cuerpo JSON que recibe el endpoint. ID es el del evento: si una entrega se
+// reenvía llega con el mismo ID, así el receptor puede descartar duplicados.
+type Envelope struct {
+	ID        int64           `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt string          `json:"created_at"` // RFC3339 UTC
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sign es la firma de X-UZM-Signature: HMAC-SHA256 con el secreto del webhook sobre
+// "<timestamp>.<cuerpo>". Incluir el timestamp permite al receptor rechazar reenvíos viejos.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret genera el secreto de un webhook nuevo.
+func NewSecret() string {
+	var b [24]byte
+	rand.Read(b[:])
+	return "whsec_" + hex.EncodeToString(b[:])
+}
+
+// Backoff es la espera antes del próximo intento tras attempts intentos fallidos (1, 2, ...).
+func Backoff(attempts int) time.Duration {
+	d := BaseDelay
+	for i := 1; i < attempts && d < MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, MaxDelay)
+}
+
+// Result resume una pasada de Deliver.
+type Result struct {
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"` // quedan pendientes para reintentar
+	Dead      int `json:"dead"`
+}
+
+type Dispatcher struct {
+	db     *sql.DB
+	log    *slog.Logger
+	client *http.Client
+	// now es la hora real: reintentos y reservas no se mueven con el viaje en el tiempo.
+	now func() time.Time
+
+	mu sync.Mutex // una pasada a la vez dentro de la instancia
+}
+
+// New arma un despachador; no entrega nada hasta Start o Deliver.
+func New(db *sql.DB, log *slog.Logger) *Dispatcher {
+	return &Dispatcher{db: db, log: log, client: &http.Client{Timeout: Timeout}, now: time.Now}
+}
+
+// Start corre Deliver al partir y luego cada every (DefaultInterval si es 0), hasta que ctx termine.
+func (d *Dispatcher) Start(ctx context.Context, every time.Duration) {
+	if every <= 0 {
+		every = DefaultInterval
+	}
+	go func() {
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			if _, err := d.Deliver(ctx); err != nil && ctx.Err() == nil {
+				d.log.Error("webhooks", "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// Deliver envía las entregas pendientes que ya tocan, hasta que no quede ninguna. El error
+// es el de la base: los de los endpoints quedan en cada entrega.
+func (d *Dispatcher) Deliver(ctx context.Context) (Result, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var res Result
+	repo := store.New(d.db).Read().Webhooks()
+	for {
+		now := d.now()
+		list, err := repo.Claim(ctx, now, now.Add(ClaimTTL), batch)
+		if err != nil || len(list) == 0 {
+			return res, err
+		}
+		for _, del := range list {
+			status, err := d.send(ctx, del)
+			done := d.now()
+			switch {
+			case err == nil:
+				res.Delivered++
+				err = repo.Delivered(ctx, del.ID, status, done)
+			case del.Attempts+1 >= MaxAttempts:
+				res.Dead++
+				d.log.Warn("webhook muerto", "webhook_id", del.WebhookID, "delivery_id", del.ID, "event", del.Event, "err", err)
+				err = repo.Failed(ctx, del.ID, status, err.Error(), done, true)
+			default:
+				res.Failed++
+				err = repo.Failed(ctx, del.ID, status, err.Error(), done.Add(Backoff(del.Attempts+1)), false)
+			}
+			if err != nil {
+				return res, err
+			}
+		}
+	}
+}
+
+// send hace el POST y devuelve el código HTTP (0 si no hubo respuesta); error si no es 2xx.
+func (d *Dispatcher) send(ctx context.Context, del store.Delivery) (int, error) {
+	body, err := json.Marshal(Envelope{ID: del.OutboxID, Event: del.Event, CreatedAt: del.EventAt, Data: del.Payload})
+	if err != nil {
+		return 0, err
+	}
+	ts := d.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, del.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "uzm-webhooks/1")
+	req.Header.Set(HeaderEvent, del.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(del.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(del.Secret, ts, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook: respondió %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,166 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tarea1-uzm/internal/db"
+	"tarea1-uzm/internal/store"
+)
+
+func newDispatcher(t *testing.T, path string, now *time.Time) (*Dispatcher, *store.Store) {
+	t.Helper()
+	sqlDB, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.Migrate(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	d := New(sqlDB, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	d.now = func() time.Time { return *now }
+	return d, store.New(sqlDB)
+}
+
+func addWebhook(t *testing.T, st *store.Store, url string, active bool, events ...string) int64 {
+	t.Helper()
+	w := store.Webhook{URL: url, Secret: "s3creto", Events: events, Active: active, CreatedAt: "2025-03-10T12:00:00Z"}
+	if err := st.Read().Webhooks().Create(context.Background(), &w); err != nil {
+		t.Fatal(err)
+	}
+	return w.ID
+}
+
+func publish(t *testing.T, st *store.Store, event string, data any, at time.Time) {
+	t.Helper()
+	if err := st.InTx(context.Background(), func(tx store.Tx) error {
+		return tx.Outbox().Publish(context.Background(), event, data, at)
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 9: 256 * time.Minute, 10: MaxDelay, 30: MaxDelay} {
+		if got := Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestDeliverRetriesAndDeadLetters(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	var (
+		mu     sync.Mutex
+		got    []Envelope
+		status = http.StatusInternalServerError
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if r.Header.Get(HeaderSignature) != Sign("s3creto", ts, body) || ts != now.Unix() {
+			t.Errorf("firma %q (ts %d) no calza", r.Header.Get(HeaderSignature), ts)
+		}
+		var env Envelope
+		json.Unmarshal(body, &env)
+		if r.Header.Get(HeaderEvent) != env.Event || r.Header.Get(HeaderDelivery) == "" {
+			t.Errorf("cabeceras %v", r.Header)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, env)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	d, st := newDispatcher(t, filepath.Join(t.TempDir(), "uzm.db"), &now)
+	ctx := context.Background()
+	hook := addWebhook(t, st, srv.URL, true, store.EventLoanCreated)
+	addWebhook(t, st, srv.URL, false, store.EventLoanCreated) // inactivo
+	addWebhook(t, st, srv.URL, true, store.EventSaleCreated)  // no suscrito
+	publish(t, st, store.EventLoanCreated, map[string]int{"id": 7}, now)
+
+	// el endpoint falla: cada intento espera el doble que el anterior
+	next := now
+	for attempt := 1; attempt < MaxAttempts; attempt++ {
+		now = next
+		res, err := d.Deliver(ctx)
+		if err != nil || res != (Result{Failed: 1}) {
+			t.Fatalf("intento %d: %+v, %v", attempt, res, err)
+		}
+		next = now.Add(Backoff(attempt))
+		now = next.Add(-time.Second)
+		if res, _ := d.Deliver(ctx); res != (Result{}) {
+			t.Fatalf("reintentó antes de tiempo tras el intento %d: %+v", attempt, res)
+		}
+	}
+	now = next
+	if res, err := d.Deliver(ctx); err != nil || res != (Result{Dead: 1}) {
+		t.Fatalf("último intento: %+v, %v", res, err)
+	}
+	dead, err := st.Read().Webhooks().Deliveries(ctx, store.DeliveryFilter{Status: "muerto", Limit: 10})
+	if err != nil || len(dead) != 1 || dead[0].WebhookID != hook || dead[0].Attempts != MaxAttempts || dead[0].LastStatus != 500 {
+		t.Fatalf("muertas = %+v, %v", dead, err)
+	}
+	if len(got) != MaxAttempts || got[0].Event != store.EventLoanCreated || string(got[0].Data) != `{"id":7}` {
+		t.Errorf("recibidas = %d, primera %+v", len(got), got[0])
+	}
+
+	// reenviar las muertas: llegan con el mismo id de evento
+	status = http.StatusNoContent
+	if n, err := st.Read().Webhooks().ReplayDead(ctx, hook); err != nil || n != 1 {
+		t.Fatalf("ReplayDead = %d, %v", n, err)
+	}
+	if res, _ := d.Deliver(ctx); res != (Result{Delivered: 1}) {
+		t.Fatalf("tras reenviar: %+v", res)
+	}
+	if last := got[len(got)-1]; last.ID != got[0].ID {
+		t.Errorf("id del evento reenviado = %d, want %d", last.ID, got[0].ID)
+	}
+	all, _ := st.Read().Webhooks().Deliveries(ctx, store.DeliveryFilter{Limit: 10})
+	if len(all) != 1 || all[0].Status != "entregado" || all[0].DeliveredAt == "" || all[0].Attempts != 1 {
+		t.Errorf("entregas = %+v", all)
+	}
+}
+
+// Dos instancias que comparten la base no envían la misma entrega dos veces.
+func TestDeliverOnce(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(5 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "uzm.db")
+	a, st := newDispatcher(t, path, &now)
+	b, _ := newDispatcher(t, path, &now)
+	addWebhook(t, st, srv.URL, true, store.WebhookEvents...)
+	for i := range 20 {
+		publish(t, st, store.WebhookEvents[i%len(store.WebhookEvents)], i, now)
+	}
+	var wg sync.WaitGroup
+	for _, d := range []*Dispatcher{a, b} {
+		wg.Go(func() {
+			if _, err := d.Deliver(context.Background()); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	if calls.Load() != 20 {
+		t.Errorf("POSTs = %d, want 20", calls.Load())
+	}
+}
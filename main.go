@@ -1,16 +1,43 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"os"
 
 	"github.com/gin-gonic/gin"
 
 	"tarea1-uzm/internal/api"
+	"tarea1-uzm/internal/backup"
+	"tarea1-uzm/internal/clock"
 	"tarea1-uzm/internal/db"
+	"tarea1-uzm/internal/logging"
+	"tarea1-uzm/internal/notify"
+	"tarea1-uzm/internal/scheduler"
+	"tarea1-uzm/internal/webhooks"
 )
 
 func main() {
-	sqlDB, err := db.Open("data/uzm.db")
+	if len(os.Args) > 1 {
+		os.Exit(command(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	logger, err := logging.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// log.Printf y log.Fatalf también pasan por este handler
+	slog.SetDefault(logger)
+
+	// lock compartido mientras el servidor corre: un restore (que lo pide exclusivo) se
+	// niega; si hay uno en curso, esto espera a que termine
+	lock, err := db.Lock(dbPath, false)
+	if err != nil {
+		log.Fatalf("db lock: %v", err)
+	}
+	defer lock.Close()
+	sqlDB, err := db.Open(dbPath)
 	if err != nil {
 		log.Fatalf("db open: %v", err)
 	}
@@ -20,10 +47,45 @@ func main() {
 		log.Fatalf("db migrate: %v", err)
 	}
 
-	r := gin.Default()
-	api.RegisterRoutes(r, sqlDB)
+	loc, err := clock.LoadZone(os.Getenv("UZM_TZ"))
+	if err != nil {
+		log.Fatalf("zona horaria: %v", err)
+	}
+	var clk clock.Clock = clock.System{Loc: loc}
+	if os.Getenv("UZM_TIME_TRAVEL") == "1" {
+		slog.Warn("viaje en el tiempo habilitado: /admin/clock puede mover la hora del negocio")
+		clk = clock.NewTravel(clk)
+	}
+
+	// vencimientos, multas, recordatorios, limpieza de Idempotency-Key y del outbox, y
+	// popularidad; el lease en job_locks permite levantar varias instancias sobre la misma base
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sched := scheduler.New(sqlDB, clk, logger)
+	channels, err := notify.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sched.Add(api.Jobs(sqlDB, channels)...)
+	backups, err := backup.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if backups.Every > 0 {
+		sched.Add(backup.Job(sqlDB, backups))
+	}
+	sched.Start(ctx, scheduler.DefaultTick)
+	// webhooks salientes: entrega lo que quedó en el outbox (webhook_deliveries reserva cada
+	// entrega, así dos instancias no la envían dos veces)
+	dispatcher := webhooks.New(sqlDB, logger)
+	dispatcher.Start(ctx, webhooks.DefaultInterval)
+
+	// sin el logger ni el recovery de gin.Default: RegisterRoutes trae los suyos, en JSON
+	r := gin.New()
+	api.RegisterRoutes(r, sqlDB, api.WithClock(clk), api.WithLogger(logger), api.WithScheduler(sched),
+		api.WithDispatcher(dispatcher), api.WithBackups(backups))
 
-	log.Println("listening on :8080")
+	slog.Info("escuchando", "addr", ":8080")
 	if err := r.Run(":8080"); err != nil {
 		log.Fatal(err)
 	}